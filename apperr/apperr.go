@@ -0,0 +1,158 @@
+// Package apperr defines the errors the API reports to clients. Every failure
+// leaves the server as one envelope:
+//
+//	{"error": {"code": "course_not_found", "message": "Course not found", "details": ...}}
+//
+// Codes are stable and meant for programs; messages are for people and may change.
+package apperr
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+)
+
+// Generic codes. Specific errors (e.g. "course_not_found") are declared where
+// they are returned, next to the code that returns them.
+const (
+	CodeInvalidInput = "invalid_input"
+	CodeValidation   = "validation_failed"
+	CodeUnauthorized = "unauthorized"
+	CodeForbidden    = "forbidden"
+	CodeNotFound     = "not_found"
+	CodeConflict     = "conflict"
+	CodeTooLarge     = "payload_too_large"
+	CodeInternal     = "internal_error"
+)
+
+// Error - An error with the HTTP status and code sent to the client. Cause is
+// logged but never serialized.
+type Error struct {
+	Status  int         `json:"-"`
+	Code    string      `json:"code"`
+	Message string      `json:"message"`
+	Details interface{} `json:"details,omitempty"`
+	Cause   error       `json:"-"`
+	// RetryAfter, when set, is sent as the Retry-After header
+	RetryAfter time.Duration `json:"-"`
+}
+
+func (e *Error) Error() string {
+	if e.Cause != nil {
+		return fmt.Sprintf("%s: %s: %v", e.Code, e.Message, e.Cause)
+	}
+	return e.Code + ": " + e.Message
+}
+
+func (e *Error) Unwrap() error { return e.Cause }
+
+// Is - Two application errors are the same error when their codes match, so a
+// copy made by WithDetails or WithCause still matches its sentinel
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+// WithDetails - Copy of e carrying client-visible details
+func (e *Error) WithDetails(details interface{}) *Error {
+	copy := *e
+	copy.Details = details
+	return &copy
+}
+
+// WithCause - Copy of e carrying an internal cause for the logs
+func (e *Error) WithCause(cause error) *Error {
+	copy := *e
+	copy.Cause = cause
+	return &copy
+}
+
+// WithRetryAfter - Copy of e telling the client when to try again
+func (e *Error) WithRetryAfter(d time.Duration) *Error {
+	copy := *e
+	copy.RetryAfter = d
+	return &copy
+}
+
+// New - An error with an explicit status
+func New(status int, code, message string) *Error {
+	return &Error{Status: status, Code: code, Message: message}
+}
+
+// BadRequest - 400, the request could not be read
+func BadRequest(code, message string) *Error { return New(http.StatusBadRequest, code, message) }
+
+// Unauthorized - 401, no or bad credentials
+func Unauthorized(code, message string) *Error { return New(http.StatusUnauthorized, code, message) }
+
+// Forbidden - 403, authenticated but not allowed
+func Forbidden(code, message string) *Error { return New(http.StatusForbidden, code, message) }
+
+// NotFound - 404
+func NotFound(code, message string) *Error { return New(http.StatusNotFound, code, message) }
+
+// Conflict - 409, the request clashes with the current state
+func Conflict(code, message string) *Error { return New(http.StatusConflict, code, message) }
+
+// Unprocessable - 422, well-formed but semantically invalid input
+func Unprocessable(code, message string) *Error {
+	return New(http.StatusUnprocessableEntity, code, message)
+}
+
+// Internal - 500 hiding cause from the client
+func Internal(cause error) *Error {
+	return &Error{Status: http.StatusInternalServerError, Code: CodeInternal, Message: "Internal server error", Cause: cause}
+}
+
+// Shared errors
+var (
+	ErrUnauthorized = Unauthorized(CodeUnauthorized, "Missing or invalid credentials")
+	ErrForbidden    = Forbidden(CodeForbidden, "You are not allowed to do this")
+	ErrNotFound     = NotFound(CodeNotFound, "Resource not found")
+	ErrInvalidID    = BadRequest("invalid_id", "Invalid ID format")
+)
+
+// Bind - Translate a ShouldBind/validator error: field rule violations are 422
+// with one message per field, anything else (bad JSON, wrong types) is 400
+func Bind(err error) *Error {
+	var verrs validator.ValidationErrors
+	if errors.As(err, &verrs) {
+		messages := make([]string, 0, len(verrs))
+		for _, fe := range verrs {
+			messages = append(messages, fmt.Sprintf("%s is %s", strings.ToLower(fe.Field()), fe.Tag()))
+		}
+		return Unprocessable(CodeValidation, "Validation failed").WithDetails(messages)
+	}
+	// Body yang terpotong oleh batas ukuran bukan kesalahan format
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return From(err)
+	}
+	return BadRequest(CodeInvalidInput, "Invalid input").WithDetails(err.Error())
+}
+
+// From - The application error for err. Unknown errors become 500s so that
+// database and filesystem messages never reach the client.
+func From(err error) *Error {
+	if err == nil {
+		return nil
+	}
+
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrNotFound.WithCause(err)
+	}
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return New(http.StatusRequestEntityTooLarge, CodeTooLarge, "Request body too large").WithCause(err)
+	}
+	return Internal(err)
+}
@@ -0,0 +1,9 @@
+package apperr
+
+import "github.com/gin-gonic/gin"
+
+// Abort - Stop the handler chain and leave err for middleware.ErrorHandler to render
+func Abort(c *gin.Context, err error) {
+	c.Error(err)
+	c.Abort()
+}
@@ -0,0 +1,67 @@
+// Package background runs work outside the request that triggered it and lets
+// shutdown wait for that work to finish.
+package background
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+)
+
+var (
+	mu       sync.Mutex
+	wg       sync.WaitGroup
+	ctx      context.Context
+	cancel   context.CancelFunc
+	draining bool
+)
+
+func init() {
+	ctx, cancel = context.WithCancel(context.Background())
+}
+
+// Go - Run fn in its own goroutine. fn should return soon after ctx is
+// cancelled, which happens when shutdown starts. Once shutdown has begun new
+// work is refused and Go returns false.
+func Go(name string, fn func(ctx context.Context)) bool {
+	mu.Lock()
+	defer mu.Unlock()
+	if draining {
+		slog.Warn("background work refused during shutdown", "worker", name)
+		return false
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer func() {
+			if r := recover(); r != nil {
+				slog.Error("background work panicked", "worker", name, "panic", r)
+			}
+		}()
+		fn(ctx)
+	}()
+	return true
+}
+
+// Shutdown - Cancel running work and wait for it to return, giving up when
+// deadline is done
+func Shutdown(deadline context.Context) error {
+	mu.Lock()
+	draining = true
+	cancel()
+	mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-deadline.Done():
+		return deadline.Err()
+	}
+}
@@ -0,0 +1,633 @@
+package config
+
+import (
+	"backend-go/logging"
+	"backend-go/ratelimit"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+// ssoName - Provider names appear in URLs
+var ssoName = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+
+// Profiles
+const (
+	ProfileDev  = "dev"
+	ProfileTest = "test"
+	ProfileProd = "prod"
+)
+
+// Config - Every setting the application reads, loaded once in main and
+// passed to the pieces that need it
+type Config struct {
+	Profile   string          `yaml:"profile"`
+	Server    ServerConfig    `yaml:"server"`
+	Database  DatabaseConfig  `yaml:"database"`
+	JWT       JWTConfig       `yaml:"jwt"`
+	Media     MediaConfig     `yaml:"media"`
+	Storage   StorageConfig   `yaml:"storage"`
+	CORS      CORSConfig      `yaml:"cors"`
+	Log       LogConfig       `yaml:"log"`
+	RateLimit RateLimitConfig `yaml:"rate_limit"`
+	MFA       MFAConfig       `yaml:"mfa"`
+	SSO       SSOConfig       `yaml:"sso"`
+	Mail      MailConfig      `yaml:"mail"`
+	Account   AccountConfig   `yaml:"account"`
+	Trash     TrashConfig     `yaml:"trash"`
+	Tenant    TenantConfig    `yaml:"tenant"`
+}
+
+type ServerConfig struct {
+	Addr              string        `yaml:"addr"`
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout"`
+	ReadTimeout       time.Duration `yaml:"read_timeout"`
+	WriteTimeout      time.Duration `yaml:"write_timeout"`
+	IdleTimeout       time.Duration `yaml:"idle_timeout"`
+	// TransferTimeout replaces the read and write timeouts on upload and media routes
+	TransferTimeout time.Duration `yaml:"transfer_timeout"`
+	// ShutdownTimeout bounds draining requests and background work on SIGTERM
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+	MaxHeaderBytes  int           `yaml:"max_header_bytes"`
+	// MaxBodyBytes limits request bodies except on upload routes, which have their own limits
+	MaxBodyBytes int64 `yaml:"max_body_bytes"`
+	// TLS is served when both files are set
+	TLSCertFile string `yaml:"tls_cert_file"`
+	TLSKeyFile  string `yaml:"tls_key_file"`
+	// TrustedProxies are the addresses or CIDRs whose X-Forwarded-For is
+	// believed; empty trusts none and uses the connection address
+	TrustedProxies []string `yaml:"trusted_proxies"`
+}
+
+type DatabaseConfig struct {
+	URL             string        `yaml:"url"`
+	MaxOpenConns    int           `yaml:"max_open_conns"`
+	MaxIdleConns    int           `yaml:"max_idle_conns"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime"`
+	// Queries slower than this are logged as warnings, 0 disables
+	SlowQueryThreshold time.Duration `yaml:"slow_query_threshold"`
+}
+
+type JWTConfig struct {
+	Secret string        `yaml:"secret"`
+	TTL    time.Duration `yaml:"ttl"`
+}
+
+type MediaConfig struct {
+	// SigningKey falls back to the JWT secret when empty
+	SigningKey string        `yaml:"signing_key"`
+	URLTTL     time.Duration `yaml:"url_ttl"`
+}
+
+type StorageConfig struct {
+	PublicDir  string `yaml:"public_dir"`
+	PrivateDir string `yaml:"private_dir"`
+}
+
+type LogConfig struct {
+	// Level is debug, info, warn or error; debug also logs every SQL query
+	Level  string `yaml:"level"`
+	Format string `yaml:"format"`
+}
+
+type MFAConfig struct {
+	// Issuer is the account label shown in authenticator apps
+	Issuer string `yaml:"issuer"`
+	// RequiredRoles may only use the API after a two-factor login
+	RequiredRoles []string `yaml:"required_roles"`
+}
+
+type SSOConfig struct {
+	// PublicURL is the API's external base URL, e.g. https://api.example.com
+	PublicURL string `yaml:"public_url"`
+	// FrontendURL receives the login result in the fragment after a callback;
+	// without it the callback answers with JSON
+	FrontendURL string              `yaml:"frontend_url"`
+	Providers   []SSOProviderConfig `yaml:"providers"`
+}
+
+type SSOProviderConfig struct {
+	// Name is used in URLs; its client secret can also come from
+	// SSO_<NAME>_CLIENT_SECRET
+	Name         string   `yaml:"name"`
+	DisplayName  string   `yaml:"display_name"`
+	Issuer       string   `yaml:"issuer"`
+	ClientID     string   `yaml:"client_id"`
+	ClientSecret string   `yaml:"client_secret"`
+	Scopes       []string `yaml:"scopes"`
+	// DefaultRole of users created on first login, "user" when empty
+	DefaultRole string `yaml:"default_role"`
+	TrustEmail  bool   `yaml:"trust_email"`
+}
+
+// Mail backends
+const (
+	MailLog  = "log"
+	MailSMTP = "smtp"
+)
+
+type MailConfig struct {
+	// Backend is log (messages are only logged) or smtp
+	Backend  string `yaml:"backend"`
+	SMTPAddr string `yaml:"smtp_addr"`
+	From     string `yaml:"from"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+}
+
+type AccountConfig struct {
+	// DeletionGrace is how long a deleted account can be restored by logging in
+	DeletionGrace time.Duration `yaml:"deletion_grace"`
+	// PurgeInterval is how often accounts past their grace period are anonymized
+	PurgeInterval time.Duration `yaml:"purge_interval"`
+	EmailTokenTTL time.Duration `yaml:"email_token_ttl"`
+	// EmailConfirmURL is the frontend page opened from the email change link
+	EmailConfirmURL string `yaml:"email_confirm_url"`
+	// PasswordResetURL is the frontend page opened from an admin-forced reset link
+	PasswordResetURL string `yaml:"password_reset_url"`
+	// ExportTTL is how long a personal data export can be downloaded
+	ExportTTL time.Duration `yaml:"export_ttl"`
+	// PublicURL is the API's external base URL, used for the export download
+	// link in emails; falls back to the SSO public URL
+	PublicURL string `yaml:"public_url"`
+}
+
+type TrashConfig struct {
+	// Retention is how long deleted courses, lessons, quizzes and answers can
+	// be restored before they are removed for good
+	Retention time.Duration `yaml:"retention"`
+	// PurgeInterval is how often content past its retention is removed
+	PurgeInterval time.Duration `yaml:"purge_interval"`
+}
+
+type TenantConfig struct {
+	// BaseDomain is the host organizations are subdomains of, e.g. with
+	// "example.com" a request to school-a.example.com is for organization
+	// school-a. Empty resolves organizations by the X-Organization header only.
+	BaseDomain string `yaml:"base_domain"`
+	// DefaultOrganization is the slug used when a request names none
+	DefaultOrganization string `yaml:"default_organization"`
+}
+
+// Rate limit backends
+const (
+	RateLimitMemory = "memory"
+	RateLimitRedis  = "redis"
+)
+
+type RateLimitConfig struct {
+	// Backend is memory (per instance) or redis (shared by every instance)
+	Backend  string `yaml:"backend"`
+	RedisURL string `yaml:"redis_url"`
+	// Limits are "<requests>/<period>", e.g. "300/1m"; empty disables the limit
+	API           string `yaml:"api"`
+	Login         string `yaml:"login"`
+	LoginMFA      string `yaml:"login_mfa"`
+	SSO           string `yaml:"sso"`
+	PasswordReset string `yaml:"password_reset"`
+	EmailConfirm  string `yaml:"email_confirm"`
+	Register      string `yaml:"register"`
+}
+
+type CORSConfig struct {
+	AllowOrigins []string      `yaml:"allow_origins"`
+	MaxAge       time.Duration `yaml:"max_age"`
+}
+
+// Defaults - Settings for a profile before any file or environment is applied
+func Defaults(profile string) Config {
+	cfg := Config{
+		Profile: profile,
+		Server: ServerConfig{
+			Addr:              ":8080",
+			ReadHeaderTimeout: 5 * time.Second,
+			ReadTimeout:       30 * time.Second,
+			WriteTimeout:      60 * time.Second,
+			IdleTimeout:       2 * time.Minute,
+			TransferTimeout:   time.Hour,
+			ShutdownTimeout:   30 * time.Second,
+			MaxHeaderBytes:    1 << 20,
+			MaxBodyBytes:      32 << 20,
+		},
+		Database: DatabaseConfig{
+			MaxOpenConns:       25,
+			MaxIdleConns:       5,
+			ConnMaxLifetime:    30 * time.Minute,
+			SlowQueryThreshold: 200 * time.Millisecond,
+		},
+		JWT:     JWTConfig{TTL: 72 * time.Hour},
+		Media:   MediaConfig{URLTTL: 15 * time.Minute},
+		Storage: StorageConfig{PublicDir: "./public/uploads", PrivateDir: "./storage/private"},
+		CORS:    CORSConfig{MaxAge: 12 * time.Hour},
+		Log:     LogConfig{Level: "info", Format: logging.FormatJSON},
+		RateLimit: RateLimitConfig{
+			Backend:       RateLimitMemory,
+			API:           "300/1m",
+			Login:         "30/1m",
+			LoginMFA:      "10/1m",
+			SSO:           "30/1m",
+			PasswordReset: "10/1m",
+			EmailConfirm:  "10/1m",
+			Register:      "20/1h",
+		},
+		MFA:  MFAConfig{Issuer: "backend-go", RequiredRoles: []string{"admin", "superadmin"}},
+		Mail: MailConfig{Backend: MailLog},
+		Account: AccountConfig{
+			DeletionGrace: 30 * 24 * time.Hour,
+			PurgeInterval: time.Hour,
+			EmailTokenTTL: 24 * time.Hour,
+			ExportTTL:     7 * 24 * time.Hour,
+		},
+		Trash: TrashConfig{
+			Retention:     30 * 24 * time.Hour,
+			PurgeInterval: time.Hour,
+		},
+		Tenant: TenantConfig{DefaultOrganization: "default"},
+	}
+
+	switch profile {
+	case ProfileDev:
+		cfg.CORS.AllowOrigins = []string{"http://localhost:5173"}
+		cfg.Log.Level = "debug"
+		cfg.MFA.RequiredRoles = nil
+	case ProfileTest:
+		cfg.Database.MaxOpenConns = 5
+		cfg.Storage = StorageConfig{PublicDir: os.TempDir() + "/backend-go-test/public", PrivateDir: os.TempDir() + "/backend-go-test/private"}
+	}
+	return cfg
+}
+
+// Load - Build the configuration: profile defaults, then the YAML file
+// (CONFIG_FILE, default config.<profile>.yaml or config.yaml if present),
+// then .env and the process environment, which always win.
+func Load() (*Config, error) {
+	// .env bersifat opsional, variabel yang sudah ada di environment tidak ditimpa
+	if err := godotenv.Load(); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("load .env: %w", err)
+	}
+
+	profile := os.Getenv("APP_ENV")
+	if profile == "" {
+		profile = ProfileDev
+	}
+	cfg := Defaults(profile)
+
+	if err := loadYAML(&cfg, profile); err != nil {
+		return nil, err
+	}
+	if err := loadEnv(&cfg); err != nil {
+		return nil, err
+	}
+	// APP_ENV menentukan profile walaupun file YAML menulis lain
+	cfg.Profile = profile
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+func loadYAML(cfg *Config, profile string) error {
+	candidates := []string{"config." + profile + ".yaml", "config.yaml"}
+	explicit := os.Getenv("CONFIG_FILE")
+	if explicit != "" {
+		candidates = []string{explicit}
+	}
+
+	for _, path := range candidates {
+		data, err := os.ReadFile(path)
+		if errors.Is(err, os.ErrNotExist) && explicit == "" {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("read config file: %w", err)
+		}
+		if err := yaml.Unmarshal(data, cfg); err != nil {
+			return fmt.Errorf("parse %s: %w", path, err)
+		}
+		return nil
+	}
+	return nil
+}
+
+func loadEnv(cfg *Config) error {
+	str := func(key string, dst *string) {
+		if v, ok := os.LookupEnv(key); ok {
+			*dst = v
+		}
+	}
+	var errs []error
+	integer := func(key string, dst *int) {
+		if v, ok := os.LookupEnv(key); ok {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", key, err))
+				return
+			}
+			*dst = n
+		}
+	}
+	integer64 := func(key string, dst *int64) {
+		if v, ok := os.LookupEnv(key); ok {
+			n, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", key, err))
+				return
+			}
+			*dst = n
+		}
+	}
+	duration := func(key string, dst *time.Duration) {
+		if v, ok := os.LookupEnv(key); ok {
+			d, err := time.ParseDuration(v)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", key, err))
+				return
+			}
+			*dst = d
+		}
+	}
+	list := func(key string, dst *[]string) {
+		if v, ok := os.LookupEnv(key); ok {
+			*dst = nil
+			for _, item := range strings.Split(v, ",") {
+				if item = strings.TrimSpace(item); item != "" {
+					*dst = append(*dst, item)
+				}
+			}
+		}
+	}
+
+	str("HTTP_ADDR", &cfg.Server.Addr)
+	duration("HTTP_READ_HEADER_TIMEOUT", &cfg.Server.ReadHeaderTimeout)
+	duration("HTTP_READ_TIMEOUT", &cfg.Server.ReadTimeout)
+	duration("HTTP_WRITE_TIMEOUT", &cfg.Server.WriteTimeout)
+	duration("HTTP_IDLE_TIMEOUT", &cfg.Server.IdleTimeout)
+	duration("HTTP_TRANSFER_TIMEOUT", &cfg.Server.TransferTimeout)
+	duration("HTTP_SHUTDOWN_TIMEOUT", &cfg.Server.ShutdownTimeout)
+	integer("HTTP_MAX_HEADER_BYTES", &cfg.Server.MaxHeaderBytes)
+	integer64("HTTP_MAX_BODY_BYTES", &cfg.Server.MaxBodyBytes)
+	str("TLS_CERT_FILE", &cfg.Server.TLSCertFile)
+	str("TLS_KEY_FILE", &cfg.Server.TLSKeyFile)
+	list("HTTP_TRUSTED_PROXIES", &cfg.Server.TrustedProxies)
+	str("DATABASE_URL", &cfg.Database.URL)
+	integer("DB_MAX_OPEN_CONNS", &cfg.Database.MaxOpenConns)
+	integer("DB_MAX_IDLE_CONNS", &cfg.Database.MaxIdleConns)
+	duration("DB_CONN_MAX_LIFETIME", &cfg.Database.ConnMaxLifetime)
+	duration("DB_SLOW_QUERY_THRESHOLD", &cfg.Database.SlowQueryThreshold)
+	str("JWT_SECRET", &cfg.JWT.Secret)
+	duration("JWT_TTL", &cfg.JWT.TTL)
+	str("MEDIA_SIGNING_KEY", &cfg.Media.SigningKey)
+	duration("MEDIA_URL_TTL", &cfg.Media.URLTTL)
+	str("STORAGE_PUBLIC_DIR", &cfg.Storage.PublicDir)
+	str("STORAGE_PRIVATE_DIR", &cfg.Storage.PrivateDir)
+	list("CORS_ALLOW_ORIGINS", &cfg.CORS.AllowOrigins)
+	duration("CORS_MAX_AGE", &cfg.CORS.MaxAge)
+	str("LOG_LEVEL", &cfg.Log.Level)
+	str("LOG_FORMAT", &cfg.Log.Format)
+	str("RATE_LIMIT_BACKEND", &cfg.RateLimit.Backend)
+	str("REDIS_URL", &cfg.RateLimit.RedisURL)
+	str("RATE_LIMIT_API", &cfg.RateLimit.API)
+	str("RATE_LIMIT_LOGIN", &cfg.RateLimit.Login)
+	str("RATE_LIMIT_LOGIN_MFA", &cfg.RateLimit.LoginMFA)
+	str("RATE_LIMIT_SSO", &cfg.RateLimit.SSO)
+	str("RATE_LIMIT_PASSWORD_RESET", &cfg.RateLimit.PasswordReset)
+	str("RATE_LIMIT_EMAIL_CONFIRM", &cfg.RateLimit.EmailConfirm)
+	str("RATE_LIMIT_REGISTER", &cfg.RateLimit.Register)
+	str("MFA_ISSUER", &cfg.MFA.Issuer)
+	list("MFA_REQUIRED_ROLES", &cfg.MFA.RequiredRoles)
+	str("SSO_PUBLIC_URL", &cfg.SSO.PublicURL)
+	str("SSO_FRONTEND_URL", &cfg.SSO.FrontendURL)
+	str("MAIL_BACKEND", &cfg.Mail.Backend)
+	str("SMTP_ADDR", &cfg.Mail.SMTPAddr)
+	str("MAIL_FROM", &cfg.Mail.From)
+	str("SMTP_USERNAME", &cfg.Mail.Username)
+	str("SMTP_PASSWORD", &cfg.Mail.Password)
+	duration("ACCOUNT_DELETION_GRACE", &cfg.Account.DeletionGrace)
+	duration("ACCOUNT_PURGE_INTERVAL", &cfg.Account.PurgeInterval)
+	duration("ACCOUNT_EMAIL_TOKEN_TTL", &cfg.Account.EmailTokenTTL)
+	str("ACCOUNT_EMAIL_CONFIRM_URL", &cfg.Account.EmailConfirmURL)
+	str("ACCOUNT_PASSWORD_RESET_URL", &cfg.Account.PasswordResetURL)
+	duration("ACCOUNT_EXPORT_TTL", &cfg.Account.ExportTTL)
+	str("PUBLIC_URL", &cfg.Account.PublicURL)
+	duration("TRASH_RETENTION", &cfg.Trash.Retention)
+	duration("TRASH_PURGE_INTERVAL", &cfg.Trash.PurgeInterval)
+	str("TENANT_BASE_DOMAIN", &cfg.Tenant.BaseDomain)
+	str("TENANT_DEFAULT_ORGANIZATION", &cfg.Tenant.DefaultOrganization)
+	for i := range cfg.SSO.Providers {
+		p := &cfg.SSO.Providers[i]
+		str("SSO_"+strings.ToUpper(strings.ReplaceAll(p.Name, "-", "_"))+"_CLIENT_SECRET", &p.ClientSecret)
+	}
+
+	return errors.Join(errs...)
+}
+
+// Validate - Fail fast on settings the application cannot run without
+func (c *Config) Validate() error {
+	var errs []error
+
+	switch c.Profile {
+	case ProfileDev, ProfileTest, ProfileProd:
+	default:
+		errs = append(errs, fmt.Errorf("APP_ENV must be one of dev, test, prod (got %q)", c.Profile))
+	}
+
+	if c.Server.Addr == "" {
+		errs = append(errs, errors.New("server address is required (HTTP_ADDR)"))
+	}
+	if c.Server.ReadHeaderTimeout < 0 || c.Server.ReadTimeout < 0 || c.Server.WriteTimeout < 0 ||
+		c.Server.IdleTimeout < 0 || c.Server.TransferTimeout < 0 {
+		errs = append(errs, errors.New("http timeouts must not be negative"))
+	}
+	if c.Server.ShutdownTimeout <= 0 {
+		errs = append(errs, errors.New("shutdown timeout must be positive (HTTP_SHUTDOWN_TIMEOUT)"))
+	}
+	if c.Server.MaxHeaderBytes <= 0 || c.Server.MaxBodyBytes <= 0 {
+		errs = append(errs, errors.New("max header and body sizes must be positive (HTTP_MAX_HEADER_BYTES, HTTP_MAX_BODY_BYTES)"))
+	}
+	if (c.Server.TLSCertFile == "") != (c.Server.TLSKeyFile == "") {
+		errs = append(errs, errors.New("tls needs both a certificate and a key (TLS_CERT_FILE, TLS_KEY_FILE)"))
+	}
+	for _, f := range []string{c.Server.TLSCertFile, c.Server.TLSKeyFile} {
+		if f == "" {
+			continue
+		}
+		if _, err := os.Stat(f); err != nil {
+			errs = append(errs, fmt.Errorf("tls file: %w", err))
+		}
+	}
+	for _, proxy := range c.Server.TrustedProxies {
+		if net.ParseIP(proxy) == nil {
+			if _, _, err := net.ParseCIDR(proxy); err != nil {
+				errs = append(errs, fmt.Errorf("trusted proxies must be IP addresses or CIDRs (HTTP_TRUSTED_PROXIES, got %q)", proxy))
+			}
+		}
+	}
+	if c.Database.URL == "" {
+		errs = append(errs, errors.New("database url is required (DATABASE_URL)"))
+	}
+	if c.JWT.Secret == "" {
+		errs = append(errs, errors.New("jwt secret is required (JWT_SECRET)"))
+	} else if c.Profile == ProfileProd && len(c.JWT.Secret) < 32 {
+		errs = append(errs, errors.New("jwt secret must be at least 32 bytes in prod"))
+	}
+	if c.JWT.TTL <= 0 {
+		errs = append(errs, errors.New("jwt ttl must be positive (JWT_TTL)"))
+	}
+	if c.Media.URLTTL <= 0 {
+		errs = append(errs, errors.New("media url ttl must be positive (MEDIA_URL_TTL)"))
+	}
+	if c.Storage.PublicDir == "" || c.Storage.PrivateDir == "" {
+		errs = append(errs, errors.New("storage directories are required (STORAGE_PUBLIC_DIR, STORAGE_PRIVATE_DIR)"))
+	}
+	if c.Profile == ProfileProd && len(c.CORS.AllowOrigins) == 0 {
+		errs = append(errs, errors.New("cors origins are required in prod (CORS_ALLOW_ORIGINS)"))
+	}
+
+	if _, err := logging.ParseLevel(c.Log.Level); err != nil {
+		errs = append(errs, fmt.Errorf("log level must be debug, info, warn or error (LOG_LEVEL, got %q)", c.Log.Level))
+	}
+	if c.Log.Format != logging.FormatJSON && c.Log.Format != logging.FormatText {
+		errs = append(errs, fmt.Errorf("log format must be json or text (LOG_FORMAT, got %q)", c.Log.Format))
+	}
+
+	switch c.RateLimit.Backend {
+	case RateLimitMemory:
+	case RateLimitRedis:
+		if c.RateLimit.RedisURL == "" {
+			errs = append(errs, errors.New("redis url is required for the redis rate limit backend (REDIS_URL)"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("rate limit backend must be memory or redis (RATE_LIMIT_BACKEND, got %q)", c.RateLimit.Backend))
+	}
+	if _, err := c.RateLimit.Limits(); err != nil {
+		errs = append(errs, err)
+	}
+
+	if c.MFA.Issuer == "" {
+		errs = append(errs, errors.New("mfa issuer is required (MFA_ISSUER)"))
+	}
+	for _, role := range c.MFA.RequiredRoles {
+		if role != "admin" && role != "superadmin" && role != "user" {
+			errs = append(errs, fmt.Errorf("mfa required roles must be admin, superadmin or user (MFA_REQUIRED_ROLES, got %q)", role))
+		}
+	}
+
+	if len(c.SSO.Providers) > 0 && c.SSO.PublicURL == "" {
+		errs = append(errs, errors.New("sso public url is required when providers are configured (SSO_PUBLIC_URL)"))
+	}
+	seen := map[string]bool{}
+	for i, p := range c.SSO.Providers {
+		switch {
+		case !ssoName.MatchString(p.Name):
+			errs = append(errs, fmt.Errorf("sso provider %d: name must be lowercase letters, digits and dashes (got %q)", i, p.Name))
+		case seen[p.Name]:
+			errs = append(errs, fmt.Errorf("sso provider %q is configured twice", p.Name))
+		}
+		seen[p.Name] = true
+		if p.Issuer == "" || p.ClientID == "" {
+			errs = append(errs, fmt.Errorf("sso provider %q: issuer and client_id are required", p.Name))
+		}
+		if p.DefaultRole != "" && p.DefaultRole != "admin" && p.DefaultRole != "user" {
+			errs = append(errs, fmt.Errorf("sso provider %q: default_role must be admin or user (got %q)", p.Name, p.DefaultRole))
+		}
+	}
+
+	switch c.Mail.Backend {
+	case MailLog:
+	case MailSMTP:
+		if c.Mail.SMTPAddr == "" || c.Mail.From == "" {
+			errs = append(errs, errors.New("smtp address and sender are required for the smtp mail backend (SMTP_ADDR, MAIL_FROM)"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("mail backend must be log or smtp (MAIL_BACKEND, got %q)", c.Mail.Backend))
+	}
+
+	if c.Account.DeletionGrace < 0 {
+		errs = append(errs, errors.New("account deletion grace must not be negative (ACCOUNT_DELETION_GRACE)"))
+	}
+	if c.Account.PurgeInterval <= 0 || c.Account.EmailTokenTTL <= 0 {
+		errs = append(errs, errors.New("account purge interval and email token ttl must be positive (ACCOUNT_PURGE_INTERVAL, ACCOUNT_EMAIL_TOKEN_TTL)"))
+	}
+	if c.Account.ExportTTL <= 0 {
+		errs = append(errs, errors.New("account export ttl must be positive (ACCOUNT_EXPORT_TTL)"))
+	}
+	if c.Trash.Retention < 0 || c.Trash.PurgeInterval <= 0 {
+		errs = append(errs, errors.New("trash retention must not be negative and purge interval must be positive (TRASH_RETENTION, TRASH_PURGE_INTERVAL)"))
+	}
+
+	if c.Tenant.DefaultOrganization == "" {
+		errs = append(errs, errors.New("default organization is required (TENANT_DEFAULT_ORGANIZATION)"))
+	}
+	if strings.HasPrefix(c.Tenant.BaseDomain, ".") || strings.Contains(c.Tenant.BaseDomain, ":") {
+		errs = append(errs, fmt.Errorf("tenant base domain must be a host name without leading dot or port (TENANT_BASE_DOMAIN, got %q)", c.Tenant.BaseDomain))
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("invalid configuration:\n%w", err)
+	}
+	return nil
+}
+
+// TLSEnabled - Whether the server should serve HTTPS
+func (s ServerConfig) TLSEnabled() bool {
+	return s.TLSCertFile != "" && s.TLSKeyFile != ""
+}
+
+// Limits - The parsed limits by name (api, login, login_mfa, sso,
+// password_reset, email_confirm, register). Empty settings are left out,
+// which leaves that scope unlimited.
+func (r RateLimitConfig) Limits() (map[string]ratelimit.Limit, error) {
+	settings := []struct{ name, value string }{
+		{"api", r.API}, {"login", r.Login}, {"login_mfa", r.LoginMFA}, {"sso", r.SSO},
+		{"password_reset", r.PasswordReset}, {"email_confirm", r.EmailConfirm}, {"register", r.Register},
+	}
+	limits := make(map[string]ratelimit.Limit, len(settings))
+	var errs []error
+	for _, setting := range settings {
+		name, value := setting.name, setting.value
+		if value == "" {
+			continue
+		}
+		limit, err := ratelimit.ParseLimit(value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s (RATE_LIMIT_%s)", err, strings.ToUpper(name)))
+			continue
+		}
+		limits[name] = limit
+	}
+	return limits, errors.Join(errs...)
+}
+
+// Logger - The application logger described by the log settings
+func (c *Config) Logger(w io.Writer) *slog.Logger {
+	level, _ := logging.ParseLevel(c.Log.Level)
+	return logging.New(w, level, c.Log.Format)
+}
+
+// MediaSigningKey - Key for signed media URLs, falling back to the JWT secret
+func (c *Config) MediaSigningKey() string {
+	if c.Media.SigningKey != "" {
+		return c.Media.SigningKey
+	}
+	return c.JWT.Secret
+}
+
+// PublicURL - External base URL of the API for links in emails, falling back
+// to the SSO public URL
+func (c *Config) PublicURL() string {
+	if c.Account.PublicURL != "" {
+		return strings.TrimSuffix(c.Account.PublicURL, "/")
+	}
+	return strings.TrimSuffix(c.SSO.PublicURL, "/")
+}
@@ -1,9 +1,9 @@
 package config
 
 import (
-	"backend-go/models"
-	"fmt"
-	"log"
+	"backend-go/logging"
+	"backend-go/tenancy"
+	"log/slog"
 	"os"
 
 	"gorm.io/driver/postgres"
@@ -13,42 +13,34 @@ import (
 
 var DB*gorm.DB
 
-func ConnectDB(){
-	dsn := os.Getenv("DATABASE_URL")
+// ConnectDB - Open the connection pool. Schema changes are applied with the
+// `migrate` subcommand (see package migrations), never on boot.
+func ConnectDB(cfg DatabaseConfig){
 	var err error
-	DB, err = gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	DB, err = gorm.Open(postgres.Open(cfg.URL), &gorm.Config{
+		Logger: logging.NewGormLogger(slog.Default(), cfg.SlowQueryThreshold),
+		// Pelanggaran unique/foreign key menjadi gorm.ErrDuplicatedKey dan kawan-kawan
+		TranslateError: true,
+	})
 	if err != nil {
-		log.Fatalf("Failed to connect to database: %v", err)
+		slog.Error("failed to connect to database", "error", err)
+		os.Exit(1)
+	}
+
+	// Query tiap request hanya melihat baris organisasinya sendiri
+	if err := DB.Use(tenancy.Plugin{}); err != nil {
+		slog.Error("failed to set up tenancy", "error", err)
+		os.Exit(1)
 	}
-	fmt.Println("Connected to database")
-
-	// DeleteMigration()
-
-	DB.AutoMigrate(
-		&models.User{},
-		&models.Profile{},
-		&models.Course{},
-		&models.Enrollment{},
-		&models.Lesson{},
-		&models.Quiz{},
-		&models.Answer{},
-		&models.UserQuiz{},
-		&models.UserAnswer{},
-	)
-	fmt.Println("Database Migrated")
-}
 
-func DeleteMigration(){
-	DB.Migrator().DropTable(
-		&models.User{},
-		&models.Profile{},
-		&models.Course{},
-		&models.Enrollment{},
-		&models.Lesson{},
-		&models.Quiz{},
-		&models.Answer{},
-		&models.UserQuiz{},
-		&models.UserAnswer{},
-	)
-	fmt.Println("Table deleted")
-}
\ No newline at end of file
+	sqlDB, err := DB.DB()
+	if err != nil {
+		slog.Error("failed to configure database pool", "error", err)
+		os.Exit(1)
+	}
+	sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+
+	slog.Info("connected to database")
+}
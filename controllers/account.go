@@ -0,0 +1,138 @@
+package controllers
+
+import (
+	"backend-go/apperr"
+	"backend-go/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+// AccountHandler - Handlers for the logged-in user's own account
+type AccountHandler struct {
+	Accounts *services.AccountService
+}
+
+// NewAccountHandler - Create an AccountHandler
+func NewAccountHandler(accounts *services.AccountService) *AccountHandler {
+	return &AccountHandler{Accounts: accounts}
+}
+
+// bindValid - Bind the JSON body into input and validate it
+func bindValid(c *gin.Context, input interface{}) bool {
+	if err := c.ShouldBindJSON(input); err != nil {
+		apperr.Abort(c, apperr.Bind(err))
+		return false
+	}
+	if err := validator.New().Struct(input); err != nil {
+		apperr.Abort(c, apperr.Bind(err))
+		return false
+	}
+	return true
+}
+
+// GetAccount - Handler to show the current user's account
+func (h *AccountHandler) GetAccount(c *gin.Context) {
+	account, err := h.Accounts.Get(c.Request.Context(), c.GetUint("user_id"))
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+
+	c.JSON(200, gin.H{"data": account})
+}
+
+// ChangePassword - Handler to change the password; every other session ends
+func (h *AccountHandler) ChangePassword(c *gin.Context) {
+	var input struct {
+		CurrentPassword string `json:"current_password" validate:"required"`
+		NewPassword     string `json:"new_password" validate:"required"`
+	}
+	if !bindValid(c, &input) {
+		return
+	}
+
+	token, err := h.Accounts.ChangePassword(c.Request.Context(), c.GetUint("user_id"), input.CurrentPassword, input.NewPassword)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+
+	c.JSON(200, gin.H{"message": "Password changed, other sessions were logged out", "token": token})
+}
+
+// RequestEmailChange - Handler to send a confirmation link to a new address
+func (h *AccountHandler) RequestEmailChange(c *gin.Context) {
+	var input struct {
+		Email    string `json:"email" validate:"required,email"`
+		Password string `json:"password" validate:"required"`
+	}
+	if !bindValid(c, &input) {
+		return
+	}
+
+	if err := h.Accounts.RequestEmailChange(c.Request.Context(), c.GetUint("user_id"), input.Email, input.Password); err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+
+	c.JSON(202, gin.H{"message": "Confirmation link sent to the new address"})
+}
+
+// ConfirmEmailChange - Handler following the confirmation link; needs no login
+func (h *AccountHandler) ConfirmEmailChange(c *gin.Context) {
+	var input struct {
+		Token string `json:"token" validate:"required"`
+	}
+	if !bindValid(c, &input) {
+		return
+	}
+
+	account, err := h.Accounts.ConfirmEmailChange(c.Request.Context(), input.Token)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+
+	c.JSON(200, gin.H{"message": "Email address changed", "data": account})
+}
+
+// ResetPassword - Handler setting a new password with the token from an
+// admin-forced reset; needs no login
+func (h *AccountHandler) ResetPassword(c *gin.Context) {
+	var input struct {
+		Token       string `json:"token" validate:"required"`
+		NewPassword string `json:"new_password" validate:"required"`
+	}
+	if !bindValid(c, &input) {
+		return
+	}
+
+	if err := h.Accounts.ResetPassword(c.Request.Context(), input.Token, input.NewPassword); err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+
+	c.JSON(200, gin.H{"message": "Password changed, you can log in now"})
+}
+
+// DeleteAccount - Handler to schedule the deletion of the current user's account
+func (h *AccountHandler) DeleteAccount(c *gin.Context) {
+	var input struct {
+		Password string `json:"password" validate:"required"`
+	}
+	if !bindValid(c, &input) {
+		return
+	}
+
+	at, err := h.Accounts.ScheduleDeletion(c.Request.Context(), c.GetUint("user_id"), input.Password)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+
+	c.JSON(202, gin.H{
+		"message":               "Account scheduled for deletion, log in before then to keep it",
+		"deletion_scheduled_at": at,
+	})
+}
@@ -0,0 +1,71 @@
+package controllers
+
+import (
+	"backend-go/apperr"
+	"backend-go/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+// APIKeyHandler - Handlers for the current user's personal API keys
+type APIKeyHandler struct {
+	APIKeys *services.APIKeyService
+}
+
+// NewAPIKeyHandler - Create an APIKeyHandler
+func NewAPIKeyHandler(keys *services.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{APIKeys: keys}
+}
+
+// ListAPIKeys - Handler to list the current user's keys, without their secrets
+func (h *APIKeyHandler) ListAPIKeys(c *gin.Context) {
+	keys, err := h.APIKeys.List(c.Request.Context(), c.GetUint("user_id"))
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+
+	c.JSON(200, gin.H{"data": keys})
+}
+
+// CreateAPIKey - Handler to issue a key; the secret is only in this response
+func (h *APIKeyHandler) CreateAPIKey(c *gin.Context) {
+	var input services.NewAPIKey
+
+	// Bind JSON input
+	if err := c.ShouldBindJSON(&input); err != nil {
+		apperr.Abort(c, apperr.Bind(err))
+		return
+	}
+
+	// Validate input
+	if err := validator.New().Struct(input); err != nil {
+		apperr.Abort(c, apperr.Bind(err))
+		return
+	}
+
+	key, secret, err := h.APIKeys.Create(c.Request.Context(), c.GetUint("user_id"), input)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+
+	c.JSON(201, gin.H{"message": "API key created, copy it now: it is not shown again", "key": secret, "data": key})
+}
+
+// RevokeAPIKey - Handler to revoke one of the current user's keys
+func (h *APIKeyHandler) RevokeAPIKey(c *gin.Context) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+
+	key, err := h.APIKeys.Revoke(c.Request.Context(), c.GetUint("user_id"), id)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+
+	c.JSON(200, gin.H{"message": "API key revoked", "data": key})
+}
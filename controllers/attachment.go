@@ -0,0 +1,119 @@
+package controllers
+
+import (
+	"backend-go/apperr"
+	"backend-go/models"
+	"backend-go/services"
+	"backend-go/storage"
+	"backend-go/utils"
+	"fmt"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MaxAttachmentSize - Largest file accepted as a lesson attachment (100 MB)
+const MaxAttachmentSize = 100 << 20
+
+// ErrFileTooLarge - An upload above the size limit for its kind
+var ErrFileTooLarge = apperr.New(http.StatusRequestEntityTooLarge, apperr.CodeTooLarge, "File too large")
+
+// AttachmentHandler - Handlers for the files attached to lessons
+type AttachmentHandler struct {
+	Attachments *services.AttachmentService
+}
+
+// NewAttachmentHandler - Create an AttachmentHandler
+func NewAttachmentHandler(attachments *services.AttachmentService) *AttachmentHandler {
+	return &AttachmentHandler{Attachments: attachments}
+}
+
+// UploadAttachment - Handler to attach a file to lesson :id (course staff only)
+func (h *AttachmentHandler) UploadAttachment(c *gin.Context) {
+	lesson := c.MustGet("lesson").(models.Lesson)
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		apperr.Abort(c, apperr.Unprocessable(apperr.CodeValidation, "Validation failed").WithDetails([]string{"file is required"}))
+		return
+	}
+	if file.Size > MaxAttachmentSize {
+		apperr.Abort(c, ErrFileTooLarge.WithDetails(fmt.Sprintf("maximum size is %d bytes", MaxAttachmentSize)))
+		return
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+	defer src.Close()
+
+	attachment, err := h.Attachments.Upload(c.Request.Context(), services.AttachmentUpload{
+		LessonID:    lesson.ID,
+		UploadedBy:  c.GetUint("user_id"),
+		FileName:    file.Filename,
+		DisplayName: strings.TrimSpace(c.PostForm("display_name")),
+		File:        src,
+	})
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+
+	withDownloadURL(attachment)
+	c.JSON(201, gin.H{"message": "Attachment uploaded successfully", "data": attachment})
+}
+
+// GetAttachmentsByLessonID - Handler to list the attachments of lesson :id
+func (h *AttachmentHandler) GetAttachmentsByLessonID(c *gin.Context) {
+	lesson := c.MustGet("lesson").(models.Lesson)
+
+	attachments, err := h.Attachments.ListByLesson(c.Request.Context(), lesson.ID)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+
+	for i := range attachments {
+		withDownloadURL(&attachments[i])
+	}
+	c.JSON(200, gin.H{"data": attachments})
+}
+
+// DownloadAttachment - Handler to stream attachment :id (see middleware.CanAccessAttachment)
+func DownloadAttachment(c *gin.Context) {
+	attachment := c.MustGet("attachment").(models.LessonAttachment)
+
+	// Nama file download mengikuti display name, dengan ekstensi file asli
+	downloadName := attachment.DisplayName
+	if ext := filepath.Ext(attachment.FileName); filepath.Ext(downloadName) != ext {
+		downloadName += ext
+	}
+
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": downloadName}))
+	c.Header("Content-Type", attachment.MimeType)
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Header("Digest", "sha-256="+attachment.Checksum)
+	serveStoredFile(c, storage.Private, attachment.StorageKey, "private, max-age=300")
+}
+
+// DeleteAttachment - Handler to delete attachment :id (course staff only)
+func (h *AttachmentHandler) DeleteAttachment(c *gin.Context) {
+	attachment := c.MustGet("attachment").(models.LessonAttachment)
+
+	if err := h.Attachments.Delete(c.Request.Context(), &attachment); err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+
+	c.JSON(200, gin.H{"message": "Attachment deleted successfully"})
+}
+
+// withDownloadURL - Fill in a short-lived signed download URL for the attachment
+func withDownloadURL(attachment *models.LessonAttachment) {
+	attachment.DownloadURL = utils.SignURL(fmt.Sprintf("/media/attachment/%d", attachment.ID), utils.MediaURLTTL)
+}
@@ -1,77 +1,57 @@
 package controllers
 
 import (
-	"backend-go/config"
-	"backend-go/models"
+	"backend-go/apperr"
+	"backend-go/services"
 	"backend-go/utils"
-	"fmt"
-	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
 )
 
+// AuthHandler - Handlers for registration and login
+type AuthHandler struct {
+	Auth *services.AuthService
+}
+
+// NewAuthHandler - Create an AuthHandler
+func NewAuthHandler(auth *services.AuthService) *AuthHandler {
+	return &AuthHandler{Auth: auth}
+}
 
-func Register(c *gin.Context) {
+func (h *AuthHandler) Register(c *gin.Context) {
 	type RegisterInput struct {
 		Email    string `json:"email" validate:"required,email"`
 		Username string `json:"username" validate:"required"`
 		Password string `json:"password" validate:"required"`
-		Role     string `json:"role"`
 	}
 
 	var input RegisterInput
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(400, gin.H{"error": err.Error()})
+		apperr.Abort(c, apperr.Bind(err))
 		return
 	}
 
 	validate := validator.New()
 	if err := validate.Struct(input); err != nil {
-		c.JSON(400, gin.H{"error": err.Error()})
-		return
-	}
-
-	if err := config.DB.Where("email = ?", input.Email).First(&models.User{}).Error; err == nil {
-		c.JSON(400, gin.H{"error": "Email already exists"})
-		return
-	}
-
-	if err := config.DB.Where("username = ?", input.Username).First(&models.User{}).Error; err == nil {
-		c.JSON(400, gin.H{"error": "Username already exists"})
+		apperr.Abort(c, apperr.Bind(err))
 		return
 	}
 
-	hashedPassword, err := utils.HashPassword(input.Password)
-	if err != nil {
-		c.JSON(500, gin.H{"error": "Failed to hash password"})
-		return
-	}
-
-	role := strings.ToLower(input.Role)
-	if role != "admin" && role != "user" {
-		fmt.Println(role)
-		c.JSON(400, gin.H{"error": "Invalid role"})
-		return
-	}	
-
-	user := models.User{
+	user, err := h.Auth.Register(c.Request.Context(), services.RegisterInput{
 		Email:    input.Email,
 		Username: input.Username,
-		Password: hashedPassword,
-		Roles:    role,
-	}
-
-	if err := config.DB.Create(&user).Error; err != nil {
-		c.JSON(500, gin.H{"error": "Failed to create user"})
+		Password: input.Password,
+	})
+	if err != nil {
+		apperr.Abort(c, err)
 		return
 	}
 
 	c.JSON(200, gin.H{"message": "User created successfully", "user": user})
-	return
 }
 
-func Login(c *gin.Context) {
+func (h *AuthHandler) Login(c *gin.Context) {
 	type LoginInput struct {
 		Email    string `json:"email" validate:"required,email"`
 		Password string `json:"password" validate:"required"`
@@ -79,31 +59,66 @@ func Login(c *gin.Context) {
 
 	var input LoginInput
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(400, gin.H{"error": err.Error()})
+		apperr.Abort(c, apperr.Bind(err))
 		return
 	}
 
 	validate := validator.New()
 	if err := validate.Struct(input); err != nil {
-		c.JSON(400, gin.H{"error": err.Error()})
+		apperr.Abort(c, apperr.Bind(err))
 		return
 	}
 
-	var user models.User
-	if err := config.DB.Where("email = ?", input.Email).First(&user).Error; err != nil {
-		c.JSON(401, gin.H{"error": "Invalid email or password"})
+	result, err := h.Auth.Login(c.Request.Context(), input.Email, input.Password)
+	if err != nil {
+		apperr.Abort(c, err)
 		return
 	}
 
-	if !utils.CheckPasswordHash(input.Password, user.Password){
-		c.JSON(401, gin.H{"error": "Invalid email or password"})
+	c.JSON(200, loginResponse(result))
+}
+
+// loginResponse - Body for a finished first login step, shared by password and SSO logins
+func loginResponse(result *services.LoginResult) gin.H {
+	// Login dengan 2FA selesai di /login/2fa
+	if result.MFAChallenge != "" {
+		return gin.H{
+			"message":         "Two-factor code required",
+			"mfa_required":    true,
+			"challenge_token": result.MFAChallenge,
+			"expires_in":      int(utils.MFAChallengeTTL.Seconds()),
+		}
+	}
+	if result.MFASetupRequired {
+		return gin.H{"message": "Login successful, two-factor setup required", "token": result.Token, "mfa_setup_required": true}
+	}
+	return gin.H{"message": "Login successful", "token": result.Token}
+}
+
+// LoginMFA - Second login step: exchange the challenge token and a TOTP or
+// recovery code for a JWT
+func (h *AuthHandler) LoginMFA(c *gin.Context) {
+	type MFALoginInput struct {
+		ChallengeToken string `json:"challenge_token" validate:"required"`
+		Code           string `json:"code" validate:"required"`
+	}
+
+	var input MFALoginInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		apperr.Abort(c, apperr.Bind(err))
+		return
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(input); err != nil {
+		apperr.Abort(c, apperr.Bind(err))
 		return
 	}
 
-	token, err := utils.GeneateToken(user.ID, user.Roles)
+	token, err := h.Auth.CompleteMFALogin(c.Request.Context(), input.ChallengeToken, input.Code)
 	if err != nil {
-		c.JSON(500, gin.H{"error": "Failed to generate token"})
+		apperr.Abort(c, err)
 		return
 	}
 	c.JSON(200, gin.H{"message": "Login successful", "token": token})
-}
\ No newline at end of file
+}
@@ -1,20 +1,25 @@
 package controllers
 
 import (
-	"backend-go/config"
+	"backend-go/apperr"
 	"backend-go/models"
-	"fmt"
+	"backend-go/services"
 	"net/http"
-	"os"
-	"path/filepath"
-	"strings"
-	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
-	"gorm.io/gorm"
 )
 
+// CourseHandler - Handlers for the course catalogue
+type CourseHandler struct {
+	Courses *services.CourseService
+}
+
+// NewCourseHandler - Create a CourseHandler
+func NewCourseHandler(courses *services.CourseService) *CourseHandler {
+	return &CourseHandler{Courses: courses}
+}
+
 type CourseInput struct {
 	Name        string  `form:"name" validate:"required"`
 	Description string  `form:"description" validate:"required"`
@@ -23,57 +28,38 @@ type CourseInput struct {
 }
 
 // Create Course
-func CreateCourse(c *gin.Context) {
+func (h *CourseHandler) CreateCourse(c *gin.Context) {
 	var input CourseInput
 	var validate = validator.New()
 
 	// Parsing data dari multipart/form-data
 	if err := c.ShouldBind(&input); err != nil {
-		c.JSON(400, gin.H{"error": "Invalid input", "details": err.Error()})
+		apperr.Abort(c, apperr.Bind(err))
 		return
 	}
 
 	// Validasi input (kecuali UserID karena akan diambil dari context)
 	if err := validate.Struct(&input); err != nil {
-		var errorMessages []string
-		for _, err := range err.(validator.ValidationErrors) {
-			errorMessages = append(errorMessages, fmt.Sprintf("%s is %s", strings.ToLower(err.Field()), err.Tag()))
-		}
-		c.JSON(400, gin.H{"error": "Validation failed", "details": errorMessages})
+		apperr.Abort(c, apperr.Bind(err))
 		return
 	}
 
 	// Ambil user_id dari context (diset oleh middleware IsLogin)
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(500, gin.H{"error": "Failed to retrieve user ID from context"})
+		apperr.Abort(c, errNoUserInContext)
 		return
 	}
 
-	// Direktori untuk menyimpan file
-	publicDir := "./public/uploads"
-	if _, err := os.Stat(publicDir); os.IsNotExist(err) {
-		os.MkdirAll(publicDir, os.ModePerm)
-	}
-
-	// Proses upload file gambar
+	// Proses upload file gambar ke public storage
 	file, err := c.FormFile("image") // Ambil file dari form-data dengan key "image"
 	var imageURL string
 	if err == nil {
-		// Membuat nama file unik
-		extension := filepath.Ext(file.Filename)
-		uniqueFilename := fmt.Sprintf("%s-%s-%d%s", "course", input.Name, time.Now().Unix(), extension)
-
-		// Simpan file ke direktori public/uploads
-		filePath := filepath.Join(publicDir, uniqueFilename)
-		if err := c.SaveUploadedFile(file, filePath); err != nil {
-			c.JSON(500, gin.H{"error": "Failed to upload file", "details": err.Error()})
+		imageURL, err = savePublicUpload(file, "course")
+		if err != nil {
+			apperr.Abort(c, err)
 			return
 		}
-
-		imageURL = fmt.Sprintf("/uploads/%s", uniqueFilename)
-	} else {
-		imageURL = ""
 	}
 
 	// Membuat Course
@@ -86,8 +72,8 @@ func CreateCourse(c *gin.Context) {
 	}
 
 	// Simpan ke database
-	if err := config.DB.Create(&course).Error; err != nil {
-		c.JSON(500, gin.H{"error": "Failed to create course", "details": err.Error()})
+	if err := h.Courses.Create(c.Request.Context(), &course); err != nil {
+		apperr.Abort(c, err)
 		return
 	}
 
@@ -95,12 +81,11 @@ func CreateCourse(c *gin.Context) {
 }
 
 // Get All Courses
-func GetCourses(c *gin.Context) {
-	var courses []models.Course
-
+func (h *CourseHandler) GetCourses(c *gin.Context) {
 	// Ambil data dari database
-	if err := config.DB.Find(&courses).Error; err != nil {
-		c.JSON(500, gin.H{"error": "Failed to fetch courses", "details": err.Error()})
+	courses, err := h.Courses.List(c.Request.Context())
+	if err != nil {
+		apperr.Abort(c, err)
 		return
 	}
 
@@ -108,13 +93,16 @@ func GetCourses(c *gin.Context) {
 }
 
 // Get Single Course by ID
-func GetCourseByID(c *gin.Context) {
-	var course models.Course
-	id := c.Param("id")
+func (h *CourseHandler) GetCourseByID(c *gin.Context) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
 
 	// Cari course berdasarkan ID
-	if err := config.DB.First(&course, id).Error; err != nil {
-		c.JSON(404, gin.H{"error": "Course not found", "details": err.Error()})
+	course, err := h.Courses.Get(c.Request.Context(), id)
+	if err != nil {
+		apperr.Abort(c, err)
 		return
 	}
 
@@ -122,13 +110,16 @@ func GetCourseByID(c *gin.Context) {
 }
 
 // Update Course
-func UpdateCourse(c *gin.Context) {
-	var course models.Course
-	id := c.Param("id")
+func (h *CourseHandler) UpdateCourse(c *gin.Context) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
 
 	// Cari course berdasarkan ID
-	if err := config.DB.First(&course, id).Error; err != nil {
-		c.JSON(404, gin.H{"error": "Course not found", "details": err.Error()})
+	course, err := h.Courses.Get(c.Request.Context(), id)
+	if err != nil {
+		apperr.Abort(c, err)
 		return
 	}
 
@@ -137,48 +128,31 @@ func UpdateCourse(c *gin.Context) {
 
 	// Parsing data dari multipart/form-data
 	if err := c.ShouldBind(&input); err != nil {
-		c.JSON(400, gin.H{"error": "Invalid input", "details": err.Error()})
+		apperr.Abort(c, apperr.Bind(err))
 		return
 	}
 
 	// Validasi input
 	if err := validate.StructExcept(&input, "UserID"); err != nil {
-		var errorMessages []string
-		for _, err := range err.(validator.ValidationErrors) {
-			errorMessages = append(errorMessages, fmt.Sprintf("%s is %s", strings.ToLower(err.Field()), err.Tag()))
-		}
-		c.JSON(400, gin.H{"error": "Validation failed", "details": errorMessages})
+		apperr.Abort(c, apperr.Bind(err))
 		return
 	}
 
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(500, gin.H{"error": "Failed to retrieve user ID from context"})
+		apperr.Abort(c, errNoUserInContext)
 		return
 	}
 
-	// Direktori untuk menyimpan file
-	publicDir := "./public/uploads"
-	if _, err := os.Stat(publicDir); os.IsNotExist(err) {
-		os.MkdirAll(publicDir, os.ModePerm)
-	}
-
 	// Proses upload file gambar (jika ada)
 	file, err := c.FormFile("image")
 	var imageURL string
 	if err == nil {
-		// Membuat nama file unik
-		extension := filepath.Ext(file.Filename)
-		uniqueFilename := fmt.Sprintf("%s-%s-%d%s", "course", input.Name, time.Now().Unix(), extension)
-
-		// Simpan file ke direktori public/uploads
-		filePath := filepath.Join(publicDir, uniqueFilename)
-		if err := c.SaveUploadedFile(file, filePath); err != nil {
-			c.JSON(500, gin.H{"error": "Failed to upload file", "details": err.Error()})
+		imageURL, err = savePublicUpload(file, "course")
+		if err != nil {
+			apperr.Abort(c, err)
 			return
 		}
-
-		imageURL = fmt.Sprintf("/uploads/%s", uniqueFilename)
 	} else {
 		// Gunakan gambar sebelumnya jika tidak ada gambar baru
 		imageURL = course.Image
@@ -193,8 +167,8 @@ func UpdateCourse(c *gin.Context) {
 		UserID:      userID.(uint),
 	}
 
-	if err := config.DB.Model(&course).Updates(updatedData).Error; err != nil {
-		c.JSON(500, gin.H{"error": "Failed to update course", "details": err.Error()})
+	if err := h.Courses.Update(c.Request.Context(), course, updatedData); err != nil {
+		apperr.Abort(c, err)
 		return
 	}
 
@@ -202,50 +176,32 @@ func UpdateCourse(c *gin.Context) {
 }
 
 // Delete Course
-func DeleteCourse(c *gin.Context) {
-	var course models.Course
-	id := c.Param("id")
-
-	// Cari course berdasarkan ID
-	if err := config.DB.First(&course, id).Error; err != nil {
-		c.JSON(404, gin.H{"error": "Course not found", "details": err.Error()})
+func (h *CourseHandler) DeleteCourse(c *gin.Context) {
+	id, ok := paramID(c, "id")
+	if !ok {
 		return
 	}
 
 	// Hapus course dari database
-	if err := config.DB.Delete(&course).Error; err != nil {
-		c.JSON(500, gin.H{"error": "Failed to delete course", "details": err.Error()})
+	if err := h.Courses.Delete(c.Request.Context(), id); err != nil {
+		apperr.Abort(c, err)
 		return
 	}
 
 	c.JSON(200, gin.H{"message": "Course deleted successfully"})
 }
 
-func GetStudentsInCourse(c *gin.Context) {
+func (h *CourseHandler) GetStudentsInCourse(c *gin.Context) {
 	// Ambil CourseID dari parameter URL
-	courseID := c.Param("id")
-
-	// Validasi CourseID
-	if courseID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Course ID is required"})
+	courseID, ok := paramID(c, "id")
+	if !ok {
 		return
 	}
 
-	// Periksa apakah kursus ada
-	var course models.Course
-	if err := config.DB.First(&course, courseID).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Course not found"})
-		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve course", "details": err.Error()})
-		}
-		return
-	}
-
-	// Ambil daftar murid yang terdaftar di kursus ini
-	var enrollments []models.Enrollment
-	if err := config.DB.Preload("User").Where("course_id = ?", courseID).Find(&enrollments).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve enrollments", "details": err.Error()})
+	// Ambil kursus beserta daftar murid yang terdaftar
+	course, enrollments, err := h.Courses.Students(c.Request.Context(), courseID)
+	if err != nil {
+		apperr.Abort(c, err)
 		return
 	}
 
@@ -258,6 +214,9 @@ func GetStudentsInCourse(c *gin.Context) {
 	}
 
 	for _, enrollment := range enrollments {
+		if enrollment.User == nil {
+			continue
+		}
 		students = append(students, struct {
 			UserID   uint   `json:"user_id"`
 			Username string `json:"username"`
@@ -271,4 +230,3 @@ func GetStudentsInCourse(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"course": course.Name, "students": students})
 }
-
@@ -1,66 +1,41 @@
 package controllers
 
 import (
-	"backend-go/config"
-	"backend-go/models"
+	"backend-go/apperr"
+	"backend-go/services"
 	"net/http"
-	"strconv"
 
 	"github.com/gin-gonic/gin"
-	"gorm.io/gorm"
 )
 
-// EnrollCourse: Mendaftarkan pengguna ke kursus
-func EnrollCourse(c *gin.Context) {
-	courseID := c.Param("id")
+// EnrollmentHandler - Handlers for enrolling in courses
+type EnrollmentHandler struct {
+	Enrollments *services.EnrollmentService
+}
 
-	// Validasi CourseID
-	if courseID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Course ID is required"})
-		return
-	}
+// NewEnrollmentHandler - Create an EnrollmentHandler
+func NewEnrollmentHandler(enrollments *services.EnrollmentService) *EnrollmentHandler {
+	return &EnrollmentHandler{Enrollments: enrollments}
+}
 
-	// Convert courseID to uint
-	courseIDUint, err := strconv.ParseUint(courseID, 10, 32)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Course ID format"})
+// EnrollCourse: Mendaftarkan pengguna ke kursus
+func (h *EnrollmentHandler) EnrollCourse(c *gin.Context) {
+	// Validasi CourseID
+	courseID, ok := paramID(c, "id")
+	if !ok {
 		return
 	}
 
 	// Ambil user_id dari context (diset oleh middleware)
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve user ID from context"})
-		return
-	}
-
-	// Periksa apakah kursus ada
-	var course models.Course
-	if err := config.DB.First(&course, courseIDUint).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Course not found"})
-		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve course", "details": err.Error()})
-		}
-		return
-	}
-
-	// Periksa apakah pengguna sudah terdaftar
-	var existingEnrollment models.Enrollment
-	if err := config.DB.Where("user_id = ? AND course_id = ?", userID, courseIDUint).First(&existingEnrollment).Error; err == nil {
-		c.JSON(http.StatusConflict, gin.H{"error": "Already enrolled in this course"})
+		apperr.Abort(c, errNoUserInContext)
 		return
 	}
 
-	// Buat enrollment baru
-	enrollment := models.Enrollment{
-		UserID:   userID.(uint),
-		CourseID: uint(courseIDUint), // Convert to uint
-	}
-
-	// Save the enrollment
-	if err := config.DB.Create(&enrollment).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to enroll in course", "details": err.Error()})
+	// Kursus harus ada dan pengguna belum terdaftar
+	if _, err := h.Enrollments.Enroll(c.Request.Context(), userID.(uint), courseID); err != nil {
+		apperr.Abort(c, err)
 		return
 	}
 
@@ -69,18 +44,18 @@ func EnrollCourse(c *gin.Context) {
 }
 
 // GetEnrollments: Melihat daftar kursus yang terdaftar
-func GetEnrollments(c *gin.Context) {
+func (h *EnrollmentHandler) GetEnrollments(c *gin.Context) {
 	// Ambil user_id dari context
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve user ID from context"})
+		apperr.Abort(c, errNoUserInContext)
 		return
 	}
 
 	// Ambil daftar kursus yang terdaftar
-	var enrollments []models.Enrollment
-	if err := config.DB.Preload("Course").Where("user_id = ?", userID).Find(&enrollments).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve enrollments", "details": err.Error()})
+	enrollments, err := h.Enrollments.List(c.Request.Context(), userID.(uint))
+	if err != nil {
+		apperr.Abort(c, err)
 		return
 	}
 
@@ -88,43 +63,23 @@ func GetEnrollments(c *gin.Context) {
 }
 
 // UnenrollCourse: Membatalkan pendaftaran dari kursus
-func UnenrollCourse(c *gin.Context) {
-	courseID := c.Param("id")
-
+func (h *EnrollmentHandler) UnenrollCourse(c *gin.Context) {
 	// Validasi CourseID
-	if courseID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Course ID is required"})
-		return
-	}
-
-	// Convert courseID to uint
-	courseIDUint, err := strconv.ParseUint(courseID, 10, 32)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Course ID format"})
+	courseID, ok := paramID(c, "id")
+	if !ok {
 		return
 	}
 
 	// Ambil user_id dari context
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve user ID from context"})
-		return
-	}
-
-	// Periksa apakah pengguna sudah terdaftar
-	var enrollment models.Enrollment
-	if err := config.DB.Where("user_id = ? AND course_id = ?", userID, courseIDUint).First(&enrollment).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Enrollment not found"})
-		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve enrollment", "details": err.Error()})
-		}
+		apperr.Abort(c, errNoUserInContext)
 		return
 	}
 
-	// Hapus enrollment
-	if err := config.DB.Delete(&enrollment).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unenroll", "details": err.Error()})
+	// Hapus enrollment jika pengguna memang terdaftar
+	if err := h.Enrollments.Unenroll(c.Request.Context(), userID.(uint), courseID); err != nil {
+		apperr.Abort(c, err)
 		return
 	}
 
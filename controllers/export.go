@@ -0,0 +1,56 @@
+package controllers
+
+import (
+	"backend-go/apperr"
+	"backend-go/models"
+	"backend-go/services"
+	"backend-go/storage"
+	"fmt"
+	"mime"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ExportHandler - Handlers for the current user's personal data exports
+type ExportHandler struct {
+	Exports *services.ExportService
+}
+
+// NewExportHandler - Create an ExportHandler
+func NewExportHandler(exports *services.ExportService) *ExportHandler {
+	return &ExportHandler{Exports: exports}
+}
+
+// RequestExport - Handler to start building an export; the user is emailed
+// a download link once it is ready
+func (h *ExportHandler) RequestExport(c *gin.Context) {
+	export, err := h.Exports.Request(c.Request.Context(), c.GetUint("user_id"))
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+
+	c.JSON(202, gin.H{"message": "Your export is being prepared, we will email you when it is ready", "data": export})
+}
+
+// ListExports - Handler to list the current user's exports
+func (h *ExportHandler) ListExports(c *gin.Context) {
+	exports, err := h.Exports.List(c.Request.Context(), c.GetUint("user_id"))
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+
+	c.JSON(200, gin.H{"data": exports})
+}
+
+// DownloadExport - Handler to stream export :id (see middleware.CanDownloadExport)
+func DownloadExport(c *gin.Context) {
+	export := c.MustGet("export").(*models.DataExport)
+
+	filename := fmt.Sprintf("data-export-%s.zip", export.CompletedAt.UTC().Format("2006-01-02"))
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	c.Header("Content-Type", "application/zip")
+	c.Header("X-Content-Type-Options", "nosniff")
+	serveStoredFile(c, storage.Private, export.StorageKey, "private, no-store")
+}
@@ -0,0 +1,60 @@
+package controllers
+
+import (
+	"backend-go/config"
+	"backend-go/storage"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// readinessTimeout - Upper bound for each dependency check in Readyz
+const readinessTimeout = 2 * time.Second
+
+// Healthz - Liveness: the process is up and serving HTTP. Checks no dependencies.
+func Healthz(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// Readyz - Readiness: the database answers a ping and both storage backends
+// accept writes. Answers 503 with the failing checks otherwise.
+func Readyz(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), readinessTimeout)
+	defer cancel()
+
+	checks := map[string]func() error{
+		"database": func() error {
+			if config.DB == nil {
+				return errors.New("not connected")
+			}
+			sqlDB, err := config.DB.DB()
+			if err != nil {
+				return err
+			}
+			return sqlDB.PingContext(ctx)
+		},
+		"storage_public":  storage.Public.Ping,
+		"storage_private": storage.Private.Ping,
+	}
+
+	status, results := http.StatusOK, gin.H{}
+	for name, check := range checks {
+		if err := check(); err != nil {
+			slog.WarnContext(c.Request.Context(), "readiness check failed", "check", name, "error", err)
+			status = http.StatusServiceUnavailable
+			results[name] = "fail"
+			continue
+		}
+		results[name] = "ok"
+	}
+
+	overall := "ok"
+	if status != http.StatusOK {
+		overall = "unavailable"
+	}
+	c.JSON(status, gin.H{"status": overall, "checks": results})
+}
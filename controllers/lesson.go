@@ -1,196 +1,189 @@
 package controllers
 
 import (
-	"backend-go/config"
+	"backend-go/apperr"
 	"backend-go/models"
-	"fmt"
-	"net/http"
-	"os"
-	"path/filepath"
+	"backend-go/services"
+	"backend-go/utils"
 	"strings"
-	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
 )
 
+// LessonHandler - Handlers for lessons
+type LessonHandler struct {
+	Lessons *services.LessonService
+}
+
+// NewLessonHandler - Create a LessonHandler
+func NewLessonHandler(lessons *services.LessonService) *LessonHandler {
+	return &LessonHandler{Lessons: lessons}
+}
+
 // CreateLesson - Handler to create a new lesson
-func CreateLesson(c *gin.Context) {
+func (h *LessonHandler) CreateLesson(c *gin.Context) {
 	var input struct {
-		Name        string `form:"name" binding:"required"`
-		Description string `form:"description" binding:"required"`
-		CourseID    uint   `form:"course_id" binding:"required"`
+		Name          string `form:"name" binding:"required"`
+		Description   string `form:"description" binding:"required"`
+		Content       string `form:"content"`
+		ContentFormat string `form:"content_format" binding:"omitempty,oneof=markdown plain"`
+		Type          string `form:"type" binding:"omitempty,oneof=text video"`
+		CourseID      uint   `form:"course_id" binding:"required"`
 	}
 
 	var validate = validator.New()
 
 	// Parsing data dari multipart/form-data
 	if err := c.ShouldBind(&input); err != nil {
-		c.JSON(400, gin.H{"error": "Invalid input", "details": err.Error()})
+		apperr.Abort(c, apperr.Bind(err))
 		return
 	}
 
 	// Validasi input
 	if err := validate.Struct(&input); err != nil {
-		var errorMessages []string
-		for _, err := range err.(validator.ValidationErrors) {
-			errorMessages = append(errorMessages, fmt.Sprintf("%s is %s", strings.ToLower(err.Field()), err.Tag()))
-		}
-		c.JSON(400, gin.H{"error": "Validation failed", "details": errorMessages})
+		apperr.Abort(c, apperr.Bind(err))
 		return
 	}
 
-	// Check if the course exists
-	var course models.Course
-	if err := config.DB.First(&course, input.CourseID).Error; err != nil {
-		if err.Error() == "record not found" {
-			c.JSON(404, gin.H{"error": "Course not found"})
-		} else {
-			c.JSON(500, gin.H{"error": "Failed to fetch course", "details": err.Error()})
-		}
-		return
-	}
-
-	// Direktori untuk menyimpan file
-	publicDir := "./public/uploads"
-	if _, err := os.Stat(publicDir); os.IsNotExist(err) {
-		os.MkdirAll(publicDir, os.ModePerm)
-	}
-
-	// Proses upload file gambar (jika ada)
+	// Proses upload file gambar (jika ada), disimpan di private storage
 	file, err := c.FormFile("image")
 	var imageURL string
 	if err == nil {
-		// Membuat nama file unik
-		extension := filepath.Ext(file.Filename)
-		uniqueFilename := fmt.Sprintf("%s-%s-%d%s", "lesson", input.Name, time.Now().Unix(), extension)
-
-		// Simpan file ke direktori public/uploads
-		filePath := filepath.Join(publicDir, uniqueFilename)
-		if err := c.SaveUploadedFile(file, filePath); err != nil {
-			c.JSON(500, gin.H{"error": "Failed to upload file", "details": err.Error()})
+		imageURL, err = saveLessonImage(file)
+		if err != nil {
+			apperr.Abort(c, err)
 			return
 		}
-
-		imageURL = fmt.Sprintf("/uploads/%s", uniqueFilename)
 	}
 
 	// Create a new lesson
+	contentFormat := input.ContentFormat
+	if contentFormat == "" {
+		contentFormat = utils.ContentFormatMarkdown
+	}
+
 	lesson := models.Lesson{
-		Name:        input.Name,
-		Description: input.Description,
-		Image:       imageURL,
-		CourseID:    input.CourseID,
+		Name:          input.Name,
+		Description:   input.Description,
+		Content:       input.Content,
+		ContentFormat: contentFormat,
+		Type:          input.Type,
+		Image:         imageURL,
+		CourseID:      input.CourseID,
 	}
 
-	// Save the lesson to the database
-	if err := config.DB.Create(&lesson).Error; err != nil {
-		c.JSON(500, gin.H{"error": "Failed to create lesson", "details": err.Error()})
+	// Course harus ada; HTML di-render dan revisi pertama dicatat oleh service
+	if err := h.Lessons.Create(c.Request.Context(), &lesson, c.GetUint("user_id")); err != nil {
+		apperr.Abort(c, err)
 		return
 	}
 
+	withMediaURL(&lesson)
 	c.JSON(201, gin.H{"message": "Lesson created successfully", "data": lesson})
 }
 
 
 // GetLessons - Handler to fetch all lessons
-func GetLessons(c *gin.Context) {
-	var lessons []models.Lesson
-
+func (h *LessonHandler) GetLessons(c *gin.Context) {
 	// Fetch lessons from the database
-	if err := config.DB.Find(&lessons).Error; err != nil {
-		c.JSON(500, gin.H{"error": "Failed to fetch lessons", "details": err.Error()})
+	lessons, err := h.Lessons.List(c.Request.Context())
+	if err != nil {
+		apperr.Abort(c, err)
 		return
 	}
 
+	for i := range lessons {
+		withMediaURL(&lessons[i])
+	}
 	c.JSON(200, gin.H{"data": lessons})
 }
 
-// GetLessonByID - Handler to fetch a specific lesson by ID
-func GetLessonByID(c *gin.Context) {
-	lessonID := c.Param("id")
+// GetLessonByID - Handler to fetch a specific lesson by ID. The response carries
+// signed media URLs, so the route must authorize through IsEnrolledInLesson.
+func (h *LessonHandler) GetLessonByID(c *gin.Context) {
+	lessonID, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
 
 	// Fetch lesson by ID from the database
-	var lesson models.Lesson
-	if err := config.DB.First(&lesson, lessonID).Error; err != nil {
-		if err.Error() == "record not found" {
-			c.JSON(404, gin.H{"error": "Lesson not found"})
-		} else {
-			c.JSON(500, gin.H{"error": "Failed to fetch lesson", "details": err.Error()})
-		}
+	lesson, err := h.Lessons.Get(c.Request.Context(), lessonID)
+	if err != nil {
+		apperr.Abort(c, err)
 		return
 	}
 
+	withMediaURL(lesson)
+	if strings.Contains(lesson.ContentHTML, "/media/attachment/") {
+		ids, err := h.Lessons.AttachmentIDs(c.Request.Context(), lesson.ID)
+		if err != nil {
+			apperr.Abort(c, err)
+			return
+		}
+		signContentHTML(lesson, ids)
+	}
 	c.JSON(200, gin.H{"data": lesson})
 }
 
-func GetLessonsByCourseID(c *gin.Context) {
-	courseID := c.Param("course_id")
+func (h *LessonHandler) GetLessonsByCourseID(c *gin.Context) {
+	courseID, ok := paramID(c, "course_id")
+	if !ok {
+		return
+	}
 
 	// Fetch all lessons belonging to the course
-	var lessons []models.Lesson
-	if err := config.DB.Where("course_id = ?", courseID).Find(&lessons).Error; err != nil {
-		if err.Error() == "record not found" {
-			c.JSON(404, gin.H{"error": "No lessons found for this course"})
-		} else {
-			c.JSON(500, gin.H{"error": "Failed to fetch lessons", "details": err.Error()})
-		}
+	lessons, err := h.Lessons.ListByCourse(c.Request.Context(), courseID)
+	if err != nil {
+		apperr.Abort(c, err)
 		return
 	}
 
+	for i := range lessons {
+		withMediaURL(&lessons[i])
+	}
 	c.JSON(200, gin.H{"data": lessons})
 }
 
 
 // UpdateLesson - Handler to update an existing lesson (optional)
-func UpdateLesson(c *gin.Context) {
-	lessonID := c.Param("id")
+func (h *LessonHandler) UpdateLesson(c *gin.Context) {
+	lessonID, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
 
 	var input struct {
-		Name        string `form:"name"`
-		Description string `form:"description"`
-		CourseID    uint   `form:"course_id"`
+		Name          string `form:"name"`
+		Description   string `form:"description"`
+		Content       string `form:"content"`
+		ContentFormat string `form:"content_format" binding:"omitempty,oneof=markdown plain"`
+		Type          string `form:"type" binding:"omitempty,oneof=text video"`
+		CourseID      uint   `form:"course_id"`
 	}
 
 	// Parsing data dari multipart/form-data
 	if err := c.ShouldBind(&input); err != nil {
-		c.JSON(400, gin.H{"error": "Invalid input", "details": err.Error()})
+		apperr.Abort(c, apperr.Bind(err))
 		return
 	}
 
 	// Check if the lesson exists
-	var lesson models.Lesson
-	if err := config.DB.First(&lesson, lessonID).Error; err != nil {
-		if err.Error() == "record not found" {
-			c.JSON(404, gin.H{"error": "Lesson not found"})
-		} else {
-			c.JSON(500, gin.H{"error": "Failed to fetch lesson", "details": err.Error()})
-		}
+	lesson, err := h.Lessons.Get(c.Request.Context(), lessonID)
+	if err != nil {
+		apperr.Abort(c, err)
 		return
 	}
 
-	// Direktori untuk menyimpan file
-	publicDir := "./public/uploads"
-	if _, err := os.Stat(publicDir); os.IsNotExist(err) {
-		os.MkdirAll(publicDir, os.ModePerm)
-	}
-
-	// Proses upload file gambar (jika ada)
+	// Proses upload file gambar (jika ada), disimpan di private storage
 	file, err := c.FormFile("image")
 	var imageURL string
 	if err == nil {
-		// Membuat nama file unik
-		extension := filepath.Ext(file.Filename)
-		uniqueFilename := fmt.Sprintf("%s-%s-%d%s", "lesson", input.Name, time.Now().Unix(), extension)
-
-		// Simpan file ke direktori public/uploads
-		filePath := filepath.Join(publicDir, uniqueFilename)
-		if err := c.SaveUploadedFile(file, filePath); err != nil {
-			c.JSON(500, gin.H{"error": "Failed to upload file", "details": err.Error()})
+		imageURL, err = saveLessonImage(file)
+		if err != nil {
+			apperr.Abort(c, err)
 			return
 		}
-
-		imageURL = fmt.Sprintf("/uploads/%s", uniqueFilename)
 	} else {
 		// Gunakan gambar sebelumnya jika tidak ada gambar baru
 		imageURL = lesson.Image
@@ -203,39 +196,41 @@ func UpdateLesson(c *gin.Context) {
 	if input.Description != "" {
 		lesson.Description = input.Description
 	}
+	if input.Content != "" {
+		lesson.Content = input.Content
+	}
+	if input.ContentFormat != "" {
+		lesson.ContentFormat = input.ContentFormat
+	}
+	if input.Type != "" {
+		lesson.Type = input.Type
+	}
 	if input.CourseID != 0 {
 		lesson.CourseID = input.CourseID
 	}
 	lesson.Image = imageURL
 
-	// Save the updated lesson to the database
-	if err := config.DB.Save(&lesson).Error; err != nil {
-		c.JSON(500, gin.H{"error": "Failed to update lesson", "details": err.Error()})
+	// Save the updated lesson to the database and keep the previous state in history
+	if err := h.Lessons.Update(c.Request.Context(), lesson, c.GetUint("user_id"), ""); err != nil {
+		apperr.Abort(c, err)
 		return
 	}
 
+	withMediaURL(lesson)
 	c.JSON(200, gin.H{"message": "Lesson updated successfully", "data": lesson})
 }
 
 
 // DeleteLesson - Handler to delete a lesson (optional)
-func DeleteLesson(c *gin.Context) {
-	lessonID := c.Param("id")
-
-	// Check if the lesson exists
-	var lesson models.Lesson
-	if err := config.DB.First(&lesson, lessonID).Error; err != nil {
-		if err.Error() == "record not found" {
-			c.JSON(404, gin.H{"error": "Lesson not found"})
-		} else {
-			c.JSON(500, gin.H{"error": "Failed to fetch lesson", "details": err.Error()})
-		}
+func (h *LessonHandler) DeleteLesson(c *gin.Context) {
+	lessonID, ok := paramID(c, "id")
+	if !ok {
 		return
 	}
 
 	// Delete the lesson from the database
-	if err := config.DB.Delete(&lesson).Error; err != nil {
-		c.JSON(500, gin.H{"error": "Failed to delete lesson", "details": err.Error()})
+	if err := h.Lessons.Delete(c.Request.Context(), lessonID); err != nil {
+		apperr.Abort(c, err)
 		return
 	}
 
@@ -243,29 +238,29 @@ func DeleteLesson(c *gin.Context) {
 }
 
 // GetLessonsInCourse - Handler to fetch all lessons for a specific course
-func GetLessonsInCourse(c *gin.Context) {
+func (h *LessonHandler) GetLessonsInCourse(c *gin.Context) {
 	// Get the CourseID from the URL parameter
-	courseID := c.Param("id")
-
-	if courseID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Course ID is required"})
+	courseID, ok := paramID(c, "id")
+	if !ok {
 		return
 	}
 
-
 	// Fetch all lessons for the specified course
-	var lessons []models.Lesson
-	if err := config.DB.Where("course_id = ?", courseID).Find(&lessons).Error; err != nil {
-		c.JSON(500, gin.H{"error": "Failed to fetch lessons for the course", "details": err.Error()})
+	lessons, err := h.Lessons.ListByCourse(c.Request.Context(), courseID)
+	if err != nil {
+		apperr.Abort(c, err)
 		return
 	}
 
 	// If no lessons are found
 	if len(lessons) == 0 {
-		c.JSON(404, gin.H{"error": "No lessons found for the specified course"})
+		apperr.Abort(c, apperr.NotFound("no_lessons", "No lessons found for the specified course"))
 		return
 	}
 
 	// Return the lessons for the course
+	for i := range lessons {
+		withMediaURL(&lessons[i])
+	}
 	c.JSON(200, gin.H{"data": lessons})
 }
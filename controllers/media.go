@@ -0,0 +1,138 @@
+package controllers
+
+import (
+	"backend-go/apperr"
+	"backend-go/models"
+	"backend-go/storage"
+	"backend-go/utils"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// publicUploadPrefixes - Only files with these prefixes are served from /uploads
+var publicUploadPrefixes = []string{"course-", "profile-"}
+
+// ErrFileNotFound - The stored file does not exist or may not be served
+var ErrFileNotFound = apperr.NotFound("file_not_found", "File not found")
+
+// ServePublicUpload - Handler to serve public files such as course thumbnails and avatars
+func ServePublicUpload(c *gin.Context) {
+	name := filepath.Base(c.Param("file"))
+
+	allowed := false
+	for _, prefix := range publicUploadPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		apperr.Abort(c, ErrFileNotFound)
+		return
+	}
+
+	serveStoredFile(c, storage.Public, name, "public, max-age=86400")
+}
+
+// ServeLessonMedia - Handler to serve a lesson's protected image (see middleware.CanAccessLessonMedia)
+func ServeLessonMedia(c *gin.Context) {
+	lesson := c.MustGet("lesson").(models.Lesson)
+	if lesson.Image == "" {
+		apperr.Abort(c, apperr.NotFound("lesson_has_no_media", "Lesson has no media"))
+		return
+	}
+
+	backend, key := lessonImageLocation(lesson.Image)
+	serveStoredFile(c, backend, key, "private, max-age=300")
+}
+
+func serveStoredFile(c *gin.Context, backend storage.Backend, key, cacheControl string) {
+	f, err := backend.Open(key)
+	if err != nil {
+		apperr.Abort(c, ErrFileNotFound)
+		return
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+
+	c.Header("Cache-Control", cacheControl)
+	http.ServeContent(c.Writer, c.Request, info.Name(), info.ModTime(), f)
+}
+
+// lessonImageLocation - Lessons created before protected media kept their image under /uploads
+func lessonImageLocation(image string) (storage.Backend, string) {
+	if strings.HasPrefix(image, "/uploads/") {
+		return storage.Public, strings.TrimPrefix(image, "/uploads/")
+	}
+	return storage.Private, image
+}
+
+// savePublicUpload - Store an uploaded thumbnail/avatar in public storage and return its /uploads URL
+func savePublicUpload(file *multipart.FileHeader, prefix string) (string, error) {
+	src, err := file.Open()
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+
+	// Nama file acak, bukan nama course/user: nama bisa berisi "/" atau ".."
+	uniqueFilename := fmt.Sprintf("%s-%s%s", prefix, randomToken(), filepath.Ext(file.Filename))
+	if _, err := storage.Public.Save(uniqueFilename, src); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("/uploads/%s", uniqueFilename), nil
+}
+
+// saveLessonImage - Store an uploaded lesson image in private storage and return its key
+func saveLessonImage(file *multipart.FileHeader) (string, error) {
+	src, err := file.Open()
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+
+	key := fmt.Sprintf("lessons/lesson-%s%s", randomToken(), filepath.Ext(file.Filename))
+	if _, err := storage.Private.Save(key, src); err != nil {
+		return "", err
+	}
+	return key, nil
+}
+
+// withMediaURL - Fill in short-lived signed URLs for the lesson image and video
+func withMediaURL(lesson *models.Lesson) {
+	if lesson.Image != "" {
+		lesson.ImageURL = utils.SignURL(fmt.Sprintf("/media/lesson/%d", lesson.ID), utils.MediaURLTTL)
+	}
+	if lesson.VideoKey != "" {
+		lesson.VideoURL = utils.SignURL(fmt.Sprintf("/media/lesson/%d/video", lesson.ID), utils.MediaURLTTL)
+	}
+}
+
+// signContentHTML - Sign the attachment URLs embedded in the lesson HTML so images
+// load in the browser. Only attachments belonging to this lesson (ids) are signed.
+func signContentHTML(lesson *models.Lesson, ids []uint) {
+	own := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		own[fmt.Sprint(id)] = true
+	}
+
+	lesson.ContentHTML = utils.AttachmentMediaPath.ReplaceAllStringFunc(lesson.ContentHTML, func(match string) string {
+		id := utils.AttachmentMediaPath.FindStringSubmatch(match)[1]
+		if !own[id] {
+			return match
+		}
+		// & harus di-escape karena URL berada di dalam atribut HTML
+		signed := utils.SignURL("/media/attachment/"+id, utils.MediaURLTTL)
+		return strings.ReplaceAll(signed, "&", "&amp;") + `"`
+	})
+}
@@ -0,0 +1,102 @@
+package controllers
+
+import (
+	"backend-go/apperr"
+	"backend-go/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+// MFAHandler - Handlers for the logged-in user's two-factor authentication
+type MFAHandler struct {
+	MFA *services.MFAService
+}
+
+// NewMFAHandler - Create an MFAHandler
+func NewMFAHandler(mfa *services.MFAService) *MFAHandler {
+	return &MFAHandler{MFA: mfa}
+}
+
+// MFACodeInput - A TOTP code, or a recovery code where the route accepts one
+type MFACodeInput struct {
+	Code string `json:"code" validate:"required"`
+}
+
+// bindCode - Read and validate the code from the body
+func bindCode(c *gin.Context) (string, bool) {
+	var input MFACodeInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		apperr.Abort(c, apperr.Bind(err))
+		return "", false
+	}
+	if err := validator.New().Struct(input); err != nil {
+		apperr.Abort(c, apperr.Bind(err))
+		return "", false
+	}
+	return input.Code, true
+}
+
+// Status - Whether two-factor authentication is on, required, and how many recovery codes are left
+func (h *MFAHandler) Status(c *gin.Context) {
+	status, err := h.MFA.Status(c.Request.Context(), c.GetUint("user_id"))
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+	c.JSON(200, gin.H{"data": status})
+}
+
+// Setup - Start enrollment: a new secret and its otpauth:// URI for the QR code
+func (h *MFAHandler) Setup(c *gin.Context) {
+	setup, err := h.MFA.Setup(c.Request.Context(), c.GetUint("user_id"))
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+	c.JSON(200, gin.H{"message": "Scan the QR code, then confirm with the first code", "data": setup})
+}
+
+// Confirm - Finish enrollment with the first code from the authenticator
+func (h *MFAHandler) Confirm(c *gin.Context) {
+	code, ok := bindCode(c)
+	if !ok {
+		return
+	}
+
+	confirmation, err := h.MFA.Confirm(c.Request.Context(), c.GetUint("user_id"), code)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+	c.JSON(200, gin.H{"message": "Two-factor authentication enabled, store the recovery codes safely", "data": confirmation})
+}
+
+// RegenerateRecoveryCodes - Replace every recovery code, given a current TOTP code
+func (h *MFAHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	code, ok := bindCode(c)
+	if !ok {
+		return
+	}
+
+	codes, err := h.MFA.RegenerateRecoveryCodes(c.Request.Context(), c.GetUint("user_id"), code)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+	c.JSON(200, gin.H{"message": "New recovery codes issued, the old ones no longer work", "data": gin.H{"recovery_codes": codes}})
+}
+
+// Disable - Turn two-factor authentication off with a TOTP or recovery code
+func (h *MFAHandler) Disable(c *gin.Context) {
+	code, ok := bindCode(c)
+	if !ok {
+		return
+	}
+
+	if err := h.MFA.Disable(c.Request.Context(), c.GetUint("user_id"), code); err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+	c.JSON(200, gin.H{"message": "Two-factor authentication disabled"})
+}
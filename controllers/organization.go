@@ -0,0 +1,96 @@
+package controllers
+
+import (
+	"backend-go/apperr"
+	"backend-go/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OrganizationHandler - Handlers for the organizations sharing the platform
+type OrganizationHandler struct {
+	Organizations *services.OrganizationService
+}
+
+// NewOrganizationHandler - Create an OrganizationHandler
+func NewOrganizationHandler(organizations *services.OrganizationService) *OrganizationHandler {
+	return &OrganizationHandler{Organizations: organizations}
+}
+
+// GetCurrentOrganization - Handler to show the organization the request is
+// for, so clients can brand themselves before login
+func (h *OrganizationHandler) GetCurrentOrganization(c *gin.Context) {
+	organization, err := h.Organizations.Get(c.Request.Context(), c.GetUint("organization_id"))
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+
+	c.JSON(200, gin.H{"data": organization})
+}
+
+// ListOrganizations - Handler to list every organization
+func (h *OrganizationHandler) ListOrganizations(c *gin.Context) {
+	organizations, err := h.Organizations.List(c.Request.Context())
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+
+	c.JSON(200, gin.H{"data": organizations})
+}
+
+// GetOrganization - Handler to show organization :id
+func (h *OrganizationHandler) GetOrganization(c *gin.Context) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+
+	organization, err := h.Organizations.Get(c.Request.Context(), id)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+
+	c.JSON(200, gin.H{"data": organization})
+}
+
+// CreateOrganization - Handler to add an organization
+func (h *OrganizationHandler) CreateOrganization(c *gin.Context) {
+	var input struct {
+		Name string `json:"name" validate:"required,max=200"`
+		Slug string `json:"slug" validate:"required,max=63"`
+	}
+	if !bindValid(c, &input) {
+		return
+	}
+
+	organization, err := h.Organizations.Create(c.Request.Context(), services.OrganizationInput{Name: input.Name, Slug: input.Slug})
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+
+	c.JSON(201, gin.H{"message": "Organization created", "data": organization})
+}
+
+// UpdateOrganization - Handler to rename organization :id or change its slug
+func (h *OrganizationHandler) UpdateOrganization(c *gin.Context) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+	var input services.OrganizationInput
+	if !bindValid(c, &input) {
+		return
+	}
+
+	organization, err := h.Organizations.Update(c.Request.Context(), id, input)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+
+	c.JSON(200, gin.H{"message": "Organization updated", "data": organization})
+}
@@ -0,0 +1,22 @@
+package controllers
+
+import (
+	"backend-go/apperr"
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// errNoUserInContext - A handler behind IsLogin ran without a user; a routing bug, reported as 500
+var errNoUserInContext = errors.New("user_id missing from context")
+
+// paramID - Parse a numeric path parameter, responding 400 when it is not an ID
+func paramID(c *gin.Context, name string) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param(name), 10, 32)
+	if err != nil {
+		apperr.Abort(c, apperr.ErrInvalidID)
+		return 0, false
+	}
+	return uint(id), true
+}
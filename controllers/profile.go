@@ -1,18 +1,27 @@
 package controllers
 
 import (
-	"backend-go/config"
+	"backend-go/apperr"
 	"backend-go/models"
-	"fmt"
-	"os"
+	"backend-go/services"
+	"backend-go/storage"
+	"log/slog"
 	"path/filepath"
-	"strings"
-	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
 )
 
+// ProfileHandler - Handlers for the logged-in user's profile
+type ProfileHandler struct {
+	Profiles *services.ProfileService
+}
+
+// NewProfileHandler - Create a ProfileHandler
+func NewProfileHandler(profiles *services.ProfileService) *ProfileHandler {
+	return &ProfileHandler{Profiles: profiles}
+}
+
 type ProfileInput struct {
 	FirstName string `form:"first_name" validate:"required"`
 	LastName  string `form:"last_name" validate:"required"`
@@ -21,57 +30,38 @@ type ProfileInput struct {
 }
 
 // Create Profile
-func CreateProfile(c *gin.Context) {
+func (h *ProfileHandler) CreateProfile(c *gin.Context) {
 	var input ProfileInput
 	var validate = validator.New()
 
 	// Parsing data dari multipart/form-data
 	if err := c.ShouldBind(&input); err != nil {
-		c.JSON(400, gin.H{"error": "Invalid input", "details": err.Error()})
+		apperr.Abort(c, apperr.Bind(err))
 		return
 	}
 
 	// Validasi input
 	if err := validate.Struct(&input); err != nil {
-		var errorMessages []string
-		for _, err := range err.(validator.ValidationErrors) {
-			errorMessages = append(errorMessages, fmt.Sprintf("%s is %s", strings.ToLower(err.Field()), err.Tag()))
-		}
-		c.JSON(400, gin.H{"error": "Validation failed", "details": errorMessages})
+		apperr.Abort(c, apperr.Bind(err))
 		return
 	}
 
 	// Ambil user_id dari context (diset oleh middleware IsLogin)
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(500, gin.H{"error": "Failed to retrieve user ID from context"})
+		apperr.Abort(c, errNoUserInContext)
 		return
 	}
 
-	// Direktori untuk menyimpan file
-	publicDir := "./public/uploads"
-	if _, err := os.Stat(publicDir); os.IsNotExist(err) {
-		os.MkdirAll(publicDir, os.ModePerm)
-	}
-
-	// Proses upload file gambar
+	// Proses upload file gambar ke public storage
 	file, err := c.FormFile("image") // Ambil file dari form-data dengan key "image"
 	var imageURL string
 	if err == nil {
-		// Membuat nama file unik
-		extension := filepath.Ext(file.Filename)
-		uniqueFilename := fmt.Sprintf("%s-%s-%d%s", "profile", input.FirstName, time.Now().Unix(), extension)
-
-		// Simpan file ke direktori public/uploads
-		filePath := filepath.Join(publicDir, uniqueFilename)
-		if err := c.SaveUploadedFile(file, filePath); err != nil {
-			c.JSON(500, gin.H{"error": "Failed to upload file", "details": err.Error()})
+		imageURL, err = savePublicUpload(file, "profile")
+		if err != nil {
+			apperr.Abort(c, err)
 			return
 		}
-
-		imageURL = fmt.Sprintf("/uploads/%s", uniqueFilename)
-	} else {
-		imageURL = ""
 	}
 
 	// Membuat Profile
@@ -84,8 +74,8 @@ func CreateProfile(c *gin.Context) {
 	}
 
 	// Simpan ke database
-	if err := config.DB.Create(&profile).Error; err != nil {
-		c.JSON(500, gin.H{"error": "Failed to create profile", "details": err.Error()})
+	if err := h.Profiles.Create(c.Request.Context(), &profile); err != nil {
+		apperr.Abort(c, err)
 		return
 	}
 
@@ -94,19 +84,18 @@ func CreateProfile(c *gin.Context) {
 
 
 // Get Profile by UserID
-func GetProfile(c *gin.Context) {
-	var profile models.Profile
-
+func (h *ProfileHandler) GetProfile(c *gin.Context) {
 	// Ambil user_id dari context
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(500, gin.H{"error": "Failed to retrieve user ID from context"})
+		apperr.Abort(c, errNoUserInContext)
 		return
 	}
 
 	// Cari profile berdasarkan UserID
-	if err := config.DB.Where("user_id = ?", userID).First(&profile).Error; err != nil {
-		c.JSON(404, gin.H{"error": "Profile not found", "details": err.Error()})
+	profile, err := h.Profiles.Get(c.Request.Context(), userID.(uint))
+	if err != nil {
+		apperr.Abort(c, err)
 		return
 	}
 
@@ -114,73 +103,53 @@ func GetProfile(c *gin.Context) {
 }
 
 // Update Profile
-func UpdateProfile(c *gin.Context) {
-	var profile models.Profile
+func (h *ProfileHandler) UpdateProfile(c *gin.Context) {
 	var input ProfileInput
 	var validate = validator.New()
 
 	// Ambil user_id dari context
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(500, gin.H{"error": "Failed to retrieve user ID from context"})
+		apperr.Abort(c, errNoUserInContext)
 		return
 	}
 
 	// Cari profile berdasarkan UserID
-	if err := config.DB.Where("user_id = ?", userID).First(&profile).Error; err != nil {
-		c.JSON(404, gin.H{"error": "Profile not found", "details": err.Error()})
+	profile, err := h.Profiles.Get(c.Request.Context(), userID.(uint))
+	if err != nil {
+		apperr.Abort(c, err)
 		return
 	}
 
 	// Validasi input JSON atau form-data
 	if err := c.ShouldBind(&input); err != nil {
-		c.JSON(400, gin.H{"error": "Invalid input", "details": err.Error()})
+		apperr.Abort(c, apperr.Bind(err))
 		return
 	}
 
 	// Validasi input menggunakan validator
 	if err := validate.Struct(&input); err != nil {
-		var errorMessages []string
-		for _, err := range err.(validator.ValidationErrors) {
-			errorMessages = append(errorMessages, fmt.Sprintf("%s is %s", strings.ToLower(err.Field()), err.Tag()))
-		}
-		c.JSON(400, gin.H{"error": "Validation failed", "details": errorMessages})
+		apperr.Abort(c, apperr.Bind(err))
 		return
 	}
 
-	// Direktori untuk menyimpan file
-	publicDir := "./public/uploads"
-	if _, err := os.Stat(publicDir); os.IsNotExist(err) {
-		os.MkdirAll(publicDir, os.ModePerm)
-	}
-
 	// Proses upload file jika ada file gambar baru
 	file, err := c.FormFile("image") // Ambil file dari form-data dengan key "image"
 	var imageURL string
 	oldImagePath := profile.Image // Simpan URL file lama sebelum diupdate
 
 	if err == nil {
-		// Membuat nama file unik
-		extension := filepath.Ext(file.Filename)
-		uniqueFilename := fmt.Sprintf("%s-%s-%d%s", "profile", profile.FirstName, time.Now().Unix(), extension)
-
-		// Simpan file ke direktori public/uploads
-		filePath := filepath.Join(publicDir, uniqueFilename)
-		if err := c.SaveUploadedFile(file, filePath); err != nil {
-			c.JSON(500, gin.H{"error": "Failed to upload file", "details": err.Error()})
+		imageURL, err = savePublicUpload(file, "profile")
+		if err != nil {
+			apperr.Abort(c, err)
 			return
 		}
 
-		// Buat URL publik untuk file
-		imageURL = fmt.Sprintf("/uploads/%s", uniqueFilename)
-
 		// Hapus file lama jika ada dan tidak kosong
 		if oldImagePath != "" {
-			oldFilePath := filepath.Join(publicDir, filepath.Base(oldImagePath)) // Path file lama
-			if _, err := os.Stat(oldFilePath); err == nil {
-				if err := os.Remove(oldFilePath); err != nil {
-					fmt.Printf("Failed to delete old file: %s, error: %v\n", oldFilePath, err)
-				}
+			oldKey := filepath.Base(oldImagePath)
+			if err := storage.Public.Remove(oldKey); err != nil {
+				slog.WarnContext(c.Request.Context(), "failed to delete old profile image", "key", oldKey, "error", err)
 			}
 		}
 	} else {
@@ -196,8 +165,8 @@ func UpdateProfile(c *gin.Context) {
 		Image:     imageURL, // Perbarui URL gambar
 	}
 
-	if err := config.DB.Model(&profile).Updates(updatedData).Error; err != nil {
-		c.JSON(500, gin.H{"error": "Failed to update profile", "details": err.Error()})
+	if err := h.Profiles.Update(c.Request.Context(), profile, updatedData); err != nil {
+		apperr.Abort(c, err)
 		return
 	}
 
@@ -205,25 +174,17 @@ func UpdateProfile(c *gin.Context) {
 }
 
 // Delete Profile
-func DeleteProfile(c *gin.Context) {
-	var profile models.Profile
-
+func (h *ProfileHandler) DeleteProfile(c *gin.Context) {
 	// Ambil user_id dari context
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(500, gin.H{"error": "Failed to retrieve user ID from context"})
-		return
-	}
-
-	// Cari profile berdasarkan UserID
-	if err := config.DB.Where("user_id = ?", userID).First(&profile).Error; err != nil {
-		c.JSON(404, gin.H{"error": "Profile not found", "details": err.Error()})
+		apperr.Abort(c, errNoUserInContext)
 		return
 	}
 
-	// Hapus profile dari database
-	if err := config.DB.Delete(&profile).Error; err != nil {
-		c.JSON(500, gin.H{"error": "Failed to delete profile", "details": err.Error()})
+	// Hapus profile milik user ini
+	if err := h.Profiles.Delete(c.Request.Context(), userID.(uint)); err != nil {
+		apperr.Abort(c, err)
 		return
 	}
 
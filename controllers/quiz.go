@@ -1,17 +1,26 @@
 package controllers
 
 import (
-	"backend-go/config"
+	"backend-go/apperr"
 	"backend-go/models"
-	"fmt"
-	"strings"
+	"backend-go/services"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
 )
 
+// QuizHandler - Handlers for quizzes and their answers
+type QuizHandler struct {
+	Quizzes *services.QuizService
+}
+
+// NewQuizHandler - Create a QuizHandler
+func NewQuizHandler(quizzes *services.QuizService) *QuizHandler {
+	return &QuizHandler{Quizzes: quizzes}
+}
+
 // CreateQuiz - Handler to create a new quiz
-func CreateQuiz(c *gin.Context) {
+func (h *QuizHandler) CreateQuiz(c *gin.Context) {
 	var input struct {
 		Name        string `json:"name" binding:"required"`
 		Description string `json:"description" binding:"required"`
@@ -22,28 +31,13 @@ func CreateQuiz(c *gin.Context) {
 
 	// Bind JSON input
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(400, gin.H{"error": "Invalid input", "details": err.Error()})
+		apperr.Abort(c, apperr.Bind(err))
 		return
 	}
 
 	// Validate input
 	if err := validate.Struct(&input); err != nil {
-		var errorMessages []string
-		for _, err := range err.(validator.ValidationErrors) {
-			errorMessages = append(errorMessages, fmt.Sprintf("%s is %s", strings.ToLower(err.Field()), err.Tag()))
-		}
-		c.JSON(400, gin.H{"error": "Validation failed", "details": errorMessages})
-		return
-	}
-
-	// Check if the course exists
-	var course models.Course
-	if err := config.DB.First(&course, input.CourseID).Error; err != nil {
-		if err.Error() == "record not found" {
-			c.JSON(404, gin.H{"error": "Course not found"})
-		} else {
-			c.JSON(500, gin.H{"error": "Failed to fetch course", "details": err.Error()})
-		}
+		apperr.Abort(c, apperr.Bind(err))
 		return
 	}
 
@@ -54,9 +48,9 @@ func CreateQuiz(c *gin.Context) {
 		CourseID:    input.CourseID,
 	}
 
-	// Save the quiz to the database
-	if err := config.DB.Create(&quiz).Error; err != nil {
-		c.JSON(500, gin.H{"error": "Failed to create quiz", "details": err.Error()})
+	// Save the quiz together with its first revision; the course must exist
+	if err := h.Quizzes.Create(c.Request.Context(), &quiz, c.GetUint("user_id")); err != nil {
+		apperr.Abort(c, err)
 		return
 	}
 
@@ -64,12 +58,11 @@ func CreateQuiz(c *gin.Context) {
 }
 
 // GetQuizzes - Handler to fetch all quizzes
-func GetQuizzes(c *gin.Context) {
-	var quizzes []models.Quiz
-
+func (h *QuizHandler) GetQuizzes(c *gin.Context) {
 	// Fetch quizzes from the database
-	if err := config.DB.Preload("Course").Find(&quizzes).Error; err != nil {
-		c.JSON(500, gin.H{"error": "Failed to fetch quizzes", "details": err.Error()})
+	quizzes, err := h.Quizzes.List(c.Request.Context())
+	if err != nil {
+		apperr.Abort(c, err)
 		return
 	}
 
@@ -77,34 +70,32 @@ func GetQuizzes(c *gin.Context) {
 }
 
 // GetQuizByID - Handler to fetch a quiz by ID
-func GetQuizByID(c *gin.Context) {
-	id := c.Param("id")
-	var quiz models.Quiz
+func (h *QuizHandler) GetQuizByID(c *gin.Context) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
 
 	// Fetch the quiz by ID
-	if err := config.DB.Preload("Course").First(&quiz, id).Error; err != nil {
-		if err.Error() == "record not found" {
-			c.JSON(404, gin.H{"error": "Quiz not found"})
-		} else {
-			c.JSON(500, gin.H{"error": "Failed to fetch quiz", "details": err.Error()})
-		}
+	quiz, err := h.Quizzes.Get(c.Request.Context(), id)
+	if err != nil {
+		apperr.Abort(c, err)
 		return
 	}
 
 	c.JSON(200, gin.H{"data": quiz})
 }
 
-func GetQuizzesByCourseID(c *gin.Context) {
-	courseID := c.Param("id")
+func (h *QuizHandler) GetQuizzesByCourseID(c *gin.Context) {
+	courseID, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
 
 	// Fetch all quizzes belonging to the course
-	var quizzes []models.Quiz
-	if err := config.DB.Where("course_id = ?", courseID).Find(&quizzes).Error; err != nil {
-		if err.Error() == "record not found" {
-			c.JSON(404, gin.H{"error": "No quizzes found for this course"})
-		} else {
-			c.JSON(500, gin.H{"error": "Failed to fetch quizzes", "details": err.Error()})
-		}
+	quizzes, err := h.Quizzes.ListByCourse(c.Request.Context(), courseID)
+	if err != nil {
+		apperr.Abort(c, err)
 		return
 	}
 
@@ -112,8 +103,12 @@ func GetQuizzesByCourseID(c *gin.Context) {
 }
 
 // UpdateQuiz - Handler to update a quiz
-func UpdateQuiz(c *gin.Context) {
-	id := c.Param("id")
+func (h *QuizHandler) UpdateQuiz(c *gin.Context) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+
 	var input struct {
 		Name        string `json:"name" binding:"required"`
 		Description string `json:"description" binding:"required"`
@@ -122,29 +117,27 @@ func UpdateQuiz(c *gin.Context) {
 
 	// Bind JSON input
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(400, gin.H{"error": "Invalid input", "details": err.Error()})
+		apperr.Abort(c, apperr.Bind(err))
 		return
 	}
 
 	// Fetch the quiz by ID
-	var quiz models.Quiz
-	if err := config.DB.First(&quiz, id).Error; err != nil {
-		if err.Error() == "record not found" {
-			c.JSON(404, gin.H{"error": "Quiz not found"})
-		} else {
-			c.JSON(500, gin.H{"error": "Failed to fetch quiz", "details": err.Error()})
-		}
+	current, err := h.Quizzes.Get(c.Request.Context(), id)
+	if err != nil {
+		apperr.Abort(c, err)
 		return
 	}
 
-	// Update quiz fields
-	quiz.Name = input.Name
-	quiz.Description = input.Description
-	quiz.CourseID = input.CourseID
+	// Update quiz fields, keeping the ones the form does not carry
+	changes := services.SnapshotQuiz(current)
+	changes.Name = input.Name
+	changes.Description = input.Description
+	changes.CourseID = input.CourseID
 
-	// Save the updated quiz
-	if err := config.DB.Save(&quiz).Error; err != nil {
-		c.JSON(500, gin.H{"error": "Failed to update quiz", "details": err.Error()})
+	// Save the updated quiz and keep the previous state in history
+	quiz, err := h.Quizzes.Update(c.Request.Context(), id, changes, c.GetUint("user_id"), "")
+	if err != nil {
+		apperr.Abort(c, err)
 		return
 	}
 
@@ -152,14 +145,54 @@ func UpdateQuiz(c *gin.Context) {
 }
 
 // DeleteQuiz - Handler to delete a quiz
-func DeleteQuiz(c *gin.Context) {
-	id := c.Param("id")
+func (h *QuizHandler) DeleteQuiz(c *gin.Context) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
 
 	// Delete the quiz
-	if err := config.DB.Delete(&models.Quiz{}, id).Error; err != nil {
-		c.JSON(500, gin.H{"error": "Failed to delete quiz", "details": err.Error()})
+	if err := h.Quizzes.Delete(c.Request.Context(), id); err != nil {
+		apperr.Abort(c, err)
 		return
 	}
 
 	c.JSON(200, gin.H{"message": "Quiz deleted successfully"})
 }
+
+// SubmitQuiz - Handler recording the current user's answers to quiz :id
+func (h *QuizHandler) SubmitQuiz(c *gin.Context) {
+	quizID, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+
+	var input struct {
+		AnswerIDs []uint `json:"answer_ids" binding:"required,min=1"`
+	}
+
+	// Bind JSON input
+	if err := c.ShouldBindJSON(&input); err != nil {
+		apperr.Abort(c, apperr.Bind(err))
+		return
+	}
+
+	submission, err := h.Quizzes.Submit(c.Request.Context(), quizID, c.GetUint("user_id"), input.AnswerIDs)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+
+	c.JSON(201, gin.H{"message": "Quiz submitted successfully", "data": submission})
+}
+
+// GetGrades - Handler to list the current user's quiz attempts
+func (h *QuizHandler) GetGrades(c *gin.Context) {
+	attempts, err := h.Quizzes.ListAttempts(c.Request.Context(), c.GetUint("user_id"))
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+
+	c.JSON(200, gin.H{"data": attempts})
+}
@@ -0,0 +1,158 @@
+package controllers
+
+import (
+	"backend-go/apperr"
+	"backend-go/models"
+	"backend-go/services"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RevisionHandler - Handlers for reading lesson and quiz history
+type RevisionHandler struct {
+	Revisions *services.RevisionService
+}
+
+// NewRevisionHandler - Create a RevisionHandler
+func NewRevisionHandler(revisions *services.RevisionService) *RevisionHandler {
+	return &RevisionHandler{Revisions: revisions}
+}
+
+// GetLessonRevisions - Handler to list the revisions of lesson :id
+func (h *RevisionHandler) GetLessonRevisions(c *gin.Context) {
+	h.listRevisions(c, models.RevisionLesson)
+}
+
+// GetLessonRevision - Handler to view one revision of lesson :id
+func (h *RevisionHandler) GetLessonRevision(c *gin.Context) {
+	h.showRevision(c, models.RevisionLesson)
+}
+
+// DiffLessonRevisions - Handler to diff two revisions of lesson :id (?from=&to=)
+func (h *RevisionHandler) DiffLessonRevisions(c *gin.Context) {
+	h.diffRevisions(c, models.RevisionLesson)
+}
+
+// GetQuizRevisions - Handler to list the revisions of quiz :id
+func (h *RevisionHandler) GetQuizRevisions(c *gin.Context) {
+	h.listRevisions(c, models.RevisionQuiz)
+}
+
+// GetQuizRevision - Handler to view one revision of quiz :id
+func (h *RevisionHandler) GetQuizRevision(c *gin.Context) {
+	h.showRevision(c, models.RevisionQuiz)
+}
+
+// DiffQuizRevisions - Handler to diff two revisions of quiz :id (?from=&to=)
+func (h *RevisionHandler) DiffQuizRevisions(c *gin.Context) {
+	h.diffRevisions(c, models.RevisionQuiz)
+}
+
+func (h *RevisionHandler) listRevisions(c *gin.Context, entityType string) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+
+	revisions, err := h.Revisions.List(c.Request.Context(), entityType, id)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+
+	c.JSON(200, gin.H{"data": revisions})
+}
+
+func (h *RevisionHandler) showRevision(c *gin.Context, entityType string) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+	version, ok := revisionVersion(c, c.Param("version"))
+	if !ok {
+		return
+	}
+
+	revision, err := h.Revisions.Get(c.Request.Context(), entityType, id, version)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+
+	c.JSON(200, gin.H{"data": revision})
+}
+
+func (h *RevisionHandler) diffRevisions(c *gin.Context, entityType string) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+	from, ok := revisionVersion(c, c.Query("from"))
+	if !ok {
+		return
+	}
+	to, ok := revisionVersion(c, c.Query("to"))
+	if !ok {
+		return
+	}
+
+	diff, err := h.Revisions.Diff(c.Request.Context(), entityType, id, from, to)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+
+	c.JSON(200, gin.H{"data": diff})
+}
+
+// RestoreLessonRevision - Handler making an earlier revision the current lesson
+func (h *LessonHandler) RestoreLessonRevision(c *gin.Context) {
+	lessonID, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+	version, ok := revisionVersion(c, c.Param("version"))
+	if !ok {
+		return
+	}
+
+	lesson, err := h.Lessons.Restore(c.Request.Context(), lessonID, version, c.GetUint("user_id"))
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+
+	withMediaURL(lesson)
+	c.JSON(200, gin.H{"message": "Lesson restored successfully", "data": lesson})
+}
+
+// RestoreQuizRevision - Handler making an earlier revision the current quiz
+func (h *QuizHandler) RestoreQuizRevision(c *gin.Context) {
+	quizID, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+	version, ok := revisionVersion(c, c.Param("version"))
+	if !ok {
+		return
+	}
+
+	quiz, err := h.Quizzes.Restore(c.Request.Context(), quizID, version, c.GetUint("user_id"))
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+
+	c.JSON(200, gin.H{"message": "Quiz restored successfully", "data": quiz})
+}
+
+// revisionVersion - Parse a revision version number or abort with 400
+func revisionVersion(c *gin.Context, version string) (int, bool) {
+	v, err := strconv.Atoi(version)
+	if err != nil {
+		apperr.Abort(c, apperr.BadRequest("invalid_revision_version", "Invalid revision version"))
+		return 0, false
+	}
+	return v, true
+}
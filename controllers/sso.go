@@ -0,0 +1,164 @@
+package controllers
+
+import (
+	"backend-go/apperr"
+	"backend-go/services"
+	"backend-go/sso"
+	"backend-go/utils"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/oauth2"
+)
+
+// ssoFlowCookie - Signed state of a login between the redirect and the callback
+const ssoFlowCookie = "sso_flow"
+
+// ssoFlowTTL - Time allowed to log in at the provider
+const ssoFlowTTL = 10 * time.Minute
+
+// SSOProviders - List the identity providers users can log in with
+func (h *AuthHandler) SSOProviders(c *gin.Context) {
+	base := strings.TrimSuffix(c.FullPath(), "/sso")
+	providers := make([]gin.H, 0)
+	for _, p := range sso.List() {
+		providers = append(providers, gin.H{
+			"name":         p.Name,
+			"display_name": p.DisplayName,
+			"login_url":    base + "/sso/" + p.Name + "/login",
+		})
+	}
+	c.JSON(200, gin.H{"data": providers})
+}
+
+// SSOLogin - Send the browser to the identity provider
+func (h *AuthHandler) SSOLogin(c *gin.Context) {
+	provider, err := sso.Get(c.Param("provider"))
+	if err != nil {
+		apperr.Abort(c, services.ErrSSOProviderNotFound)
+		return
+	}
+
+	flow := utils.SSOFlow{
+		Provider:    provider.Name,
+		State:       randomToken(),
+		Nonce:       randomToken(),
+		Verifier:    oauth2.GenerateVerifier(),
+		RedirectURL: sso.Current().PublicURL + callbackPath(c, provider.Name),
+	}
+	authURL, err := provider.AuthCodeURL(c.Request.Context(), flow.RedirectURL, flow.State, flow.Nonce, flow.Verifier)
+	if err != nil {
+		apperr.Abort(c, services.ErrSSOFailed.WithCause(err))
+		return
+	}
+
+	signed, err := utils.GenerateSSOFlow(flow, ssoFlowTTL)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+	setFlowCookie(c, signed, int(ssoFlowTTL.Seconds()), provider.Name)
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// SSOCallback - Finish the login the provider redirected back from. The
+// result goes to the frontend in the URL fragment when one is configured,
+// otherwise it is the same JSON as POST /login.
+func (h *AuthHandler) SSOCallback(c *gin.Context) {
+	result, err := h.ssoCallback(c)
+
+	frontend := sso.Current().FrontendURL
+	if frontend == "" {
+		if err != nil {
+			apperr.Abort(c, err)
+			return
+		}
+		c.JSON(200, loginResponse(result))
+		return
+	}
+
+	// Fragment tidak dikirim ke server mana pun, jadi token tidak masuk log
+	fragment := url.Values{}
+	if err != nil {
+		appErr := apperr.From(err)
+		if appErr.Status >= 500 {
+			slog.ErrorContext(c.Request.Context(), "sso login failed", "error", err)
+		}
+		fragment.Set("error", appErr.Code)
+	} else {
+		body := loginResponse(result)
+		keys := make([]string, 0, len(body))
+		for k := range body {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			fragment.Set(k, fmt.Sprint(body[k]))
+		}
+	}
+	c.Redirect(http.StatusFound, frontend+"#"+fragment.Encode())
+}
+
+func (h *AuthHandler) ssoCallback(c *gin.Context) (*services.LoginResult, error) {
+	name := c.Param("provider")
+	provider, err := sso.Get(name)
+	if err != nil {
+		return nil, services.ErrSSOProviderNotFound
+	}
+
+	// Cookie hanya berlaku untuk satu percobaan login
+	signed, _ := c.Cookie(ssoFlowCookie)
+	setFlowCookie(c, "", -1, name)
+	flow, err := utils.ParseSSOFlow(signed)
+	if err != nil || flow.Provider != name ||
+		subtle.ConstantTimeCompare([]byte(flow.State), []byte(c.Query("state"))) != 1 {
+		return nil, services.ErrSSOInvalidState
+	}
+
+	if reason := c.Query("error"); reason != "" {
+		if description := c.Query("error_description"); description != "" {
+			reason = description
+		}
+		return nil, services.ErrSSODenied.WithDetails(reason)
+	}
+
+	identity, err := provider.Exchange(c.Request.Context(), flow.RedirectURL, c.Query("code"), flow.Nonce, flow.Verifier)
+	if err != nil {
+		return nil, services.ErrSSOFailed.WithCause(err)
+	}
+	return h.Auth.LoginWithIdentity(c.Request.Context(), identity, provider.DefaultRole)
+}
+
+// callbackPath - Callback route in the same API version as the login route being served
+func callbackPath(c *gin.Context, provider string) string {
+	base := strings.TrimSuffix(c.FullPath(), "/login")
+	return strings.Replace(base, ":provider", provider, 1) + "/callback"
+}
+
+// setFlowCookie - Store or (maxAge < 0) clear the flow cookie of provider. It
+// is only sent to that provider's callback.
+func setFlowCookie(c *gin.Context, value string, maxAge int, provider string) {
+	path := strings.Replace(strings.TrimSuffix(strings.TrimSuffix(c.FullPath(), "/login"), "/callback"), ":provider", provider, 1)
+	secure := strings.HasPrefix(sso.Current().PublicURL, "https://")
+	// Lax: callback adalah navigasi GET dari situs provider
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(ssoFlowCookie, value, maxAge, path, "", secure, true)
+}
+
+// randomToken - 128 random bits, URL safe
+func randomToken() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
@@ -0,0 +1,58 @@
+package controllers
+
+import (
+	"backend-go/apperr"
+	"backend-go/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+// TrashHandler - Handlers for admins looking at and restoring deleted content
+type TrashHandler struct {
+	Trash *services.TrashService
+}
+
+// NewTrashHandler - Create a TrashHandler
+func NewTrashHandler(trash *services.TrashService) *TrashHandler {
+	return &TrashHandler{Trash: trash}
+}
+
+// ListTrash - Handler to list deleted courses, lessons, attachments, quizzes
+// and answers, one page at a time
+func (h *TrashHandler) ListTrash(c *gin.Context) {
+	var query services.TrashQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		apperr.Abort(c, apperr.Bind(err))
+		return
+	}
+	if err := validator.New().Struct(query); err != nil {
+		apperr.Abort(c, apperr.Bind(err))
+		return
+	}
+
+	items, page, err := h.Trash.List(c.Request.Context(), query)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+
+	c.JSON(200, gin.H{"data": items, "meta": page})
+}
+
+// RestoreTrash - Handler to restore deleted :type :id with everything deleted
+// together with it
+func (h *TrashHandler) RestoreTrash(c *gin.Context) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+
+	result, err := h.Trash.Restore(c.Request.Context(), c.Param("type"), id)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+
+	c.JSON(200, gin.H{"message": "Restored successfully", "data": result})
+}
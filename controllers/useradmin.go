@@ -0,0 +1,147 @@
+package controllers
+
+import (
+	"backend-go/apperr"
+	"backend-go/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+// UserAdminHandler - Handlers for admins managing other users
+type UserAdminHandler struct {
+	Users *services.UserAdminService
+}
+
+// NewUserAdminHandler - Create a UserAdminHandler
+func NewUserAdminHandler(users *services.UserAdminService) *UserAdminHandler {
+	return &UserAdminHandler{Users: users}
+}
+
+// ListUsers - Handler to search users, one page at a time
+func (h *UserAdminHandler) ListUsers(c *gin.Context) {
+	var query services.UserQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		apperr.Abort(c, apperr.Bind(err))
+		return
+	}
+	if err := validator.New().Struct(query); err != nil {
+		apperr.Abort(c, apperr.Bind(err))
+		return
+	}
+
+	users, page, err := h.Users.List(c.Request.Context(), query)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+
+	c.JSON(200, gin.H{"data": users, "meta": page})
+}
+
+// GetUser - Handler to show user :id with their profile and enrollments
+func (h *UserAdminHandler) GetUser(c *gin.Context) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+
+	user, err := h.Users.Get(c.Request.Context(), id)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+
+	c.JSON(200, gin.H{"data": user})
+}
+
+// SetRole - Handler to change the role of user :id
+func (h *UserAdminHandler) SetRole(c *gin.Context) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+	var input struct {
+		Role string `json:"role" validate:"required"`
+	}
+	if !bindValid(c, &input) {
+		return
+	}
+
+	user, err := h.Users.SetRole(c.Request.Context(), c.GetUint("user_id"), id, input.Role)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+
+	c.JSON(200, gin.H{"message": "Role changed", "data": user})
+}
+
+// SuspendUser - Handler to suspend user :id
+func (h *UserAdminHandler) SuspendUser(c *gin.Context) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+	var input struct {
+		Reason string `json:"reason" validate:"required,max=500"`
+	}
+	if !bindValid(c, &input) {
+		return
+	}
+
+	user, err := h.Users.Suspend(c.Request.Context(), c.GetUint("user_id"), id, input.Reason)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+
+	c.JSON(200, gin.H{"message": "User suspended", "data": user})
+}
+
+// UnsuspendUser - Handler to lift the suspension of user :id
+func (h *UserAdminHandler) UnsuspendUser(c *gin.Context) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+
+	user, err := h.Users.Unsuspend(c.Request.Context(), c.GetUint("user_id"), id)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+
+	c.JSON(200, gin.H{"message": "Suspension lifted", "data": user})
+}
+
+// ForcePasswordReset - Handler to clear the password of user :id and email them a reset link
+func (h *UserAdminHandler) ForcePasswordReset(c *gin.Context) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.Users.ForcePasswordReset(c.Request.Context(), c.GetUint("user_id"), id); err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+
+	c.JSON(202, gin.H{"message": "Password cleared and reset link sent to the user"})
+}
+
+// Impersonate - Handler issuing a short-lived token to act as user :id
+func (h *UserAdminHandler) Impersonate(c *gin.Context) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+
+	token, expiresAt, err := h.Users.Impersonate(c.Request.Context(), c.GetUint("user_id"), id)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+
+	c.JSON(200, gin.H{"message": "Impersonation token issued, every request with it is logged", "token": token, "expires_at": expiresAt})
+}
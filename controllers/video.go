@@ -0,0 +1,247 @@
+package controllers
+
+import (
+	"backend-go/apperr"
+	"backend-go/models"
+	"backend-go/services"
+	"backend-go/storage"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Resumable uploads follow the core tus 1.0.0 protocol plus the creation and
+// termination extensions, so off-the-shelf tus clients can talk to us.
+const (
+	TusVersion      = "1.0.0"
+	TusExtensions   = "creation,termination"
+	MaxVideoSize    = 5 << 30
+	tusOffsetOctets = "application/offset+octet-stream"
+)
+
+// ErrTusVersion - The client speaks another tus version
+var ErrTusVersion = apperr.New(http.StatusPreconditionFailed, "tus_version_unsupported", "Unsupported tus version")
+
+// VideoHandler - Handlers for lesson videos: resumable uploads and playback positions
+type VideoHandler struct {
+	Videos *services.VideoService
+}
+
+// NewVideoHandler - Create a VideoHandler
+func NewVideoHandler(videos *services.VideoService) *VideoHandler {
+	return &VideoHandler{Videos: videos}
+}
+
+// TusOptions - Handler advertising the supported tus version and extensions
+func TusOptions(c *gin.Context) {
+	c.Header("Tus-Resumable", TusVersion)
+	c.Header("Tus-Version", TusVersion)
+	c.Header("Tus-Extension", TusExtensions)
+	c.Header("Tus-Max-Size", strconv.FormatInt(MaxVideoSize, 10))
+	c.Status(http.StatusNoContent)
+}
+
+// checkTusVersion - Reject clients speaking a different tus version
+func checkTusVersion(c *gin.Context) bool {
+	c.Header("Tus-Resumable", TusVersion)
+	if c.GetHeader("Tus-Resumable") != TusVersion {
+		c.Header("Tus-Version", TusVersion)
+		apperr.Abort(c, ErrTusVersion)
+		return false
+	}
+	return true
+}
+
+// CreateVideoUpload - Handler to start a resumable video upload for lesson :id (course staff only)
+func (h *VideoHandler) CreateVideoUpload(c *gin.Context) {
+	if !checkTusVersion(c) {
+		return
+	}
+	lesson := c.MustGet("lesson").(models.Lesson)
+
+	length, err := strconv.ParseInt(c.GetHeader("Upload-Length"), 10, 64)
+	if err != nil || length <= 0 {
+		apperr.Abort(c, apperr.BadRequest("upload_length_required", "Upload-Length header is required"))
+		return
+	}
+	if length > MaxVideoSize {
+		apperr.Abort(c, ErrFileTooLarge.WithDetails(fmt.Sprintf("maximum size is %d bytes", int64(MaxVideoSize))))
+		return
+	}
+
+	metadata := parseUploadMetadata(c.GetHeader("Upload-Metadata"))
+	upload := models.VideoUpload{
+		LessonID:   lesson.ID,
+		UploadedBy: c.GetUint("user_id"),
+		FileName:   filepath.Base(metadata["filename"]),
+		Length:     length,
+	}
+
+	if err := h.Videos.CreateUpload(c.Request.Context(), &upload); err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+
+	// Location ikut prefix versi API tempat upload dibuat
+	prefix := strings.TrimSuffix(c.FullPath(), "/lesson/:id/video/uploads")
+	c.Header("Location", fmt.Sprintf("%s/video-upload/%d", prefix, upload.ID))
+	c.Header("Upload-Offset", "0")
+	c.JSON(http.StatusCreated, gin.H{"message": "Upload created", "data": upload})
+}
+
+// GetVideoUploadOffset - Handler telling the client where to resume upload :id
+func (h *VideoHandler) GetVideoUploadOffset(c *gin.Context) {
+	if !checkTusVersion(c) {
+		return
+	}
+	upload, ok := h.findOwnUpload(c)
+	if !ok {
+		return
+	}
+
+	c.Header("Upload-Offset", strconv.FormatInt(upload.Offset, 10))
+	c.Header("Upload-Length", strconv.FormatInt(upload.Length, 10))
+	c.Header("Cache-Control", "no-store")
+	c.Status(http.StatusOK)
+}
+
+// PatchVideoUpload - Handler appending a chunk to upload :id
+func (h *VideoHandler) PatchVideoUpload(c *gin.Context) {
+	if !checkTusVersion(c) {
+		return
+	}
+	if c.ContentType() != tusOffsetOctets {
+		apperr.Abort(c, apperr.New(http.StatusUnsupportedMediaType, "unsupported_media_type", "Content-Type must be "+tusOffsetOctets))
+		return
+	}
+
+	upload, ok := h.findOwnUpload(c)
+	if !ok {
+		return
+	}
+	offset, err := strconv.ParseInt(c.GetHeader("Upload-Offset"), 10, 64)
+	if err != nil {
+		apperr.Abort(c, apperr.BadRequest("upload_offset_required", "Upload-Offset header is required"))
+		return
+	}
+
+	if err := h.Videos.Append(c.Request.Context(), upload, offset, c.Request.Body); err != nil {
+		c.Header("Upload-Offset", strconv.FormatInt(upload.Offset, 10))
+		apperr.Abort(c, err)
+		return
+	}
+
+	c.Header("Upload-Offset", strconv.FormatInt(upload.Offset, 10))
+	c.Status(http.StatusNoContent)
+}
+
+// DeleteVideoUpload - Handler to abandon upload :id (tus termination extension)
+func (h *VideoHandler) DeleteVideoUpload(c *gin.Context) {
+	if !checkTusVersion(c) {
+		return
+	}
+	upload, ok := h.findOwnUpload(c)
+	if !ok {
+		return
+	}
+
+	if err := h.Videos.DeleteUpload(c.Request.Context(), upload); err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// findOwnUpload - Fetch upload :id of the current user or abort with 404/500
+func (h *VideoHandler) findOwnUpload(c *gin.Context) (*models.VideoUpload, bool) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return nil, false
+	}
+	upload, err := h.Videos.FindUpload(c.Request.Context(), id, c.GetUint("user_id"))
+	if err != nil {
+		apperr.Abort(c, err)
+		return nil, false
+	}
+	return upload, true
+}
+
+// parseUploadMetadata - Decode the tus Upload-Metadata header ("key base64,key base64")
+func parseUploadMetadata(header string) map[string]string {
+	metadata := map[string]string{}
+	for _, pair := range strings.Split(header, ",") {
+		parts := strings.Fields(pair)
+		if len(parts) == 0 {
+			continue
+		}
+		value := ""
+		if len(parts) > 1 {
+			if decoded, err := base64.StdEncoding.DecodeString(parts[1]); err == nil {
+				value = string(decoded)
+			}
+		}
+		metadata[parts[0]] = value
+	}
+	return metadata
+}
+
+// StreamLessonVideo - Handler streaming the video of lesson :id with HTTP Range support
+func StreamLessonVideo(c *gin.Context) {
+	lesson := c.MustGet("lesson").(models.Lesson)
+	if lesson.VideoKey == "" {
+		apperr.Abort(c, apperr.NotFound("lesson_has_no_video", "Lesson has no video"))
+		return
+	}
+
+	if lesson.VideoMimeType != "" {
+		c.Header("Content-Type", lesson.VideoMimeType)
+	}
+	serveStoredFile(c, storage.Private, lesson.VideoKey, "private, max-age=300")
+}
+
+// GetPlaybackPosition - Handler returning where the current user stopped in lesson :id
+func (h *VideoHandler) GetPlaybackPosition(c *gin.Context) {
+	lesson := c.MustGet("lesson").(models.Lesson)
+
+	position, err := h.Videos.Playback(c.Request.Context(), c.GetUint("user_id"), lesson.ID)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+
+	c.JSON(200, gin.H{"data": gin.H{"lesson_id": lesson.ID, "position": position.Position, "duration": position.Duration}})
+}
+
+// UpdatePlaybackPosition - Handler saving the current user's position in lesson :id
+func (h *VideoHandler) UpdatePlaybackPosition(c *gin.Context) {
+	lesson := c.MustGet("lesson").(models.Lesson)
+
+	var input struct {
+		Position float64 `json:"position" binding:"gte=0"`
+		Duration float64 `json:"duration" binding:"gte=0"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		apperr.Abort(c, apperr.Bind(err))
+		return
+	}
+
+	position := models.PlaybackPosition{
+		UserID:   c.GetUint("user_id"),
+		LessonID: lesson.ID,
+		Position: input.Position,
+		Duration: input.Duration,
+	}
+
+	// Satu baris per user per lesson, posisi terakhir menimpa yang lama
+	if err := h.Videos.SavePlayback(c.Request.Context(), &position); err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+
+	c.JSON(200, gin.H{"message": "Playback position saved", "data": gin.H{"lesson_id": lesson.ID, "position": input.Position, "duration": input.Duration}})
+}
@@ -0,0 +1,149 @@
+package docs
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// newOp - Start an operation under tag
+func newOp(tag, summary string) *Operation {
+	return &Operation{Tags: []string{tag}, Summary: summary, Responses: map[string]*Response{}}
+}
+
+// bearer - Requires a JWT from POST /login
+func (o *Operation) bearer() *Operation {
+	o.Security = []map[string][]string{{"bearerAuth": {}}}
+	return o.fails(http.StatusUnauthorized)
+}
+
+// scoped - Requires a JWT, or an API key granted scope
+func (o *Operation) scoped(scope string) *Operation {
+	o.Security = []map[string][]string{{"bearerAuth": {}}}
+	o.Description = "Also accepts a personal API key with the " + scope + " scope."
+	return o.fails(http.StatusUnauthorized, http.StatusForbidden)
+}
+
+// signedOrBearer - Media served to <img>/<video> tags: a signed URL or a JWT
+func (o *Operation) signedOrBearer() *Operation {
+	o.Security = []map[string][]string{{"bearerAuth": {}}, {}}
+	o.Description = "Accepts either a Bearer token or the expires/signature pair of a signed URL returned by the API."
+	o.query("expires", "Unix time the signed URL stops working", Integer(), false)
+	o.query("signature", "HMAC signature of the path and expiry", String(), false)
+	return o.fails(http.StatusUnauthorized, http.StatusForbidden)
+}
+
+// id - Numeric path parameter
+func (o *Operation) id(name, description string) *Operation {
+	o.Parameters = append(o.Parameters, Parameter{Name: name, In: "path", Description: description, Required: true, Schema: Integer()})
+	return o.fails(http.StatusBadRequest)
+}
+
+func (o *Operation) pathParam(name, description string, schema *Schema) *Operation {
+	o.Parameters = append(o.Parameters, Parameter{Name: name, In: "path", Description: description, Required: true, Schema: schema})
+	return o
+}
+
+func (o *Operation) query(name, description string, schema *Schema, required bool) *Operation {
+	o.Parameters = append(o.Parameters, Parameter{Name: name, In: "query", Description: description, Required: required, Schema: schema})
+	return o
+}
+
+func (o *Operation) header(name, description string, schema *Schema, required bool) *Operation {
+	o.Parameters = append(o.Parameters, Parameter{Name: name, In: "header", Description: description, Required: required, Schema: schema})
+	return o
+}
+
+// json - application/json request body
+func (o *Operation) json(schema *Schema) *Operation {
+	return o.body("application/json", schema).fails(http.StatusBadRequest, http.StatusUnprocessableEntity)
+}
+
+// form - multipart/form-data request body (used where files may be uploaded)
+func (o *Operation) form(schema *Schema) *Operation {
+	return o.body("multipart/form-data", schema).fails(http.StatusBadRequest, http.StatusUnprocessableEntity)
+}
+
+func (o *Operation) body(contentType string, schema *Schema) *Operation {
+	o.RequestBody = &RequestBody{Required: true, Content: map[string]*MediaType{contentType: {Schema: schema}}}
+	return o
+}
+
+// reply - JSON response
+func (o *Operation) reply(status int, description string, schema *Schema) *Operation {
+	return o.replyAs(status, description, "application/json", schema)
+}
+
+func (o *Operation) replyAs(status int, description, contentType string, schema *Schema) *Operation {
+	o.Responses[strconv.Itoa(status)] = &Response{
+		Description: description,
+		Content:     map[string]*MediaType{contentType: {Schema: schema}},
+	}
+	return o
+}
+
+// empty - Response without a body, optionally listing the headers it sets
+func (o *Operation) empty(status int, description string, headers ...string) *Operation {
+	r := &Response{Description: description}
+	if len(headers) > 0 {
+		r.Headers = map[string]*Header{}
+		for _, h := range headers {
+			r.Headers[h] = &Header{Schema: String()}
+		}
+	}
+	o.Responses[strconv.Itoa(status)] = r
+	return o
+}
+
+// withHeaders - Document headers set on an already declared response
+func (o *Operation) withHeaders(status int, headers ...string) *Operation {
+	r := o.Responses[strconv.Itoa(status)]
+	if r.Headers == nil {
+		r.Headers = map[string]*Header{}
+	}
+	for _, h := range headers {
+		r.Headers[h] = &Header{Schema: String()}
+	}
+	return o
+}
+
+// fails - Error envelope responses for the given statuses
+func (o *Operation) fails(statuses ...int) *Operation {
+	for _, status := range statuses {
+		key := strconv.Itoa(status)
+		if _, ok := o.Responses[key]; ok {
+			continue
+		}
+		o.Responses[key] = &Response{
+			Description: http.StatusText(status),
+			Content:     map[string]*MediaType{"application/json": {Schema: Ref("ErrorEnvelope")}},
+		}
+	}
+	return o
+}
+
+// dataOf - {"data": schema}
+func dataOf(schema *Schema) *Schema {
+	return Object(map[string]*Schema{"data": schema}, "data")
+}
+
+// messageOf - {"message": "...", "data": schema}
+func messageOf(schema *Schema) *Schema {
+	return Object(map[string]*Schema{"message": String(), "data": schema}, "message", "data")
+}
+
+// message - {"message": "..."}
+func message() *Schema {
+	return Object(map[string]*Schema{"message": String()}, "message")
+}
+
+// OpenAPIPath - Convert a Gin route path ("/course/:id") to OpenAPI form ("/course/{id}")
+func OpenAPIPath(ginPath string) string {
+	parts := strings.Split(ginPath, "/")
+	for i, p := range parts {
+		if strings.HasPrefix(p, ":") || strings.HasPrefix(p, "*") {
+			parts[i] = "{" + p[1:] + "}"
+		}
+	}
+	return strings.Join(parts, "/")
+}
@@ -0,0 +1,49 @@
+package docs
+
+import (
+	"backend-go/apperr"
+	"embed"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+//go:embed ui.html
+var uiPage []byte
+
+// uiAssets - swagger-ui, vendored so the docs page loads nothing from a CDN
+//
+//go:embed swagger-ui/swagger-ui-bundle.js swagger-ui/swagger-ui.css
+var uiAssets embed.FS
+
+// ServeSpec - Handler returning the OpenAPI document as JSON
+func ServeSpec(c *gin.Context) {
+	c.JSON(http.StatusOK, Spec())
+}
+
+// ServeUI - Handler serving the interactive docs page backed by /openapi.json
+func ServeUI(c *gin.Context) {
+	c.Data(http.StatusOK, "text/html; charset=utf-8", uiPage)
+}
+
+// ServeUIAsset - Handler serving the script and stylesheet of the docs page
+func ServeUIAsset(c *gin.Context) {
+	var contentType string
+	switch c.Param("file") {
+	case "swagger-ui-bundle.js":
+		contentType = "text/javascript; charset=utf-8"
+	case "swagger-ui.css":
+		contentType = "text/css; charset=utf-8"
+	default:
+		apperr.Abort(c, apperr.ErrNotFound)
+		return
+	}
+
+	data, err := uiAssets.ReadFile("swagger-ui/" + c.Param("file"))
+	if err != nil {
+		apperr.Abort(c, apperr.ErrNotFound)
+		return
+	}
+	c.Header("Cache-Control", "public, max-age=86400")
+	c.Data(http.StatusOK, contentType, data)
+}
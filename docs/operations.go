@@ -0,0 +1,529 @@
+package docs
+
+import (
+	"backend-go/apikeys"
+	"backend-go/apperr"
+	"backend-go/models"
+	"backend-go/services"
+	"net/http"
+	"reflect"
+	"sync"
+)
+
+// named - Types published under components/schemas
+var named = map[string]interface{}{
+	"User":             models.User{},
+	"Profile":          models.Profile{},
+	"Course":           models.Course{},
+	"Enrollment":       models.Enrollment{},
+	"Lesson":           models.Lesson{},
+	"LessonAttachment": models.LessonAttachment{},
+	"VideoUpload":      models.VideoUpload{},
+	"Quiz":             models.Quiz{},
+	"Answer":           models.Answer{},
+	"UserQuiz":         models.UserQuiz{},
+	"UserAnswer":       models.UserAnswer{},
+	"Revision":         models.Revision{},
+	"QuizSubmission":   services.QuizSubmission{},
+	"MFAStatus":        services.MFAStatus{},
+	"MFASetup":         services.MFASetup{},
+	"MFAConfirmation":  services.MFAConfirmation{},
+	"APIKey":           models.APIKey{},
+	"Account":          services.Account{},
+	"DataExport":       models.DataExport{},
+	"AdminUser":        services.AdminUser{},
+	"AdminUserDetail":  services.AdminUserDetail{},
+	"Page":             services.Page{},
+	"AuditEntry":       models.AuditEntry{},
+	"TrashItem":        services.TrashItem{},
+	"TrashRestore":     services.TrashRestore{},
+	"Organization":     models.Organization{},
+	"Error":            apperr.Error{},
+}
+
+var (
+	specOnce sync.Once
+	spec     *Document
+)
+
+// Spec - The API description, built once
+func Spec() *Document {
+	specOnce.Do(func() { spec = build() })
+	return spec
+}
+
+func build() *Document {
+	g := &generator{refs: map[reflect.Type]string{}}
+	for name, v := range named {
+		g.refs[reflect.TypeOf(v)] = name
+	}
+
+	schemas := map[string]*Schema{
+		"ErrorEnvelope": Object(map[string]*Schema{"error": Ref("Error")}, "error"),
+	}
+	for name, v := range named {
+		schemas[name] = g.structSchema(reflect.TypeOf(v))
+	}
+
+	doc := &Document{
+		OpenAPI: "3.0.3",
+		Info: Info{
+			Title:   "Course platform API",
+			Version: "1.0.0",
+			Description: "Courses, lessons, quizzes and their media. Errors always use the ErrorEnvelope schema; " +
+				"the error code is stable, the message is for humans. Every /api route works within one organization (school), " +
+				"named by the X-Organization header or the subdomain, otherwise the default one.",
+		},
+		Tags: []Tag{
+			{Name: "Auth"}, {Name: "Two-factor"}, {Name: "Account"}, {Name: "API keys"}, {Name: "Admin: users"}, {Name: "Admin: audit"}, {Name: "Admin: trash"}, {Name: "Admin: organizations"}, {Name: "Organization"}, {Name: "Profile"}, {Name: "Courses"}, {Name: "Enrollments"},
+			{Name: "Lessons"}, {Name: "Attachments"}, {Name: "Video"}, {Name: "Quizzes"},
+			{Name: "Answers"}, {Name: "Media"}, {Name: "Docs"}, {Name: "Ops"},
+		},
+		Paths: map[string]PathItem{},
+		Components: Components{
+			Schemas: schemas,
+			SecuritySchemes: map[string]*SecurityScheme{
+				"bearerAuth": {
+					Type: "http", Scheme: "bearer", BearerFormat: "JWT",
+					Description: "A JWT from POST /login, or on routes that list a scope a personal API key (bgk_...) from POST /api-keys.",
+				},
+			},
+		},
+	}
+
+	for _, r := range operations() {
+		item, ok := doc.Paths[r.path]
+		if !ok {
+			item = PathItem{}
+			doc.Paths[r.path] = item
+		}
+		item[r.method] = r.op
+	}
+	return doc
+}
+
+type route struct {
+	method string
+	path   string
+	op     *Operation
+}
+
+// operations - Every route of routes.InitRouter: the unversioned media and docs
+// routes, /api/v1 with its deprecated root aliases, and /api/v2.
+// routes/openapi_test.go fails when the two lists drift apart.
+func operations() []route {
+	const (
+		created = http.StatusCreated
+		ok      = http.StatusOK
+	)
+
+	userInput := Object(map[string]*Schema{
+		"email": {Type: "string", Format: "email"}, "username": String(), "password": {Type: "string", Format: "password"},
+	}, "email", "username", "password")
+	loginInput := Object(map[string]*Schema{
+		"email": {Type: "string", Format: "email"}, "password": {Type: "string", Format: "password"},
+	}, "email", "password")
+	// loginReply - A token, or a challenge for /login/2fa
+	loginReply := Object(map[string]*Schema{
+		"message": String(), "token": String(), "mfa_setup_required": Boolean(),
+		"mfa_required": Boolean(), "challenge_token": String(), "expires_in": Integer(),
+	}, "message")
+	codeInput := Object(map[string]*Schema{"code": String()}, "code")
+	profileForm := Object(map[string]*Schema{
+		"first_name": String(), "last_name": String(), "phone": String(), "address": String(), "image": Binary(),
+	}, "first_name", "last_name", "phone", "address")
+	courseForm := Object(map[string]*Schema{
+		"name": String(), "description": String(), "price": Number(), "image": Binary(),
+	}, "name", "description")
+	lessonFields := map[string]*Schema{
+		"name": String(), "description": String(), "content": String(),
+		"content_format": Enum("markdown", "plain"), "type": Enum("text", "video"),
+		"course_id": Integer(), "image": Binary(),
+	}
+	quizInput := Object(map[string]*Schema{
+		"name": String(), "description": String(), "course_id": Integer(),
+	}, "name", "description", "course_id")
+	answerInput := Object(map[string]*Schema{"content": String(), "quiz_id": Integer()}, "content", "quiz_id")
+	playback := Object(map[string]*Schema{"lesson_id": Integer(), "position": Number(), "duration": Number()})
+	diff := Object(map[string]*Schema{"from": Integer(), "to": Integer(), "diff": String()}, "from", "to", "diff")
+	readiness := Object(map[string]*Schema{
+		"status": Enum("ok", "unavailable"),
+		"checks": Object(map[string]*Schema{
+			"database": Enum("ok", "fail"), "storage_public": Enum("ok", "fail"), "storage_private": Enum("ok", "fail"),
+		}),
+	}, "status", "checks")
+	tusHeaders := []string{"Tus-Resumable", "Tus-Version", "Tus-Extension", "Tus-Max-Size"}
+
+	revisions := func(tag, entity string, schema *Schema) []route {
+		base := "/" + entity + "/{id}/revisions"
+		return []route{
+			{"get", base, newOp(tag, "List revisions of a "+entity).bearer().id("id", entity+" ID").
+				reply(ok, "Revisions, newest first, without snapshots", dataOf(ArrayOf(Ref("Revision")))).fails(http.StatusForbidden)},
+			{"get", base + "/diff", newOp(tag, "Diff two revisions of a "+entity).bearer().id("id", entity+" ID").
+				query("from", "Older version", Integer(), true).query("to", "Newer version", Integer(), true).
+				reply(ok, "Unified diff", dataOf(diff)).fails(http.StatusForbidden, http.StatusNotFound)},
+			{"get", base + "/{version}", newOp(tag, "Get one revision of a "+entity).bearer().id("id", entity+" ID").
+				pathParam("version", "Revision version", Integer()).
+				reply(ok, "Revision with snapshot", dataOf(Ref("Revision"))).fails(http.StatusForbidden, http.StatusNotFound)},
+			{"post", base + "/{version}/restore", newOp(tag, "Restore a "+entity+" to an earlier revision").bearer().id("id", entity+" ID").
+				pathParam("version", "Revision version", Integer()).
+				reply(ok, "Restored "+entity, messageOf(schema)).fails(http.StatusForbidden, http.StatusNotFound)},
+		}
+	}
+
+	root := []route{
+		{"get", "/uploads/{file}", newOp("Media", "Download a public course or profile image").
+			pathParam("file", "File name from a Course.Image or Profile.Image URL", String()).
+			replyAs(ok, "File contents", "application/octet-stream", Binary()).fails(http.StatusNotFound)},
+		{"head", "/uploads/{file}", newOp("Media", "Headers of a public image").
+			pathParam("file", "File name", String()).empty(ok, "File exists").fails(http.StatusNotFound)},
+		{"get", "/media/lesson/{id}", newOp("Media", "Download a lesson image").signedOrBearer().id("id", "Lesson ID").
+			replyAs(ok, "Image", "application/octet-stream", Binary()).fails(http.StatusNotFound)},
+		{"get", "/media/attachment/{id}", newOp("Media", "Download an attachment through its signed download URL or as an enrolled user").
+			signedOrBearer().id("id", "Attachment ID").
+			replyAs(ok, "File contents", "application/octet-stream", Binary()).withHeaders(ok, "Content-Disposition", "Digest").
+			fails(http.StatusForbidden, http.StatusNotFound)},
+		{"get", "/media/export/{id}", newOp("Media", "Download a personal data export (ZIP) through the emailed link or as its owner").
+			signedOrBearer().id("id", "Export ID").
+			replyAs(ok, "ZIP archive", "application/zip", Binary()).fails(http.StatusNotFound)},
+		{"get", "/media/lesson/{id}/video", newOp("Media", "Stream a lesson video (supports Range)").signedOrBearer().id("id", "Lesson ID").
+			header("Range", "Byte range, e.g. bytes=0-", String(), false).
+			replyAs(ok, "Whole video", "video/*", Binary()).replyAs(http.StatusPartialContent, "Requested range", "video/*", Binary()).
+			withHeaders(http.StatusPartialContent, "Content-Range", "Accept-Ranges").fails(http.StatusNotFound)},
+		{"head", "/media/lesson/{id}/video", newOp("Media", "Headers of a lesson video").signedOrBearer().id("id", "Lesson ID").
+			empty(ok, "Video exists", "Content-Length", "Accept-Ranges").fails(http.StatusNotFound)},
+		{"get", "/healthz", newOp("Ops", "Liveness probe").
+			reply(ok, "The process is serving HTTP", Object(map[string]*Schema{"status": Enum("ok")}, "status"))},
+		{"get", "/readyz", newOp("Ops", "Readiness probe: database ping and storage write check").
+			reply(ok, "Ready", readiness).reply(http.StatusServiceUnavailable, "A dependency is failing", readiness)},
+		{"get", "/metrics", newOp("Ops", "Prometheus metrics").
+			replyAs(ok, "Prometheus text exposition format", "text/plain", String())},
+		{"get", "/openapi.json", newOp("Docs", "This document").reply(ok, "OpenAPI 3 document", &Schema{Type: "object"})},
+		{"get", "/docs", newOp("Docs", "Interactive API documentation").replyAs(ok, "HTML page", "text/html", String())},
+		{"get", "/docs/assets/{file}", newOp("Docs", "Script and stylesheet of the documentation page").
+			pathParam("file", "swagger-ui-bundle.js or swagger-ui.css", Enum("swagger-ui-bundle.js", "swagger-ui.css")).
+			replyAs(ok, "Asset", "text/javascript", String()).fails(http.StatusNotFound)},
+	}
+
+	shared := []route{
+		{"post", "/register", newOp("Auth", "Create an account").json(userInput).
+			reply(ok, "Account created", Object(map[string]*Schema{"message": String(), "user": Ref("User")}, "message", "user")).
+			fails(http.StatusConflict, http.StatusTooManyRequests)},
+		{"post", "/login", newOp("Auth", "Exchange credentials for a JWT, or for a challenge when two-factor authentication is on").
+			json(loginInput).
+			reply(ok, "Logged in (token) or second factor needed (mfa_required and challenge_token, see /login/2fa)", loginReply).
+			fails(http.StatusUnauthorized, http.StatusTooManyRequests)},
+		{"post", "/login/2fa", newOp("Auth", "Exchange a login challenge and a TOTP or recovery code for a JWT").
+			json(Object(map[string]*Schema{"challenge_token": String(), "code": String()}, "challenge_token", "code")).
+			reply(ok, "Logged in", Object(map[string]*Schema{"message": String(), "token": String()}, "message", "token")).
+			fails(http.StatusUnauthorized, http.StatusUnprocessableEntity, http.StatusTooManyRequests)},
+		{"get", "/auth/sso", newOp("Auth", "List the OpenID Connect providers users can log in with").
+			reply(ok, "Providers", dataOf(ArrayOf(Object(map[string]*Schema{
+				"name": String(), "display_name": String(), "login_url": String(),
+			}, "name", "login_url"))))},
+		{"get", "/auth/sso/{provider}/login", newOp("Auth", "Start an SSO login: redirects to the identity provider (authorization code with PKCE)").
+			pathParam("provider", "Provider name", String()).
+			empty(http.StatusFound, "Redirect to the provider", "Location", "Set-Cookie").fails(http.StatusNotFound, http.StatusBadGateway)},
+		{"get", "/auth/sso/{provider}/callback", newOp("Auth", "Finish an SSO login. Links the identity to the user with the same verified email "+
+			"or creates a user; responds like /login, or redirects to the configured frontend with the result in the fragment").
+			pathParam("provider", "Provider name", String()).
+			query("code", "Authorization code", String(), false).query("state", "State from the login redirect", String(), true).
+			reply(ok, "Logged in or second factor needed, as for /login", loginReply).
+			empty(http.StatusFound, "Redirect to the frontend", "Location").
+			fails(http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusBadGateway)},
+		{"get", "/2fa", newOp("Two-factor", "Two-factor status of the current user").bearer().
+			reply(ok, "Status", dataOf(Ref("MFAStatus")))},
+		{"post", "/2fa/setup", newOp("Two-factor", "Start enrollment: new secret and otpauth:// URI").bearer().
+			reply(ok, "Secret to add to an authenticator app", messageOf(Ref("MFASetup"))).fails(http.StatusConflict)},
+		{"post", "/2fa/confirm", newOp("Two-factor", "Enable two-factor authentication with the first code; returns the recovery codes once").
+			bearer().json(codeInput).
+			reply(ok, "Enabled", messageOf(Ref("MFAConfirmation"))).fails(http.StatusConflict, http.StatusUnprocessableEntity)},
+		{"post", "/2fa/recovery-codes", newOp("Two-factor", "Replace the recovery codes, given a TOTP code").bearer().json(codeInput).
+			reply(ok, "New recovery codes", messageOf(Object(map[string]*Schema{"recovery_codes": ArrayOf(String())}, "recovery_codes"))).
+			fails(http.StatusConflict, http.StatusUnprocessableEntity)},
+		{"post", "/2fa/disable", newOp("Two-factor", "Disable two-factor authentication with a TOTP or recovery code").bearer().json(codeInput).
+			reply(ok, "Disabled", message()).fails(http.StatusForbidden, http.StatusConflict, http.StatusUnprocessableEntity)},
+		{"get", "/account", newOp("Account", "The current user's account").bearer().
+			reply(ok, "Account", dataOf(Ref("Account")))},
+		{"put", "/account/password", newOp("Account", "Change the password; every session ends and a new token is returned").bearer().
+			json(Object(map[string]*Schema{
+				"current_password": {Type: "string", Format: "password"}, "new_password": {Type: "string", Format: "password"},
+			}, "current_password", "new_password")).
+			reply(ok, "Password changed", Object(map[string]*Schema{"message": String(), "token": String()}, "message", "token")).
+			fails(http.StatusForbidden, http.StatusTooManyRequests)},
+		{"post", "/account/email", newOp("Account", "Send a confirmation link to a new email address").bearer().
+			json(Object(map[string]*Schema{
+				"email": {Type: "string", Format: "email"}, "password": {Type: "string", Format: "password"},
+			}, "email", "password")).
+			reply(http.StatusAccepted, "Link sent", message()).
+			fails(http.StatusForbidden, http.StatusConflict, http.StatusUnprocessableEntity, http.StatusTooManyRequests)},
+		{"post", "/account/email/confirm", newOp("Account", "Switch to the new email address with the token from the link").
+			json(Object(map[string]*Schema{"token": String()}, "token")).
+			reply(ok, "Email changed", messageOf(Ref("Account"))).
+			fails(http.StatusBadRequest, http.StatusConflict, http.StatusTooManyRequests)},
+		{"post", "/account/password/reset", newOp("Account", "Choose a new password with the token from an admin-forced reset").
+			json(Object(map[string]*Schema{"token": String(), "new_password": {Type: "string", Format: "password"}}, "token", "new_password")).
+			reply(ok, "Password changed", message()).
+			fails(http.StatusBadRequest, http.StatusTooManyRequests)},
+		{"delete", "/account", newOp("Account", "Delete the account after a grace period; logging in before then cancels it").bearer().
+			json(Object(map[string]*Schema{"password": {Type: "string", Format: "password"}}, "password")).
+			reply(http.StatusAccepted, "Deletion scheduled", Object(map[string]*Schema{
+				"message": String(), "deletion_scheduled_at": {Type: "string", Format: "date-time"},
+			}, "message", "deletion_scheduled_at")).
+			fails(http.StatusForbidden, http.StatusTooManyRequests)},
+		{"post", "/me/export", newOp("Account", "Start a personal data export; a download link is emailed when it is ready").bearer().
+			reply(http.StatusAccepted, "Export started, or the one already being built", messageOf(Ref("DataExport"))).
+			fails(http.StatusServiceUnavailable)},
+		{"get", "/me/exports", newOp("Account", "List the current user's data exports; ready ones carry a short-lived DownloadURL").bearer().
+			reply(ok, "Exports", dataOf(ArrayOf(Ref("DataExport"))))},
+		{"get", "/api-keys", newOp("API keys", "List the current user's API keys, without their secrets").bearer().
+			reply(ok, "API keys", dataOf(ArrayOf(Ref("APIKey"))))},
+		{"post", "/api-keys", newOp("API keys", "Create an API key; the key is only returned here").bearer().
+			json(Object(map[string]*Schema{
+				"name": String(), "scopes": ArrayOf(Enum(apikeys.Scopes...)), "expires_at": {Type: "string", Format: "date-time"},
+			}, "name", "scopes")).
+			reply(created, "API key created", Object(map[string]*Schema{
+				"message": String(), "key": String(), "data": Ref("APIKey"),
+			}, "message", "key", "data")).fails(http.StatusConflict, http.StatusUnprocessableEntity)},
+		{"delete", "/api-keys/{id}", newOp("API keys", "Revoke an API key").bearer().id("id", "API key ID").
+			reply(ok, "API key revoked", messageOf(Ref("APIKey"))).fails(http.StatusNotFound)},
+		{"get", "/admin/users", newOp("Admin: users", "Search users, newest first").bearer().
+			query("q", "Part of the email or username", String(), false).
+			query("role", "Only this role", Enum("admin", "user", "superadmin"), false).
+			query("status", "Only active or only suspended users", Enum("active", "suspended"), false).
+			query("page", "Page number, from 1", Integer(), false).
+			query("per_page", "Users per page, at most 100 (default 20)", Integer(), false).
+			reply(ok, "One page of users", Object(map[string]*Schema{"data": ArrayOf(Ref("AdminUser")), "meta": Ref("Page")}, "data", "meta")).
+			fails(http.StatusForbidden, http.StatusUnprocessableEntity)},
+		{"get", "/admin/users/{id}", newOp("Admin: users", "A user with their profile and enrollments").bearer().id("id", "User ID").
+			reply(ok, "User", dataOf(Ref("AdminUserDetail"))).fails(http.StatusForbidden, http.StatusNotFound)},
+		{"put", "/admin/users/{id}/role", newOp("Admin: users", "Change a user's role; it applies to their next request. "+
+			"Only superadmins grant superadmin or manage superadmins").bearer().id("id", "User ID").
+			json(Object(map[string]*Schema{"role": Enum("admin", "user", "superadmin")}, "role")).
+			reply(ok, "Role changed", messageOf(Ref("AdminUser"))).
+			fails(http.StatusForbidden, http.StatusNotFound, http.StatusConflict, http.StatusUnprocessableEntity)},
+		{"post", "/admin/users/{id}/suspend", newOp("Admin: users", "Suspend a user: logins, sessions and API keys stop working").bearer().id("id", "User ID").
+			json(Object(map[string]*Schema{"reason": String()}, "reason")).
+			reply(ok, "Suspended", messageOf(Ref("AdminUser"))).
+			fails(http.StatusForbidden, http.StatusNotFound, http.StatusConflict, http.StatusUnprocessableEntity)},
+		{"delete", "/admin/users/{id}/suspend", newOp("Admin: users", "Lift a suspension").bearer().id("id", "User ID").
+			reply(ok, "Suspension lifted", messageOf(Ref("AdminUser"))).fails(http.StatusForbidden, http.StatusNotFound, http.StatusConflict)},
+		{"post", "/admin/users/{id}/password-reset", newOp("Admin: users", "Clear a user's password, end their sessions and email them a reset link").
+			bearer().id("id", "User ID").
+			reply(http.StatusAccepted, "Reset link sent", message()).fails(http.StatusForbidden, http.StatusNotFound, http.StatusConflict)},
+		{"post", "/admin/users/{id}/impersonate", newOp("Admin: users", "Get a one-hour token acting as the user for support. "+
+			"It carries an impersonator_id claim, every request with it is logged with the admin's id, and account, "+
+			"two-factor, API key and export routes refuse it").bearer().id("id", "User ID").
+			reply(ok, "Impersonation token", Object(map[string]*Schema{
+				"message": String(), "token": String(), "expires_at": {Type: "string", Format: "date-time"},
+			}, "message", "token", "expires_at")).
+			fails(http.StatusForbidden, http.StatusNotFound, http.StatusConflict)},
+		{"get", "/admin/audit", newOp("Admin: audit", "Search the audit log of changes made through the API, newest first. "+
+			"Changes maps each changed field to its from and to values. Admins see their organization's entries, superadmins the whole platform's").bearer().
+			query("actor_id", "Only changes made by this user, or by this admin while impersonating", Integer(), false).
+			query("action", "Only this action, e.g. course.delete", String(), false).
+			query("entity_type", "Only changes to this kind of entity, e.g. quiz", String(), false).
+			query("entity_id", "Only changes to this entity", Integer(), false).
+			query("request_id", "Only changes made by this request", String(), false).
+			query("since", "Only changes at or after this time (RFC 3339)", &Schema{Type: "string", Format: "date-time"}, false).
+			query("until", "Only changes before this time (RFC 3339)", &Schema{Type: "string", Format: "date-time"}, false).
+			query("page", "Page number, from 1", Integer(), false).
+			query("per_page", "Entries per page, at most 100 (default 20)", Integer(), false).
+			reply(ok, "One page of entries", Object(map[string]*Schema{"data": ArrayOf(Ref("AuditEntry")), "meta": Ref("Page")}, "data", "meta")).
+			fails(http.StatusForbidden, http.StatusUnprocessableEntity)},
+		{"get", "/admin/audit/export", newOp("Admin: audit", "Download every entry matching the same filters as /admin/audit, oldest first, "+
+			"as CSV or, with format=json, newline-delimited JSON").bearer().
+			query("format", "csv (default) or json", Enum("csv", "json"), false).
+			query("actor_id", "As for /admin/audit", Integer(), false).
+			query("action", "As for /admin/audit", String(), false).
+			query("entity_type", "As for /admin/audit", String(), false).
+			query("entity_id", "As for /admin/audit", Integer(), false).
+			query("request_id", "As for /admin/audit", String(), false).
+			query("since", "As for /admin/audit", &Schema{Type: "string", Format: "date-time"}, false).
+			query("until", "As for /admin/audit", &Schema{Type: "string", Format: "date-time"}, false).
+			replyAs(ok, "Audit entries", "text/csv", String()).
+			fails(http.StatusForbidden, http.StatusUnprocessableEntity)},
+		{"get", "/admin/trash", newOp("Admin: trash", "List deleted courses, lessons, attachments, quizzes and answers, most recently deleted first. "+
+			"They can be restored until purge_at, then they are removed for good").bearer().
+			query("type", "Only this kind of item", Enum("course", "lesson", "attachment", "quiz", "answer"), false).
+			query("cascaded", "Also list items deleted together with their parent (default false)", &Schema{Type: "boolean"}, false).
+			query("page", "Page number, from 1", Integer(), false).
+			query("per_page", "Items per page, at most 100 (default 20)", Integer(), false).
+			reply(ok, "One page of deleted items", Object(map[string]*Schema{"data": ArrayOf(Ref("TrashItem")), "meta": Ref("Page")}, "data", "meta")).
+			fails(http.StatusForbidden, http.StatusUnprocessableEntity)},
+		{"post", "/admin/trash/{type}/{id}/restore", newOp("Admin: trash", "Restore a deleted item and everything deleted together with it, "+
+			"e.g. a course with its lessons, attachments, quizzes and answers. An item whose parent is still deleted cannot be restored on its own").bearer().
+			pathParam("type", "Kind of item", Enum("course", "lesson", "attachment", "quiz", "answer")).id("id", "Item ID").
+			reply(ok, "Restored", messageOf(Ref("TrashRestore"))).
+			fails(http.StatusForbidden, http.StatusNotFound, http.StatusConflict)},
+		{"get", "/organization", newOp("Organization", "The organization the request is for, named by the X-Organization header or the subdomain").
+			reply(ok, "Organization", dataOf(Ref("Organization"))).fails(http.StatusNotFound)},
+		{"get", "/admin/organizations", newOp("Admin: organizations", "List every organization (superadmin)").bearer().
+			reply(ok, "Organizations", dataOf(ArrayOf(Ref("Organization")))).fails(http.StatusForbidden)},
+		{"post", "/admin/organizations", newOp("Admin: organizations", "Add an organization; its slug is its subdomain and X-Organization value (superadmin)").bearer().
+			json(Object(map[string]*Schema{"name": String(), "slug": String()}, "name", "slug")).
+			reply(created, "Organization created", messageOf(Ref("Organization"))).
+			fails(http.StatusForbidden, http.StatusConflict, http.StatusUnprocessableEntity)},
+		{"get", "/admin/organizations/{id}", newOp("Admin: organizations", "An organization (superadmin)").bearer().id("id", "Organization ID").
+			reply(ok, "Organization", dataOf(Ref("Organization"))).fails(http.StatusForbidden, http.StatusNotFound)},
+		{"put", "/admin/organizations/{id}", newOp("Admin: organizations", "Rename an organization or change its slug (superadmin)").bearer().id("id", "Organization ID").
+			json(Object(map[string]*Schema{"name": String(), "slug": String()})).
+			reply(ok, "Organization updated", messageOf(Ref("Organization"))).
+			fails(http.StatusForbidden, http.StatusNotFound, http.StatusConflict, http.StatusUnprocessableEntity)},
+		{"post", "/profile", newOp("Profile", "Create the current user's profile").bearer().form(profileForm).
+			reply(created, "Profile created", messageOf(Ref("Profile")))},
+		{"get", "/profile", newOp("Profile", "Get the current user's profile").bearer().
+			reply(ok, "Profile", dataOf(Ref("Profile"))).fails(http.StatusNotFound)},
+		{"put", "/profile", newOp("Profile", "Update the current user's profile").bearer().form(profileForm).
+			reply(ok, "Profile updated", messageOf(Ref("Profile"))).fails(http.StatusNotFound)},
+		{"delete", "/profile", newOp("Profile", "Delete the current user's profile").bearer().
+			reply(ok, "Profile deleted", message()).fails(http.StatusNotFound)},
+		{"post", "/course", newOp("Courses", "Create a course (admin)").bearer().form(courseForm).
+			reply(created, "Course created", messageOf(Ref("Course"))).fails(http.StatusForbidden)},
+		{"get", "/courses", newOp("Courses", "List courses").scoped(apikeys.ScopeCoursesRead).reply(ok, "Courses", dataOf(ArrayOf(Ref("Course"))))},
+		{"get", "/course/{id}", newOp("Courses", "Get a course").scoped(apikeys.ScopeCoursesRead).id("id", "Course ID").
+			reply(ok, "Course", dataOf(Ref("Course"))).fails(http.StatusNotFound)},
+		{"get", "/course/{id}/students", newOp("Courses", "List students enrolled in a course").id("id", "Course ID").
+			reply(ok, "Students", Object(map[string]*Schema{
+				"course": String(),
+				"students": ArrayOf(Object(map[string]*Schema{
+					"user_id": Integer(), "username": String(), "email": String(),
+				}, "user_id", "username", "email")),
+			}, "course", "students")).fails(http.StatusNotFound)},
+		{"get", "/course/{id}/lessons", newOp("Courses", "List the lessons of a course (enrolled users)").scoped(apikeys.ScopeCoursesRead).id("id", "Course ID").
+			reply(ok, "Lessons", dataOf(ArrayOf(Ref("Lesson")))).fails(http.StatusForbidden, http.StatusNotFound)},
+		{"get", "/course/{id}/quizzes", newOp("Courses", "List the quizzes of a course (enrolled users)").scoped(apikeys.ScopeCoursesRead).id("id", "Course ID").
+			reply(ok, "Quizzes", dataOf(ArrayOf(Ref("Quiz")))).fails(http.StatusForbidden)},
+		{"put", "/course/{id}", newOp("Courses", "Update a course (admin)").bearer().id("id", "Course ID").form(courseForm).
+			reply(ok, "Course updated", messageOf(Ref("Course"))).fails(http.StatusForbidden, http.StatusNotFound)},
+		{"delete", "/course/{id}", newOp("Courses", "Move a course with its lessons, attachments, quizzes and answers to the trash (admin)").bearer().id("id", "Course ID").
+			reply(ok, "Course deleted", message()).fails(http.StatusForbidden, http.StatusNotFound)},
+		{"post", "/enroll/{id}", newOp("Enrollments", "Enroll the current user in a course").scoped(apikeys.ScopeEnrollmentsManage).id("id", "Course ID").
+			reply(ok, "Enrolled", message()).fails(http.StatusNotFound, http.StatusConflict)},
+		{"delete", "/enroll/{id}", newOp("Enrollments", "Leave a course").scoped(apikeys.ScopeEnrollmentsManage).id("id", "Course ID").
+			reply(ok, "Unenrolled", message()).fails(http.StatusNotFound)},
+		{"get", "/enrollments", newOp("Enrollments", "List the current user's enrollments").scoped(apikeys.ScopeEnrollmentsManage).
+			reply(ok, "Enrollments with their course", dataOf(ArrayOf(Ref("Enrollment"))))},
+		{"post", "/lesson", newOp("Lessons", "Create a lesson (admin)").bearer().
+			form(Object(lessonFields, "name", "description", "course_id")).
+			reply(created, "Lesson created", messageOf(Ref("Lesson"))).fails(http.StatusForbidden, http.StatusNotFound)},
+		{"put", "/lesson/{id}", newOp("Lessons", "Update a lesson (admin); omitted fields are kept").bearer().id("id", "Lesson ID").
+			form(Object(lessonFields)).
+			reply(ok, "Lesson updated", messageOf(Ref("Lesson"))).fails(http.StatusForbidden, http.StatusNotFound)},
+		{"delete", "/lesson/{id}", newOp("Lessons", "Move a lesson with its attachments to the trash (admin)").bearer().id("id", "Lesson ID").
+			reply(ok, "Lesson deleted", message()).fails(http.StatusForbidden, http.StatusNotFound)},
+		{"post", "/lesson/{id}/attachments", newOp("Attachments", "Attach a file to a lesson (course staff)").bearer().id("id", "Lesson ID").
+			form(Object(map[string]*Schema{"file": Binary(), "display_name": String()}, "file")).
+			reply(created, "Attachment stored", messageOf(Ref("LessonAttachment"))).
+			fails(http.StatusForbidden, http.StatusNotFound, http.StatusRequestEntityTooLarge)},
+		{"get", "/lesson/{id}/attachments", newOp("Attachments", "List the attachments of a lesson").bearer().id("id", "Lesson ID").
+			reply(ok, "Attachments with signed download URLs", dataOf(ArrayOf(Ref("LessonAttachment")))).
+			fails(http.StatusForbidden, http.StatusNotFound)},
+		{"delete", "/attachment/{id}", newOp("Attachments", "Move an attachment to the trash (course staff)").bearer().id("id", "Attachment ID").
+			reply(ok, "Attachment deleted", message()).fails(http.StatusForbidden, http.StatusNotFound)},
+		{"options", "/lesson/{id}/video/uploads", newOp("Video", "tus capabilities").pathParam("id", "Lesson ID", Integer()).
+			empty(http.StatusNoContent, "Supported tus version and extensions", tusHeaders...)},
+		{"post", "/lesson/{id}/video/uploads", newOp("Video", "Start a resumable video upload (tus creation)").bearer().id("id", "Lesson ID").
+			header("Tus-Resumable", "Must be 1.0.0", String(), true).
+			header("Upload-Length", "Total size in bytes", Integer(), true).
+			header("Upload-Metadata", "tus metadata, e.g. filename <base64>", String(), false).
+			reply(created, "Upload created", messageOf(Ref("VideoUpload"))).withHeaders(created, "Location", "Upload-Offset").
+			fails(http.StatusForbidden, http.StatusNotFound, http.StatusPreconditionFailed, http.StatusRequestEntityTooLarge)},
+		{"options", "/video-upload/{id}", newOp("Video", "tus capabilities").pathParam("id", "Upload ID", Integer()).
+			empty(http.StatusNoContent, "Supported tus version and extensions", tusHeaders...)},
+		{"head", "/video-upload/{id}", newOp("Video", "Current offset of an upload").bearer().id("id", "Upload ID").
+			header("Tus-Resumable", "Must be 1.0.0", String(), true).
+			empty(ok, "Offset to resume from", "Upload-Offset", "Upload-Length").
+			fails(http.StatusNotFound, http.StatusPreconditionFailed)},
+		{"patch", "/video-upload/{id}", newOp("Video", "Append a chunk to an upload").bearer().id("id", "Upload ID").
+			header("Tus-Resumable", "Must be 1.0.0", String(), true).
+			header("Upload-Offset", "Offset the chunk starts at", Integer(), true).
+			body("application/offset+octet-stream", Binary()).
+			empty(http.StatusNoContent, "Chunk stored; the last chunk attaches the video to the lesson", "Upload-Offset").
+			fails(http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusPreconditionFailed,
+				http.StatusUnsupportedMediaType, http.StatusUnprocessableEntity)},
+		{"delete", "/video-upload/{id}", newOp("Video", "Abandon an upload (tus termination)").bearer().id("id", "Upload ID").
+			header("Tus-Resumable", "Must be 1.0.0", String(), true).
+			empty(http.StatusNoContent, "Upload removed").fails(http.StatusNotFound, http.StatusPreconditionFailed)},
+		{"get", "/lesson/{id}/progress", newOp("Video", "Where the current user stopped in a lesson video").bearer().id("id", "Lesson ID").
+			reply(ok, "Playback position", dataOf(playback)).fails(http.StatusForbidden, http.StatusNotFound)},
+		{"put", "/lesson/{id}/progress", newOp("Video", "Save the current user's playback position").bearer().id("id", "Lesson ID").
+			json(Object(map[string]*Schema{"position": Number(), "duration": Number()})).
+			reply(ok, "Playback position saved", messageOf(playback)).fails(http.StatusForbidden, http.StatusNotFound)},
+		{"post", "/quiz", newOp("Quizzes", "Create a quiz (admin)").bearer().json(quizInput).
+			reply(created, "Quiz created", messageOf(Ref("Quiz"))).fails(http.StatusForbidden, http.StatusNotFound)},
+		{"put", "/quiz/{id}", newOp("Quizzes", "Update a quiz (admin)").bearer().id("id", "Quiz ID").json(quizInput).
+			reply(ok, "Quiz updated", messageOf(Ref("Quiz"))).fails(http.StatusForbidden, http.StatusNotFound)},
+		{"delete", "/quiz/{id}", newOp("Quizzes", "Move a quiz with its answers to the trash (admin)").bearer().id("id", "Quiz ID").
+			reply(ok, "Quiz deleted", message()).fails(http.StatusForbidden)},
+		{"post", "/quiz/{id}/submit", newOp("Quizzes", "Submit answers to a quiz (enrolled users)").bearer().id("id", "Quiz ID").
+			json(Object(map[string]*Schema{"answer_ids": ArrayOf(Integer())}, "answer_ids")).
+			reply(created, "Attempt recorded", messageOf(Ref("QuizSubmission"))).fails(http.StatusForbidden, http.StatusNotFound)},
+		{"get", "/grades", newOp("Quizzes", "List the current user's quiz attempts").scoped(apikeys.ScopeGradesRead).
+			reply(ok, "Attempts with their quiz, newest first", dataOf(ArrayOf(Ref("UserQuiz"))))},
+		{"post", "/answer", newOp("Answers", "Add an answer to a quiz").bearer().json(answerInput).
+			reply(created, "Answer created", messageOf(Ref("Answer"))).fails(http.StatusNotFound)},
+		{"put", "/answer/{id}", newOp("Answers", "Update an answer (admin)").bearer().id("id", "Answer ID").json(answerInput).
+			reply(ok, "Answer updated", messageOf(Ref("Answer"))).fails(http.StatusForbidden, http.StatusNotFound)},
+		{"delete", "/answer/{id}", newOp("Answers", "Move an answer to the trash (admin)").bearer().id("id", "Answer ID").
+			reply(ok, "Answer deleted", message()).fails(http.StatusForbidden)},
+	}
+
+	v1 := []route{
+		{"get", "/lessons", deprecate(newOp("Lessons", "List lessons").bearer().
+			reply(ok, "Lessons", dataOf(ArrayOf(Ref("Lesson")))).fails(http.StatusForbidden), v1ReadNote)},
+		{"get", "/lesson/{id}", deprecate(newOp("Lessons", "Get a lesson with signed media URLs").bearer().id("id", "Lesson ID").
+			reply(ok, "Lesson", dataOf(Ref("Lesson"))).fails(http.StatusForbidden, http.StatusNotFound), v1ReadNote)},
+		{"get", "/quizzes", deprecate(newOp("Quizzes", "List quizzes").bearer().
+			reply(ok, "Quizzes with their course", dataOf(ArrayOf(Ref("Quiz")))).fails(http.StatusForbidden), v1ReadNote)},
+		{"get", "/quiz/{id}", deprecate(newOp("Quizzes", "Get a quiz").bearer().id("id", "Quiz ID").
+			reply(ok, "Quiz with its course", dataOf(Ref("Quiz"))).fails(http.StatusForbidden, http.StatusNotFound), v1ReadNote)},
+		{"get", "/answers/{id}/question", deprecate(newOp("Answers", "List the answers of a quiz").bearer().id("id", "Quiz ID").
+			reply(ok, "Answers", dataOf(ArrayOf(Ref("Answer")))).fails(http.StatusForbidden, http.StatusNotFound), v1ReadNote)},
+		{"get", "/answer/{id}", deprecate(newOp("Answers", "Get an answer").bearer().id("id", "Answer ID").
+			reply(ok, "Answer", dataOf(Ref("Answer"))).fails(http.StatusForbidden, http.StatusNotFound), v1ReadNote)},
+		{"get", "/protected", newOp("Auth", "Echo the caller's identity (admin)").bearer().
+			reply(ok, "Caller", Object(map[string]*Schema{
+				"message": String(),
+				"data":    Object(map[string]*Schema{"user_id": Integer(), "role": String()}),
+			})).fails(http.StatusForbidden)},
+	}
+
+	shared = append(shared, revisions("Lessons", "lesson", Ref("Lesson"))...)
+	shared = append(shared, revisions("Quizzes", "quiz", Ref("Quiz"))...)
+
+	v2 := []route{
+		{"get", "/lesson/{id}", newOp("Lessons", "Get a lesson with signed media URLs (enrolled users or staff)").bearer().id("id", "Lesson ID").
+			reply(ok, "Lesson", dataOf(Ref("Lesson"))).fails(http.StatusForbidden, http.StatusNotFound)},
+		{"get", "/quiz/{id}", newOp("Quizzes", "Get a quiz (enrolled users or staff)").bearer().id("id", "Quiz ID").
+			reply(ok, "Quiz with its course", dataOf(Ref("Quiz"))).fails(http.StatusForbidden, http.StatusNotFound)},
+		{"get", "/quiz/{id}/answers", newOp("Answers", "List the answers of a quiz (enrolled users or staff)").bearer().id("id", "Quiz ID").
+			reply(ok, "Answers", dataOf(ArrayOf(Ref("Answer")))).fails(http.StatusForbidden, http.StatusNotFound)},
+	}
+
+	routes := root
+	for _, r := range append(shared, v1...) {
+		routes = append(routes, route{r.method, "/api/v1" + r.path, r.op})
+		routes = append(routes, route{r.method, r.path, deprecate(r.op, legacyNote)})
+	}
+	for _, r := range append(shared, v2...) {
+		routes = append(routes, route{r.method, "/api/v2" + r.path, r.op})
+	}
+	return routes
+}
+
+const (
+	legacyNote = "Unversioned alias of /api/v1, sunset on 2027-04-30. Responses carry Deprecation, Sunset and Link headers."
+	v1ReadNote = "Superseded by the v2 route. Sunset on 2027-10-31."
+)
+
+// deprecate - Copy of op marked deprecated with note appended to its description
+func deprecate(op *Operation, note string) *Operation {
+	copy := *op
+	copy.Deprecated = true
+	if copy.Description != "" {
+		note = copy.Description + " " + note
+	}
+	copy.Description = note
+	return &copy
+}
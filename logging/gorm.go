@@ -0,0 +1,76 @@
+package logging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// GormLogger - Routes GORM's logging through slog. Failed queries are errors,
+// queries slower than SlowThreshold are warnings and every other query is
+// logged at debug level.
+type GormLogger struct {
+	Logger        *slog.Logger
+	SlowThreshold time.Duration
+	Level         gormlogger.LogLevel
+}
+
+// NewGormLogger - A GORM logger writing to logger; slow <= 0 disables slow-query warnings
+func NewGormLogger(logger *slog.Logger, slow time.Duration) *GormLogger {
+	return &GormLogger{Logger: logger, SlowThreshold: slow, Level: gormlogger.Info}
+}
+
+func (l *GormLogger) LogMode(level gormlogger.LogLevel) gormlogger.Interface {
+	copy := *l
+	copy.Level = level
+	return &copy
+}
+
+func (l *GormLogger) Info(ctx context.Context, msg string, data ...interface{}) {
+	if l.Level >= gormlogger.Info {
+		l.Logger.InfoContext(ctx, fmt.Sprintf(msg, data...))
+	}
+}
+
+func (l *GormLogger) Warn(ctx context.Context, msg string, data ...interface{}) {
+	if l.Level >= gormlogger.Warn {
+		l.Logger.WarnContext(ctx, fmt.Sprintf(msg, data...))
+	}
+}
+
+func (l *GormLogger) Error(ctx context.Context, msg string, data ...interface{}) {
+	if l.Level >= gormlogger.Error {
+		l.Logger.ErrorContext(ctx, fmt.Sprintf(msg, data...))
+	}
+}
+
+func (l *GormLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	if l.Level <= gormlogger.Silent {
+		return
+	}
+
+	elapsed := time.Since(begin)
+	switch {
+	// Record not found adalah alur normal (404), bukan error database
+	case err != nil && l.Level >= gormlogger.Error && !errors.Is(err, gorm.ErrRecordNotFound):
+		sql, rows := fc()
+		l.Logger.ErrorContext(ctx, "query failed", "sql", sql, "rows", rows, "duration_ms", ms(elapsed), "error", err)
+	case l.SlowThreshold > 0 && elapsed > l.SlowThreshold && l.Level >= gormlogger.Warn:
+		sql, rows := fc()
+		l.Logger.WarnContext(ctx, "slow query", "sql", sql, "rows", rows, "duration_ms", ms(elapsed),
+			"threshold_ms", ms(l.SlowThreshold))
+	case l.Level >= gormlogger.Info && l.Logger.Enabled(ctx, slog.LevelDebug):
+		sql, rows := fc()
+		l.Logger.DebugContext(ctx, "query", "sql", sql, "rows", rows, "duration_ms", ms(elapsed))
+	}
+}
+
+// ms - Duration in milliseconds with sub-millisecond precision
+func ms(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
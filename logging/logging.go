@@ -0,0 +1,163 @@
+// Package logging builds the structured logger shared by the HTTP layer and
+// GORM. Records logged with a request context carry its request and user IDs.
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+)
+
+// Formats
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+// New - A logger writing records of at least level to w as JSON (or text)
+func New(w io.Writer, level slog.Level, format string) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: level}
+
+	var handler slog.Handler
+	if format == FormatText {
+		handler = slog.NewTextHandler(w, opts)
+	} else {
+		handler = slog.NewJSONHandler(w, opts)
+	}
+	return slog.New(contextHandler{handler})
+}
+
+// ParseLevel - Level from its name: debug, info, warn or error
+func ParseLevel(name string) (slog.Level, error) {
+	var level slog.Level
+	err := level.UnmarshalText([]byte(strings.ToUpper(name)))
+	return level, err
+}
+
+// request - Correlation fields of one request. The user is only known after
+// authentication, so the fields are filled in as the request goes through.
+type request struct {
+	mu             sync.Mutex
+	requestID      string
+	clientIP       string
+	userID         uint
+	impersonatorID uint
+}
+
+type ctxKey struct{}
+
+// WithRequestID - Context carrying the correlation fields of a new request
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, &request{requestID: id})
+}
+
+// RequestID - The request ID carried by ctx, empty when there is none
+func RequestID(ctx context.Context) string {
+	r, ok := ctx.Value(ctxKey{}).(*request)
+	if !ok {
+		return ""
+	}
+	return r.requestID
+}
+
+// SetClientIP - Record the address the request carried by ctx came from
+func SetClientIP(ctx context.Context, ip string) {
+	r, ok := ctx.Value(ctxKey{}).(*request)
+	if !ok {
+		return
+	}
+	r.mu.Lock()
+	r.clientIP = ip
+	r.mu.Unlock()
+}
+
+// ClientIP - The address the request carried by ctx came from, empty when
+// there is none
+func ClientIP(ctx context.Context) string {
+	r, ok := ctx.Value(ctxKey{}).(*request)
+	if !ok {
+		return ""
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.clientIP
+}
+
+// UserID - The authenticated user of the request carried by ctx, zero when
+// there is none
+func UserID(ctx context.Context) uint {
+	r, ok := ctx.Value(ctxKey{}).(*request)
+	if !ok {
+		return 0
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.userID
+}
+
+// SetUserID - Record the authenticated user on the request carried by ctx
+func SetUserID(ctx context.Context, id uint) {
+	r, ok := ctx.Value(ctxKey{}).(*request)
+	if !ok {
+		return
+	}
+	r.mu.Lock()
+	r.userID = id
+	r.mu.Unlock()
+}
+
+// SetImpersonator - Record that the authenticated user is being impersonated
+// by admin id on the request carried by ctx
+func SetImpersonator(ctx context.Context, id uint) {
+	r, ok := ctx.Value(ctxKey{}).(*request)
+	if !ok {
+		return
+	}
+	r.mu.Lock()
+	r.impersonatorID = id
+	r.mu.Unlock()
+}
+
+// Impersonator - The admin impersonating the user of the request carried by
+// ctx, zero when there is none
+func Impersonator(ctx context.Context) uint {
+	r, ok := ctx.Value(ctxKey{}).(*request)
+	if !ok {
+		return 0
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.impersonatorID
+}
+
+// contextHandler - Adds request_id, user_id and impersonator_id from the record's context
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if r, ok := ctx.Value(ctxKey{}).(*request); ok {
+		r.mu.Lock()
+		requestID, userID, impersonatorID := r.requestID, r.userID, r.impersonatorID
+		r.mu.Unlock()
+
+		record.AddAttrs(slog.String("request_id", requestID))
+		if userID != 0 {
+			record.AddAttrs(slog.Uint64("user_id", uint64(userID)))
+		}
+		if impersonatorID != 0 {
+			record.AddAttrs(slog.Uint64("impersonator_id", uint64(impersonatorID)))
+		}
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
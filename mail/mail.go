@@ -0,0 +1,86 @@
+// Package mail sends the few emails the API needs (address verification,
+// security notices). The sender is configured once from main; until then
+// messages are only logged, which is also what development uses.
+package mail
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/smtp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Message - A plain-text email
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Sender - Delivers messages
+type Sender interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+var (
+	mu     sync.RWMutex
+	sender Sender = LogSender{}
+)
+
+// Configure - Set the sender used by Send
+func Configure(s Sender) {
+	mu.Lock()
+	defer mu.Unlock()
+	sender = s
+}
+
+// Send - Deliver msg with the configured sender
+func Send(ctx context.Context, msg Message) error {
+	mu.RLock()
+	s := sender
+	mu.RUnlock()
+	return s.Send(ctx, msg)
+}
+
+// LogSender - Writes messages to the log instead of sending them
+type LogSender struct{}
+
+func (LogSender) Send(ctx context.Context, msg Message) error {
+	slog.InfoContext(ctx, "mail not sent, logging it instead", "to", msg.To, "subject", msg.Subject, "body", msg.Body)
+	return nil
+}
+
+// SMTPSender - Sends through an SMTP relay. STARTTLS is used when the server
+// offers it; credentials are only sent over TLS or to localhost (net/smtp).
+type SMTPSender struct {
+	Addr     string
+	From     string
+	Username string
+	Password string
+}
+
+func (s SMTPSender) Send(ctx context.Context, msg Message) error {
+	var auth smtp.Auth
+	if s.Username != "" {
+		host, _, _ := strings.Cut(s.Addr, ":")
+		auth = smtp.PlainAuth("", s.Username, s.Password, host)
+	}
+
+	header := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\nDate: %s\r\nMIME-Version: 1.0\r\n"+
+		"Content-Type: text/plain; charset=utf-8\r\n\r\n",
+		s.From, msg.To, msg.Subject, time.Now().Format(time.RFC1123Z))
+	body := strings.ReplaceAll(msg.Body, "\n", "\r\n")
+
+	// net/smtp tidak mengenal context; kirim di goroutine dan berhenti menunggu saat ctx selesai
+	done := make(chan error, 1)
+	go func() { done <- smtp.SendMail(s.Addr, auth, s.From, []string{msg.To}, []byte(header+body)) }()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
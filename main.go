@@ -1,41 +1,204 @@
 package main
 
 import (
+	"backend-go/background"
 	"backend-go/config"
+	"backend-go/mail"
+	"backend-go/middleware"
+	"backend-go/migrations"
+	"backend-go/ratelimit"
+	"backend-go/repository"
 	"backend-go/routes"
-	"log"
-	"time"
+	"backend-go/server"
+	"backend-go/services"
+	"backend-go/sso"
+	"backend-go/storage"
+	"backend-go/utils"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
-	"github.com/joho/godotenv"
+	"github.com/redis/go-redis/v9"
 )
 
+// fatal - Log err and exit; used before the server is up
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
+}
+
+// configureRateLimits - Keep buckets in memory or, when instances must share
+// them, in Redis
+func configureRateLimits(cfg config.RateLimitConfig) error {
+	limits, err := cfg.Limits()
+	if err != nil {
+		return err
+	}
+
+	var store ratelimit.Store = ratelimit.NewMemory()
+	if cfg.Backend == config.RateLimitRedis {
+		opts, err := redis.ParseURL(cfg.RedisURL)
+		if err != nil {
+			return err
+		}
+		store = ratelimit.NewRedis(redis.NewClient(opts))
+	}
+
+	// Nama limit di konfigurasi sama dengan nama scope di middleware
+	middleware.ConfigureRateLimits(store, limits)
+	return nil
+}
+
+// configureSSO - Register the OpenID Connect providers; they are contacted on first login
+func configureSSO(cfg config.SSOConfig) {
+	providers := make([]sso.ProviderConfig, 0, len(cfg.Providers))
+	for _, p := range cfg.Providers {
+		role := p.DefaultRole
+		if role == "" {
+			role = "user"
+		}
+		providers = append(providers, sso.ProviderConfig{
+			Name:         p.Name,
+			DisplayName:  p.DisplayName,
+			Issuer:       p.Issuer,
+			ClientID:     p.ClientID,
+			ClientSecret: p.ClientSecret,
+			Scopes:       p.Scopes,
+			DefaultRole:  role,
+			TrustEmail:   p.TrustEmail,
+		})
+	}
+	sso.Configure(sso.Settings{PublicURL: cfg.PublicURL, FrontendURL: cfg.FrontendURL}, providers)
+}
+
+// configureMail - Send through SMTP, or only log messages
+func configureMail(cfg config.MailConfig) {
+	if cfg.Backend == config.MailSMTP {
+		mail.Configure(mail.SMTPSender{Addr: cfg.SMTPAddr, From: cfg.From, Username: cfg.Username, Password: cfg.Password})
+	}
+}
+
 func main() {
-	// Load .env file
-	if err := godotenv.Load(); err != nil {
-		log.Fatal("Error loading .env file")
+	// Load configuration (defaults, config file, .env, environment)
+	cfg, err := config.Load()
+	if err != nil {
+		fatal("failed to load configuration", err)
+	}
+
+	// Structured JSON logs; the standard log package is routed here as well
+	slog.SetDefault(cfg.Logger(os.Stdout))
+
+	utils.ConfigureJWT(cfg.JWT.Secret, cfg.JWT.TTL)
+	utils.ConfigureMediaSigning(cfg.MediaSigningKey(), cfg.Media.URLTTL)
+	storage.Configure(cfg.Storage.PublicDir, cfg.Storage.PrivateDir)
+	middleware.ConfigureTransfers(cfg.Server.TransferTimeout)
+	services.ConfigureMFA(cfg.MFA.Issuer, cfg.MFA.RequiredRoles)
+	configureSSO(cfg.SSO)
+	configureMail(cfg.Mail)
+	services.ConfigureAccounts(services.AccountSettings{
+		DeletionGrace:    cfg.Account.DeletionGrace,
+		EmailTokenTTL:    cfg.Account.EmailTokenTTL,
+		EmailConfirmURL:  cfg.Account.EmailConfirmURL,
+		PasswordResetURL: cfg.Account.PasswordResetURL,
+	})
+	services.ConfigureExports(services.ExportSettings{
+		TTL:       cfg.Account.ExportTTL,
+		PublicURL: cfg.PublicURL(),
+	})
+	services.ConfigureTrash(services.TrashSettings{Retention: cfg.Trash.Retention})
+	middleware.ConfigureTenancy(cfg.Tenant.BaseDomain, cfg.Tenant.DefaultOrganization)
+	if err := configureRateLimits(cfg.RateLimit); err != nil {
+		fatal("failed to set up rate limiting", err)
 	}
 
 	// Connect to the database
-	config.ConnectDB()
+	config.ConnectDB(cfg.Database)
+
+	// `go run . migrate <command>` manages the schema and exits
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := migrations.Run(config.DB, os.Args[2:], os.Stdout); err != nil {
+			fatal("migration failed", err)
+		}
+		return
+	}
+
+	// Refuse to serve against a schema this build was not written for
+	if err := migrations.Check(config.DB); err != nil {
+		fatal("database schema is not up to date", err)
+	}
+
+	// `go run . superadmin <email>` grants the platform role, which through
+	// the API only an existing superadmin can give
+	if len(os.Args) > 1 && os.Args[1] == "superadmin" {
+		if len(os.Args) != 3 {
+			fatal("usage: superadmin <email>", errors.New("missing email"))
+		}
+		user, err := services.NewUserAdminService(repository.NewStore(config.DB)).GrantSuperAdmin(context.Background(), os.Args[2])
+		if err != nil {
+			fatal("granting superadmin failed", err)
+		}
+		fmt.Printf("%s is now a superadmin\n", user.Email)
+		return
+	}
+
+	// Akun yang masa tenggangnya habis dianonimkan di background
+	accounts := services.NewAccountService(repository.NewStore(config.DB))
+	background.Go("account-purge", func(ctx context.Context) { accounts.RunPurge(ctx, cfg.Account.PurgeInterval) })
+	exports := services.NewExportService(repository.NewStore(config.DB))
+	background.Go("export-cleanup", func(ctx context.Context) { exports.RunCleanup(ctx, cfg.Account.PurgeInterval) })
+	// Konten di trash dihapus permanen setelah masa simpannya habis
+	trash := services.NewTrashService(repository.NewStore(config.DB))
+	background.Go("trash-purge", func(ctx context.Context) { trash.RunPurge(ctx, cfg.Trash.PurgeInterval) })
 
 	// Initialize Gin router
-	r := gin.Default()
+	switch cfg.Profile {
+	case config.ProfileProd:
+		gin.SetMode(gin.ReleaseMode)
+	case config.ProfileTest:
+		gin.SetMode(gin.TestMode)
+	}
+	r := gin.New()
+	// Tanpa proxy tepercaya, X-Forwarded-For diabaikan dan ClientIP memakai alamat koneksi
+	if err := r.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		fatal("trusted proxies", err)
+	}
+
+	// Request ID first so every later log line, including CORS rejections, carries it
+	r.Use(middleware.RequestID, middleware.AccessLog, middleware.Metrics, middleware.Recovery)
+	r.Use(middleware.BodyLimit(cfg.Server.MaxBodyBytes))
 
 	// Setup CORS
 	r.Use(cors.New(cors.Config{
-		AllowOrigins:     []string{"http://localhost:5173"},
-		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowOrigins:     cfg.CORS.AllowOrigins,
+		AllowMethods:     []string{"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", "Range", "Tus-Resumable", "Upload-Length", "Upload-Offset", "Upload-Metadata", middleware.RequestIDHeader, middleware.OrganizationHeader},
+		ExposeHeaders:    []string{"Content-Length", "Content-Range", "Accept-Ranges", "Location", "Tus-Resumable", "Upload-Offset", "Upload-Length", middleware.RequestIDHeader},
 		AllowCredentials: true,
-		MaxAge:           12 * time.Hour, // Cache hasil preflight request selama 12 jam
+		MaxAge:           cfg.CORS.MaxAge, // Cache hasil preflight request
 	}))
 
 	// Initialize routes
-    routes.InitRouter(r) 
+    routes.InitRouter(r, config.DB) 
+
+	// Run the server until SIGINT/SIGTERM, then drain requests and background work
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	// Run the server
-	r.Run(":8080")
+	slog.Info("starting", "profile", cfg.Profile)
+	runErr := server.Run(ctx, server.New(cfg.Server, r), cfg.Server)
+
+	// Pool ditutup paling akhir, setelah semua request dan worker selesai
+	if sqlDB, err := config.DB.DB(); err == nil {
+		sqlDB.Close()
+	}
+	if runErr != nil {
+		fatal("unclean shutdown", runErr)
+	}
+	slog.Info("stopped")
 }
@@ -1,27 +1,64 @@
 package middleware
 
 import (
-	"backend-go/config"
+	"backend-go/apikeys"
+	"backend-go/apperr"
+	"backend-go/logging"
 	"backend-go/models"
+	"backend-go/repository"
+	"backend-go/services"
+	"backend-go/tenancy"
 	"backend-go/utils"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
-	"gorm.io/gorm"
 )
 
-func IsLogin(c *gin.Context) {
-    authHeader := c.GetHeader("Authorization")
-    if authHeader == "" {
-        c.JSON(400, gin.H{"error": "Unauthorized"})
-        c.Abort()
-        return
-    }
+// Authorization failures
+var (
+	ErrNotAdmin    = apperr.Forbidden("not_admin", "You are not an admin")
+	ErrInvalidRole = apperr.Forbidden("invalid_role", "Invalid role")
+)
 
-    token := authHeader[len("Bearer "):]
-    userID, role, err := utils.ParseToken(token)
-    if err != nil {
-        c.JSON(400, gin.H{"error": "Unauthorized"})
-        c.Abort()
+// Access - Middleware that needs the database to tell who the caller is and
+// what they may reach
+type Access struct {
+	store         repository.Store
+	access        *services.AccessService
+	organizations *services.OrganizationService
+	exports       *services.ExportService
+}
+
+// NewAccess - Create the Access middleware on top of store
+func NewAccess(store repository.Store) *Access {
+	return &Access{
+		store:         store,
+		access:        services.NewAccessService(store),
+		organizations: services.NewOrganizationService(store),
+		exports:       services.NewExportService(store),
+	}
+}
+
+// mfaSetupKey - Context flag set by AllowMFASetup
+const mfaSetupKey = "mfa_setup"
+
+// impersonatorKey - Admin acting as the user, set for impersonation tokens
+const impersonatorKey = "impersonator_id"
+
+// userOrganizationKey - Organization the authenticated user belongs to, which
+// for superadmins need not be the request's
+const userOrganizationKey = "user_organization_id"
+
+// AllowMFASetup - Let users whose role requires two-factor authentication reach
+// the route before they have set it up. Put it before IsLogin.
+func AllowMFASetup(c *gin.Context) {
+	c.Set(mfaSetupKey, true)
+	c.Next()
+}
+
+func (a *Access) IsLogin(c *gin.Context) {
+    userID, role, ok := a.bearerUser(c)
+    if !ok {
         return
     }
 
@@ -30,56 +67,110 @@ func IsLogin(c *gin.Context) {
     c.Next()
 }
 
+// NoImpersonation - Refuse the route to admins impersonating a user: the
+// user's credentials, keys and data stay theirs. Put it after IsLogin.
+func NoImpersonation(c *gin.Context) {
+	if c.GetUint(impersonatorKey) != 0 {
+		apperr.Abort(c, services.ErrImpersonationForbidden)
+		return
+	}
+	c.Next()
+}
+
 func IsAdmin(c*gin.Context) {
 	role := c.GetString("role")
-	if role != "admin" {
-		c.JSON(400, gin.H{"error": "Unauthorized", "message": "You are not an admin"})
-		c.Abort()
+	if !models.IsAdminRole(role) {
+		apperr.Abort(c, ErrNotAdmin)
 		return
 	}
 	c.Next()
 }
 
-func IsEnrolled(c *gin.Context) {
-    authHeader := c.GetHeader("Authorization")
-    if authHeader == "" {
-        c.JSON(400, gin.H{"error": "Unauthorized"})
-        c.Abort()
+func (a *Access) IsEnrolled(c *gin.Context) {
+    userID, role, ok := a.bearerUser(c)
+    if !ok {
         return
     }
 
-    token := authHeader[len("Bearer "):]
-    userID, role, err := utils.ParseToken(token)
-    if err != nil {
-        c.JSON(400, gin.H{"error": "Unauthorized"})
-        c.Abort()
-        return
-    }
-
-	if role != "admin" && role != "user" {
-		c.JSON(400, gin.H{"error": "Invalid role"})
+	if !models.IsAdminRole(role) && role != models.RoleUser {
+		apperr.Abort(c, ErrInvalidRole)
 		return
-	}	
+	}
 
-    courseID := c.Param("id")
-    if courseID == "" {
-        c.JSON(400, gin.H{"error": "Course ID is required"})
-        c.Abort()
+    courseID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+    if err != nil {
+        apperr.Abort(c, apperr.BadRequest("course_id_required", "Course ID is required"))
         return
     }
 
     // Periksa apakah user terdaftar di kursus ini
-    var enrollment models.Enrollment
-    if err := config.DB.Where("user_id = ? AND course_id = ?", userID, courseID).First(&enrollment).Error; err != nil {
-        if err == gorm.ErrRecordNotFound {
-            c.JSON(400, gin.H{"error": "Unauthorized", "message": "You are not enrolled in this course"})
-        } else {
-            c.JSON(500, gin.H{"error": "Failed to check enrollment", "details": err.Error()})
-        }
-        c.Abort()
+    if err := a.access.CheckEnrolled(c.Request.Context(), userID, uint(courseID)); err != nil {
+        apperr.Abort(c, err)
         return
     }
 
     // Jika user terdaftar, lanjutkan ke handler berikutnya
     c.Next()
 }
+
+// bearerUser - Parse the Bearer token, aborting with Unauthorized when it is missing or invalid
+func (a *Access) bearerUser(c *gin.Context) (uint, string, bool) {
+    authHeader := c.GetHeader("Authorization")
+    if len(authHeader) <= len("Bearer ") {
+        apperr.Abort(c, apperr.ErrUnauthorized)
+        return 0, "", false
+    }
+
+    token := authHeader[len("Bearer "):]
+    if apikeys.Looks(token) {
+        return a.apiKeyUser(c, token)
+    }
+
+    claims, err := utils.ParseAccessToken(token)
+    if err != nil {
+        apperr.Abort(c, apperr.ErrUnauthorized)
+        return 0, "", false
+    }
+    userID := claims.UserID
+
+    // Token ditolak kalau sesi user sudah dicabut (ganti password, hapus akun, suspend)
+    user, err := services.NewAccountService(a.accountStore(c)).CheckSession(userID, claims.IssuedAt)
+    if err != nil {
+        apperr.Abort(c, err)
+        return 0, "", false
+    }
+    // Token organisasi lain tidak berlaku di sini, kecuali milik superadmin
+    if err := services.CheckOrganization(c.Request.Context(), user); err != nil {
+        apperr.Abort(c, err)
+        return 0, "", false
+    }
+    // Role diambil dari database supaya perubahan role oleh admin langsung berlaku
+    role := user.Roles
+
+    if claims.ImpersonatorID != 0 {
+        if err := services.NewUserAdminService(a.accountStore(c)).CheckImpersonator(claims.ImpersonatorID, claims.IssuedAt); err != nil {
+            apperr.Abort(c, err)
+            return 0, "", false
+        }
+        c.Set(impersonatorKey, claims.ImpersonatorID)
+        logging.SetImpersonator(c.Request.Context(), claims.ImpersonatorID)
+    }
+
+    // Role yang wajib 2FA hanya boleh memakai rute /2fa sampai login dengan kode kedua
+    if !claims.MFA && services.MFARequired(role) && !c.GetBool(mfaSetupKey) {
+        apperr.Abort(c, services.ErrMFARequired)
+        return 0, "", false
+    }
+
+    // Semua baris log berikutnya di request ini membawa user_id
+    logging.SetUserID(c.Request.Context(), userID)
+    c.Set(userOrganizationKey, user.OrganizationID)
+    return userID, role, true
+}
+
+// accountStore - Repositories finding the user behind the request in any
+// organization, before services.CheckOrganization decides whether they may
+// use the request's
+func (a *Access) accountStore(c *gin.Context) repository.Store {
+	return a.store.WithContext(tenancy.AllOrganizations(c.Request.Context()))
+}
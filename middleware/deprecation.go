@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"backend-go/apperr"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ErrSunset - The route was deprecated and its sunset date has passed
+var ErrSunset = apperr.New(http.StatusGone, "api_sunset", "This API version has been retired")
+
+// Deprecation - Lifecycle of a deprecated route or route group
+type Deprecation struct {
+	Since  time.Time
+	Sunset time.Time
+	// Successor returns the replacement URL of the request, empty when there is none
+	Successor func(c *gin.Context) string
+}
+
+// MovedTo - Successor that swaps the path prefix from for to, e.g. "/api/v1" to "/api/v2"
+func MovedTo(from, to string) func(c *gin.Context) string {
+	return func(c *gin.Context) string {
+		return to + strings.TrimPrefix(c.Request.URL.Path, from)
+	}
+}
+
+// Deprecated - Announce the deprecation with the Deprecation (RFC 9745), Sunset
+// (RFC 8594) and successor-version Link headers, and answer 410 once the
+// sunset date has passed. When groups are nested the outermost policy wins.
+func Deprecated(d Deprecation) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Writer.Header().Get("Deprecation") != "" {
+			c.Next()
+			return
+		}
+
+		c.Header("Deprecation", fmt.Sprintf("@%d", d.Since.Unix()))
+		c.Header("Sunset", d.Sunset.UTC().Format(http.TimeFormat))
+
+		successor := ""
+		if d.Successor != nil {
+			successor = d.Successor(c)
+		}
+		if successor != "" {
+			c.Header("Link", fmt.Sprintf("<%s>; rel=\"successor-version\"", successor))
+		}
+
+		if !time.Now().Before(d.Sunset) {
+			err := ErrSunset
+			if successor != "" {
+				err = err.WithDetails(gin.H{"successor": successor})
+			}
+			apperr.Abort(c, err)
+			return
+		}
+		c.Next()
+	}
+}
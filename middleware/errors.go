@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"backend-go/apperr"
+	"log/slog"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ErrorHandler - Render the last error recorded with apperr.Abort as the
+// standard error envelope. Register it before every other middleware.
+func ErrorHandler(c *gin.Context) {
+	c.Next()
+
+	if len(c.Errors) == 0 || c.Writer.Written() {
+		return
+	}
+
+	err := apperr.From(c.Errors.Last().Err)
+	if err.Status >= 500 {
+		slog.ErrorContext(c.Request.Context(), "request failed", "code", err.Code, "error", err.Error())
+	}
+
+	// Handler yang gagal di tengah jalan mungkin sudah menyiapkan header file
+	c.Writer.Header().Del("Content-Disposition")
+	c.Writer.Header().Set("Content-Type", "application/json; charset=utf-8")
+	if err.RetryAfter > 0 {
+		c.Header("Retry-After", strconv.Itoa(ceilSeconds(err.RetryAfter)))
+	}
+	c.JSON(err.Status, gin.H{"error": err})
+}
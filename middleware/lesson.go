@@ -0,0 +1,103 @@
+package middleware
+
+import (
+	"backend-go/apperr"
+	"backend-go/models"
+	"backend-go/services"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// pathID - Parse path parameter :id; an ID that cannot exist is reported as notFound
+func pathID(c *gin.Context, notFound error) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		apperr.Abort(c, notFound)
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// loadLesson - Fetch lesson :id or abort with 404/500
+func (a *Access) loadLesson(c *gin.Context) (models.Lesson, bool) {
+	id, ok := pathID(c, services.ErrLessonNotFound)
+	if !ok {
+		return models.Lesson{}, false
+	}
+	lesson, err := a.access.Lesson(c.Request.Context(), id)
+	if err != nil {
+		apperr.Abort(c, err)
+		return models.Lesson{}, false
+	}
+	return *lesson, true
+}
+
+// loadAttachment - Fetch attachment :id with its lesson or abort with 404/500
+func (a *Access) loadAttachment(c *gin.Context) (models.LessonAttachment, bool) {
+	id, ok := pathID(c, services.ErrAttachmentNotFound)
+	if !ok {
+		return models.LessonAttachment{}, false
+	}
+	attachment, err := a.access.Attachment(c.Request.Context(), id)
+	if err != nil {
+		apperr.Abort(c, err)
+		return models.LessonAttachment{}, false
+	}
+	return *attachment, true
+}
+
+// IsEnrolledInLesson - Allow staff or users enrolled in the course owning lesson :id
+func (a *Access) IsEnrolledInLesson(c *gin.Context) {
+	userID, role, ok := a.bearerUser(c)
+	if !ok {
+		return
+	}
+
+	lesson, ok := a.loadLesson(c)
+	if !ok {
+		return
+	}
+
+	if err := a.access.CheckStaffOrEnrolled(c.Request.Context(), userID, role, lesson.CourseID); err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+
+	c.Set("user_id", userID)
+	c.Set("role", role)
+	c.Set("lesson", lesson)
+	c.Next()
+}
+
+// IsLessonStaff - Allow only staff of the course owning lesson :id. Use after IsLogin.
+func (a *Access) IsLessonStaff(c *gin.Context) {
+	lesson, ok := a.loadLesson(c)
+	if !ok {
+		return
+	}
+
+	if err := a.access.CheckStaff(c.Request.Context(), c.GetUint("user_id"), c.GetString("role"), lesson.CourseID); err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+
+	c.Set("lesson", lesson)
+	c.Next()
+}
+
+// IsAttachmentStaff - Allow only staff of the course owning attachment :id. Use after IsLogin.
+func (a *Access) IsAttachmentStaff(c *gin.Context) {
+	attachment, ok := a.loadAttachment(c)
+	if !ok {
+		return
+	}
+
+	if err := a.access.CheckStaff(c.Request.Context(), c.GetUint("user_id"), c.GetString("role"), attachment.Lesson.CourseID); err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+
+	c.Set("attachment", attachment)
+	c.Next()
+}
@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// transferTimeout - Read and write deadline given to LargeTransfer routes
+var transferTimeout = time.Hour
+
+// ConfigureTransfers - Set the deadline of uploads and media downloads
+func ConfigureTransfers(timeout time.Duration) {
+	transferTimeout = timeout
+}
+
+// rawBodyKey - Context key holding the request body before any limit was applied
+const rawBodyKey = "raw_body"
+
+// BodyLimit - Reject request bodies larger than n bytes with 413. A later
+// BodyLimit (e.g. on an upload route) replaces an earlier one instead of
+// stacking on top of it.
+func BodyLimit(n int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		raw, ok := c.Get(rawBodyKey)
+		if !ok {
+			raw = c.Request.Body
+			c.Set(rawBodyKey, raw)
+		}
+		c.Request.Body = http.MaxBytesReader(c.Writer, raw.(io.ReadCloser), n)
+		c.Next()
+	}
+}
+
+// LargeTransfer - For uploads and media streams: allow bodies up to maxBody
+// bytes (0 keeps the current limit) and replace the server's read and write
+// timeouts with the transfer timeout.
+func LargeTransfer(maxBody int64) gin.HandlerFunc {
+	limit := BodyLimit(maxBody)
+	return func(c *gin.Context) {
+		deadline := time.Time{}
+		if transferTimeout > 0 {
+			deadline = time.Now().Add(transferTimeout)
+		}
+		// Recorder di test tidak mendukung deadline, errornya diabaikan
+		rc := http.NewResponseController(c.Writer)
+		rc.SetReadDeadline(deadline)
+		rc.SetWriteDeadline(deadline)
+
+		if maxBody > 0 {
+			limit(c)
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,80 @@
+package middleware
+
+import (
+	"backend-go/apperr"
+	"backend-go/logging"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"runtime/debug"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestIDHeader - Header carrying the request ID in both directions
+const RequestIDHeader = "X-Request-ID"
+
+// validRequestID - IDs from clients and proxies are echoed into headers and
+// logs, so only short, plain tokens are accepted
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// RequestID - Accept the caller's X-Request-ID or generate one, echo it in the
+// response and attach it, with the client's address, to the request context
+// for logging and auditing
+func RequestID(c *gin.Context) {
+	id := c.GetHeader(RequestIDHeader)
+	if !validRequestID.MatchString(id) {
+		id = newRequestID()
+	}
+
+	c.Set("request_id", id)
+	c.Header(RequestIDHeader, id)
+	ctx := logging.WithRequestID(c.Request.Context(), id)
+	logging.SetClientIP(ctx, c.ClientIP())
+	c.Request = c.Request.WithContext(ctx)
+	c.Next()
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// AccessLog - One structured line per request, replacing gin.Logger
+func AccessLog(c *gin.Context) {
+	start := time.Now()
+	c.Next()
+
+	status := c.Writer.Status()
+	level := slog.LevelInfo
+	switch {
+	case status >= 500:
+		level = slog.LevelError
+	case status >= 400:
+		level = slog.LevelWarn
+	}
+
+	slog.Log(c.Request.Context(), level, "request",
+		"method", c.Request.Method,
+		"path", c.Request.URL.Path,
+		"route", c.FullPath(),
+		"status", status,
+		"duration_ms", float64(time.Since(start))/float64(time.Millisecond),
+		"bytes", c.Writer.Size(),
+		"client_ip", c.ClientIP(),
+		"user_agent", c.Request.UserAgent(),
+	)
+}
+
+// Recovery - Log panics with their stack and answer with the standard 500
+// envelope, replacing gin.Recovery
+var Recovery = gin.CustomRecoveryWithWriter(io.Discard, func(c *gin.Context, recovered any) {
+	slog.ErrorContext(c.Request.Context(), "panic", "panic", fmt.Sprint(recovered), "stack", string(debug.Stack()))
+	c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": apperr.Internal(nil)})
+})
@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"backend-go/metrics"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Metrics - Count and time requests by route template. Unmatched paths share
+// one label so scanners cannot blow up the series count.
+func Metrics(c *gin.Context) {
+	start := time.Now()
+	metrics.HTTPInFlight.Inc()
+	defer metrics.HTTPInFlight.Dec()
+
+	c.Next()
+
+	route := c.FullPath()
+	if route == "" {
+		route = "unmatched"
+	}
+	metrics.HTTPRequests.WithLabelValues(c.Request.Method, route, strconv.Itoa(c.Writer.Status())).Inc()
+	metrics.HTTPDuration.WithLabelValues(c.Request.Method, route).Observe(time.Since(start).Seconds())
+}
@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"backend-go/apperr"
+	"backend-go/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// IsEnrolledInQuiz - Allow staff or users enrolled in the course owning quiz :id
+func (a *Access) IsEnrolledInQuiz(c *gin.Context) {
+	userID, role, ok := a.bearerUser(c)
+	if !ok {
+		return
+	}
+
+	id, ok := pathID(c, services.ErrQuizNotFound)
+	if !ok {
+		return
+	}
+	quiz, err := a.access.Quiz(c.Request.Context(), id)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+
+	if err := a.access.CheckStaffOrEnrolled(c.Request.Context(), userID, role, quiz.CourseID); err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+
+	c.Set("user_id", userID)
+	c.Set("role", role)
+	c.Set("quiz", *quiz)
+	c.Next()
+}
+
+// IsEnrolledInAnswer - Allow staff or users enrolled in the course owning answer :id
+func (a *Access) IsEnrolledInAnswer(c *gin.Context) {
+	userID, role, ok := a.bearerUser(c)
+	if !ok {
+		return
+	}
+
+	id, ok := pathID(c, services.ErrAnswerNotFound)
+	if !ok {
+		return
+	}
+	quiz, err := a.access.AnswerQuiz(c.Request.Context(), id)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+
+	if err := a.access.CheckStaffOrEnrolled(c.Request.Context(), userID, role, quiz.CourseID); err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+
+	c.Set("user_id", userID)
+	c.Set("role", role)
+	c.Next()
+}
@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"backend-go/apperr"
+	"backend-go/models"
+	"backend-go/tenancy"
+	"net"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OrganizationHeader - Request header naming the organization by slug. It wins
+// over the subdomain, for clients that call the API on a shared host.
+const OrganizationHeader = "X-Organization"
+
+// ErrNotSuperAdmin - The route is for the platform, not an organization's admins
+var ErrNotSuperAdmin = apperr.Forbidden("not_superadmin", "You are not a superadmin")
+
+var (
+	// tenantBaseDomain - Host whose subdomains name organizations, "" to use
+	// the header only
+	tenantBaseDomain = ""
+	// defaultOrganization - Slug used when the request names no organization
+	defaultOrganization = "default"
+)
+
+// ConfigureTenancy - Set how requests are resolved to an organization
+func ConfigureTenancy(baseDomain, defaultSlug string) {
+	tenantBaseDomain = strings.ToLower(strings.TrimSuffix(baseDomain, "."))
+	defaultOrganization = defaultSlug
+}
+
+// Tenant - Resolve the organization of the request from the X-Organization
+// header, the subdomain of the host or the default, and limit every query
+// made with the request's context to it. Unknown organizations are a 404.
+func (a *Access) Tenant(c *gin.Context) {
+	organization, err := a.organizations.Resolve(c.Request.Context(), organizationSlug(c))
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+
+	c.Set("organization_id", organization.ID)
+	c.Request = c.Request.WithContext(tenancy.WithOrganization(c.Request.Context(), organization.ID))
+	c.Next()
+}
+
+// organizationSlug - The slug the request names, header first
+func organizationSlug(c *gin.Context) string {
+	if slug := strings.TrimSpace(c.GetHeader(OrganizationHeader)); slug != "" {
+		return slug
+	}
+	if tenantBaseDomain != "" {
+		host := c.Request.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		// Hanya satu label di depan base domain, a.b.example.com bukan organisasi
+		sub, ok := strings.CutSuffix(strings.ToLower(host), "."+tenantBaseDomain)
+		if ok && sub != "" && !strings.Contains(sub, ".") {
+			return sub
+		}
+	}
+	return defaultOrganization
+}
+
+// IsSuperAdmin - Allow only platform superadmins. Put it after IsLogin.
+func IsSuperAdmin(c *gin.Context) {
+	if c.GetString("role") != models.RoleSuperAdmin {
+		apperr.Abort(c, ErrNotSuperAdmin)
+		return
+	}
+	c.Next()
+}
@@ -0,0 +1,77 @@
+package migrations
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+
+	"gorm.io/gorm"
+)
+
+// Usage - Help text for the migrate subcommand
+const Usage = `usage: migrate <command>
+
+commands:
+  up           apply all pending migrations
+  down [n]     roll back the last n migrations (default 1)
+  status       list migrations and whether they are applied
+  to <version> migrate up or down to exactly <version> (0 rolls back everything)`
+
+// Run - Execute the migrate subcommand, e.g. `go run . migrate up`
+func Run(db *gorm.DB, args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return fmt.Errorf("missing command\n%s", Usage)
+	}
+
+	switch args[0] {
+	case "up":
+		if err := Up(db); err != nil {
+			return err
+		}
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n < 1 {
+				return fmt.Errorf("invalid step count %q", args[1])
+			}
+			steps = n
+		}
+		if err := Down(db, steps); err != nil {
+			return err
+		}
+	case "to":
+		if len(args) < 2 {
+			return fmt.Errorf("missing version\n%s", Usage)
+		}
+		version, err := strconv.Atoi(args[1])
+		if err != nil {
+			return fmt.Errorf("invalid version %q", args[1])
+		}
+		if err := To(db, version); err != nil {
+			return err
+		}
+	case "status":
+		statuses, err := StatusOf(db)
+		if err != nil {
+			return err
+		}
+		for _, s := range statuses {
+			state := "pending"
+			if s.Applied {
+				state = "applied " + s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(out, "%04d  %-24s %s\n", s.Version, s.Name, state)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown command %q\n%s", args[0], Usage)
+	}
+
+	current, err := Current(db)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "Database at version %d\n", current)
+	return nil
+}
@@ -0,0 +1,173 @@
+package migrations_test
+
+import (
+	"backend-go/migrations"
+	"backend-go/testutil"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// schema - Columns of every table, as far as the migration SQL tells
+type schema map[string]map[string]bool
+
+var (
+	sqlComment  = regexp.MustCompile(`--[^\n]*`)
+	createTable = regexp.MustCompile(`(?is)CREATE TABLE (?:IF NOT EXISTS )?"?(\w+)"?\s*\((.*?)\n\);`)
+	dropTable   = regexp.MustCompile(`(?i)DROP TABLE (?:IF EXISTS )?"?(\w+)"?`)
+	addColumn   = regexp.MustCompile(`(?i)ALTER TABLE (?:IF EXISTS )?"?(\w+)"?\s+ADD COLUMN (?:IF NOT EXISTS )?"?(\w+)"?`)
+	dropColumn  = regexp.MustCompile(`(?i)ALTER TABLE (?:IF EXISTS )?"?(\w+)"?\s+DROP COLUMN (?:IF EXISTS )?"?(\w+)"?`)
+	// Baris di dalam CREATE TABLE yang bukan kolom
+	tableConstraint = regexp.MustCompile(`(?i)^(CONSTRAINT|PRIMARY|UNIQUE|FOREIGN|CHECK)\b`)
+)
+
+// apply - Record the tables and columns sql creates and drops, in statement order
+func (s schema) apply(sql string) {
+	sql = sqlComment.ReplaceAllString(sql, "")
+	type change struct {
+		at    int
+		apply func()
+	}
+	var changes []change
+	for _, m := range createTable.FindAllStringSubmatchIndex(sql, -1) {
+		table, body := sql[m[2]:m[3]], sql[m[4]:m[5]]
+		changes = append(changes, change{m[0], func() {
+			columns := map[string]bool{}
+			for _, line := range strings.Split(body, "\n") {
+				line = strings.TrimSpace(line)
+				if line == "" || tableConstraint.MatchString(line) {
+					continue
+				}
+				columns[strings.Trim(strings.Fields(line)[0], `"`)] = true
+			}
+			s[table] = columns
+		}})
+	}
+	for _, m := range dropTable.FindAllStringSubmatchIndex(sql, -1) {
+		table := sql[m[2]:m[3]]
+		changes = append(changes, change{m[0], func() { delete(s, table) }})
+	}
+	for _, m := range addColumn.FindAllStringSubmatchIndex(sql, -1) {
+		table, column := sql[m[2]:m[3]], sql[m[4]:m[5]]
+		changes = append(changes, change{m[0], func() {
+			if s[table] == nil {
+				s[table] = map[string]bool{}
+			}
+			s[table][column] = true
+		}})
+	}
+	for _, m := range dropColumn.FindAllStringSubmatchIndex(sql, -1) {
+		table, column := sql[m[2]:m[3]], sql[m[4]:m[5]]
+		changes = append(changes, change{m[0], func() { delete(s[table], column) }})
+	}
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].at < changes[j].at })
+	for _, c := range changes {
+		c.apply()
+	}
+}
+
+// missingFrom - "table.column" of s that other lacks, sorted
+func (s schema) missingFrom(other schema) []string {
+	var missing []string
+	for table, columns := range s {
+		if _, ok := other[table]; !ok {
+			missing = append(missing, table)
+			continue
+		}
+		for column := range columns {
+			if !other[table][column] {
+				missing = append(missing, table+"."+column)
+			}
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+// compare - Fail unless a and b have the same tables and columns
+func compare(t *testing.T, step, aName string, a schema, bName string, b schema) {
+	t.Helper()
+	if only := a.missingFrom(b); len(only) > 0 {
+		t.Errorf("%s: only %s has %v", step, aName, only)
+	}
+	if only := b.missingFrom(a); len(only) > 0 {
+		t.Errorf("%s: only %s has %v", step, bName, only)
+	}
+}
+
+func TestDialectsHaveTheSameMigrations(t *testing.T) {
+	postgres, err := migrations.All("postgres")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlite, err := migrations.All("sqlite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(postgres) != len(sqlite) {
+		t.Fatalf("postgres has %d migrations, sqlite %d", len(postgres), len(sqlite))
+	}
+	for i := range postgres {
+		if postgres[i].Version != sqlite[i].Version || postgres[i].Name != sqlite[i].Name {
+			t.Fatalf("migration %d: postgres %04d_%s, sqlite %04d_%s", i,
+				postgres[i].Version, postgres[i].Name, sqlite[i].Version, sqlite[i].Name)
+		}
+	}
+
+	// Setelah tiap versi, naik maupun turun, kedua dialek punya tabel dan kolom yang sama
+	pg, lite := schema{}, schema{}
+	for i := range postgres {
+		pg.apply(postgres[i].Up)
+		lite.apply(sqlite[i].Up)
+		compare(t, "after up "+postgres[i].Name, "postgres", pg, "sqlite", lite)
+		if t.Failed() {
+			return
+		}
+	}
+	for i := len(postgres) - 1; i >= 0; i-- {
+		pg.apply(postgres[i].Down)
+		lite.apply(sqlite[i].Down)
+		compare(t, "after down "+postgres[i].Name, "postgres", pg, "sqlite", lite)
+		if t.Failed() {
+			return
+		}
+	}
+	if len(pg) != 0 || len(lite) != 0 {
+		t.Errorf("tables left after every down: postgres %v, sqlite %v", pg, lite)
+	}
+}
+
+func TestParsedSchemaMatchesDatabase(t *testing.T) {
+	// Perbandingan di atas hanya sebaik pembacaan SQL-nya; cocokkan dengan database sungguhan
+	db := testutil.NewDB(t)
+	all, err := migrations.All(db.Dialector.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed := schema{}
+	for _, m := range all {
+		parsed.apply(m.Up)
+	}
+
+	tables, err := db.Migrator().GetTables()
+	if err != nil {
+		t.Fatal(err)
+	}
+	actual := schema{}
+	for _, table := range tables {
+		if table == "schema_migrations" || strings.HasPrefix(table, "sqlite_") {
+			continue
+		}
+		columns, err := db.Migrator().ColumnTypes(table)
+		if err != nil {
+			t.Fatal(err)
+		}
+		actual[table] = map[string]bool{}
+		for _, column := range columns {
+			actual[table][column.Name()] = true
+		}
+	}
+	compare(t, db.Dialector.Name(), "the migration SQL", parsed, "the database", actual)
+}
@@ -0,0 +1,321 @@
+package migrations
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// files - Migrations per database dialect. sql/postgres is what deployments
+// run; sql/sqlite mirrors it version for version for the test harness, so both
+// directories change together.
+//
+//go:embed sql/postgres/*.sql sql/sqlite/*.sql
+var files embed.FS
+
+// Migration - One versioned schema change with its up and down SQL
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// SchemaMigration - Row in schema_migrations recording an applied version
+type SchemaMigration struct {
+	Version   int    `gorm:"primaryKey;autoIncrement:false"`
+	Name      string `gorm:"not null"`
+	AppliedAt time.Time
+}
+
+// Status - Whether a known migration has been applied
+type Status struct {
+	Version   int
+	Name      string
+	Applied   bool
+	AppliedAt *time.Time
+}
+
+// ErrOutdatedSchema is returned by Check when the database lags behind this build
+var ErrOutdatedSchema = errors.New("database schema is outdated")
+
+// ErrUnknownSchema is returned by Check when the database is ahead of this build
+var ErrUnknownSchema = errors.New("database schema is newer than this build")
+
+// All - Every embedded migration for the dialect (gorm's Dialector.Name()),
+// ordered by version
+func All(dialect string) ([]Migration, error) {
+	dir := path.Join("sql", dialect)
+	entries, err := fs.ReadDir(files, dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("no migrations for database dialect %q", dialect)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, entry := range entries {
+		// Format nama file: 0001_nama.up.sql / 0001_nama.down.sql
+		name := entry.Name()
+		base := strings.TrimSuffix(name, ".sql")
+		direction := path.Ext(base)
+		base = strings.TrimSuffix(base, direction)
+
+		prefix, label, ok := strings.Cut(base, "_")
+		if !ok {
+			return nil, fmt.Errorf("migration %s: missing version prefix", name)
+		}
+		version, err := strconv.Atoi(prefix)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: invalid version: %w", name, err)
+		}
+
+		body, err := files.ReadFile(path.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+
+		m, exists := byVersion[version]
+		if !exists {
+			m = &Migration{Version: version, Name: label}
+			byVersion[version] = m
+		}
+		switch direction {
+		case ".up":
+			m.Up = string(body)
+		case ".down":
+			m.Down = string(body)
+		default:
+			return nil, fmt.Errorf("migration %s: expected .up.sql or .down.sql", name)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %04d_%s: both up and down files are required", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Latest - Highest version known to this build for the dialect
+func Latest(dialect string) (int, error) {
+	migrations, err := All(dialect)
+	if err != nil || len(migrations) == 0 {
+		return 0, err
+	}
+	return migrations[len(migrations)-1].Version, nil
+}
+
+// ensureTable - Create schema_migrations; only commands that change the schema call it
+func ensureTable(db *gorm.DB) error {
+	return db.AutoMigrate(&SchemaMigration{})
+}
+
+// applied - Recorded migrations by version, none while schema_migrations does not exist
+func applied(db *gorm.DB) (map[int]SchemaMigration, error) {
+	if !db.Migrator().HasTable(&SchemaMigration{}) {
+		return map[int]SchemaMigration{}, nil
+	}
+	var rows []SchemaMigration
+	if err := db.Order("version").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	result := make(map[int]SchemaMigration, len(rows))
+	for _, row := range rows {
+		result[row.Version] = row
+	}
+	return result, nil
+}
+
+// Current - Highest applied version, 0 for an empty database
+func Current(db *gorm.DB) (int, error) {
+	if !db.Migrator().HasTable(&SchemaMigration{}) {
+		return 0, nil
+	}
+	var version int
+	err := db.Model(&SchemaMigration{}).Select("COALESCE(MAX(version), 0)").Scan(&version).Error
+	return version, err
+}
+
+// StatusOf - Every known migration with its applied state
+func StatusOf(db *gorm.DB) ([]Status, error) {
+	migrations, err := All(db.Dialector.Name())
+	if err != nil {
+		return nil, err
+	}
+	done, err := applied(db)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(migrations))
+	for _, m := range migrations {
+		s := Status{Version: m.Version, Name: m.Name}
+		if row, ok := done[m.Version]; ok {
+			s.Applied = true
+			appliedAt := row.AppliedAt
+			s.AppliedAt = &appliedAt
+		}
+		statuses = append(statuses, s)
+	}
+	return statuses, nil
+}
+
+// Up - Apply every pending migration
+func Up(db *gorm.DB) error {
+	latest, err := Latest(db.Dialector.Name())
+	if err != nil {
+		return err
+	}
+	return To(db, latest)
+}
+
+// Down - Roll back the last steps applied migrations
+func Down(db *gorm.DB, steps int) error {
+	done, err := applied(db)
+	if err != nil {
+		return err
+	}
+
+	versions := make([]int, 0, len(done))
+	for v := range done {
+		versions = append(versions, v)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(versions)))
+
+	if steps > len(versions) {
+		steps = len(versions)
+	}
+	target := 0
+	if steps < len(versions) {
+		target = versions[steps]
+	}
+	return To(db, target)
+}
+
+// To - Migrate up or down until exactly the migrations <= version are applied
+func To(db *gorm.DB, version int) error {
+	migrations, err := All(db.Dialector.Name())
+	if err != nil {
+		return err
+	}
+	if err := ensureTable(db); err != nil {
+		return err
+	}
+	done, err := applied(db)
+	if err != nil {
+		return err
+	}
+
+	known := map[int]bool{0: true}
+	for _, m := range migrations {
+		known[m.Version] = true
+	}
+	if !known[version] {
+		return fmt.Errorf("unknown migration version %d", version)
+	}
+
+	// Naik: urut dari versi terkecil
+	for _, m := range migrations {
+		if m.Version > version {
+			break
+		}
+		if _, ok := done[m.Version]; ok {
+			continue
+		}
+		if err := apply(db, m); err != nil {
+			return err
+		}
+	}
+
+	// Turun: urut dari versi terbesar
+	for i := len(migrations) - 1; i >= 0; i-- {
+		m := migrations[i]
+		if m.Version <= version {
+			break
+		}
+		if _, ok := done[m.Version]; !ok {
+			continue
+		}
+		if err := revert(db, m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func apply(db *gorm.DB, m Migration) error {
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(m.Up).Error; err != nil {
+			return err
+		}
+		return tx.Create(&SchemaMigration{Version: m.Version, Name: m.Name, AppliedAt: time.Now()}).Error
+	})
+	if err != nil {
+		return fmt.Errorf("migration %04d_%s up: %w", m.Version, m.Name, err)
+	}
+	return nil
+}
+
+func revert(db *gorm.DB, m Migration) error {
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(m.Down).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&SchemaMigration{}, m.Version).Error
+	})
+	if err != nil {
+		return fmt.Errorf("migration %04d_%s down: %w", m.Version, m.Name, err)
+	}
+	return nil
+}
+
+// Check - Refuse to serve unless exactly the migrations of this build are
+// applied. It only reads, so a database nobody migrated yet stays untouched.
+func Check(db *gorm.DB) error {
+	migrations, err := All(db.Dialector.Name())
+	if err != nil {
+		return err
+	}
+	done, err := applied(db)
+	if err != nil {
+		return err
+	}
+
+	// Dibandingkan per versi: versi yang terlewat tidak terlihat dari MAX(version)
+	var missing, unknown []int
+	known := make(map[int]bool, len(migrations))
+	for _, m := range migrations {
+		known[m.Version] = true
+		if _, ok := done[m.Version]; !ok {
+			missing = append(missing, m.Version)
+		}
+	}
+	for v := range done {
+		if !known[v] {
+			unknown = append(unknown, v)
+		}
+	}
+	sort.Ints(unknown)
+
+	if len(unknown) > 0 {
+		return fmt.Errorf("%w: applied versions %v are unknown to this build", ErrUnknownSchema, unknown)
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: versions %v are not applied (run `migrate up`)", ErrOutdatedSchema, missing)
+	}
+	return nil
+}
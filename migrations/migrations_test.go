@@ -0,0 +1,118 @@
+package migrations_test
+
+import (
+	"backend-go/migrations"
+	"backend-go/testutil"
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// run - Execute the migrate subcommand and return what it printed
+func run(t *testing.T, db *gorm.DB, args ...string) string {
+	t.Helper()
+	var out bytes.Buffer
+	if err := migrations.Run(db, args, &out); err != nil {
+		t.Fatalf("migrate %s: %v", strings.Join(args, " "), err)
+	}
+	return out.String()
+}
+
+func TestMigrateCommands(t *testing.T) {
+	db := testutil.NewDB(t)
+	latest, err := migrations.Latest(db.Dialector.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := migrations.Check(db); err != nil {
+		t.Fatalf("check after up: %v", err)
+	}
+
+	status := run(t, db, "status")
+	if !strings.Contains(status, "0013  organizations") || strings.Contains(status, "pending") {
+		t.Fatalf("status:\n%s", status)
+	}
+
+	if out := run(t, db, "down", "2"); out != "Database at version 11\n" {
+		t.Fatalf("down 2: %q", out)
+	}
+	if err := migrations.Check(db); !errors.Is(err, migrations.ErrOutdatedSchema) {
+		t.Fatalf("check after down = %v", err)
+	}
+	status = run(t, db, "status")
+	if !strings.Contains(status, "0011  audit_log                applied") || !strings.Contains(status, "0012  trash                    pending") {
+		t.Fatalf("status after down:\n%s", status)
+	}
+
+	if out := run(t, db, "up"); out != "Database at version 13\n" {
+		t.Fatalf("up: %q", out)
+	}
+	if out := run(t, db, "to", "0"); out != "Database at version 0\n" {
+		t.Fatalf("to 0: %q", out)
+	}
+	if db.Migrator().HasTable("users") {
+		t.Error("users survived migrate to 0")
+	}
+	run(t, db, "to", "3")
+	if !db.Migrator().HasTable("revisions") || db.Migrator().HasTable("identities") {
+		t.Error("to 3 applied the wrong migrations")
+	}
+	run(t, db, "up")
+
+	for _, args := range [][]string{{}, {"down", "x"}, {"to", "42"}, {"sideways"}} {
+		if err := migrations.Run(db, args, &bytes.Buffer{}); err == nil {
+			t.Errorf("migrate %v succeeded", args)
+		}
+	}
+	if current, _ := migrations.Current(db); current != latest {
+		t.Errorf("current = %d, want %d", current, latest)
+	}
+}
+
+func TestCheckComparesEveryVersion(t *testing.T) {
+	db := testutil.NewDB(t)
+
+	// Versi tertinggi ada, tapi satu versi di tengah belum pernah dijalankan
+	if err := db.Delete(&migrations.SchemaMigration{}, 4).Error; err != nil {
+		t.Fatal(err)
+	}
+	err := migrations.Check(db)
+	if !errors.Is(err, migrations.ErrOutdatedSchema) || !strings.Contains(err.Error(), "[4]") {
+		t.Fatalf("check with a gap = %v", err)
+	}
+	db.Create(&migrations.SchemaMigration{Version: 4, Name: "login_lockout", AppliedAt: time.Now()})
+
+	db.Create(&migrations.SchemaMigration{Version: 99, Name: "from_the_future", AppliedAt: time.Now()})
+	if err := migrations.Check(db); !errors.Is(err, migrations.ErrUnknownSchema) {
+		t.Fatalf("check with an unknown version = %v", err)
+	}
+}
+
+func TestCheckIsReadOnly(t *testing.T) {
+	db := testutil.NewDB(t)
+	run(t, db, "to", "0")
+	if err := db.Migrator().DropTable(&migrations.SchemaMigration{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := migrations.Check(db); !errors.Is(err, migrations.ErrOutdatedSchema) {
+		t.Fatalf("check on an empty database = %v", err)
+	}
+	if out := run(t, db, "status"); !strings.Contains(out, "0001  baseline                 pending") {
+		t.Fatalf("status on an empty database:\n%s", out)
+	}
+	if db.Migrator().HasTable(&migrations.SchemaMigration{}) {
+		t.Fatal("check or status created schema_migrations")
+	}
+
+	if out := run(t, db, "up"); out != "Database at version 13\n" {
+		t.Fatalf("up on an empty database: %q", out)
+	}
+	if err := migrations.Check(db); err != nil {
+		t.Fatalf("check after up: %v", err)
+	}
+}
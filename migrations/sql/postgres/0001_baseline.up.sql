@@ -0,0 +1,118 @@
+-- Baseline schema, equivalent to what AutoMigrate produced before versioned
+-- migrations. IF NOT EXISTS lets existing deployments adopt it in place.
+
+CREATE TABLE IF NOT EXISTS users (
+    id         bigserial PRIMARY KEY,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    email      text NOT NULL,
+    username   text NOT NULL,
+    password   text NOT NULL,
+    roles      text NOT NULL,
+    CONSTRAINT uni_users_email UNIQUE (email)
+);
+CREATE INDEX IF NOT EXISTS idx_users_deleted_at ON users (deleted_at);
+
+CREATE TABLE IF NOT EXISTS profiles (
+    id         bigserial PRIMARY KEY,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    user_id    bigint NOT NULL,
+    first_name text NOT NULL,
+    last_name  text NOT NULL,
+    phone      text NOT NULL,
+    image      text,
+    CONSTRAINT uni_profiles_user_id UNIQUE (user_id),
+    CONSTRAINT fk_users_profile FOREIGN KEY (user_id) REFERENCES users (id) ON UPDATE CASCADE ON DELETE SET NULL
+);
+CREATE INDEX IF NOT EXISTS idx_profiles_deleted_at ON profiles (deleted_at);
+
+CREATE TABLE IF NOT EXISTS courses (
+    id          bigserial PRIMARY KEY,
+    created_at  timestamptz,
+    updated_at  timestamptz,
+    deleted_at  timestamptz,
+    name        text NOT NULL,
+    description text NOT NULL,
+    price       decimal DEFAULT 0,
+    image       text,
+    user_id     bigint,
+    CONSTRAINT fk_courses_user FOREIGN KEY (user_id) REFERENCES users (id) ON UPDATE CASCADE ON DELETE SET NULL
+);
+CREATE INDEX IF NOT EXISTS idx_courses_deleted_at ON courses (deleted_at);
+
+CREATE TABLE IF NOT EXISTS enrollments (
+    id         bigserial PRIMARY KEY,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    user_id    bigint,
+    course_id  bigint,
+    CONSTRAINT fk_enrollments_user FOREIGN KEY (user_id) REFERENCES users (id) ON UPDATE CASCADE ON DELETE SET NULL,
+    CONSTRAINT fk_enrollments_course FOREIGN KEY (course_id) REFERENCES courses (id) ON UPDATE CASCADE ON DELETE SET NULL
+);
+CREATE INDEX IF NOT EXISTS idx_enrollments_deleted_at ON enrollments (deleted_at);
+
+CREATE TABLE IF NOT EXISTS lessons (
+    id          bigserial PRIMARY KEY,
+    created_at  timestamptz,
+    updated_at  timestamptz,
+    deleted_at  timestamptz,
+    name        text NOT NULL,
+    description text NOT NULL,
+    content     text NOT NULL,
+    image       text,
+    course_id   bigint,
+    CONSTRAINT fk_lessons_course FOREIGN KEY (course_id) REFERENCES courses (id) ON UPDATE CASCADE ON DELETE SET NULL
+);
+CREATE INDEX IF NOT EXISTS idx_lessons_deleted_at ON lessons (deleted_at);
+
+CREATE TABLE IF NOT EXISTS quizzes (
+    id          bigserial PRIMARY KEY,
+    created_at  timestamptz,
+    updated_at  timestamptz,
+    deleted_at  timestamptz,
+    name        text NOT NULL,
+    description text NOT NULL,
+    content     text NOT NULL,
+    course_id   bigint,
+    CONSTRAINT fk_quizzes_course FOREIGN KEY (course_id) REFERENCES courses (id) ON UPDATE CASCADE ON DELETE SET NULL
+);
+CREATE INDEX IF NOT EXISTS idx_quizzes_deleted_at ON quizzes (deleted_at);
+
+CREATE TABLE IF NOT EXISTS answers (
+    id         bigserial PRIMARY KEY,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    content    text NOT NULL,
+    quiz_id    bigint,
+    CONSTRAINT fk_answers_quiz FOREIGN KEY (quiz_id) REFERENCES quizzes (id) ON UPDATE CASCADE ON DELETE SET NULL
+);
+CREATE INDEX IF NOT EXISTS idx_answers_deleted_at ON answers (deleted_at);
+
+CREATE TABLE IF NOT EXISTS user_quizzes (
+    id         bigserial PRIMARY KEY,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    user_id    bigint,
+    quiz_id    bigint,
+    CONSTRAINT fk_user_quizzes_user FOREIGN KEY (user_id) REFERENCES users (id) ON UPDATE CASCADE ON DELETE SET NULL,
+    CONSTRAINT fk_user_quizzes_quiz FOREIGN KEY (quiz_id) REFERENCES quizzes (id) ON UPDATE CASCADE ON DELETE SET NULL
+);
+CREATE INDEX IF NOT EXISTS idx_user_quizzes_deleted_at ON user_quizzes (deleted_at);
+
+CREATE TABLE IF NOT EXISTS user_answers (
+    id         bigserial PRIMARY KEY,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    user_id    bigint,
+    answer_id  bigint,
+    CONSTRAINT fk_user_answers_user FOREIGN KEY (user_id) REFERENCES users (id) ON UPDATE CASCADE ON DELETE SET NULL,
+    CONSTRAINT fk_user_answers_answer FOREIGN KEY (answer_id) REFERENCES answers (id) ON UPDATE CASCADE ON DELETE SET NULL
+);
+CREATE INDEX IF NOT EXISTS idx_user_answers_deleted_at ON user_answers (deleted_at);
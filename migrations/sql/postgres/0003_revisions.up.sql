@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS revisions (
+    id          bigserial PRIMARY KEY,
+    created_at  timestamptz,
+    updated_at  timestamptz,
+    deleted_at  timestamptz,
+    entity_type text NOT NULL,
+    entity_id   bigint NOT NULL,
+    version     bigint NOT NULL,
+    author_id   bigint,
+    note        text,
+    snapshot    text NOT NULL,
+    CONSTRAINT fk_revisions_author FOREIGN KEY (author_id) REFERENCES users (id) ON UPDATE CASCADE ON DELETE SET NULL
+);
+CREATE INDEX IF NOT EXISTS idx_revisions_deleted_at ON revisions (deleted_at);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_revision_entity_version ON revisions (entity_type, entity_id, version);
@@ -0,0 +1,4 @@
+-- Progressive lockout after repeated failed logins
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS failed_logins bigint NOT NULL DEFAULT 0;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS locked_until timestamptz;
@@ -0,0 +1,17 @@
+-- Accounts at OpenID Connect providers linked to users
+
+CREATE TABLE IF NOT EXISTS identities (
+    id         bigserial PRIMARY KEY,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    user_id    bigint NOT NULL,
+    provider   text NOT NULL,
+    subject    text NOT NULL,
+    email      text,
+    CONSTRAINT fk_identities_user FOREIGN KEY (user_id) REFERENCES users (id) ON UPDATE CASCADE ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_identities_deleted_at ON identities (deleted_at);
+CREATE INDEX IF NOT EXISTS idx_identities_user_id ON identities (user_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_identity_provider_subject ON identities (provider, subject);
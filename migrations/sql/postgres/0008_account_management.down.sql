@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS idx_users_deletion_scheduled_at;
+DROP INDEX IF EXISTS idx_users_email_change_hash;
+
+ALTER TABLE users DROP COLUMN IF EXISTS deletion_scheduled_at;
+ALTER TABLE users DROP COLUMN IF EXISTS email_change_expires_at;
+ALTER TABLE users DROP COLUMN IF EXISTS email_change_hash;
+ALTER TABLE users DROP COLUMN IF EXISTS pending_email;
+ALTER TABLE users DROP COLUMN IF EXISTS sessions_valid_after;
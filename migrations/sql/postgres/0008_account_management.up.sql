@@ -0,0 +1,10 @@
+-- Session revocation, email change verification and scheduled account deletion
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS sessions_valid_after timestamptz;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS pending_email text;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email_change_hash text;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email_change_expires_at timestamptz;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS deletion_scheduled_at timestamptz;
+
+CREATE INDEX IF NOT EXISTS idx_users_email_change_hash ON users (email_change_hash);
+CREATE INDEX IF NOT EXISTS idx_users_deletion_scheduled_at ON users (deletion_scheduled_at);
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_users_password_reset_hash;
+DROP INDEX IF EXISTS idx_users_suspended_at;
+
+ALTER TABLE users DROP COLUMN IF EXISTS password_reset_expires_at;
+ALTER TABLE users DROP COLUMN IF EXISTS password_reset_hash;
+ALTER TABLE users DROP COLUMN IF EXISTS suspension_reason;
+ALTER TABLE users DROP COLUMN IF EXISTS suspended_at;
@@ -0,0 +1,31 @@
+-- Append-only audit log of changes made through the API
+
+CREATE TABLE IF NOT EXISTS audit_entries (
+    id              bigserial PRIMARY KEY,
+    created_at      timestamptz,
+    actor_id        bigint,
+    impersonator_id bigint,
+    action          text NOT NULL,
+    entity_type     text NOT NULL,
+    entity_id       bigint NOT NULL,
+    changes         text,
+    ip              text,
+    request_id      text
+);
+CREATE INDEX IF NOT EXISTS idx_audit_entries_created_at ON audit_entries (created_at);
+CREATE INDEX IF NOT EXISTS idx_audit_entries_actor_id ON audit_entries (actor_id);
+CREATE INDEX IF NOT EXISTS idx_audit_entries_action ON audit_entries (action);
+CREATE INDEX IF NOT EXISTS idx_audit_entity ON audit_entries (entity_type, entity_id);
+CREATE INDEX IF NOT EXISTS idx_audit_entries_request_id ON audit_entries (request_id);
+
+-- Entries outlive the users they name, so there are no foreign keys, and the
+-- database refuses to change them even outside the application
+CREATE OR REPLACE FUNCTION audit_entries_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit entries are append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS audit_entries_append_only ON audit_entries;
+CREATE TRIGGER audit_entries_append_only BEFORE UPDATE OR DELETE ON audit_entries
+    FOR EACH ROW EXECUTE FUNCTION audit_entries_append_only();
@@ -0,0 +1,11 @@
+DROP INDEX IF EXISTS idx_answers_deletion_id;
+DROP INDEX IF EXISTS idx_quizzes_deletion_id;
+DROP INDEX IF EXISTS idx_lesson_attachments_deletion_id;
+DROP INDEX IF EXISTS idx_lessons_deletion_id;
+DROP INDEX IF EXISTS idx_courses_deletion_id;
+
+ALTER TABLE answers DROP COLUMN IF EXISTS deletion_id;
+ALTER TABLE quizzes DROP COLUMN IF EXISTS deletion_id;
+ALTER TABLE lesson_attachments DROP COLUMN IF EXISTS deletion_id;
+ALTER TABLE lessons DROP COLUMN IF EXISTS deletion_id;
+ALTER TABLE courses DROP COLUMN IF EXISTS deletion_id;
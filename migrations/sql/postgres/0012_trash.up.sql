@@ -0,0 +1,13 @@
+-- Rows soft-deleted in one operation share a deletion_id so they can be restored together
+
+ALTER TABLE courses ADD COLUMN IF NOT EXISTS deletion_id text NOT NULL DEFAULT '';
+ALTER TABLE lessons ADD COLUMN IF NOT EXISTS deletion_id text NOT NULL DEFAULT '';
+ALTER TABLE lesson_attachments ADD COLUMN IF NOT EXISTS deletion_id text NOT NULL DEFAULT '';
+ALTER TABLE quizzes ADD COLUMN IF NOT EXISTS deletion_id text NOT NULL DEFAULT '';
+ALTER TABLE answers ADD COLUMN IF NOT EXISTS deletion_id text NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_courses_deletion_id ON courses (deletion_id);
+CREATE INDEX IF NOT EXISTS idx_lessons_deletion_id ON lessons (deletion_id);
+CREATE INDEX IF NOT EXISTS idx_lesson_attachments_deletion_id ON lesson_attachments (deletion_id);
+CREATE INDEX IF NOT EXISTS idx_quizzes_deletion_id ON quizzes (deletion_id);
+CREATE INDEX IF NOT EXISTS idx_answers_deletion_id ON answers (deletion_id);
@@ -0,0 +1,16 @@
+-- Before organizations there was no superadmin role
+UPDATE users SET roles = 'admin' WHERE roles = 'superadmin';
+
+DROP INDEX IF EXISTS idx_audit_entries_organization_id;
+ALTER TABLE audit_entries DROP COLUMN IF EXISTS organization_id;
+ALTER TABLE revisions DROP COLUMN IF EXISTS organization_id;
+ALTER TABLE video_uploads DROP COLUMN IF EXISTS organization_id;
+ALTER TABLE answers DROP COLUMN IF EXISTS organization_id;
+ALTER TABLE quizzes DROP COLUMN IF EXISTS organization_id;
+ALTER TABLE lesson_attachments DROP COLUMN IF EXISTS organization_id;
+ALTER TABLE lessons DROP COLUMN IF EXISTS organization_id;
+ALTER TABLE enrollments DROP COLUMN IF EXISTS organization_id;
+ALTER TABLE courses DROP COLUMN IF EXISTS organization_id;
+ALTER TABLE users DROP COLUMN IF EXISTS organization_id;
+
+DROP TABLE IF EXISTS organizations;
@@ -0,0 +1,63 @@
+-- Organizations (schools) sharing the platform. Existing data moves to the
+-- "default" organization, whose admins existing admins become. Superadmins
+-- are granted explicitly with the `superadmin <email>` command.
+
+CREATE TABLE IF NOT EXISTS organizations (
+    id         bigserial PRIMARY KEY,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    name       text NOT NULL,
+    slug       text NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_organizations_deleted_at ON organizations (deleted_at);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_organizations_slug ON organizations (slug);
+
+INSERT INTO organizations (id, created_at, updated_at, name, slug)
+VALUES (1, now(), now(), 'Default', 'default')
+ON CONFLICT DO NOTHING;
+SELECT setval(pg_get_serial_sequence('organizations', 'id'), (SELECT MAX(id) FROM organizations));
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS organization_id bigint NOT NULL DEFAULT 1;
+ALTER TABLE courses ADD COLUMN IF NOT EXISTS organization_id bigint NOT NULL DEFAULT 1;
+ALTER TABLE enrollments ADD COLUMN IF NOT EXISTS organization_id bigint NOT NULL DEFAULT 1;
+ALTER TABLE lessons ADD COLUMN IF NOT EXISTS organization_id bigint NOT NULL DEFAULT 1;
+ALTER TABLE lesson_attachments ADD COLUMN IF NOT EXISTS organization_id bigint NOT NULL DEFAULT 1;
+ALTER TABLE quizzes ADD COLUMN IF NOT EXISTS organization_id bigint NOT NULL DEFAULT 1;
+ALTER TABLE answers ADD COLUMN IF NOT EXISTS organization_id bigint NOT NULL DEFAULT 1;
+ALTER TABLE video_uploads ADD COLUMN IF NOT EXISTS organization_id bigint NOT NULL DEFAULT 1;
+ALTER TABLE revisions ADD COLUMN IF NOT EXISTS organization_id bigint NOT NULL DEFAULT 1;
+ALTER TABLE audit_entries ADD COLUMN IF NOT EXISTS organization_id bigint NOT NULL DEFAULT 0;
+
+-- Baris baru selalu menyebut organisasinya sendiri
+ALTER TABLE users ALTER COLUMN organization_id DROP DEFAULT;
+ALTER TABLE courses ALTER COLUMN organization_id DROP DEFAULT;
+ALTER TABLE enrollments ALTER COLUMN organization_id DROP DEFAULT;
+ALTER TABLE lessons ALTER COLUMN organization_id DROP DEFAULT;
+ALTER TABLE lesson_attachments ALTER COLUMN organization_id DROP DEFAULT;
+ALTER TABLE quizzes ALTER COLUMN organization_id DROP DEFAULT;
+ALTER TABLE answers ALTER COLUMN organization_id DROP DEFAULT;
+ALTER TABLE video_uploads ALTER COLUMN organization_id DROP DEFAULT;
+ALTER TABLE revisions ALTER COLUMN organization_id DROP DEFAULT;
+
+ALTER TABLE users ADD CONSTRAINT fk_users_organization FOREIGN KEY (organization_id) REFERENCES organizations (id) ON UPDATE CASCADE;
+ALTER TABLE courses ADD CONSTRAINT fk_courses_organization FOREIGN KEY (organization_id) REFERENCES organizations (id) ON UPDATE CASCADE;
+ALTER TABLE enrollments ADD CONSTRAINT fk_enrollments_organization FOREIGN KEY (organization_id) REFERENCES organizations (id) ON UPDATE CASCADE;
+ALTER TABLE lessons ADD CONSTRAINT fk_lessons_organization FOREIGN KEY (organization_id) REFERENCES organizations (id) ON UPDATE CASCADE;
+ALTER TABLE lesson_attachments ADD CONSTRAINT fk_lesson_attachments_organization FOREIGN KEY (organization_id) REFERENCES organizations (id) ON UPDATE CASCADE;
+ALTER TABLE quizzes ADD CONSTRAINT fk_quizzes_organization FOREIGN KEY (organization_id) REFERENCES organizations (id) ON UPDATE CASCADE;
+ALTER TABLE answers ADD CONSTRAINT fk_answers_organization FOREIGN KEY (organization_id) REFERENCES organizations (id) ON UPDATE CASCADE;
+ALTER TABLE video_uploads ADD CONSTRAINT fk_video_uploads_organization FOREIGN KEY (organization_id) REFERENCES organizations (id) ON UPDATE CASCADE;
+ALTER TABLE revisions ADD CONSTRAINT fk_revisions_organization FOREIGN KEY (organization_id) REFERENCES organizations (id) ON UPDATE CASCADE;
+
+CREATE INDEX IF NOT EXISTS idx_users_organization_id ON users (organization_id);
+CREATE INDEX IF NOT EXISTS idx_courses_organization_id ON courses (organization_id);
+CREATE INDEX IF NOT EXISTS idx_enrollments_organization_id ON enrollments (organization_id);
+CREATE INDEX IF NOT EXISTS idx_lessons_organization_id ON lessons (organization_id);
+CREATE INDEX IF NOT EXISTS idx_lesson_attachments_organization_id ON lesson_attachments (organization_id);
+CREATE INDEX IF NOT EXISTS idx_quizzes_organization_id ON quizzes (organization_id);
+CREATE INDEX IF NOT EXISTS idx_answers_organization_id ON answers (organization_id);
+CREATE INDEX IF NOT EXISTS idx_video_uploads_organization_id ON video_uploads (organization_id);
+CREATE INDEX IF NOT EXISTS idx_revisions_organization_id ON revisions (organization_id);
+CREATE INDEX IF NOT EXISTS idx_audit_entries_organization_id ON audit_entries (organization_id);
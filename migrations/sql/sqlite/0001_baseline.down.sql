@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS user_answers;
+DROP TABLE IF EXISTS user_quizzes;
+DROP TABLE IF EXISTS answers;
+DROP TABLE IF EXISTS quizzes;
+DROP TABLE IF EXISTS lessons;
+DROP TABLE IF EXISTS enrollments;
+DROP TABLE IF EXISTS courses;
+DROP TABLE IF EXISTS profiles;
+DROP TABLE IF EXISTS users;
@@ -0,0 +1,10 @@
+DROP TABLE IF EXISTS playback_positions;
+DROP TABLE IF EXISTS video_uploads;
+DROP TABLE IF EXISTS lesson_attachments;
+
+ALTER TABLE lessons DROP COLUMN video_size;
+ALTER TABLE lessons DROP COLUMN video_mime_type;
+ALTER TABLE lessons DROP COLUMN video_key;
+ALTER TABLE lessons DROP COLUMN type;
+ALTER TABLE lessons DROP COLUMN content_html;
+ALTER TABLE lessons DROP COLUMN content_format;
@@ -0,0 +1,58 @@
+-- Markdown content, video lessons, attachments and playback positions
+
+ALTER TABLE lessons ADD COLUMN content_format text NOT NULL DEFAULT 'markdown';
+ALTER TABLE lessons ADD COLUMN content_html text;
+ALTER TABLE lessons ADD COLUMN type text NOT NULL DEFAULT 'text';
+ALTER TABLE lessons ADD COLUMN video_key text;
+ALTER TABLE lessons ADD COLUMN video_mime_type text;
+ALTER TABLE lessons ADD COLUMN video_size bigint;
+
+CREATE TABLE IF NOT EXISTS lesson_attachments (
+    id           integer PRIMARY KEY AUTOINCREMENT,
+    created_at   datetime,
+    updated_at   datetime,
+    deleted_at   datetime,
+    lesson_id    bigint NOT NULL,
+    display_name text NOT NULL,
+    file_name    text NOT NULL,
+    storage_key  text NOT NULL,
+    mime_type    text NOT NULL,
+    size         bigint NOT NULL,
+    checksum     text NOT NULL,
+    uploaded_by  bigint,
+    CONSTRAINT fk_lesson_attachments_lesson FOREIGN KEY (lesson_id) REFERENCES lessons (id) ON UPDATE CASCADE ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_lesson_attachments_deleted_at ON lesson_attachments (deleted_at);
+CREATE INDEX IF NOT EXISTS idx_lesson_attachments_lesson_id ON lesson_attachments (lesson_id);
+
+CREATE TABLE IF NOT EXISTS video_uploads (
+    id          integer PRIMARY KEY AUTOINCREMENT,
+    created_at  datetime,
+    updated_at  datetime,
+    deleted_at  datetime,
+    lesson_id   bigint NOT NULL,
+    uploaded_by bigint NOT NULL,
+    file_name   text,
+    length      bigint NOT NULL,
+    "offset"    bigint NOT NULL DEFAULT 0,
+    storage_key text NOT NULL,
+    completed   boolean NOT NULL DEFAULT false,
+    CONSTRAINT fk_video_uploads_lesson FOREIGN KEY (lesson_id) REFERENCES lessons (id) ON UPDATE CASCADE ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_video_uploads_deleted_at ON video_uploads (deleted_at);
+CREATE INDEX IF NOT EXISTS idx_video_uploads_lesson_id ON video_uploads (lesson_id);
+
+CREATE TABLE IF NOT EXISTS playback_positions (
+    id         integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    updated_at datetime,
+    deleted_at datetime,
+    user_id    bigint NOT NULL,
+    lesson_id  bigint NOT NULL,
+    position   decimal NOT NULL DEFAULT 0,
+    duration   decimal NOT NULL DEFAULT 0,
+    CONSTRAINT fk_playback_positions_user FOREIGN KEY (user_id) REFERENCES users (id) ON UPDATE CASCADE ON DELETE CASCADE,
+    CONSTRAINT fk_playback_positions_lesson FOREIGN KEY (lesson_id) REFERENCES lessons (id) ON UPDATE CASCADE ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_playback_positions_deleted_at ON playback_positions (deleted_at);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_playback_user_lesson ON playback_positions (user_id, lesson_id);
@@ -0,0 +1,4 @@
+-- Progressive lockout after repeated failed logins
+
+ALTER TABLE users ADD COLUMN failed_logins bigint NOT NULL DEFAULT 0;
+ALTER TABLE users ADD COLUMN locked_until datetime;
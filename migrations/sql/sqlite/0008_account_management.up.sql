@@ -0,0 +1,10 @@
+-- Session revocation, email change verification and scheduled account deletion
+
+ALTER TABLE users ADD COLUMN sessions_valid_after datetime;
+ALTER TABLE users ADD COLUMN pending_email text;
+ALTER TABLE users ADD COLUMN email_change_hash text;
+ALTER TABLE users ADD COLUMN email_change_expires_at datetime;
+ALTER TABLE users ADD COLUMN deletion_scheduled_at datetime;
+
+CREATE INDEX IF NOT EXISTS idx_users_email_change_hash ON users (email_change_hash);
+CREATE INDEX IF NOT EXISTS idx_users_deletion_scheduled_at ON users (deletion_scheduled_at);
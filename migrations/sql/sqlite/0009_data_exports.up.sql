@@ -0,0 +1,18 @@
+-- Personal data exports, built in the background and downloadable until they expire
+
+CREATE TABLE IF NOT EXISTS data_exports (
+    id           integer PRIMARY KEY AUTOINCREMENT,
+    created_at   datetime,
+    updated_at   datetime,
+    deleted_at   datetime,
+    user_id      bigint NOT NULL,
+    status       text NOT NULL,
+    storage_key  text,
+    size         bigint NOT NULL DEFAULT 0,
+    completed_at datetime,
+    expires_at   datetime,
+    CONSTRAINT fk_data_exports_user FOREIGN KEY (user_id) REFERENCES users (id) ON UPDATE CASCADE ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_data_exports_deleted_at ON data_exports (deleted_at);
+CREATE INDEX IF NOT EXISTS idx_data_exports_user_id ON data_exports (user_id);
@@ -0,0 +1,9 @@
+-- Suspension and admin-forced password resets
+
+ALTER TABLE users ADD COLUMN suspended_at datetime;
+ALTER TABLE users ADD COLUMN suspension_reason text;
+ALTER TABLE users ADD COLUMN password_reset_hash text;
+ALTER TABLE users ADD COLUMN password_reset_expires_at datetime;
+
+CREATE INDEX IF NOT EXISTS idx_users_suspended_at ON users (suspended_at);
+CREATE INDEX IF NOT EXISTS idx_users_password_reset_hash ON users (password_reset_hash);
@@ -0,0 +1,25 @@
+-- Before organizations there was no superadmin role
+UPDATE users SET roles = 'admin' WHERE roles = 'superadmin';
+
+DROP INDEX IF EXISTS idx_audit_entries_organization_id;
+ALTER TABLE audit_entries DROP COLUMN organization_id;
+DROP INDEX IF EXISTS idx_revisions_organization_id;
+DROP INDEX IF EXISTS idx_video_uploads_organization_id;
+DROP INDEX IF EXISTS idx_answers_organization_id;
+DROP INDEX IF EXISTS idx_quizzes_organization_id;
+DROP INDEX IF EXISTS idx_lesson_attachments_organization_id;
+DROP INDEX IF EXISTS idx_lessons_organization_id;
+DROP INDEX IF EXISTS idx_enrollments_organization_id;
+DROP INDEX IF EXISTS idx_courses_organization_id;
+DROP INDEX IF EXISTS idx_users_organization_id;
+ALTER TABLE revisions DROP COLUMN organization_id;
+ALTER TABLE video_uploads DROP COLUMN organization_id;
+ALTER TABLE answers DROP COLUMN organization_id;
+ALTER TABLE quizzes DROP COLUMN organization_id;
+ALTER TABLE lesson_attachments DROP COLUMN organization_id;
+ALTER TABLE lessons DROP COLUMN organization_id;
+ALTER TABLE enrollments DROP COLUMN organization_id;
+ALTER TABLE courses DROP COLUMN organization_id;
+ALTER TABLE users DROP COLUMN organization_id;
+
+DROP TABLE IF EXISTS organizations;
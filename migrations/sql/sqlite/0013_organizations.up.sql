@@ -0,0 +1,44 @@
+-- Organizations (schools) sharing the platform. Existing data moves to the
+-- "default" organization, whose admins existing admins become. Superadmins
+-- are granted explicitly with the `superadmin <email>` command.
+
+CREATE TABLE IF NOT EXISTS organizations (
+    id         integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    updated_at datetime,
+    deleted_at datetime,
+    name       text NOT NULL,
+    slug       text NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_organizations_deleted_at ON organizations (deleted_at);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_organizations_slug ON organizations (slug);
+
+INSERT INTO organizations (id, created_at, updated_at, name, slug)
+VALUES (1, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP, 'Default', 'default')
+ON CONFLICT DO NOTHING;
+
+ALTER TABLE users ADD COLUMN organization_id bigint NOT NULL DEFAULT 1;
+ALTER TABLE courses ADD COLUMN organization_id bigint NOT NULL DEFAULT 1;
+ALTER TABLE enrollments ADD COLUMN organization_id bigint NOT NULL DEFAULT 1;
+ALTER TABLE lessons ADD COLUMN organization_id bigint NOT NULL DEFAULT 1;
+ALTER TABLE lesson_attachments ADD COLUMN organization_id bigint NOT NULL DEFAULT 1;
+ALTER TABLE quizzes ADD COLUMN organization_id bigint NOT NULL DEFAULT 1;
+ALTER TABLE answers ADD COLUMN organization_id bigint NOT NULL DEFAULT 1;
+ALTER TABLE video_uploads ADD COLUMN organization_id bigint NOT NULL DEFAULT 1;
+ALTER TABLE revisions ADD COLUMN organization_id bigint NOT NULL DEFAULT 1;
+ALTER TABLE audit_entries ADD COLUMN organization_id bigint NOT NULL DEFAULT 0;
+
+-- SQLite tidak bisa menambah foreign key atau membuang default lewat ALTER TABLE;
+-- aplikasi selalu mengisi organization_id sendiri
+
+CREATE INDEX IF NOT EXISTS idx_users_organization_id ON users (organization_id);
+CREATE INDEX IF NOT EXISTS idx_courses_organization_id ON courses (organization_id);
+CREATE INDEX IF NOT EXISTS idx_enrollments_organization_id ON enrollments (organization_id);
+CREATE INDEX IF NOT EXISTS idx_lessons_organization_id ON lessons (organization_id);
+CREATE INDEX IF NOT EXISTS idx_lesson_attachments_organization_id ON lesson_attachments (organization_id);
+CREATE INDEX IF NOT EXISTS idx_quizzes_organization_id ON quizzes (organization_id);
+CREATE INDEX IF NOT EXISTS idx_answers_organization_id ON answers (organization_id);
+CREATE INDEX IF NOT EXISTS idx_video_uploads_organization_id ON video_uploads (organization_id);
+CREATE INDEX IF NOT EXISTS idx_revisions_organization_id ON revisions (organization_id);
+CREATE INDEX IF NOT EXISTS idx_audit_entries_organization_id ON audit_entries (organization_id);
@@ -1,6 +1,36 @@
 package models
 
-import "gorm.io/gorm"
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Organization is one school using the platform. Users, courses and their
+// content belong to exactly one; requests are resolved to one by subdomain or
+// the X-Organization header (see package tenancy).
+type Organization struct {
+	gorm.Model
+	Name string `gorm:"not null" json:"name"`
+	// Slug is the subdomain and X-Organization value of the organization
+	Slug string `gorm:"uniqueIndex;not null" json:"slug"`
+}
+
+// Roles. An admin manages their own organization; a superadmin runs the
+// platform and may act in every organization.
+const (
+	RoleUser       = "user"
+	RoleAdmin      = "admin"
+	RoleSuperAdmin = "superadmin"
+)
+
+// IsAdminRole - Whether role may use the admin routes of an organization
+func IsAdminRole(role string) bool {
+	return role == RoleAdmin || role == RoleSuperAdmin
+}
 
 type User struct {
 	gorm.Model
@@ -9,6 +39,133 @@ type User struct {
 	Password string   `gorm:"not null"`
 	Roles    string   `gorm:"not null"`
 	Profile  *Profile `gorm:"constraint:OnUpdate:CASCADE,OnDelete:SET NULL;foreignKey:UserID"`
+
+	// Emails are unique across organizations, so logging in at another
+	// organization is refused rather than finding a different account
+	OrganizationID uint `gorm:"not null;index" json:"-"`
+
+	// Login lockout, never sent to clients
+	FailedLogins int        `gorm:"not null;default:0" json:"-"`
+	LockedUntil  *time.Time `json:"-"`
+
+	// TOTP two-factor authentication. TOTPSecret is set at enrollment and only
+	// used for logins once TOTPEnabledAt is set; TOTPLastStep stops a code
+	// from being used twice.
+	TOTPSecret    string     `json:"-"`
+	TOTPEnabledAt *time.Time `json:"-"`
+	TOTPLastStep  int64      `gorm:"not null;default:0" json:"-"`
+
+	// Access tokens issued before SessionsValidAfter are refused
+	SessionsValidAfter *time.Time `json:"-"`
+
+	// Email change waiting for the link sent to PendingEmail; only the hash of
+	// the token in the link is stored
+	PendingEmail         string     `json:"-"`
+	EmailChangeHash      string     `gorm:"index" json:"-"`
+	EmailChangeExpiresAt *time.Time `json:"-"`
+
+	// DeletionScheduledAt is when the account will be anonymized; logging in
+	// before then cancels the deletion
+	DeletionScheduledAt *time.Time `gorm:"index" json:"-"`
+
+	// A suspended user cannot log in or use existing sessions and API keys
+	SuspendedAt      *time.Time `gorm:"index" json:"-"`
+	SuspensionReason string     `json:"-"`
+
+	// Password reset forced by an admin: the password is cleared and only the
+	// hash of the token emailed to the user is stored
+	PasswordResetHash      string     `gorm:"index" json:"-"`
+	PasswordResetExpiresAt *time.Time `json:"-"`
+}
+
+// Suspended - Whether an admin has blocked the account
+func (u *User) Suspended() bool {
+	return u.SuspendedAt != nil
+}
+
+// TwoFactorEnabled - Whether logins need a TOTP or recovery code
+func (u *User) TwoFactorEnabled() bool {
+	return u.TOTPEnabledAt != nil
+}
+
+// RecoveryCode is a one-time code that replaces a TOTP code when the
+// authenticator is lost. Only the SHA-256 hash is stored.
+type RecoveryCode struct {
+	gorm.Model
+	UserID   uint       `gorm:"not null;index"`
+	User     *User      `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;foreignKey:UserID" json:"-"`
+	CodeHash string     `gorm:"not null" json:"-"`
+	UsedAt   *time.Time `json:"-"`
+}
+
+// Identity links a user to an account at an OpenID Connect provider
+type Identity struct {
+	gorm.Model
+	UserID   uint   `gorm:"not null;index"`
+	User     *User  `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;foreignKey:UserID" json:"-"`
+	Provider string `gorm:"not null;uniqueIndex:idx_identity_provider_subject"`
+	Subject  string `gorm:"not null;uniqueIndex:idx_identity_provider_subject"`
+	// Email is what the provider reported at the last login
+	Email string
+}
+
+// APIKey is a personal key scripts use instead of a user's password. Only
+// the prefix and a SHA-256 hash of the key are stored; Scopes limits the
+// routes it reaches (see package apikeys).
+type APIKey struct {
+	gorm.Model
+	UserID      uint     `gorm:"not null;index"`
+	User        *User    `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;foreignKey:UserID" json:"-"`
+	Name        string   `gorm:"not null"`
+	Prefix      string   `gorm:"not null;uniqueIndex"`
+	Hash        string   `gorm:"not null" json:"-"`
+	ScopeString string   `gorm:"column:scopes;not null" json:"-"`
+	Scopes      []string `gorm:"-"`
+	ExpiresAt   *time.Time
+	LastUsedAt  *time.Time
+	RevokedAt   *time.Time
+}
+
+// AfterFind - Split the stored scopes
+func (k *APIKey) AfterFind(tx *gorm.DB) error {
+	k.Scopes = strings.Fields(k.ScopeString)
+	return nil
+}
+
+// HasScope - Whether the key was granted scope
+func (k *APIKey) HasScope(scope string) bool {
+	for _, s := range k.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// Usable - Whether the key is neither revoked nor expired at now
+func (k *APIKey) Usable(now time.Time) bool {
+	return k.RevokedAt == nil && (k.ExpiresAt == nil || now.Before(*k.ExpiresAt))
+}
+
+// Data export states
+const (
+	ExportPending = "pending"
+	ExportReady   = "ready"
+	ExportFailed  = "failed"
+)
+
+// DataExport is a ZIP of everything stored about a user, built in the
+// background and kept in private storage until ExpiresAt
+type DataExport struct {
+	gorm.Model
+	UserID      uint   `gorm:"not null;index"`
+	User        *User  `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;foreignKey:UserID" json:"-"`
+	Status      string `gorm:"not null"`
+	StorageKey  string `json:"-"`
+	Size        int64  `gorm:"not null;default:0"`
+	CompletedAt *time.Time
+	ExpiresAt   *time.Time
+	DownloadURL string `gorm:"-"`
 }
 
 type Profile struct {
@@ -28,6 +185,11 @@ type Course struct {
 	Image   	string
 	UserID      uint
 	User        *User `gorm:"constraint:OnUpdate:CASCADE,OnDelete:SET NULL;foreignKey:UserID"`
+	// DeletionID is shared by the course and its lessons, attachments, quizzes
+	// and answers soft-deleted with it, so they are restored together
+	DeletionID string `gorm:"index" json:"-"`
+
+	OrganizationID uint `gorm:"not null;index" json:"-"`
 }
 
 type Enrollment struct {
@@ -36,16 +198,79 @@ type Enrollment struct {
 	User     *User   `gorm:"constraint:OnUpdate:CASCADE,OnDelete:SET NULL;foreignKey:UserID"`
 	CourseID uint
 	Course   *Course `gorm:"constraint:OnUpdate:CASCADE,OnDelete:SET NULL;foreignKey:CourseID"`
+
+	OrganizationID uint `gorm:"not null;index" json:"-"`
 }
 
+// Lesson types
+const (
+	LessonTypeText  = "text"
+	LessonTypeVideo = "video"
+)
+
 type Lesson struct {
 	gorm.Model
-	Name        string `gorm:"not null"`
-	Description string `gorm:"not null"`
-	Content     string `gorm:"not null"`
-	Image       string
-	CourseID    uint
-	Course      *Course `gorm:"constraint:OnUpdate:CASCADE,OnDelete:SET NULL;foreignKey:CourseID"`
+	Name          string `gorm:"not null"`
+	Description   string `gorm:"not null"`
+	Content       string `gorm:"not null"`
+	ContentFormat string `gorm:"not null;default:markdown"`
+	ContentHTML   string
+	Type          string `gorm:"not null;default:text"`
+	Image         string
+	ImageURL      string `gorm:"-"`
+	VideoKey      string
+	VideoMimeType string
+	VideoSize     int64
+	VideoURL      string `gorm:"-"`
+	CourseID      uint
+	Course        *Course `gorm:"constraint:OnUpdate:CASCADE,OnDelete:SET NULL;foreignKey:CourseID"`
+	DeletionID    string  `gorm:"index" json:"-"`
+
+	// OrganizationID is the course's, copied so every query can be filtered by it
+	OrganizationID uint `gorm:"not null;index" json:"-"`
+}
+
+// VideoUpload tracks a resumable (tus-style) upload until all bytes have arrived
+type VideoUpload struct {
+	gorm.Model
+	LessonID   uint    `gorm:"not null;index"`
+	Lesson     *Lesson `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;foreignKey:LessonID"`
+	UploadedBy uint    `gorm:"not null"`
+	FileName   string
+	Length     int64  `gorm:"not null"`
+	Offset     int64  `gorm:"not null;default:0"`
+	StorageKey string `gorm:"not null"`
+	Completed  bool   `gorm:"not null;default:false"`
+
+	OrganizationID uint `gorm:"not null;index" json:"-"`
+}
+
+// PlaybackPosition remembers where a learner stopped watching a video lesson
+type PlaybackPosition struct {
+	gorm.Model
+	UserID   uint    `gorm:"not null;uniqueIndex:idx_playback_user_lesson"`
+	User     *User   `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;foreignKey:UserID"`
+	LessonID uint    `gorm:"not null;uniqueIndex:idx_playback_user_lesson"`
+	Lesson   *Lesson `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;foreignKey:LessonID"`
+	Position float64 `gorm:"not null;default:0"`
+	Duration float64 `gorm:"not null;default:0"`
+}
+
+type LessonAttachment struct {
+	gorm.Model
+	LessonID    uint    `gorm:"not null;index"`
+	Lesson      *Lesson `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;foreignKey:LessonID"`
+	DisplayName string  `gorm:"not null"`
+	FileName    string  `gorm:"not null"`
+	StorageKey  string  `gorm:"not null"`
+	MimeType    string  `gorm:"not null"`
+	Size        int64   `gorm:"not null"`
+	Checksum    string  `gorm:"not null"`
+	UploadedBy  uint
+	DownloadURL string `gorm:"-"`
+	DeletionID  string `gorm:"index" json:"-"`
+
+	OrganizationID uint `gorm:"not null;index" json:"-"`
 }
 
 type Quiz struct {
@@ -55,13 +280,19 @@ type Quiz struct {
 	Content    	string `gorm:"not null"`
 	CourseID    uint
 	Course      *Course `gorm:"constraint:OnUpdate:CASCADE,OnDelete:SET NULL;foreignKey:CourseID"`
+	DeletionID  string  `gorm:"index" json:"-"`
+
+	OrganizationID uint `gorm:"not null;index" json:"-"`
 }
 
 type Answer struct {
 	gorm.Model
 	Content     string `gorm:"not null"`
 	QuizID     uint
-	Quiz       *Quiz `gorm:"constraint:OnUpdate:CASCADE,OnDelete:SET NULL;foreignKey:QuestionID"`
+	Quiz       *Quiz `gorm:"constraint:OnUpdate:CASCADE,OnDelete:SET NULL;foreignKey:QuizID"`
+	DeletionID string `gorm:"index" json:"-"`
+
+	OrganizationID uint `gorm:"not null;index" json:"-"`
 }
 
 type UserQuiz struct {
@@ -79,3 +310,92 @@ type UserAnswer struct {
 	AnswerID uint
 	Answer   *Answer `gorm:"constraint:OnUpdate:CASCADE,OnDelete:SET NULL;foreignKey:AnswerID"`
 }
+
+// Revision entity types
+const (
+	RevisionLesson = "lesson"
+	RevisionQuiz   = "quiz"
+)
+
+// ErrRevisionImmutable is returned when something tries to change a stored revision
+var ErrRevisionImmutable = errors.New("revisions are immutable")
+
+// Revision is an immutable full snapshot of a lesson or quiz taken after each edit
+type Revision struct {
+	gorm.Model
+	EntityType string `gorm:"not null;uniqueIndex:idx_revision_entity_version"`
+	EntityID   uint   `gorm:"not null;uniqueIndex:idx_revision_entity_version"`
+	Version    int    `gorm:"not null;uniqueIndex:idx_revision_entity_version"`
+	AuthorID   *uint
+	Author     *User  `gorm:"constraint:OnUpdate:CASCADE,OnDelete:SET NULL;foreignKey:AuthorID"`
+	Note       string
+	Snapshot   string `gorm:"type:text;not null"`
+
+	OrganizationID uint `gorm:"not null;index" json:"-"`
+}
+
+func (r *Revision) BeforeUpdate(tx *gorm.DB) error {
+	return ErrRevisionImmutable
+}
+
+func (r *Revision) BeforeDelete(tx *gorm.DB) error {
+	return ErrRevisionImmutable
+}
+
+// Audit entity types
+const (
+	AuditUser        = "user"
+	AuditProfile     = "profile"
+	AuditCourse      = "course"
+	AuditEnrollment  = "enrollment"
+	AuditLesson      = "lesson"
+	AuditAttachment  = "attachment"
+	AuditVideoUpload = "video_upload"
+	AuditQuiz        = "quiz"
+	AuditAnswer      = "answer"
+	AuditQuizAttempt = "quiz_attempt"
+	AuditAPIKey      = "api_key"
+	AuditExport      = "data_export"
+
+	AuditOrganization = "organization"
+)
+
+// ErrAuditImmutable is returned when something tries to change or remove an audit entry
+var ErrAuditImmutable = errors.New("audit entries are append-only")
+
+// AuditEntry records one change made through the API: who made it (and the
+// admin impersonating them, if any), what it changed and which request did
+// it. Entries are written in the transaction of the change and never change.
+type AuditEntry struct {
+	ID        uint      `gorm:"primarykey"`
+	CreatedAt time.Time `gorm:"index"`
+	// ActorID is nil for changes made by the system itself, e.g. scheduled purges
+	ActorID        *uint `gorm:"index"`
+	ImpersonatorID *uint
+	Action         string `gorm:"not null;index"`
+	EntityType     string `gorm:"not null;index:idx_audit_entity"`
+	EntityID       uint   `gorm:"not null;index:idx_audit_entity"`
+	// Changes maps each changed field to {"from": ..., "to": ...}
+	ChangeString string          `gorm:"column:changes;type:text" json:"-"`
+	Changes      json.RawMessage `gorm:"-"`
+	IP           string
+	RequestID    string `gorm:"index"`
+	// OrganizationID is where the change was made, 0 for the platform itself
+	OrganizationID uint `gorm:"not null;default:0;index"`
+}
+
+// AfterFind - Expose the stored changes as JSON
+func (e *AuditEntry) AfterFind(tx *gorm.DB) error {
+	if e.ChangeString != "" {
+		e.Changes = json.RawMessage(e.ChangeString)
+	}
+	return nil
+}
+
+func (e *AuditEntry) BeforeUpdate(tx *gorm.DB) error {
+	return ErrAuditImmutable
+}
+
+func (e *AuditEntry) BeforeDelete(tx *gorm.DB) error {
+	return ErrAuditImmutable
+}
@@ -0,0 +1,63 @@
+package ratelimit
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// takeScript - The token bucket as one atomic step. Buckets are hashes holding
+// the tokens left and the time of the last take; they expire once full.
+var takeScript = redis.NewScript(`
+local burst = tonumber(ARGV[1])
+local rate = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(state[1]) or burst
+local ts = tonumber(state[2]) or now
+
+tokens = math.min(burst, tokens + math.max(0, now - ts) * rate)
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', tostring(now))
+redis.call('PEXPIRE', KEYS[1], math.ceil((burst - tokens) / rate) + 1000)
+return {allowed, tostring(tokens)}
+`)
+
+// Redis - Store shared by every instance through Redis or a Redis-compatible
+// server (Valkey, KeyDB, ...)
+type Redis struct {
+	client redis.Scripter
+	prefix string
+	now    func() time.Time
+}
+
+// NewRedis - Store keeping buckets in client under keys starting with "ratelimit:"
+func NewRedis(client redis.Scripter) *Redis {
+	return &Redis{client: client, prefix: "ratelimit:", now: time.Now}
+}
+
+func (r *Redis) Take(ctx context.Context, key string, limit Limit) (Result, error) {
+	perMilli := limit.perSecond() / 1000
+	now := r.now().UnixMilli()
+
+	reply, err := takeScript.Run(ctx, r.client, []string{r.prefix + key},
+		limit.Burst, strconv.FormatFloat(perMilli, 'f', -1, 64), now).Slice()
+	if err != nil {
+		return Result{}, err
+	}
+
+	allowed, _ := reply[0].(int64)
+	tokens, err := strconv.ParseFloat(reply[1].(string), 64)
+	if err != nil {
+		return Result{}, err
+	}
+	return result(limit, tokens, allowed == 1), nil
+}
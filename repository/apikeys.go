@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"backend-go/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type gormAPIKeys struct {
+	db *gorm.DB
+}
+
+func (r *gormAPIKeys) ListByUser(userID uint) ([]models.APIKey, error) {
+	var keys []models.APIKey
+	err := r.db.Where("user_id = ?", userID).Order("id DESC").Find(&keys).Error
+	return keys, err
+}
+
+func (r *gormAPIKeys) FindForUser(id, userID uint) (*models.APIKey, error) {
+	var key models.APIKey
+	if err := r.db.Where("user_id = ?", userID).First(&key, id).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &key, nil
+}
+
+func (r *gormAPIKeys) FindByPrefix(prefix string) (*models.APIKey, error) {
+	var key models.APIKey
+	if err := r.db.Preload("User").Where("prefix = ?", prefix).First(&key).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &key, nil
+}
+
+func (r *gormAPIKeys) CountUsable(userID uint, now time.Time) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.APIKey{}).
+		Where("user_id = ? AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > ?)", userID, now).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *gormAPIKeys) Create(key *models.APIKey) error {
+	return r.db.Create(key).Error
+}
+
+func (r *gormAPIKeys) Revoke(id uint, at time.Time) error {
+	return r.db.Model(&models.APIKey{}).Where("id = ? AND revoked_at IS NULL", id).UpdateColumn("revoked_at", at).Error
+}
+
+func (r *gormAPIKeys) Touch(id uint, at time.Time, interval time.Duration) error {
+	// Satu UPDATE bersyarat, bukan baca lalu tulis, supaya request paralel tidak saling menimpa
+	return r.db.Model(&models.APIKey{}).
+		Where("id = ? AND (last_used_at IS NULL OR last_used_at < ?)", id, at.Add(-interval)).
+		UpdateColumn("last_used_at", at).Error
+}
@@ -0,0 +1,35 @@
+package repository
+
+import (
+	"backend-go/models"
+
+	"gorm.io/gorm"
+)
+
+type gormAttachments struct {
+	db *gorm.DB
+}
+
+func (r *gormAttachments) ListByLesson(lessonID uint) ([]models.LessonAttachment, error) {
+	var attachments []models.LessonAttachment
+	err := r.db.Where("lesson_id = ?", lessonID).Order("id").Find(&attachments).Error
+	return attachments, err
+}
+
+func (r *gormAttachments) ListIDsByLesson(lessonID uint) ([]uint, error) {
+	var ids []uint
+	err := r.db.Model(&models.LessonAttachment{}).Where("lesson_id = ?", lessonID).Pluck("id", &ids).Error
+	return ids, err
+}
+
+func (r *gormAttachments) FindByID(id uint) (*models.LessonAttachment, error) {
+	var attachment models.LessonAttachment
+	if err := r.db.Preload("Lesson").First(&attachment, id).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &attachment, nil
+}
+
+func (r *gormAttachments) Create(attachment *models.LessonAttachment) error {
+	return r.db.Create(attachment).Error
+}
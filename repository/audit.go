@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"backend-go/models"
+
+	"gorm.io/gorm"
+)
+
+// auditBatchSize - Entries loaded at a time by Each
+const auditBatchSize = 500
+
+type gormAudit struct {
+	db *gorm.DB
+}
+
+func (r *gormAudit) Record(entry *models.AuditEntry) error {
+	return r.db.Create(entry).Error
+}
+
+func (r *gormAudit) List(filter AuditFilter) ([]models.AuditEntry, int64, error) {
+	q := r.filtered(filter)
+
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var entries []models.AuditEntry
+	err := q.Order("id DESC").Offset(filter.Offset).Limit(filter.Limit).Find(&entries).Error
+	return entries, total, err
+}
+
+func (r *gormAudit) Each(filter AuditFilter, fn func(entry *models.AuditEntry) error) error {
+	var batch []models.AuditEntry
+	return r.filtered(filter).FindInBatches(&batch, auditBatchSize, func(tx *gorm.DB, _ int) error {
+		for i := range batch {
+			if err := fn(&batch[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}).Error
+}
+
+func (r *gormAudit) filtered(filter AuditFilter) *gorm.DB {
+	q := r.db.Model(&models.AuditEntry{})
+	if filter.ActorID != 0 {
+		q = q.Where("actor_id = ? OR impersonator_id = ?", filter.ActorID, filter.ActorID)
+	}
+	if filter.Action != "" {
+		q = q.Where("action = ?", filter.Action)
+	}
+	if filter.EntityType != "" {
+		q = q.Where("entity_type = ?", filter.EntityType)
+	}
+	if filter.EntityID != 0 {
+		q = q.Where("entity_id = ?", filter.EntityID)
+	}
+	if filter.RequestID != "" {
+		q = q.Where("request_id = ?", filter.RequestID)
+	}
+	if filter.Since != nil {
+		q = q.Where("created_at >= ?", *filter.Since)
+	}
+	if filter.Until != nil {
+		q = q.Where("created_at < ?", *filter.Until)
+	}
+	return q
+}
@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"backend-go/models"
+
+	"gorm.io/gorm"
+)
+
+type gormCourses struct {
+	db *gorm.DB
+}
+
+func (r *gormCourses) List() ([]models.Course, error) {
+	var courses []models.Course
+	err := r.db.Find(&courses).Error
+	return courses, err
+}
+
+func (r *gormCourses) ListByOwner(userID uint) ([]models.Course, error) {
+	var courses []models.Course
+	err := r.db.Where("user_id = ?", userID).Order("id").Find(&courses).Error
+	return courses, err
+}
+
+func (r *gormCourses) FindByID(id uint) (*models.Course, error) {
+	var course models.Course
+	if err := r.db.First(&course, id).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &course, nil
+}
+
+func (r *gormCourses) Create(course *models.Course) error {
+	return r.db.Create(course).Error
+}
+
+func (r *gormCourses) Update(course *models.Course, changes models.Course) error {
+	return r.db.Model(course).Updates(changes).Error
+}
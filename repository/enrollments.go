@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"backend-go/models"
+
+	"gorm.io/gorm"
+)
+
+type gormEnrollments struct {
+	db *gorm.DB
+}
+
+func (r *gormEnrollments) Find(userID, courseID uint) (*models.Enrollment, error) {
+	var enrollment models.Enrollment
+	if err := r.db.Where("user_id = ? AND course_id = ?", userID, courseID).First(&enrollment).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &enrollment, nil
+}
+
+func (r *gormEnrollments) ListByUser(userID uint) ([]models.Enrollment, error) {
+	var enrollments []models.Enrollment
+	err := r.db.Preload("Course").Where("user_id = ?", userID).Find(&enrollments).Error
+	return enrollments, err
+}
+
+func (r *gormEnrollments) ListByCourse(courseID uint) ([]models.Enrollment, error) {
+	var enrollments []models.Enrollment
+	err := r.db.Preload("User").Where("course_id = ?", courseID).Find(&enrollments).Error
+	return enrollments, err
+}
+
+func (r *gormEnrollments) Create(enrollment *models.Enrollment) error {
+	return r.db.Create(enrollment).Error
+}
+
+func (r *gormEnrollments) Delete(enrollment *models.Enrollment) error {
+	return r.db.Delete(enrollment).Error
+}
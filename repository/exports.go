@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"backend-go/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type gormExports struct {
+	db *gorm.DB
+}
+
+func (r *gormExports) Create(export *models.DataExport) error {
+	return r.db.Create(export).Error
+}
+
+func (r *gormExports) FindByID(id uint) (*models.DataExport, error) {
+	var export models.DataExport
+	if err := r.db.First(&export, id).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &export, nil
+}
+
+func (r *gormExports) FindPending(userID uint) (*models.DataExport, error) {
+	var export models.DataExport
+	if err := r.db.Where("user_id = ? AND status = ?", userID, models.ExportPending).First(&export).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &export, nil
+}
+
+func (r *gormExports) ListByUser(userID uint) ([]models.DataExport, error) {
+	var exports []models.DataExport
+	err := r.db.Where("user_id = ?", userID).Order("id DESC").Find(&exports).Error
+	return exports, err
+}
+
+func (r *gormExports) MarkReady(id uint, key string, size int64, at, expiresAt time.Time) error {
+	return r.db.Model(&models.DataExport{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":       models.ExportReady,
+		"storage_key":  key,
+		"size":         size,
+		"completed_at": at,
+		"expires_at":   expiresAt,
+	}).Error
+}
+
+func (r *gormExports) MarkFailed(id uint, at time.Time) error {
+	return r.db.Model(&models.DataExport{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":       models.ExportFailed,
+		"completed_at": at,
+	}).Error
+}
+
+func (r *gormExports) ListExpired(now time.Time) ([]models.DataExport, error) {
+	var exports []models.DataExport
+	err := r.db.Where("expires_at IS NOT NULL AND expires_at <= ?", now).Find(&exports).Error
+	return exports, err
+}
+
+func (r *gormExports) Delete(id uint) error {
+	// Hard delete: arsipnya sudah dihapus dari storage
+	return r.db.Unscoped().Delete(&models.DataExport{}, id).Error
+}
@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// gormStore - Store backed by GORM
+type gormStore struct {
+	db *gorm.DB
+}
+
+// NewStore - Create a Store on top of db
+func NewStore(db *gorm.DB) Store {
+	return &gormStore{db: db}
+}
+
+func (s *gormStore) Users() UserRepository             { return &gormUsers{db: s.db} }
+func (s *gormStore) Courses() CourseRepository         { return &gormCourses{db: s.db} }
+func (s *gormStore) Enrollments() EnrollmentRepository { return &gormEnrollments{db: s.db} }
+func (s *gormStore) Lessons() LessonRepository         { return &gormLessons{db: s.db} }
+func (s *gormStore) Attachments() AttachmentRepository { return &gormAttachments{db: s.db} }
+func (s *gormStore) Videos() VideoRepository           { return &gormVideos{db: s.db} }
+func (s *gormStore) Quizzes() QuizRepository           { return &gormQuizzes{db: s.db} }
+func (s *gormStore) Revisions() RevisionRepository     { return &gormRevisions{db: s.db} }
+func (s *gormStore) APIKeys() APIKeyRepository         { return &gormAPIKeys{db: s.db} }
+func (s *gormStore) Exports() ExportRepository         { return &gormExports{db: s.db} }
+func (s *gormStore) Audit() AuditRepository            { return &gormAudit{db: s.db} }
+func (s *gormStore) Trash() TrashRepository            { return &gormTrash{db: s.db} }
+func (s *gormStore) Organizations() OrganizationRepository {
+	return &gormOrganizations{db: s.db}
+}
+
+func (s *gormStore) Transaction(fn func(tx Store) error) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		return fn(&gormStore{db: tx})
+	})
+}
+
+func (s *gormStore) WithContext(ctx context.Context) Store {
+	return &gormStore{db: s.db.WithContext(ctx)}
+}
+
+// translate - Map GORM's not-found error onto ErrNotFound
+func translate(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrNotFound
+	}
+	return err
+}
+
+// exists - Whether any row matches the query
+func exists(query *gorm.DB) (bool, error) {
+	var count int64
+	if err := query.Limit(1).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
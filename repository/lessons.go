@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"backend-go/models"
+
+	"gorm.io/gorm"
+)
+
+type gormLessons struct {
+	db *gorm.DB
+}
+
+func (r *gormLessons) List() ([]models.Lesson, error) {
+	var lessons []models.Lesson
+	err := r.db.Find(&lessons).Error
+	return lessons, err
+}
+
+func (r *gormLessons) ListByCourse(courseID uint) ([]models.Lesson, error) {
+	var lessons []models.Lesson
+	err := r.db.Where("course_id = ?", courseID).Find(&lessons).Error
+	return lessons, err
+}
+
+func (r *gormLessons) FindByID(id uint) (*models.Lesson, error) {
+	var lesson models.Lesson
+	if err := r.db.First(&lesson, id).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &lesson, nil
+}
+
+func (r *gormLessons) Create(lesson *models.Lesson) error {
+	return r.db.Create(lesson).Error
+}
+
+func (r *gormLessons) Save(lesson *models.Lesson) error {
+	return r.db.Save(lesson).Error
+}
+
+func (r *gormLessons) UpdateContentHTML(id uint, html string) error {
+	return r.db.Model(&models.Lesson{}).Where("id = ?", id).UpdateColumn("content_html", html).Error
+}
+
+func (r *gormLessons) ListPlaybackByUser(userID uint) ([]models.PlaybackPosition, error) {
+	var positions []models.PlaybackPosition
+	err := r.db.Where("user_id = ?", userID).Order("id").Find(&positions).Error
+	return positions, err
+}
@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"backend-go/models"
+
+	"gorm.io/gorm"
+)
+
+type gormOrganizations struct {
+	db *gorm.DB
+}
+
+func (r *gormOrganizations) List() ([]models.Organization, error) {
+	var organizations []models.Organization
+	err := r.db.Order("id").Find(&organizations).Error
+	return organizations, err
+}
+
+func (r *gormOrganizations) FindByID(id uint) (*models.Organization, error) {
+	var organization models.Organization
+	if err := r.db.First(&organization, id).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &organization, nil
+}
+
+func (r *gormOrganizations) FindBySlug(slug string) (*models.Organization, error) {
+	var organization models.Organization
+	if err := r.db.Where("slug = ?", slug).First(&organization).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &organization, nil
+}
+
+func (r *gormOrganizations) ExistsBySlug(slug string) (bool, error) {
+	return exists(r.db.Model(&models.Organization{}).Where("slug = ?", slug))
+}
+
+func (r *gormOrganizations) Create(organization *models.Organization) error {
+	return r.db.Create(organization).Error
+}
+
+func (r *gormOrganizations) Update(organization *models.Organization, changes models.Organization) error {
+	return r.db.Model(organization).Updates(changes).Error
+}
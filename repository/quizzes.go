@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"backend-go/models"
+
+	"gorm.io/gorm"
+)
+
+type gormQuizzes struct {
+	db *gorm.DB
+}
+
+func (r *gormQuizzes) List() ([]models.Quiz, error) {
+	var quizzes []models.Quiz
+	err := r.db.Preload("Course").Find(&quizzes).Error
+	return quizzes, err
+}
+
+func (r *gormQuizzes) ListByCourse(courseID uint) ([]models.Quiz, error) {
+	var quizzes []models.Quiz
+	err := r.db.Where("course_id = ?", courseID).Find(&quizzes).Error
+	return quizzes, err
+}
+
+func (r *gormQuizzes) FindByID(id uint) (*models.Quiz, error) {
+	var quiz models.Quiz
+	if err := r.db.First(&quiz, id).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &quiz, nil
+}
+
+func (r *gormQuizzes) FindByIDWithCourse(id uint) (*models.Quiz, error) {
+	var quiz models.Quiz
+	if err := r.db.Preload("Course").First(&quiz, id).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &quiz, nil
+}
+
+func (r *gormQuizzes) Create(quiz *models.Quiz) error {
+	return r.db.Create(quiz).Error
+}
+
+func (r *gormQuizzes) Save(quiz *models.Quiz) error {
+	return r.db.Save(quiz).Error
+}
+
+func (r *gormQuizzes) ListAnswers(quizID uint) ([]models.Answer, error) {
+	var answers []models.Answer
+	err := r.db.Where("quiz_id = ?", quizID).Find(&answers).Error
+	return answers, err
+}
+
+func (r *gormQuizzes) FindAnswer(id uint) (*models.Answer, error) {
+	var answer models.Answer
+	if err := r.db.First(&answer, id).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &answer, nil
+}
+
+func (r *gormQuizzes) CreateAnswer(answer *models.Answer) error {
+	return r.db.Create(answer).Error
+}
+
+func (r *gormQuizzes) SaveAnswer(answer *models.Answer) error {
+	return r.db.Save(answer).Error
+}
+
+func (r *gormQuizzes) CreateAttempt(attempt *models.UserQuiz) error {
+	return r.db.Create(attempt).Error
+}
+
+func (r *gormQuizzes) ListAttemptsByUser(userID uint) ([]models.UserQuiz, error) {
+	var attempts []models.UserQuiz
+	err := r.db.Preload("Quiz").Where("user_id = ?", userID).Order("id DESC").Find(&attempts).Error
+	return attempts, err
+}
+
+func (r *gormQuizzes) ListUserAnswersByUser(userID uint) ([]models.UserAnswer, error) {
+	var answers []models.UserAnswer
+	err := r.db.Preload("Answer").Where("user_id = ?", userID).Order("id").Find(&answers).Error
+	return answers, err
+}
+
+func (r *gormQuizzes) CreateUserAnswers(answers []models.UserAnswer) error {
+	return r.db.Create(&answers).Error
+}
@@ -0,0 +1,303 @@
+package repository
+
+import (
+	"backend-go/models"
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrNotFound is returned by every repository when the requested row does not exist
+var ErrNotFound = errors.New("record not found")
+
+// ErrVersionTaken is returned by RevisionRepository.Record when a concurrent
+// change recorded the same version first. The transaction can be retried.
+var ErrVersionTaken = errors.New("revision version already recorded")
+
+// UserFilter - Which users Search returns, newest first
+type UserFilter struct {
+	// Query matches part of the email or username, ignoring case
+	Query string
+	Role  string
+	// Suspended, when set, keeps only suspended or only active users
+	Suspended *bool
+	Offset    int
+	Limit     int
+}
+
+// UserRepository - Users and their profile
+type UserRepository interface {
+	FindByID(id uint) (*models.User, error)
+	FindByEmail(email string) (*models.User, error)
+	ExistsByEmail(email string) (bool, error)
+	ExistsByUsername(username string) (bool, error)
+	Create(user *models.User) error
+
+	// IncrementFailedLogins atomically counts a failed login and returns the new count
+	IncrementFailedLogins(id uint) (int, error)
+	LockUntil(id uint, until time.Time) error
+	ResetFailedLogins(id uint) error
+
+	// Two-factor authentication. UseTOTPStep and UseRecoveryCode succeed at most
+	// once per step or code, even under concurrent logins.
+	SetTOTPSecret(id uint, secret string) error
+	EnableTOTP(id uint, at time.Time, step int64) error
+	DisableTOTP(id uint) error
+	UseTOTPStep(id uint, step int64) (bool, error)
+	ReplaceRecoveryCodes(userID uint, hashes []string) error
+	UseRecoveryCode(userID uint, hash string) (bool, error)
+	CountRecoveryCodes(userID uint) (int64, error)
+
+	// Accounts at OpenID Connect providers
+	FindIdentity(provider, subject string) (*models.Identity, error)
+	CreateIdentity(identity *models.Identity) error
+	UpdateIdentityEmail(id uint, email string) error
+	ListIdentities(userID uint) ([]models.Identity, error)
+
+	// Account management. RevokeSessions refuses every access token issued before at.
+	SetPassword(id uint, hash string) error
+	RevokeSessions(id uint, at time.Time) error
+	SetPendingEmail(id uint, email, tokenHash string, expiresAt time.Time) error
+	FindByEmailChangeHash(hash string) (*models.User, error)
+	ConfirmEmail(id uint, email string) error
+	ScheduleDeletion(id uint, at time.Time) error
+	CancelDeletion(id uint) error
+	ListDueForDeletion(now time.Time) ([]models.User, error)
+	// Anonymize scrubs the user's personal data, removes what only made sense
+	// for a live account and soft-deletes the row. Quiz attempts stay, pointing
+	// at the anonymized user.
+	Anonymize(user *models.User, at time.Time) error
+
+	// Administration
+	Search(filter UserFilter) ([]models.User, int64, error)
+	SetRole(id uint, role string) error
+	Suspend(id uint, at time.Time, reason string) error
+	Unsuspend(id uint) error
+	// RequirePasswordReset clears the password until the reset token is used
+	RequirePasswordReset(id uint, tokenHash string, expiresAt time.Time) error
+	FindByPasswordResetHash(hash string) (*models.User, error)
+	CompletePasswordReset(id uint, passwordHash string) error
+
+	FindProfile(userID uint) (*models.Profile, error)
+	CreateProfile(profile *models.Profile) error
+	UpdateProfile(profile *models.Profile, changes models.Profile) error
+	DeleteProfile(profile *models.Profile) error
+}
+
+// OrganizationRepository - The organizations (schools) sharing the platform
+type OrganizationRepository interface {
+	List() ([]models.Organization, error)
+	FindByID(id uint) (*models.Organization, error)
+	FindBySlug(slug string) (*models.Organization, error)
+	ExistsBySlug(slug string) (bool, error)
+	Create(organization *models.Organization) error
+	Update(organization *models.Organization, changes models.Organization) error
+}
+
+// CourseRepository - Courses
+type CourseRepository interface {
+	List() ([]models.Course, error)
+	ListByOwner(userID uint) ([]models.Course, error)
+	FindByID(id uint) (*models.Course, error)
+	Create(course *models.Course) error
+	Update(course *models.Course, changes models.Course) error
+}
+
+// EnrollmentRepository - Which user is enrolled in which course
+type EnrollmentRepository interface {
+	Find(userID, courseID uint) (*models.Enrollment, error)
+	ListByUser(userID uint) ([]models.Enrollment, error)
+	ListByCourse(courseID uint) ([]models.Enrollment, error)
+	Create(enrollment *models.Enrollment) error
+	Delete(enrollment *models.Enrollment) error
+}
+
+// LessonRepository - Lessons
+type LessonRepository interface {
+	List() ([]models.Lesson, error)
+	ListByCourse(courseID uint) ([]models.Lesson, error)
+	FindByID(id uint) (*models.Lesson, error)
+	Create(lesson *models.Lesson) error
+	Save(lesson *models.Lesson) error
+	UpdateContentHTML(id uint, html string) error
+	ListPlaybackByUser(userID uint) ([]models.PlaybackPosition, error)
+}
+
+// QuizRepository - Quizzes, their answers and users' submissions
+type QuizRepository interface {
+	List() ([]models.Quiz, error)
+	ListByCourse(courseID uint) ([]models.Quiz, error)
+	FindByID(id uint) (*models.Quiz, error)
+	FindByIDWithCourse(id uint) (*models.Quiz, error)
+	Create(quiz *models.Quiz) error
+	Save(quiz *models.Quiz) error
+
+	ListAnswers(quizID uint) ([]models.Answer, error)
+	FindAnswer(id uint) (*models.Answer, error)
+	CreateAnswer(answer *models.Answer) error
+	SaveAnswer(answer *models.Answer) error
+
+	CreateAttempt(attempt *models.UserQuiz) error
+	ListAttemptsByUser(userID uint) ([]models.UserQuiz, error)
+	// ListUserAnswersByUser also loads each answer
+	ListUserAnswersByUser(userID uint) ([]models.UserAnswer, error)
+	CreateUserAnswers(answers []models.UserAnswer) error
+}
+
+// AttachmentRepository - Files attached to lessons
+type AttachmentRepository interface {
+	ListByLesson(lessonID uint) ([]models.LessonAttachment, error)
+	ListIDsByLesson(lessonID uint) ([]uint, error)
+	// FindByID also loads the attachment's lesson
+	FindByID(id uint) (*models.LessonAttachment, error)
+	Create(attachment *models.LessonAttachment) error
+}
+
+// VideoRepository - Resumable video uploads and where learners stopped watching
+type VideoRepository interface {
+	CreateUpload(upload *models.VideoUpload) error
+	FindUpload(id uint) (*models.VideoUpload, error)
+	SetUploadKey(id uint, key string) error
+	// AdvanceUploadOffset moves the offset from from to to and reports false,
+	// changing nothing, when the stored offset is no longer from
+	AdvanceUploadOffset(id uint, from, to int64) (bool, error)
+	CompleteUpload(id uint, key string) error
+	DeleteUpload(upload *models.VideoUpload) error
+
+	FindPlayback(userID, lessonID uint) (*models.PlaybackPosition, error)
+	// SavePlayback keeps one position per user and lesson, the latest wins
+	SavePlayback(position *models.PlaybackPosition) error
+}
+
+// RevisionRepository - Append-only lesson/quiz history
+type RevisionRepository interface {
+	Record(entityType string, entityID, authorID uint, snapshot interface{}, note string) error
+	// Latest is the highest version recorded for the entity, 0 when it has no history
+	Latest(entityType string, entityID uint) (int, error)
+	// List is the history of an entity without snapshots, newest first
+	List(entityType string, entityID uint) ([]models.Revision, error)
+	Find(entityType string, entityID uint, version int) (*models.Revision, error)
+}
+
+// APIKeyRepository - Users' personal API keys
+type APIKeyRepository interface {
+	ListByUser(userID uint) ([]models.APIKey, error)
+	FindForUser(id, userID uint) (*models.APIKey, error)
+	// FindByPrefix also loads the key's user
+	FindByPrefix(prefix string) (*models.APIKey, error)
+	CountUsable(userID uint, now time.Time) (int64, error)
+	Create(key *models.APIKey) error
+	Revoke(id uint, at time.Time) error
+	// Touch records a use, writing at most once per interval per key
+	Touch(id uint, at time.Time, interval time.Duration) error
+}
+
+// ExportRepository - Users' personal data exports
+type ExportRepository interface {
+	Create(export *models.DataExport) error
+	FindByID(id uint) (*models.DataExport, error)
+	// FindPending is the user's export still being built, if any
+	FindPending(userID uint) (*models.DataExport, error)
+	ListByUser(userID uint) ([]models.DataExport, error)
+	MarkReady(id uint, key string, size int64, at, expiresAt time.Time) error
+	MarkFailed(id uint, at time.Time) error
+	ListExpired(now time.Time) ([]models.DataExport, error)
+	Delete(id uint) error
+}
+
+// AuditFilter - Which audit entries List returns, newest first
+type AuditFilter struct {
+	// ActorID matches entries made by the user or by an admin impersonating someone
+	ActorID    uint
+	Action     string
+	EntityType string
+	EntityID   uint
+	RequestID  string
+	// Since and Until bound the time of the change, Until excluded
+	Since  *time.Time
+	Until  *time.Time
+	Offset int
+	Limit  int
+}
+
+// AuditRepository - Append-only log of changes made through the API
+type AuditRepository interface {
+	// Record appends entry; call it inside the transaction that made the change
+	Record(entry *models.AuditEntry) error
+	List(filter AuditFilter) ([]models.AuditEntry, int64, error)
+	// Each calls fn for every entry matching filter, oldest first, loading
+	// them in batches. Offset and Limit are ignored.
+	Each(filter AuditFilter, fn func(entry *models.AuditEntry) error) error
+}
+
+// TrashedRow - A soft-deleted course, lesson, attachment, quiz or answer
+type TrashedRow struct {
+	// Type is the entity type as used in the audit log (models.AuditCourse, ...)
+	Type string
+	ID   uint
+	// Name is the name, display name or content of the row
+	Name      string
+	ParentID  uint
+	DeletedAt time.Time
+	// DeletionID is empty for rows deleted before deletions were tagged
+	DeletionID string
+	// Cascaded rows were deleted together with their parent
+	Cascaded bool
+	// OrganizationID owns the row
+	OrganizationID uint
+}
+
+// TrashFilter - Which soft-deleted rows List returns, most recently deleted first
+type TrashFilter struct {
+	// Type keeps one entity type only
+	Type string
+	// Cascaded also lists rows deleted together with their parent; without it
+	// only what someone deleted directly is listed
+	Cascaded bool
+	Offset   int
+	Limit    int
+}
+
+// TrashRepository - Soft deletion of courses and their content, restore and
+// permanent removal. A course owns its lessons and quizzes, a lesson its
+// attachments and a quiz its answers.
+type TrashRepository interface {
+	// Trash soft-deletes the row and every live row it owns, all at the same
+	// time and tagged with deletionID
+	Trash(entityType string, id uint, at time.Time, deletionID string) error
+	List(filter TrashFilter) ([]TrashedRow, int64, error)
+	// Find is the soft-deleted row of entityType with id
+	Find(entityType string, id uint) (*TrashedRow, error)
+	// ParentTrashed - Whether the row owning row is soft-deleted as well
+	ParentTrashed(row *TrashedRow) (bool, error)
+	// Restore undeletes row and, when it has a deletion ID, every row deleted with it
+	Restore(row *TrashedRow) (int64, error)
+	// ListExpired is up to limit rows deleted before before, owned rows
+	// before their owners so nothing is removed by a foreign key cascade first
+	ListExpired(before time.Time, limit int) ([]TrashedRow, error)
+	// Purge permanently deletes the soft-deleted row and returns the keys of
+	// the private files only it referenced, for the caller to remove
+	Purge(row *TrashedRow) ([]string, error)
+}
+
+// Store - Entry point to every repository. Transaction runs fn with a Store
+// whose repositories all share one database transaction.
+type Store interface {
+	Users() UserRepository
+	Courses() CourseRepository
+	Enrollments() EnrollmentRepository
+	Lessons() LessonRepository
+	Attachments() AttachmentRepository
+	Videos() VideoRepository
+	Quizzes() QuizRepository
+	Revisions() RevisionRepository
+	APIKeys() APIKeyRepository
+	Exports() ExportRepository
+	Audit() AuditRepository
+	Trash() TrashRepository
+	Organizations() OrganizationRepository
+	Transaction(fn func(tx Store) error) error
+	// WithContext returns the store running its queries with ctx, which also
+	// limits them to the organization ctx carries (see package tenancy)
+	WithContext(ctx context.Context) Store
+}
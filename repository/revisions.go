@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"backend-go/models"
+	"encoding/json"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+type gormRevisions struct {
+	db *gorm.DB
+}
+
+// Record - Append the next revision for an entity. Call inside the transaction
+// that saved the change so history never drifts from the data.
+func (r *gormRevisions) Record(entityType string, entityID, authorID uint, snapshot interface{}, note string) error {
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+
+	latest, err := r.Latest(entityType, entityID)
+	if err != nil {
+		return err
+	}
+
+	revision := models.Revision{
+		EntityType: entityType,
+		EntityID:   entityID,
+		Version:    latest + 1,
+		Note:       note,
+		Snapshot:   string(data),
+	}
+	if authorID != 0 {
+		revision.AuthorID = &authorID
+	}
+	// Dua perubahan bersamaan bisa mendapat versi yang sama; yang kalah diulang pemanggil
+	if err := r.db.Create(&revision).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return ErrVersionTaken
+		}
+		return err
+	}
+	return nil
+}
+
+func (r *gormRevisions) Latest(entityType string, entityID uint) (int, error) {
+	var latest int
+	err := r.db.Model(&models.Revision{}).
+		Where("entity_type = ? AND entity_id = ?", entityType, entityID).
+		Select("COALESCE(MAX(version), 0)").Scan(&latest).Error
+	return latest, err
+}
+
+func (r *gormRevisions) List(entityType string, entityID uint) ([]models.Revision, error) {
+	var revisions []models.Revision
+	err := r.db.Select("id", "created_at", "entity_type", "entity_id", "version", "author_id", "note").
+		Where("entity_type = ? AND entity_id = ?", entityType, entityID).
+		Order("version DESC").Find(&revisions).Error
+	return revisions, err
+}
+
+func (r *gormRevisions) Find(entityType string, entityID uint, version int) (*models.Revision, error) {
+	var revision models.Revision
+	if err := r.db.Where("entity_type = ? AND entity_id = ? AND version = ?", entityType, entityID, version).
+		First(&revision).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &revision, nil
+}
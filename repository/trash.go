@@ -0,0 +1,292 @@
+package repository
+
+import (
+	"backend-go/models"
+	"backend-go/tenancy"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// trashTable - A table whose rows go to the trash, and the table owning them
+type trashTable struct {
+	entityType string
+	table      string
+	// name - Column shown as the name of a row
+	name         string
+	parentType   string
+	parentColumn string
+}
+
+// trashTables - Every trashable table, owned tables before their owners
+var trashTables = []trashTable{
+	{entityType: models.AuditAnswer, table: "answers", name: "content", parentType: models.AuditQuiz, parentColumn: "quiz_id"},
+	{entityType: models.AuditAttachment, table: "lesson_attachments", name: "display_name", parentType: models.AuditLesson, parentColumn: "lesson_id"},
+	{entityType: models.AuditQuiz, table: "quizzes", name: "name", parentType: models.AuditCourse, parentColumn: "course_id"},
+	{entityType: models.AuditLesson, table: "lessons", name: "name", parentType: models.AuditCourse, parentColumn: "course_id"},
+	{entityType: models.AuditCourse, table: "courses", name: "name"},
+}
+
+func trashTableOf(entityType string) (trashTable, bool) {
+	for _, t := range trashTables {
+		if t.entityType == entityType {
+			return t, true
+		}
+	}
+	return trashTable{}, false
+}
+
+// deleted - SELECT of columns from the soft-deleted rows of t (aliased "t"),
+// adding cascaded and parent_id. A row is cascaded when its parent carries the
+// same deletion ID. where is appended to the WHERE clause.
+func (t trashTable) deleted(columns, where string) string {
+	if t.parentType == "" {
+		return fmt.Sprintf("SELECT %s, 1 = 0 AS cascaded, 0 AS parent_id FROM %s t WHERE t.deleted_at IS NOT NULL%s", columns, t.table, where)
+	}
+	parent, _ := trashTableOf(t.parentType)
+	return fmt.Sprintf(
+		"SELECT %s, p.id IS NOT NULL AS cascaded, COALESCE(t.%s, 0) AS parent_id FROM %s t"+
+			" LEFT JOIN %s p ON p.id = t.%s AND p.deletion_id = t.deletion_id AND t.deletion_id <> ''"+
+			" WHERE t.deleted_at IS NOT NULL%s",
+		columns, t.parentColumn, t.table, parent.table, t.parentColumn, where)
+}
+
+// details - Columns filling a TrashedRow
+func (t trashTable) details() string {
+	return fmt.Sprintf("t.id, t.%s AS name, t.deleted_at, t.deletion_id, t.organization_id", t.name)
+}
+
+type gormTrash struct {
+	db *gorm.DB
+}
+
+// organization - Condition keeping the rows of the context's organization.
+// The plugin does not rewrite raw SQL, so the trash filters itself.
+func (r *gormTrash) organization() string {
+	if id, ok := tenancy.OrganizationID(r.db.Statement.Context); ok {
+		return fmt.Sprintf(" AND t.organization_id = %d", id)
+	}
+	return ""
+}
+
+func (r *gormTrash) Trash(entityType string, id uint, at time.Time, deletionID string) error {
+	return r.trash(entityType, "id", []uint{id}, at, deletionID)
+}
+
+// trash - Soft-delete the live rows of entityType whose column is in ids, then
+// what they own
+func (r *gormTrash) trash(entityType, column string, ids []uint, at time.Time, deletionID string) error {
+	t, ok := trashTableOf(entityType)
+	if !ok {
+		return ErrNotFound
+	}
+
+	var live []uint
+	if err := r.db.Table(t.table).Where(column+" IN ?", ids).Where("deleted_at IS NULL").Pluck("id", &live).Error; err != nil {
+		return err
+	}
+	if len(live) == 0 {
+		return nil
+	}
+	err := r.db.Table(t.table).Where("id IN ?", live).
+		Updates(map[string]interface{}{"deleted_at": at, "deletion_id": deletionID}).Error
+	if err != nil {
+		return err
+	}
+
+	for _, child := range trashTables {
+		if child.parentType != entityType {
+			continue
+		}
+		if err := r.trash(child.entityType, child.parentColumn, live, at, deletionID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *gormTrash) List(filter TrashFilter) ([]TrashedRow, int64, error) {
+	var (
+		parts []string
+		sql   string
+	)
+	for _, t := range trashTables {
+		if filter.Type == "" || filter.Type == t.entityType {
+			parts = append(parts, t.deleted(fmt.Sprintf("'%s' AS type, t.id, t.deleted_at", t.entityType), r.organization()))
+		}
+	}
+	if len(parts) == 0 {
+		return nil, 0, nil
+	}
+	for i, part := range parts {
+		if i > 0 {
+			sql += " UNION ALL "
+		}
+		sql += part
+	}
+	sql = "FROM (" + sql + ") trash"
+	if !filter.Cascaded {
+		sql += " WHERE NOT cascaded"
+	}
+
+	var total int64
+	if err := r.db.Raw("SELECT COUNT(*) " + sql).Scan(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var keys []TrashedRow
+	err := r.db.Raw("SELECT type, id "+sql+" ORDER BY deleted_at DESC, type, id LIMIT ? OFFSET ?", filter.Limit, filter.Offset).
+		Scan(&keys).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Daftar di atas hanya kunci dan urutan; detail dibaca per tabel
+	ids := map[string][]uint{}
+	for _, key := range keys {
+		ids[key.Type] = append(ids[key.Type], key.ID)
+	}
+	found := map[string]map[uint]TrashedRow{}
+	for entityType, list := range ids {
+		rows, err := r.load(entityType, list)
+		if err != nil {
+			return nil, 0, err
+		}
+		found[entityType] = map[uint]TrashedRow{}
+		for _, row := range rows {
+			found[entityType][row.ID] = row
+		}
+	}
+
+	rows := make([]TrashedRow, 0, len(keys))
+	for _, key := range keys {
+		if row, ok := found[key.Type][key.ID]; ok {
+			rows = append(rows, row)
+		}
+	}
+	return rows, total, nil
+}
+
+func (r *gormTrash) Find(entityType string, id uint) (*TrashedRow, error) {
+	rows, err := r.load(entityType, []uint{id})
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, ErrNotFound
+	}
+	return &rows[0], nil
+}
+
+// load - The soft-deleted rows of entityType with ids
+func (r *gormTrash) load(entityType string, ids []uint) ([]TrashedRow, error) {
+	t, ok := trashTableOf(entityType)
+	if !ok {
+		return nil, ErrNotFound
+	}
+	var rows []TrashedRow
+	if err := r.db.Raw(t.deleted(t.details(), r.organization())+" AND t.id IN ?", ids).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	for i := range rows {
+		rows[i].Type = entityType
+	}
+	return rows, nil
+}
+
+func (r *gormTrash) ParentTrashed(row *TrashedRow) (bool, error) {
+	t, ok := trashTableOf(row.Type)
+	if !ok || t.parentType == "" || row.ParentID == 0 {
+		return false, nil
+	}
+	parent, _ := trashTableOf(t.parentType)
+	return exists(r.db.Table(parent.table).Where("id = ? AND deleted_at IS NOT NULL", row.ParentID))
+}
+
+func (r *gormTrash) Restore(row *TrashedRow) (int64, error) {
+	restore := map[string]interface{}{"deleted_at": nil, "deletion_id": ""}
+	if row.DeletionID == "" {
+		t, ok := trashTableOf(row.Type)
+		if !ok {
+			return 0, ErrNotFound
+		}
+		res := r.db.Table(t.table).Where("id = ? AND deleted_at IS NOT NULL", row.ID).Updates(restore)
+		return res.RowsAffected, res.Error
+	}
+
+	var restored int64
+	for _, t := range trashTables {
+		res := r.db.Table(t.table).Where("deletion_id = ? AND deleted_at IS NOT NULL", row.DeletionID).Updates(restore)
+		if res.Error != nil {
+			return restored, res.Error
+		}
+		restored += res.RowsAffected
+	}
+	return restored, nil
+}
+
+func (r *gormTrash) ListExpired(before time.Time, limit int) ([]TrashedRow, error) {
+	var rows []TrashedRow
+	for _, t := range trashTables {
+		if len(rows) >= limit {
+			break
+		}
+		var batch []TrashedRow
+		err := r.db.Raw(t.deleted(t.details(), r.organization())+" AND t.deleted_at < ? ORDER BY t.id LIMIT ?", before, limit-len(rows)).
+			Scan(&batch).Error
+		if err != nil {
+			return nil, err
+		}
+		for i := range batch {
+			batch[i].Type = t.entityType
+		}
+		rows = append(rows, batch...)
+	}
+	return rows, nil
+}
+
+func (r *gormTrash) Purge(row *TrashedRow) ([]string, error) {
+	t, ok := trashTableOf(row.Type)
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	// File di storage hanya dikenal lewat baris yang akan dihapus
+	var keys []string
+	switch row.Type {
+	case models.AuditAttachment:
+		if err := r.db.Table(t.table).Where("id = ?", row.ID).Pluck("storage_key", &keys).Error; err != nil {
+			return nil, err
+		}
+	case models.AuditLesson:
+		var lesson models.Lesson
+		if err := r.db.Unscoped().Select("id", "image", "video_key").First(&lesson, row.ID).Error; err != nil {
+			return nil, translate(err)
+		}
+		keys = append(keys, lesson.Image, lesson.VideoKey)
+		// Attachment dan upload ikut terhapus oleh foreign key lessons
+		for _, owned := range []string{"lesson_attachments", "video_uploads"} {
+			var ownedKeys []string
+			if err := r.db.Table(owned).Where("lesson_id = ?", row.ID).Pluck("storage_key", &ownedKeys).Error; err != nil {
+				return nil, err
+			}
+			keys = append(keys, ownedKeys...)
+		}
+	}
+
+	res := r.db.Exec(fmt.Sprintf("DELETE FROM %s WHERE id = ? AND deleted_at IS NOT NULL", t.table), row.ID)
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return nil, ErrNotFound
+	}
+
+	files := keys[:0]
+	for _, key := range keys {
+		if key != "" {
+			files = append(files, key)
+		}
+	}
+	return files, nil
+}
@@ -0,0 +1,286 @@
+package repository
+
+import (
+	"backend-go/models"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type gormUsers struct {
+	db *gorm.DB
+}
+
+func (r *gormUsers) FindByID(id uint) (*models.User, error) {
+	var user models.User
+	if err := r.db.First(&user, id).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &user, nil
+}
+
+func (r *gormUsers) FindByEmail(email string) (*models.User, error) {
+	var user models.User
+	if err := r.db.Where("email = ?", email).First(&user).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &user, nil
+}
+
+func (r *gormUsers) ExistsByEmail(email string) (bool, error) {
+	return exists(r.db.Model(&models.User{}).Where("email = ?", email))
+}
+
+func (r *gormUsers) ExistsByUsername(username string) (bool, error) {
+	return exists(r.db.Model(&models.User{}).Where("username = ?", username))
+}
+
+func (r *gormUsers) Create(user *models.User) error {
+	return r.db.Create(user).Error
+}
+
+func (r *gormUsers) IncrementFailedLogins(id uint) (int, error) {
+	if err := r.db.Model(&models.User{}).Where("id = ?", id).
+		UpdateColumn("failed_logins", gorm.Expr("failed_logins + 1")).Error; err != nil {
+		return 0, err
+	}
+	var failures int
+	err := r.db.Model(&models.User{}).Where("id = ?", id).Pluck("failed_logins", &failures).Error
+	return failures, err
+}
+
+func (r *gormUsers) LockUntil(id uint, until time.Time) error {
+	return r.db.Model(&models.User{}).Where("id = ?", id).UpdateColumn("locked_until", until).Error
+}
+
+func (r *gormUsers) ResetFailedLogins(id uint) error {
+	return r.db.Model(&models.User{}).Where("id = ?", id).
+		UpdateColumns(map[string]interface{}{"failed_logins": 0, "locked_until": nil}).Error
+}
+
+func (r *gormUsers) SetTOTPSecret(id uint, secret string) error {
+	return r.db.Model(&models.User{}).Where("id = ?", id).UpdateColumn("totp_secret", secret).Error
+}
+
+func (r *gormUsers) EnableTOTP(id uint, at time.Time, step int64) error {
+	return r.db.Model(&models.User{}).Where("id = ?", id).
+		UpdateColumns(map[string]interface{}{"totp_enabled_at": at, "totp_last_step": step}).Error
+}
+
+func (r *gormUsers) DisableTOTP(id uint) error {
+	if err := r.db.Model(&models.User{}).Where("id = ?", id).
+		UpdateColumns(map[string]interface{}{"totp_secret": "", "totp_enabled_at": nil, "totp_last_step": 0}).Error; err != nil {
+		return err
+	}
+	return r.db.Unscoped().Where("user_id = ?", id).Delete(&models.RecoveryCode{}).Error
+}
+
+func (r *gormUsers) UseTOTPStep(id uint, step int64) (bool, error) {
+	res := r.db.Model(&models.User{}).Where("id = ? AND totp_last_step < ?", id, step).
+		UpdateColumn("totp_last_step", step)
+	return res.RowsAffected == 1, res.Error
+}
+
+func (r *gormUsers) ReplaceRecoveryCodes(userID uint, hashes []string) error {
+	if err := r.db.Unscoped().Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+		return err
+	}
+	codes := make([]models.RecoveryCode, len(hashes))
+	for i, hash := range hashes {
+		codes[i] = models.RecoveryCode{UserID: userID, CodeHash: hash}
+	}
+	return r.db.Create(&codes).Error
+}
+
+func (r *gormUsers) UseRecoveryCode(userID uint, hash string) (bool, error) {
+	res := r.db.Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, hash).
+		UpdateColumn("used_at", time.Now())
+	return res.RowsAffected == 1, res.Error
+}
+
+func (r *gormUsers) CountRecoveryCodes(userID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.RecoveryCode{}).Where("user_id = ? AND used_at IS NULL", userID).Count(&count).Error
+	return count, err
+}
+
+func (r *gormUsers) FindIdentity(provider, subject string) (*models.Identity, error) {
+	var identity models.Identity
+	if err := r.db.Where("provider = ? AND subject = ?", provider, subject).First(&identity).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &identity, nil
+}
+
+func (r *gormUsers) CreateIdentity(identity *models.Identity) error {
+	return r.db.Create(identity).Error
+}
+
+func (r *gormUsers) UpdateIdentityEmail(id uint, email string) error {
+	return r.db.Model(&models.Identity{}).Where("id = ?", id).UpdateColumn("email", email).Error
+}
+
+func (r *gormUsers) ListIdentities(userID uint) ([]models.Identity, error) {
+	var identities []models.Identity
+	err := r.db.Where("user_id = ?", userID).Order("id").Find(&identities).Error
+	return identities, err
+}
+
+func (r *gormUsers) SetPassword(id uint, hash string) error {
+	return r.db.Model(&models.User{}).Where("id = ?", id).UpdateColumn("password", hash).Error
+}
+
+func (r *gormUsers) RevokeSessions(id uint, at time.Time) error {
+	return r.db.Model(&models.User{}).Where("id = ?", id).UpdateColumn("sessions_valid_after", at).Error
+}
+
+func (r *gormUsers) SetPendingEmail(id uint, email, tokenHash string, expiresAt time.Time) error {
+	return r.db.Model(&models.User{}).Where("id = ?", id).UpdateColumns(map[string]interface{}{
+		"pending_email": email, "email_change_hash": tokenHash, "email_change_expires_at": expiresAt,
+	}).Error
+}
+
+func (r *gormUsers) FindByEmailChangeHash(hash string) (*models.User, error) {
+	var user models.User
+	if err := r.db.Where("email_change_hash = ?", hash).First(&user).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &user, nil
+}
+
+func (r *gormUsers) ConfirmEmail(id uint, email string) error {
+	return r.db.Model(&models.User{}).Where("id = ?", id).UpdateColumns(map[string]interface{}{
+		"email": email, "pending_email": "", "email_change_hash": "", "email_change_expires_at": nil,
+	}).Error
+}
+
+func (r *gormUsers) ScheduleDeletion(id uint, at time.Time) error {
+	return r.db.Model(&models.User{}).Where("id = ?", id).UpdateColumn("deletion_scheduled_at", at).Error
+}
+
+func (r *gormUsers) CancelDeletion(id uint) error {
+	return r.db.Model(&models.User{}).Where("id = ?", id).UpdateColumn("deletion_scheduled_at", nil).Error
+}
+
+func (r *gormUsers) ListDueForDeletion(now time.Time) ([]models.User, error) {
+	var users []models.User
+	err := r.db.Where("deletion_scheduled_at <= ?", now).Find(&users).Error
+	return users, err
+}
+
+func (r *gormUsers) Anonymize(user *models.User, at time.Time) error {
+	// Data yang hanya berarti untuk akun aktif dihapus permanen
+	for _, model := range []interface{}{
+		&models.Profile{}, &models.Enrollment{}, &models.PlaybackPosition{},
+		&models.Identity{}, &models.APIKey{}, &models.RecoveryCode{}, &models.DataExport{},
+	} {
+		if err := r.db.Unscoped().Where("user_id = ?", user.ID).Delete(model).Error; err != nil {
+			return err
+		}
+	}
+
+	tombstone := fmt.Sprintf("deleted-%d", user.ID)
+	return r.db.Model(&models.User{}).Where("id = ?", user.ID).UpdateColumns(map[string]interface{}{
+		"email":                     tombstone + "@deleted.invalid",
+		"username":                  tombstone,
+		"password":                  "",
+		"totp_secret":               "",
+		"totp_enabled_at":           nil,
+		"pending_email":             "",
+		"email_change_hash":         "",
+		"email_change_expires_at":   nil,
+		"deletion_scheduled_at":     nil,
+		"password_reset_hash":       "",
+		"password_reset_expires_at": nil,
+		"sessions_valid_after":      at,
+		"deleted_at":                at,
+	}).Error
+}
+
+func (r *gormUsers) Search(filter UserFilter) ([]models.User, int64, error) {
+	q := r.db.Model(&models.User{})
+	if filter.Query != "" {
+		pattern := "%" + likeEscaper.Replace(strings.ToLower(filter.Query)) + "%"
+		q = q.Where(`LOWER(email) LIKE ? ESCAPE '\' OR LOWER(username) LIKE ? ESCAPE '\'`, pattern, pattern)
+	}
+	if filter.Role != "" {
+		q = q.Where("roles = ?", filter.Role)
+	}
+	if filter.Suspended != nil {
+		if *filter.Suspended {
+			q = q.Where("suspended_at IS NOT NULL")
+		} else {
+			q = q.Where("suspended_at IS NULL")
+		}
+	}
+
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var users []models.User
+	err := q.Order("id DESC").Offset(filter.Offset).Limit(filter.Limit).Find(&users).Error
+	return users, total, err
+}
+
+// likeEscaper - Search text is matched literally, not as a LIKE pattern
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+func (r *gormUsers) SetRole(id uint, role string) error {
+	return r.db.Model(&models.User{}).Where("id = ?", id).UpdateColumn("roles", role).Error
+}
+
+func (r *gormUsers) Suspend(id uint, at time.Time, reason string) error {
+	return r.db.Model(&models.User{}).Where("id = ?", id).UpdateColumns(map[string]interface{}{
+		"suspended_at": at, "suspension_reason": reason,
+	}).Error
+}
+
+func (r *gormUsers) Unsuspend(id uint) error {
+	return r.db.Model(&models.User{}).Where("id = ?", id).UpdateColumns(map[string]interface{}{
+		"suspended_at": nil, "suspension_reason": "",
+	}).Error
+}
+
+func (r *gormUsers) RequirePasswordReset(id uint, tokenHash string, expiresAt time.Time) error {
+	return r.db.Model(&models.User{}).Where("id = ?", id).UpdateColumns(map[string]interface{}{
+		"password": "", "password_reset_hash": tokenHash, "password_reset_expires_at": expiresAt,
+	}).Error
+}
+
+func (r *gormUsers) FindByPasswordResetHash(hash string) (*models.User, error) {
+	var user models.User
+	if err := r.db.Where("password_reset_hash = ?", hash).First(&user).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &user, nil
+}
+
+func (r *gormUsers) CompletePasswordReset(id uint, passwordHash string) error {
+	return r.db.Model(&models.User{}).Where("id = ?", id).UpdateColumns(map[string]interface{}{
+		"password": passwordHash, "password_reset_hash": "", "password_reset_expires_at": nil,
+	}).Error
+}
+
+func (r *gormUsers) FindProfile(userID uint) (*models.Profile, error) {
+	var profile models.Profile
+	if err := r.db.Where("user_id = ?", userID).First(&profile).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &profile, nil
+}
+
+func (r *gormUsers) CreateProfile(profile *models.Profile) error {
+	return r.db.Create(profile).Error
+}
+
+func (r *gormUsers) UpdateProfile(profile *models.Profile, changes models.Profile) error {
+	return r.db.Model(profile).Updates(changes).Error
+}
+
+func (r *gormUsers) DeleteProfile(profile *models.Profile) error {
+	return r.db.Delete(profile).Error
+}
@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"backend-go/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type gormVideos struct {
+	db *gorm.DB
+}
+
+func (r *gormVideos) CreateUpload(upload *models.VideoUpload) error {
+	return r.db.Create(upload).Error
+}
+
+func (r *gormVideos) FindUpload(id uint) (*models.VideoUpload, error) {
+	var upload models.VideoUpload
+	if err := r.db.First(&upload, id).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &upload, nil
+}
+
+func (r *gormVideos) SetUploadKey(id uint, key string) error {
+	return r.db.Model(&models.VideoUpload{}).Where("id = ?", id).Update("storage_key", key).Error
+}
+
+func (r *gormVideos) AdvanceUploadOffset(id uint, from, to int64) (bool, error) {
+	res := r.db.Model(&models.VideoUpload{}).Where(`id = ? AND "offset" = ?`, id, from).Update("offset", to)
+	return res.RowsAffected == 1, res.Error
+}
+
+func (r *gormVideos) CompleteUpload(id uint, key string) error {
+	return r.db.Model(&models.VideoUpload{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"completed": true, "storage_key": key}).Error
+}
+
+func (r *gormVideos) DeleteUpload(upload *models.VideoUpload) error {
+	return r.db.Delete(upload).Error
+}
+
+func (r *gormVideos) FindPlayback(userID, lessonID uint) (*models.PlaybackPosition, error) {
+	var position models.PlaybackPosition
+	if err := r.db.Where("user_id = ? AND lesson_id = ?", userID, lessonID).First(&position).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &position, nil
+}
+
+func (r *gormVideos) SavePlayback(position *models.PlaybackPosition) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "lesson_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"position", "duration", "updated_at"}),
+	}).Create(position).Error
+}
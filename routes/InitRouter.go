@@ -1,70 +1,98 @@
 package routes
 
 import (
+	"backend-go/apperr"
 	"backend-go/controllers"
+	"backend-go/docs"
+	"backend-go/metrics"
 	"backend-go/middleware"
+	"backend-go/repository"
+	"backend-go/services"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
-func InitRouter(r *gin.Engine) {
+// handlers - Controllers shared by every API version
+type handlers struct {
+	access      *middleware.Access
+	auth        *controllers.AuthHandler
+	mfa         *controllers.MFAHandler
+	apiKeys     *controllers.APIKeyHandler
+	accounts    *controllers.AccountHandler
+	exports     *controllers.ExportHandler
+	users       *controllers.UserAdminHandler
+	audit       *controllers.AuditHandler
+	trash       *controllers.TrashHandler
+	orgs        *controllers.OrganizationHandler
+	profiles    *controllers.ProfileHandler
+	courses     *controllers.CourseHandler
+	enrollments *controllers.EnrollmentHandler
+	lessons     *controllers.LessonHandler
+	quizzes     *controllers.QuizHandler
+	revisions   *controllers.RevisionHandler
+	attachments *controllers.AttachmentHandler
+	videos      *controllers.VideoHandler
+}
 
-	r.Static("/uploads", "./public/uploads")
+func newHandlers(db *gorm.DB) *handlers {
+	store := repository.NewStore(db)
+	return &handlers{
+		access:      middleware.NewAccess(store),
+		auth:        controllers.NewAuthHandler(services.NewAuthService(store)),
+		mfa:         controllers.NewMFAHandler(services.NewMFAService(store)),
+		apiKeys:     controllers.NewAPIKeyHandler(services.NewAPIKeyService(store)),
+		accounts:    controllers.NewAccountHandler(services.NewAccountService(store)),
+		exports:     controllers.NewExportHandler(services.NewExportService(store)),
+		users:       controllers.NewUserAdminHandler(services.NewUserAdminService(store)),
+		audit:       controllers.NewAuditHandler(services.NewAuditService(store)),
+		trash:       controllers.NewTrashHandler(services.NewTrashService(store)),
+		orgs:        controllers.NewOrganizationHandler(services.NewOrganizationService(store)),
+		profiles:    controllers.NewProfileHandler(services.NewProfileService(store)),
+		courses:     controllers.NewCourseHandler(services.NewCourseService(store)),
+		enrollments: controllers.NewEnrollmentHandler(services.NewEnrollmentService(store)),
+		lessons:     controllers.NewLessonHandler(services.NewLessonService(store)),
+		quizzes:     controllers.NewQuizHandler(services.NewQuizService(store)),
+		revisions:   controllers.NewRevisionHandler(services.NewRevisionService(store)),
+		attachments: controllers.NewAttachmentHandler(services.NewAttachmentService(store)),
+		videos:      controllers.NewVideoHandler(services.NewVideoService(store)),
+	}
+}
 
-	//auth
-	r.POST("/register", controllers.Register)
-	r.POST("/login", controllers.Login)
+func InitRouter(r *gin.Engine, db *gorm.DB) {
+	// Semua error dari handler dan middleware dirender di sini
+	r.Use(middleware.ErrorHandler)
+	r.NoRoute(func(c *gin.Context) { apperr.Abort(c, apperr.ErrNotFound) })
 
-	//profile
-	r.POST("/profile", middleware.IsLogin, controllers.CreateProfile)
-	r.GET("/profile", middleware.IsLogin, controllers.GetProfile)
-	r.PUT("/profile", middleware.IsLogin, controllers.UpdateProfile)
-	r.DELETE("/profile", middleware.IsLogin, controllers.DeleteProfile)
+	h := newHandlers(db)
 
-	//course
-	r.POST("/course", middleware.IsLogin, middleware.IsAdmin, controllers.CreateCourse)
-	r.GET("/courses", middleware.IsLogin, controllers.GetCourses)
-	r.GET("/course/:id", middleware.IsLogin, controllers.GetCourseByID)
-	r.GET("/course/:id/students", controllers.GetStudentsInCourse)
-	r.GET("course/:id/lessons", middleware.IsEnrolled, controllers.GetLessonsInCourse)
-	r.GET("/course/:id/quizzes", middleware.IsEnrolled, controllers.GetQuizzesByCourseID)
-	r.PUT("/course/:id", middleware.IsLogin, middleware.IsAdmin, controllers.UpdateCourse)
-	r.DELETE("/course/:id", middleware.IsLogin, middleware.IsAdmin, controllers.DeleteCourse)
+	//media - tidak berversi, URL-nya tersimpan di data (image, konten lesson).
+	//Tanpa Tenant karena <img>/<video> tidak bisa mengirim header X-Organization;
+	//URL bertanda tangan berlaku per baris, Bearer dibatasi ke organisasi user.
+	media := r.Group("", middleware.LargeTransfer(0))
+	media.GET("/uploads/:file", controllers.ServePublicUpload)
+	media.HEAD("/uploads/:file", controllers.ServePublicUpload)
+	media.GET("/media/lesson/:id", h.access.CanAccessLessonMedia, controllers.ServeLessonMedia)
+	media.GET("/media/attachment/:id", h.access.CanAccessAttachment, controllers.DownloadAttachment)
+	media.GET("/media/lesson/:id/video", h.access.CanAccessLessonMedia, controllers.StreamLessonVideo)
+	media.HEAD("/media/lesson/:id/video", h.access.CanAccessLessonMedia, controllers.StreamLessonVideo)
+	media.GET("/media/export/:id", h.access.CanDownloadExport, controllers.DownloadExport)
 
-	//enrollment
-	r.POST("/enroll/:id", middleware.IsLogin, controllers.EnrollCourse)
-	r.DELETE("/enroll/:id", middleware.IsLogin, controllers.UnenrollCourse)
-	r.GET("/enrollments", middleware.IsLogin, controllers.GetEnrollments)
+	//ops
+	r.GET("/healthz", controllers.Healthz)
+	r.GET("/readyz", controllers.Readyz)
+	r.GET("/metrics", metrics.Handler())
 
-	//lesson
-	r.POST("/lesson", middleware.IsLogin, middleware.IsAdmin, controllers.CreateLesson)
-	r.GET("/lessons", middleware.IsEnrolled, controllers.GetLessons)
-	r.GET("/lesson/:id", middleware.IsEnrolled, controllers.GetLessonByID)
-	r.PUT("/lesson/:id", middleware.IsLogin, middleware.IsAdmin, controllers.UpdateLesson)
-	r.DELETE("/lesson/:id", middleware.IsLogin, middleware.IsAdmin, controllers.DeleteLesson)
+	//docs
+	r.GET("/openapi.json", docs.ServeSpec)
+	r.GET("/docs", docs.ServeUI)
+	r.GET("/docs/assets/:file", docs.ServeUIAsset)
 
-	//quiz
-	r.POST("/quiz", middleware.IsLogin, middleware.IsAdmin, controllers.CreateQuiz)
-	r.GET("/quizzes", middleware.IsEnrolled, controllers.GetQuizzes)
-	r.GET("/quiz/:id", middleware.IsEnrolled, controllers.GetQuizByID)
-	r.PUT("/quiz/:id", middleware.IsLogin, middleware.IsAdmin, controllers.UpdateQuiz)
-	r.DELETE("/quiz/:id", middleware.IsLogin, middleware.IsAdmin, controllers.DeleteQuiz)
+	//api - setiap request dibatasi ke satu organisasi
+	limit := middleware.RateLimit(middleware.ScopeAPI, middleware.ByUserOrIP)
+	registerV1(r.Group("/api/v1", limit, h.access.Tenant), h)
+	registerV2(r.Group("/api/v2", limit, h.access.Tenant), h)
 
-	//answer
-	r.POST("/answer", middleware.IsLogin, controllers.CreateAnswer)
-	r.GET("/answers/:id/question", middleware.IsEnrolled, controllers.GetAnswersByQuizID)
-	r.GET("/answer/:id", middleware.IsEnrolled, controllers.GetAnswerByID)
-	r.PUT("/answer/:id", middleware.IsLogin, middleware.IsAdmin, controllers.UpdateAnswer)
-	r.DELETE("/answer/:id", middleware.IsLogin, middleware.IsAdmin, controllers.DeleteAnswer)
-
-	//test
-	r.GET("/protected", middleware.IsLogin, middleware.IsAdmin, func(ctx *gin.Context) {
-		ctx.JSON(200, gin.H{
-			"message": "Hello World",
-			"data": gin.H{ // Gunakan gin.H untuk nested map
-				"user_id": ctx.GetInt("userId"),
-				"role":    ctx.GetString("role"),
-			},
-		})
-	})
-}
\ No newline at end of file
+	//legacy - rute lama tanpa prefix untuk klien mobile yang belum update
+	registerV1(r.Group("", middleware.Deprecated(LegacyDeprecation), limit, h.access.Tenant), h)
+}
@@ -0,0 +1,148 @@
+package routes_test
+
+import (
+	"backend-go/apikeys"
+	"backend-go/models"
+	"backend-go/repository"
+	"backend-go/services"
+	"backend-go/testutil"
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func login(t *testing.T, h *testutil.Harness, email, password string) string {
+	t.Helper()
+
+	var body struct {
+		Token string `json:"token"`
+	}
+	h.Expect(http.StatusOK, "POST", "/api/v1/login", map[string]string{"email": email, "password": password}, "").JSON(t, &body)
+	return body.Token
+}
+
+func TestChangePassword(t *testing.T) {
+	h := testutil.New(t)
+	box := testutil.NewMailbox(t)
+	user := h.CreateUser("user")
+	other := login(t, h, user.Email, testutil.Password)
+
+	var body errorBody
+	h.Expect(http.StatusForbidden, "PUT", "/api/v1/account/password", map[string]string{
+		"current_password": "wrong", "new_password": "n3w-secret",
+	}, h.Token(user)).JSON(t, &body)
+	if body.Error.Code != "wrong_password" {
+		t.Errorf("wrong current password: %s", body.Error.Code)
+	}
+
+	// Token iat berpresisi milidetik; beri jeda supaya token lama jelas lebih tua
+	time.Sleep(2 * time.Millisecond)
+	var changed struct {
+		Token string `json:"token"`
+	}
+	h.Expect(http.StatusOK, "PUT", "/api/v1/account/password", map[string]string{
+		"current_password": testutil.Password, "new_password": "n3w-secret",
+	}, h.Token(user)).JSON(t, &changed)
+
+	h.Expect(http.StatusUnauthorized, "GET", "/api/v1/account", nil, other).JSON(t, &body)
+	if body.Error.Code != "session_revoked" {
+		t.Errorf("old session: %s", body.Error.Code)
+	}
+	h.Expect(http.StatusOK, "GET", "/api/v1/account", nil, changed.Token)
+
+	h.Expect(http.StatusUnauthorized, "POST", "/api/v1/login", map[string]string{"email": user.Email, "password": testutil.Password}, "")
+	login(t, h, user.Email, "n3w-secret")
+	if len(box.To(user.Email)) != 1 {
+		t.Errorf("notices = %+v", box.To(user.Email))
+	}
+}
+
+func TestChangeEmail(t *testing.T) {
+	h := testutil.New(t)
+	box := testutil.NewMailbox(t)
+	user := h.CreateUser("user")
+	taken := h.CreateUser("user")
+	token := h.Token(user)
+
+	h.Expect(http.StatusConflict, "POST", "/api/v1/account/email", map[string]string{"email": taken.Email, "password": testutil.Password}, token)
+	h.Expect(http.StatusAccepted, "POST", "/api/v1/account/email", map[string]string{"email": "new@example.com", "password": testutil.Password}, token)
+
+	// Alamat belum berubah sampai link diikuti
+	var account struct {
+		Data services.Account `json:"data"`
+	}
+	h.Expect(http.StatusOK, "GET", "/api/v1/account", nil, token).JSON(t, &account)
+	if account.Data.Email != user.Email || account.Data.PendingEmail != "new@example.com" {
+		t.Fatalf("account before confirming = %+v", account.Data)
+	}
+
+	sent := box.To("new@example.com")
+	if len(sent) != 1 {
+		t.Fatalf("confirmation mails = %+v", sent)
+	}
+	lines := strings.Split(strings.TrimSpace(strings.Split(sent[0].Body, "\n\n")[1]), "\n")
+	confirmToken := lines[0]
+
+	h.Expect(http.StatusBadRequest, "POST", "/api/v1/account/email/confirm", map[string]string{"token": "nope"}, "")
+	h.Expect(http.StatusOK, "POST", "/api/v1/account/email/confirm", map[string]string{"token": confirmToken}, "")
+	h.Expect(http.StatusBadRequest, "POST", "/api/v1/account/email/confirm", map[string]string{"token": confirmToken}, "")
+
+	login(t, h, "new@example.com", testutil.Password)
+	if len(box.To(user.Email)) != 1 {
+		t.Errorf("the old address was not told about the change")
+	}
+}
+
+func TestDeleteAccount(t *testing.T) {
+	h := testutil.New(t)
+	testutil.NewMailbox(t)
+	admin := h.CreateUser("admin")
+	user := h.CreateUser("user")
+	course := h.CreateCourse(admin)
+	quiz, answers := h.CreateQuiz(course, "a")
+	h.Enroll(user, course)
+	token := h.Token(user)
+
+	h.Expect(http.StatusCreated, "POST", fmt.Sprintf("/api/v1/quiz/%d/submit", quiz.ID), map[string]interface{}{"answer_ids": []uint{answers[0].ID}}, token)
+	_, key := createAPIKey(t, h, token, map[string]interface{}{"name": "ci", "scopes": []string{apikeys.ScopeCoursesRead}})
+
+	h.Expect(http.StatusForbidden, "DELETE", "/api/v1/account", map[string]string{"password": "wrong"}, token)
+	h.Expect(http.StatusAccepted, "DELETE", "/api/v1/account", map[string]string{"password": testutil.Password}, token)
+	h.Expect(http.StatusUnauthorized, "GET", "/api/v1/courses", nil, token)
+	h.Expect(http.StatusUnauthorized, "GET", "/api/v1/courses", nil, key)
+
+	// Login selama masa tenggang membatalkan penghapusan
+	fresh := login(t, h, user.Email, testutil.Password)
+	h.Expect(http.StatusOK, "GET", "/api/v1/courses", nil, key)
+
+	time.Sleep(2 * time.Millisecond)
+	h.Expect(http.StatusAccepted, "DELETE", "/api/v1/account", map[string]string{"password": testutil.Password}, fresh)
+	accounts := services.NewAccountService(repository.NewStore(h.DB))
+	if n, _ := accounts.PurgeDue(context.Background(), time.Now()); n != 0 {
+		t.Fatalf("purged %d accounts inside the grace period", n)
+	}
+	if n, err := accounts.PurgeDue(context.Background(), time.Now().Add(31*24*time.Hour)); n != 1 || err != nil {
+		t.Fatalf("purge: %d %v", n, err)
+	}
+
+	var gone models.User
+	h.DB.Unscoped().First(&gone, user.ID)
+	if !gone.DeletedAt.Valid || gone.Email == user.Email || gone.Username == user.Username {
+		t.Errorf("deleted user = %+v", gone)
+	}
+	var enrollments, attempts, keys int64
+	h.DB.Unscoped().Model(&models.Enrollment{}).Where("user_id = ?", user.ID).Count(&enrollments)
+	h.DB.Model(&models.UserQuiz{}).Where("user_id = ?", user.ID).Count(&attempts)
+	h.DB.Unscoped().Model(&models.APIKey{}).Where("user_id = ?", user.ID).Count(&keys)
+	if enrollments != 0 || keys != 0 || attempts != 1 {
+		t.Errorf("enrollments=%d keys=%d attempts=%d, want 0, 0 and the anonymized attempt", enrollments, keys, attempts)
+	}
+
+	// Alamat email bisa dipakai lagi untuk akun baru
+	h.Expect(http.StatusOK, "POST", "/api/v1/register", map[string]string{
+		"email": user.Email, "username": user.Username, "password": "again",
+	}, "")
+}
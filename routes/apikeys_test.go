@@ -0,0 +1,121 @@
+package routes_test
+
+import (
+	"backend-go/apikeys"
+	"backend-go/models"
+	"backend-go/testutil"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// createAPIKey - Create a key for the user behind token and return its id and secret
+func createAPIKey(t *testing.T, h *testutil.Harness, token string, body map[string]interface{}) (uint, string) {
+	t.Helper()
+
+	var created struct {
+		Key  string        `json:"key"`
+		Data models.APIKey `json:"data"`
+	}
+	h.Expect(http.StatusCreated, "POST", "/api/v1/api-keys", body, token).JSON(t, &created)
+	if !strings.HasPrefix(created.Key, created.Data.Prefix+"_") || created.Data.ID == 0 {
+		t.Fatalf("create returned key %q for %+v", created.Key, created.Data)
+	}
+	return created.Data.ID, created.Key
+}
+
+func TestAPIKeyScopes(t *testing.T) {
+	h := testutil.New(t)
+	admin := h.CreateUser("admin")
+	user := h.CreateUser("user")
+	course := h.CreateCourse(admin)
+	quiz, answers := h.CreateQuiz(course, "a", "b")
+	h.Enroll(user, course)
+	token := h.Token(user)
+
+	_, key := createAPIKey(t, h, token, map[string]interface{}{
+		"name": "grades export", "scopes": []string{apikeys.ScopeGradesRead, apikeys.ScopeCoursesRead},
+	})
+
+	h.Expect(http.StatusOK, "GET", "/api/v1/courses", nil, key)
+	h.Expect(http.StatusOK, "GET", fmt.Sprintf("/api/v2/course/%d/quizzes", course.ID), nil, key)
+
+	h.Expect(http.StatusCreated, "POST", fmt.Sprintf("/api/v1/quiz/%d/submit", quiz.ID),
+		map[string]interface{}{"answer_ids": []uint{answers[0].ID}}, token)
+	var grades struct {
+		Data []models.UserQuiz `json:"data"`
+	}
+	h.Expect(http.StatusOK, "GET", "/api/v1/grades", nil, key).JSON(t, &grades)
+	if len(grades.Data) != 1 || grades.Data[0].Quiz == nil || grades.Data[0].Quiz.ID != quiz.ID {
+		t.Errorf("grades = %+v", grades.Data)
+	}
+
+	// Scope yang tidak diberikan, dan rute tanpa scope sama sekali
+	var body errorBody
+	h.Expect(http.StatusForbidden, "GET", "/api/v1/enrollments", nil, key).JSON(t, &body)
+	if body.Error.Code != "insufficient_scope" {
+		t.Errorf("enrollments with a grades key: %s", body.Error.Code)
+	}
+	h.Expect(http.StatusForbidden, "GET", "/api/v1/profile", nil, key)
+	h.Expect(http.StatusForbidden, "POST", "/api/v1/api-keys", map[string]interface{}{
+		"name": "escalate", "scopes": []string{apikeys.ScopeEnrollmentsManage},
+	}, key)
+
+	// JWTs keep access to every route
+	h.Expect(http.StatusOK, "GET", "/api/v1/enrollments", nil, token)
+
+	h.Expect(http.StatusUnprocessableEntity, "POST", "/api/v1/api-keys", map[string]interface{}{
+		"name": "bad", "scopes": []string{"courses:write"},
+	}, token)
+	h.Expect(http.StatusUnprocessableEntity, "POST", "/api/v1/api-keys", map[string]interface{}{
+		"name": "old", "scopes": []string{apikeys.ScopeCoursesRead}, "expires_at": time.Now().Add(-time.Hour),
+	}, token)
+}
+
+func TestAPIKeyLifecycle(t *testing.T) {
+	h := testutil.New(t)
+	user := h.CreateUser("user")
+	other := h.CreateUser("user")
+	token := h.Token(user)
+
+	id, key := createAPIKey(t, h, token, map[string]interface{}{
+		"name": "sync script", "scopes": []string{apikeys.ScopeCoursesRead},
+	})
+	h.Expect(http.StatusOK, "GET", "/api/v1/courses", nil, key)
+
+	// Only the hash is stored, and the list never shows the secret
+	var stored models.APIKey
+	h.DB.First(&stored, id)
+	if stored.Hash == "" || strings.Contains(stored.Hash, key) || stored.LastUsedAt == nil {
+		t.Errorf("stored key = %+v", stored)
+	}
+	list := h.Expect(http.StatusOK, "GET", "/api/v1/api-keys", nil, token)
+	if strings.Contains(string(list.Body), key) || !strings.Contains(string(list.Body), stored.Prefix) {
+		t.Errorf("list: %s", list.Body)
+	}
+
+	// Kunci dengan secret yang salah tidak boleh lolos hanya karena prefiksnya cocok
+	forged := key[:len(key)-1] + "x"
+	if forged == key {
+		forged = key[:len(key)-1] + "y"
+	}
+	var body errorBody
+	h.Expect(http.StatusUnauthorized, "GET", "/api/v1/courses", nil, forged).JSON(t, &body)
+	if body.Error.Code != "invalid_api_key" {
+		t.Errorf("forged key: %s", body.Error.Code)
+	}
+
+	h.Expect(http.StatusNotFound, "DELETE", fmt.Sprintf("/api/v1/api-keys/%d", id), nil, h.Token(other))
+	h.Expect(http.StatusOK, "DELETE", fmt.Sprintf("/api/v1/api-keys/%d", id), nil, token)
+	h.Expect(http.StatusUnauthorized, "GET", "/api/v1/courses", nil, key)
+
+	// Expired keys stop working without being revoked
+	expiring, expiringKey := createAPIKey(t, h, token, map[string]interface{}{
+		"name": "temp", "scopes": []string{apikeys.ScopeCoursesRead}, "expires_at": time.Now().Add(time.Hour),
+	})
+	h.Expect(http.StatusOK, "GET", "/api/v1/courses", nil, expiringKey)
+	h.DB.Model(&models.APIKey{}).Where("id = ?", expiring).Update("expires_at", time.Now().Add(-time.Minute))
+	h.Expect(http.StatusUnauthorized, "GET", "/api/v1/courses", nil, expiringKey)
+}
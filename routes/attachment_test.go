@@ -0,0 +1,88 @@
+package routes_test
+
+import (
+	"backend-go/models"
+	"backend-go/testutil"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestAttachmentUploadAndDownload(t *testing.T) {
+	h := testutil.New(t)
+	owner := h.CreateUser("user")
+	student := h.CreateUser("user")
+	outsider := h.CreateUser("user")
+	course := h.CreateCourse(owner)
+	lesson := h.CreateLesson(course, "# Slides")
+	h.Enroll(student, course)
+
+	pdf := []byte("%PDF-1.4\n1 0 obj << /Type /Catalog >> endobj\ntrailer << >>\n%%EOF\n")
+	upload := &testutil.Upload{Field: "file", Name: "week-1.pdf", Content: pdf}
+	path := fmt.Sprintf("/api/v1/lesson/%d/attachments", lesson.ID)
+
+	// Hanya staff course (admin atau pemilik course) yang boleh upload
+	for _, user := range []*models.User{student, outsider} {
+		if res := h.DoForm("POST", path, nil, upload, h.Token(user)); res.Code != http.StatusForbidden {
+			t.Fatalf("upload by non-staff: %d %s", res.Code, res.Body)
+		}
+	}
+	if res := h.DoForm("POST", path, nil, nil, h.Token(owner)); res.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("upload without file: %d %s", res.Code, res.Body)
+	}
+
+	res := h.DoForm("POST", path, map[string]string{"display_name": "Slides"}, upload, h.Token(owner))
+	if res.Code != http.StatusCreated {
+		t.Fatalf("upload: %d %s", res.Code, res.Body)
+	}
+	var created struct {
+		Data models.LessonAttachment `json:"data"`
+	}
+	res.JSON(t, &created)
+	attachment := created.Data
+	sum := sha256.Sum256(pdf)
+	if attachment.DisplayName != "Slides" || attachment.FileName != "week-1.pdf" || attachment.MimeType != "application/pdf" ||
+		attachment.Size != int64(len(pdf)) || attachment.Checksum != hex.EncodeToString(sum[:]) {
+		t.Fatalf("attachment metadata = %+v", attachment)
+	}
+
+	var listed struct {
+		Data []models.LessonAttachment `json:"data"`
+	}
+	h.Expect(http.StatusOK, "GET", path, nil, h.Token(student)).JSON(t, &listed)
+	if len(listed.Data) != 1 || !strings.HasPrefix(listed.Data[0].DownloadURL, fmt.Sprintf("/media/attachment/%d?expires=", attachment.ID)) {
+		t.Fatalf("attachments = %+v", listed.Data)
+	}
+	h.Expect(http.StatusForbidden, "GET", path, nil, h.Token(outsider))
+
+	// Link download bertanda tangan bisa dibuka tanpa token
+	res = h.Expect(http.StatusOK, "GET", listed.Data[0].DownloadURL, nil, "")
+	if string(res.Body) != string(pdf) {
+		t.Fatalf("download body = %q", res.Body)
+	}
+	if got := res.Header.Get("Content-Disposition"); got != `attachment; filename=Slides.pdf` {
+		t.Errorf("Content-Disposition = %q", got)
+	}
+	if got := res.Header.Get("Digest"); got != "sha-256="+attachment.Checksum {
+		t.Errorf("Digest = %q", got)
+	}
+	if res.Header.Get("Content-Type") != "application/pdf" || res.Header.Get("X-Content-Type-Options") != "nosniff" {
+		t.Errorf("download headers = %v", res.Header)
+	}
+
+	download := fmt.Sprintf("/media/attachment/%d", attachment.ID)
+	h.Expect(http.StatusOK, "GET", download, nil, h.Token(student))
+	h.Expect(http.StatusOK, "GET", download, nil, h.Token(owner))
+	h.Expect(http.StatusForbidden, "GET", download, nil, h.Token(outsider))
+	h.Expect(http.StatusUnauthorized, "GET", download, nil, "")
+	h.Expect(http.StatusNotFound, "GET", "/api/v1/attachment/1/download", nil, h.Token(student))
+
+	remove := fmt.Sprintf("/api/v1/attachment/%d", attachment.ID)
+	h.Expect(http.StatusForbidden, "DELETE", remove, nil, h.Token(student))
+	h.Expect(http.StatusOK, "DELETE", remove, nil, h.Token(owner))
+	h.Expect(http.StatusNotFound, "GET", download, nil, h.Token(student))
+	h.Expect(http.StatusNotFound, "GET", listed.Data[0].DownloadURL, nil, "")
+}
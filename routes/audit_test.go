@@ -0,0 +1,154 @@
+package routes_test
+
+import (
+	"backend-go/apikeys"
+	"backend-go/models"
+	"backend-go/services"
+	"backend-go/testutil"
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"testing"
+)
+
+// auditPage - One page of GET /admin/audit
+type auditPage struct {
+	Data []models.AuditEntry `json:"data"`
+	Meta services.Page       `json:"meta"`
+}
+
+func TestAuditLogRecordsChanges(t *testing.T) {
+	h := testutil.New(t)
+	admin := h.CreateUser("admin")
+	token := h.Token(admin)
+	course := h.CreateCourse(admin)
+	quiz, _ := h.CreateQuiz(course, "a")
+
+	res := h.Expect(http.StatusOK, "PUT", fmt.Sprintf("/api/v1/quiz/%d", quiz.ID), map[string]interface{}{
+		"name": "Renamed", "description": quiz.Description, "course_id": course.ID,
+	}, token)
+	requestID := res.Header.Get("X-Request-ID")
+	h.Expect(http.StatusOK, "DELETE", fmt.Sprintf("/api/v1/course/%d", course.ID), nil, token)
+
+	var page auditPage
+	h.Expect(http.StatusOK, "GET", fmt.Sprintf("/api/v1/admin/audit?entity_type=quiz&entity_id=%d", quiz.ID), nil, token).JSON(t, &page)
+	if page.Meta.Total != 1 {
+		t.Fatalf("quiz entries = %+v", page)
+	}
+	entry := page.Data[0]
+	if entry.Action != "quiz.update" || entry.ActorID == nil || *entry.ActorID != admin.ID || entry.RequestID != requestID || entry.IP == "" {
+		t.Errorf("quiz entry = %+v", entry)
+	}
+	var changes map[string]services.FieldChange
+	json.Unmarshal(entry.Changes, &changes)
+	if len(changes) != 1 || string(changes["name"].From) != fmt.Sprintf("%q", quiz.Name) || string(changes["name"].To) != `"Renamed"` {
+		t.Errorf("quiz changes = %s", entry.Changes)
+	}
+
+	h.Expect(http.StatusOK, "GET", "/api/v1/admin/audit?action=course.delete", nil, token).JSON(t, &page)
+	if page.Meta.Total != 1 || page.Data[0].EntityID != course.ID {
+		t.Fatalf("course.delete entries = %+v", page)
+	}
+	json.Unmarshal(page.Data[0].Changes, &changes)
+	if string(changes["Name"].From) != fmt.Sprintf("%q", course.Name) || string(changes["Name"].To) != "null" {
+		t.Errorf("course.delete changes = %s", page.Data[0].Changes)
+	}
+
+	// Entri tidak bisa diubah atau dihapus, juga lewat GORM langsung
+	stored := models.AuditEntry{ID: entry.ID}
+	if err := h.DB.Model(&stored).Update("action", "nothing").Error; err == nil {
+		t.Error("audit entry was updated")
+	}
+	if err := h.DB.Delete(&stored).Error; err == nil {
+		t.Error("audit entry was deleted")
+	}
+
+	h.Expect(http.StatusForbidden, "GET", "/api/v1/admin/audit", nil, h.Token(h.CreateUser("user")))
+	h.Expect(http.StatusBadRequest, "GET", "/api/v1/admin/audit?since=yesterday", nil, token)
+}
+
+func TestAuditLogSecretsAndImpersonation(t *testing.T) {
+	h := testutil.New(t)
+	admin := h.CreateUser("admin")
+	user := h.CreateUser("user")
+	adminToken := h.Token(admin)
+	course := h.CreateCourse(admin)
+
+	var issued struct {
+		Token string `json:"token"`
+	}
+	h.Expect(http.StatusOK, "POST", fmt.Sprintf("/api/v1/admin/users/%d/impersonate", user.ID), nil, adminToken).JSON(t, &issued)
+	h.Expect(http.StatusOK, "POST", fmt.Sprintf("/api/v1/enroll/%d", course.ID), nil, issued.Token)
+	h.Expect(http.StatusOK, "PUT", "/api/v1/account/password", map[string]string{
+		"current_password": testutil.Password, "new_password": "another-pass",
+	}, h.Token(user))
+
+	var page auditPage
+	h.Expect(http.StatusOK, "GET", "/api/v1/admin/audit?action=enrollment.create", nil, adminToken).JSON(t, &page)
+	if page.Meta.Total != 1 {
+		t.Fatalf("enrollment entries = %+v", page)
+	}
+	if e := page.Data[0]; e.ActorID == nil || *e.ActorID != user.ID || e.ImpersonatorID == nil || *e.ImpersonatorID != admin.ID {
+		t.Errorf("impersonated entry = %+v", e)
+	}
+
+	// Filter actor_id juga menemukan apa yang dilakukan admin saat menyamar
+	h.Expect(http.StatusOK, "GET", fmt.Sprintf("/api/v1/admin/audit?actor_id=%d", admin.ID), nil, adminToken).JSON(t, &page)
+	actions := map[string]bool{}
+	for _, e := range page.Data {
+		actions[e.Action] = true
+	}
+	if !actions["user.impersonate"] || !actions["enrollment.create"] {
+		t.Errorf("admin's actions = %v", actions)
+	}
+
+	h.Expect(http.StatusOK, "GET", "/api/v1/admin/audit?action=user.password_change", nil, adminToken).JSON(t, &page)
+	// Hash password tidak pernah masuk log
+	if page.Meta.Total != 1 || page.Data[0].EntityID != user.ID || string(page.Data[0].Changes) != "null" {
+		t.Errorf("password change entries = %+v", page)
+	}
+}
+
+func TestAuditLogExport(t *testing.T) {
+	h := testutil.New(t)
+	admin := h.CreateUser("admin")
+	token := h.Token(admin)
+	for i := 0; i < 3; i++ {
+		h.Expect(http.StatusCreated, "POST", "/api/v1/api-keys", map[string]interface{}{
+			"name": fmt.Sprintf("key %d", i), "scopes": []string{apikeys.ScopeCoursesRead},
+		}, h.Token(h.CreateUser("user")))
+	}
+
+	res := h.Expect(http.StatusOK, "GET", "/api/v1/admin/audit/export?entity_type=api_key", nil, token)
+	rows, err := csv.NewReader(bytes.NewReader(res.Body)).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 4 || rows[0][4] != "action" || rows[1][4] != "api_key.create" {
+		t.Fatalf("csv = %q", rows)
+	}
+	first, _ := strconv.Atoi(rows[1][0])
+	last, _ := strconv.Atoi(rows[3][0])
+	if first >= last || bytes.Contains(res.Body, []byte("Hash")) {
+		t.Errorf("csv = %q", rows)
+	}
+
+	res = h.Expect(http.StatusOK, "GET", "/api/v1/admin/audit/export?entity_type=api_key&format=json", nil, token)
+	lines := 0
+	scanner := bufio.NewScanner(bytes.NewReader(res.Body))
+	for scanner.Scan() {
+		var entry models.AuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil || entry.EntityType != models.AuditAPIKey {
+			t.Errorf("line %q: %v", scanner.Text(), err)
+		}
+		lines++
+	}
+	if lines != 3 || res.Header.Get("Content-Type") != "application/x-ndjson" {
+		t.Errorf("ndjson: %d lines, %s", lines, res.Header.Get("Content-Type"))
+	}
+	h.Expect(http.StatusUnprocessableEntity, "GET", "/api/v1/admin/audit/export?format=xml", nil, token)
+}
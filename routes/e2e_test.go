@@ -0,0 +1,146 @@
+package routes_test
+
+import (
+	"backend-go/testutil"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// TestStudentJourney walks a student through register → login → enroll → read lessons → submit quiz
+func TestStudentJourney(t *testing.T) {
+	h := testutil.New(t)
+
+	teacher := h.CreateUser("admin")
+	course := h.CreateCourse(teacher)
+	lesson := h.CreateLesson(course, "# Welcome\n\nFirst **lesson**.")
+	quiz, answers := h.CreateQuiz(course, "Yes", "No")
+
+	// register
+	h.Expect(http.StatusOK, "POST", "/api/v1/register", map[string]string{
+		"email":    "student@example.com",
+		"username": "student",
+		"password": "hunter22",
+	}, "")
+
+	// login
+	var login struct {
+		Token string `json:"token"`
+	}
+	h.Expect(http.StatusOK, "POST", "/api/v1/login", map[string]string{
+		"email":    "student@example.com",
+		"password": "hunter22",
+	}, "").JSON(t, &login)
+	if login.Token == "" {
+		t.Fatal("login returned no token")
+	}
+
+	// lessons are closed until the student enrolls
+	lessonsPath := fmt.Sprintf("/api/v1/course/%d/lessons", course.ID)
+	if res := h.Do("GET", lessonsPath, nil, login.Token); res.Code == http.StatusOK {
+		t.Fatalf("GET %s before enrolling: got 200", lessonsPath)
+	}
+
+	// enroll
+	h.Expect(http.StatusOK, "POST", fmt.Sprintf("/api/v1/enroll/%d", course.ID), nil, login.Token)
+	h.Expect(http.StatusConflict, "POST", fmt.Sprintf("/api/v1/enroll/%d", course.ID), nil, login.Token)
+
+	var enrollments struct {
+		Data []struct {
+			CourseID uint `json:"CourseID"`
+		} `json:"data"`
+	}
+	h.Expect(http.StatusOK, "GET", "/api/v1/enrollments", nil, login.Token).JSON(t, &enrollments)
+	if len(enrollments.Data) != 1 || enrollments.Data[0].CourseID != course.ID {
+		t.Fatalf("enrollments = %+v, want course %d", enrollments.Data, course.ID)
+	}
+
+	// read lessons
+	var lessons struct {
+		Data []struct {
+			ID          uint   `json:"ID"`
+			ContentHTML string `json:"ContentHTML"`
+		} `json:"data"`
+	}
+	h.Expect(http.StatusOK, "GET", lessonsPath, nil, login.Token).JSON(t, &lessons)
+	if len(lessons.Data) != 1 || lessons.Data[0].ID != lesson.ID {
+		t.Fatalf("lessons = %+v, want lesson %d", lessons.Data, lesson.ID)
+	}
+	if !strings.Contains(lessons.Data[0].ContentHTML, "<strong>lesson</strong>") {
+		t.Errorf("lesson HTML = %q, want rendered Markdown", lessons.Data[0].ContentHTML)
+	}
+
+	var quizzes struct {
+		Data []struct {
+			ID uint `json:"ID"`
+		} `json:"data"`
+	}
+	h.Expect(http.StatusOK, "GET", fmt.Sprintf("/api/v1/course/%d/quizzes", course.ID), nil, login.Token).JSON(t, &quizzes)
+	if len(quizzes.Data) != 1 || quizzes.Data[0].ID != quiz.ID {
+		t.Fatalf("quizzes = %+v, want quiz %d", quizzes.Data, quiz.ID)
+	}
+
+	// submit quiz
+	submitPath := fmt.Sprintf("/api/v1/quiz/%d/submit", quiz.ID)
+	var submission struct {
+		Data struct {
+			Attempt struct {
+				QuizID uint `json:"QuizID"`
+			} `json:"attempt"`
+			Answers []struct {
+				AnswerID uint `json:"AnswerID"`
+			} `json:"answers"`
+		} `json:"data"`
+	}
+	h.Expect(http.StatusCreated, "POST", submitPath, map[string][]uint{
+		"answer_ids": {answers[0].ID},
+	}, login.Token).JSON(t, &submission)
+	if submission.Data.Attempt.QuizID != quiz.ID {
+		t.Errorf("attempt quiz = %d, want %d", submission.Data.Attempt.QuizID, quiz.ID)
+	}
+	if len(submission.Data.Answers) != 1 || submission.Data.Answers[0].AnswerID != answers[0].ID {
+		t.Errorf("submitted answers = %+v, want [%d]", submission.Data.Answers, answers[0].ID)
+	}
+
+	var attempts int64
+	h.DB.Table("user_quizzes").Where("quiz_id = ?", quiz.ID).Count(&attempts)
+	if attempts != 1 {
+		t.Errorf("stored attempts = %d, want 1", attempts)
+	}
+}
+
+func TestSubmitQuizRequiresEnrollment(t *testing.T) {
+	h := testutil.New(t)
+
+	course := h.CreateCourse(h.CreateUser("admin"))
+	quiz, answers := h.CreateQuiz(course, "Yes")
+	student := h.CreateUser("user")
+
+	body := map[string][]uint{"answer_ids": {answers[0].ID}}
+	path := fmt.Sprintf("/api/v1/quiz/%d/submit", quiz.ID)
+
+	if res := h.Do("POST", path, body, ""); res.Code == http.StatusCreated {
+		t.Fatal("anonymous submission was accepted")
+	}
+	if res := h.Do("POST", path, body, h.Token(student)); res.Code == http.StatusCreated {
+		t.Fatal("submission from a student who is not enrolled was accepted")
+	}
+
+	h.Enroll(student, course)
+	h.Expect(http.StatusCreated, "POST", path, body, h.Token(student))
+}
+
+func TestSubmitQuizRejectsForeignAnswers(t *testing.T) {
+	h := testutil.New(t)
+
+	course := h.CreateCourse(h.CreateUser("admin"))
+	quiz, _ := h.CreateQuiz(course, "Yes")
+	_, other := h.CreateQuiz(course, "Elsewhere")
+	student := h.CreateUser("user")
+	h.Enroll(student, course)
+
+	h.Expect(http.StatusUnprocessableEntity, "POST", fmt.Sprintf("/api/v1/quiz/%d/submit", quiz.ID), map[string][]uint{
+		"answer_ids": {other[0].ID},
+	}, h.Token(student))
+}
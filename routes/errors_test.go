@@ -0,0 +1,61 @@
+package routes_test
+
+import (
+	"backend-go/testutil"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+type envelope struct {
+	Error struct {
+		Code    string      `json:"code"`
+		Message string      `json:"message"`
+		Details interface{} `json:"details"`
+	} `json:"error"`
+}
+
+func TestErrorEnvelope(t *testing.T) {
+	h := testutil.New(t)
+
+	admin := h.Token(h.CreateUser("admin"))
+	student := h.CreateUser("user")
+	course := h.CreateCourse(h.CreateUser("admin"))
+
+	cases := []struct {
+		name   string
+		method string
+		path   string
+		body   interface{}
+		token  string
+		status int
+		code   string
+	}{
+		{"missing token", "GET", "/api/v1/courses", nil, "", http.StatusUnauthorized, "unauthorized"},
+		{"bad token", "GET", "/api/v1/courses", nil, "not-a-jwt", http.StatusUnauthorized, "unauthorized"},
+		{"not admin", "POST", "/api/v1/quiz", map[string]interface{}{"name": "q", "description": "d", "course_id": course.ID}, h.Token(student), http.StatusForbidden, "not_admin"},
+		{"not enrolled", "GET", "/api/v1/course/1/lessons", nil, h.Token(student), http.StatusForbidden, "not_enrolled"},
+		{"invalid id", "GET", "/api/v1/course/abc", nil, admin, http.StatusBadRequest, "invalid_id"},
+		{"unknown course", "GET", "/api/v1/course/9999", nil, admin, http.StatusNotFound, "course_not_found"},
+		{"malformed json", "POST", "/api/v1/login", strings.NewReader("{"), "", http.StatusBadRequest, "invalid_input"},
+		{"validation", "POST", "/api/v1/quiz", map[string]interface{}{"name": "q"}, admin, http.StatusUnprocessableEntity, "validation_failed"},
+		{"wrong password", "POST", "/api/v1/login", map[string]string{"email": student.Email, "password": "wrong"}, "", http.StatusUnauthorized, "invalid_credentials"},
+		{"duplicate email", "POST", "/api/v1/register", map[string]string{"email": student.Email, "username": "fresh", "password": "pw"}, "", http.StatusConflict, "email_taken"},
+		{"unknown route", "GET", "/no/such/route", nil, "", http.StatusNotFound, "not_found"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			res := h.Expect(tc.status, tc.method, tc.path, tc.body, tc.token)
+
+			var body envelope
+			res.JSON(t, &body)
+			if body.Error.Code != tc.code {
+				t.Errorf("code = %q, want %q", body.Error.Code, tc.code)
+			}
+			if body.Error.Message == "" {
+				t.Error("message is empty")
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package routes_test
+
+import (
+	"archive/zip"
+	"backend-go/models"
+	"backend-go/repository"
+	"backend-go/services"
+	"backend-go/storage"
+	"backend-go/testutil"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// waitForExport - Poll the export list until export id is no longer pending
+func waitForExport(t *testing.T, h *testutil.Harness, token string, id uint) models.DataExport {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		var list struct {
+			Data []models.DataExport `json:"data"`
+		}
+		h.Expect(http.StatusOK, "GET", "/api/v1/me/exports", nil, token).JSON(t, &list)
+		for _, export := range list.Data {
+			if export.ID == id && export.Status != models.ExportPending {
+				return export
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("export %d still pending", id)
+	return models.DataExport{}
+}
+
+func TestDataExport(t *testing.T) {
+	h := testutil.New(t)
+	box := testutil.NewMailbox(t)
+	admin := h.CreateUser("admin")
+	user := h.CreateUser("user")
+	other := h.CreateUser("user")
+	course := h.CreateCourse(admin)
+	quiz, answers := h.CreateQuiz(course, "a", "b")
+	h.Enroll(user, course)
+	token := h.Token(user)
+
+	h.Expect(http.StatusCreated, "POST", fmt.Sprintf("/api/v1/quiz/%d/submit", quiz.ID), map[string]interface{}{"answer_ids": []uint{answers[1].ID}}, token)
+	if _, err := storage.Public.Save("profile-avatar.png", strings.NewReader("png bytes")); err != nil {
+		t.Fatal(err)
+	}
+	h.DB.Create(&models.Profile{UserID: user.ID, FirstName: "Ada", LastName: "L", Phone: "1", Image: "/uploads/profile-avatar.png"})
+
+	var started struct {
+		Data models.DataExport `json:"data"`
+	}
+	h.Expect(http.StatusAccepted, "POST", "/api/v1/me/export", nil, token).JSON(t, &started)
+	export := waitForExport(t, h, token, started.Data.ID)
+	if export.Status != models.ExportReady || export.DownloadURL == "" || export.ExpiresAt == nil {
+		t.Fatalf("export = %+v", export)
+	}
+
+	sent := box.To(user.Email)
+	if len(sent) != 1 {
+		t.Fatalf("notices = %+v", sent)
+	}
+	link := strings.Split(strings.TrimSpace(strings.Split(sent[0].Body, "\n\n")[1]), "\n")[0]
+
+	// Link dari email bisa dibuka tanpa login
+	archive := h.Expect(http.StatusOK, "GET", link, nil, "")
+	zr, err := zip.NewReader(bytes.NewReader(archive.Body), int64(len(archive.Body)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string][]byte{}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[f.Name], _ = io.ReadAll(rc)
+		rc.Close()
+	}
+	for _, name := range []string{"account.json", "profile.json", "enrollments.json", "quiz_attempts.json", "quiz_answers.json", "files/profile-avatar.png"} {
+		if _, ok := files[name]; !ok {
+			t.Errorf("archive has no %s", name)
+		}
+	}
+	var account services.Account
+	json.Unmarshal(files["account.json"], &account)
+	if account.Email != user.Email || bytes.Contains(files["account.json"], []byte(user.Password)) {
+		t.Errorf("account.json = %s", files["account.json"])
+	}
+	var chosen []models.UserAnswer
+	json.Unmarshal(files["quiz_answers.json"], &chosen)
+	if len(chosen) != 1 || chosen[0].AnswerID != answers[1].ID {
+		t.Errorf("quiz_answers.json = %s", files["quiz_answers.json"])
+	}
+
+	// Tanpa tanda tangan hanya pemiliknya yang boleh mengunduh
+	path := fmt.Sprintf("/media/export/%d", export.ID)
+	h.Expect(http.StatusUnauthorized, "GET", path, nil, "")
+	h.Expect(http.StatusNotFound, "GET", path, nil, h.Token(other))
+	h.Expect(http.StatusOK, "GET", path, nil, token)
+	h.Expect(http.StatusForbidden, "GET", link+"0", nil, "")
+
+	exports := services.NewExportService(repository.NewStore(h.DB))
+	if n, _ := exports.PurgeExpired(context.Background(), time.Now()); n != 0 {
+		t.Fatalf("purged %d exports before they expired", n)
+	}
+	if n, err := exports.PurgeExpired(context.Background(), time.Now().Add(8*24*time.Hour)); n != 1 || err != nil {
+		t.Fatalf("purge: %d %v", n, err)
+	}
+	h.Expect(http.StatusNotFound, "GET", link, nil, "")
+}
@@ -0,0 +1,46 @@
+package routes_test
+
+import (
+	"backend-go/apperr"
+	"backend-go/middleware"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestBodyLimit(t *testing.T) {
+	r := gin.New()
+	r.Use(middleware.ErrorHandler, middleware.BodyLimit(16))
+	bind := func(c *gin.Context) {
+		var input map[string]string
+		if err := c.ShouldBindJSON(&input); err != nil {
+			apperr.Abort(c, apperr.Bind(err))
+			return
+		}
+		c.Status(http.StatusNoContent)
+	}
+	r.POST("/small", bind)
+	r.POST("/upload", middleware.LargeTransfer(1024), bind)
+
+	body := `{"name": "` + strings.Repeat("x", 64) + `"}`
+	cases := []struct {
+		path   string
+		status int
+	}{
+		{"/small", http.StatusRequestEntityTooLarge},
+		// Batas rute upload menggantikan batas global, bukan ditumpuk
+		{"/upload", http.StatusNoContent},
+	}
+	for _, tc := range cases {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("POST", tc.path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		r.ServeHTTP(w, req)
+		if w.Code != tc.status {
+			t.Errorf("POST %s: got %d, want %d: %s", tc.path, w.Code, tc.status, w.Body)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package routes_test
+
+import (
+	"backend-go/logging"
+	"backend-go/testutil"
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequestIDIsPropagatedAndLogged(t *testing.T) {
+	h := testutil.New(t)
+	user := h.CreateUser("user")
+
+	var logs bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(logging.New(&logs, slog.LevelInfo, logging.FormatJSON))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+
+	req := httptest.NewRequest("GET", "/api/v1/courses", nil)
+	req.Header.Set("X-Request-ID", "abc-123")
+	req.Header.Set("Authorization", "Bearer "+h.Token(user))
+	w := httptest.NewRecorder()
+	h.Router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK || w.Header().Get("X-Request-ID") != "abc-123" {
+		t.Fatalf("got %d with X-Request-ID %q", w.Code, w.Header().Get("X-Request-ID"))
+	}
+
+	var line struct {
+		Msg       string `json:"msg"`
+		RequestID string `json:"request_id"`
+		UserID    uint   `json:"user_id"`
+		Route     string `json:"route"`
+		Status    int    `json:"status"`
+	}
+	found := false
+	for scanner := bufio.NewScanner(&logs); scanner.Scan(); {
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			t.Fatalf("log line is not JSON: %s", scanner.Bytes())
+		}
+		if line.Msg == "request" {
+			found = true
+			break
+		}
+	}
+	if !found {
+		t.Fatal("no access log line")
+	}
+	if line.RequestID != "abc-123" || line.UserID != user.ID || line.Route != "/api/v1/courses" || line.Status != 200 {
+		t.Fatalf("unexpected access log line: %+v", line)
+	}
+
+	// ID yang tidak valid diganti dengan ID baru
+	req = httptest.NewRequest("GET", "/api/v1/courses", nil)
+	req.Header.Set("X-Request-ID", "bad id\r\nx")
+	w = httptest.NewRecorder()
+	h.Router.ServeHTTP(w, req)
+	if id := w.Header().Get("X-Request-ID"); id == "" || id == "bad id\r\nx" {
+		t.Fatalf("invalid request ID was not replaced: %q", id)
+	}
+}
@@ -0,0 +1,122 @@
+package routes_test
+
+import (
+	"backend-go/models"
+	"backend-go/testutil"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// xssMarkdown - Lesson content trying every way we know to get script into content_html
+const xssMarkdown = "# Lesson **one**\n\n" +
+	"<script>alert('script')</script>\n\n" +
+	"<img src=x onerror=alert('img')>\n\n" +
+	"<svg onload=alert('svg')></svg>\n\n" +
+	"<iframe src=\"https://evil.example\"></iframe>\n\n" +
+	"<a href=\"javascript:alert('raw link')\">raw link</a>\n\n" +
+	"<div style=\"background:url(javascript:alert('style'))\">styled</div>\n\n" +
+	"[md link](javascript:alert('md link'))\n\n" +
+	"[data link](data:text/html;base64,PHNjcmlwdD5hbGVydCgxKTwvc2NyaXB0Pg==)\n\n" +
+	"![md image](javascript:alert('md image'))\n\n" +
+	"<p onclick=\"alert('click')\">click me</p>\n\n" +
+	"```go\nfmt.Println(\"<script>\")\n```\n\n" +
+	"<code class=\"x onmouseover=alert(1)\">code</code>\n\n" +
+	"[slides](attachment:7)\n"
+
+// renderedHTML - content_html of the lesson response
+func renderedHTML(t *testing.T, res *testutil.Response) string {
+	t.Helper()
+	var body struct {
+		Data models.Lesson `json:"data"`
+	}
+	res.JSON(t, &body)
+	return body.Data.ContentHTML
+}
+
+// assertNoScript - Fail when html has an element or attribute able to run script.
+// Payloads escaped into plain text are harmless and allowed.
+func assertNoScript(t *testing.T, fragment string) {
+	t.Helper()
+	nodes, err := html.ParseFragment(strings.NewReader(fragment), &html.Node{Type: html.ElementNode, Data: "div", DataAtom: atom.Div})
+	if err != nil {
+		t.Fatalf("parse content_html: %v", err)
+	}
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			switch n.DataAtom {
+			case atom.Script, atom.Iframe, atom.Svg, atom.Object, atom.Embed, atom.Style:
+				t.Errorf("content_html has <%s>:\n%s", n.Data, fragment)
+			}
+			for _, attr := range n.Attr {
+				value := strings.ToLower(strings.TrimSpace(attr.Val))
+				if strings.HasPrefix(attr.Key, "on") || attr.Key == "style" ||
+					strings.HasPrefix(value, "javascript:") || strings.HasPrefix(value, "data:") {
+					t.Errorf("content_html has %s=%q on <%s>:\n%s", attr.Key, attr.Val, n.Data, fragment)
+				}
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	for _, n := range nodes {
+		walk(n)
+	}
+}
+
+func TestLessonMarkdownIsSanitized(t *testing.T) {
+	h := testutil.New(t)
+	admin := h.CreateUser("admin")
+	course := h.CreateCourse(admin)
+	token := h.Token(admin)
+
+	res := h.DoForm("POST", "/api/v1/lesson", map[string]string{
+		"name": "XSS", "description": "Payloads", "course_id": fmt.Sprint(course.ID), "content": xssMarkdown,
+	}, nil, token)
+	if res.Code != http.StatusCreated && res.Code != http.StatusOK {
+		t.Fatalf("create: %d %s", res.Code, res.Body)
+	}
+	content := renderedHTML(t, res)
+	assertNoScript(t, content)
+
+	// Markdown biasa tetap ter-render
+	for _, want := range []string{"<h1>Lesson <strong>one</strong></h1>", `<code class="language-go">`, "fmt.Println(&#34;&lt;script&gt;&#34;)", `<a href="/media/attachment/7" rel="nofollow">slides</a>`} {
+		if !strings.Contains(content, want) {
+			t.Errorf("content_html lacks %q:\n%s", want, content)
+		}
+	}
+
+	// Yang disimpan tetap sumber aslinya; hanya HTML-nya yang dibersihkan
+	var lesson models.Lesson
+	h.DB.Where("name = ?", "XSS").First(&lesson)
+	if lesson.Content != xssMarkdown {
+		t.Errorf("stored content changed: %q", lesson.Content)
+	}
+
+	// Edit melewati sanitizer yang sama
+	base := fmt.Sprintf("/api/v1/lesson/%d", lesson.ID)
+	res = h.DoForm("PUT", base, map[string]string{"content": "ok\n\n<img src=x onerror=alert('edit')>"}, nil, token)
+	if res.Code != http.StatusOK {
+		t.Fatalf("update: %d %s", res.Code, res.Body)
+	}
+	assertNoScript(t, renderedHTML(t, res))
+
+	// Plain text di-escape, bukan ditafsirkan
+	res = h.DoForm("PUT", base, map[string]string{"content": "<script>alert('plain')</script>\nline two", "content_format": "plain"}, nil, token)
+	content = renderedHTML(t, res)
+	assertNoScript(t, content)
+	if !strings.Contains(content, "<p>&lt;script&gt;alert(&#39;plain&#39;)&lt;/script&gt;<br>line two</p>") {
+		t.Errorf("plain content_html = %s", content)
+	}
+
+	// Yang dibaca siswa juga sudah bersih
+	student := h.CreateUser("user")
+	h.Enroll(student, course)
+	assertNoScript(t, renderedHTML(t, h.Expect(http.StatusOK, "GET", base, nil, h.Token(student))))
+}
@@ -0,0 +1,162 @@
+package routes_test
+
+import (
+	"backend-go/models"
+	"backend-go/storage"
+	"backend-go/testutil"
+	"backend-go/utils"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// pngBytes - Enough of a PNG for content sniffing
+var pngBytes = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR fake image")
+
+// mediaError - The code of an error response
+func mediaError(t *testing.T, res *testutil.Response) string {
+	t.Helper()
+	var body struct {
+		Error struct {
+			Code string `json:"code"`
+		} `json:"error"`
+	}
+	res.JSON(t, &body)
+	return body.Error.Code
+}
+
+func TestSignedLessonMedia(t *testing.T) {
+	h := testutil.New(t)
+	admin := h.CreateUser("admin")
+	student := h.CreateUser("user")
+	outsider := h.CreateUser("user")
+	course := h.CreateCourse(admin)
+	h.Enroll(student, course)
+
+	// Nama lesson dengan "/" dan ".." tidak boleh ikut menjadi storage key
+	var created struct {
+		Data models.Lesson `json:"data"`
+	}
+	h.DoForm("POST", "/api/v1/lesson", map[string]string{
+		"name": "../../intro/part 1", "description": "Intro", "course_id": fmt.Sprint(course.ID),
+	}, &testutil.Upload{Field: "image", Name: "cover.png", Content: pngBytes}, h.Token(admin)).JSON(t, &created)
+	lesson := created.Data
+	if lesson.ID == 0 || strings.Contains(lesson.Image, "..") || strings.Count(lesson.Image, "/") != 1 {
+		t.Fatalf("lesson image stored at %q", lesson.Image)
+	}
+	if !strings.HasPrefix(lesson.ImageURL, fmt.Sprintf("/media/lesson/%d?expires=", lesson.ID)) {
+		t.Fatalf("image URL = %q", lesson.ImageURL)
+	}
+
+	// URL bertanda tangan cukup tanpa token, seperti <img src>
+	res := h.Expect(http.StatusOK, "GET", lesson.ImageURL, nil, "")
+	if string(res.Body) != string(pngBytes) || res.Header.Get("Cache-Control") != "private, max-age=300" {
+		t.Fatalf("signed image: %q %v", res.Body, res.Header)
+	}
+
+	tampered := lesson.ImageURL[:len(lesson.ImageURL)-1] + "0"
+	if strings.HasSuffix(lesson.ImageURL, "0") {
+		tampered = lesson.ImageURL[:len(lesson.ImageURL)-1] + "1"
+	}
+	if code := mediaError(t, h.Expect(http.StatusForbidden, "GET", tampered, nil, "")); code != "signature_invalid" {
+		t.Errorf("tampered signature: %s", code)
+	}
+
+	// Tanda tangan hanya berlaku untuk path yang ditandatangani
+	other := h.CreateLesson(course, "other")
+	_, query, _ := strings.Cut(lesson.ImageURL, "?")
+	moved := fmt.Sprintf("/media/lesson/%d?%s", other.ID, query)
+	if code := mediaError(t, h.Expect(http.StatusForbidden, "GET", moved, nil, "")); code != "signature_invalid" {
+		t.Errorf("signature moved to another lesson: %s", code)
+	}
+
+	expired := utils.SignURL(fmt.Sprintf("/media/lesson/%d", lesson.ID), -time.Minute)
+	if code := mediaError(t, h.Expect(http.StatusForbidden, "GET", expired, nil, "")); code != "signature_expired" {
+		t.Errorf("expired signature: %s", code)
+	}
+	h.Expect(http.StatusForbidden, "GET", fmt.Sprintf("/media/lesson/%d?expires=abc&signature=00", lesson.ID), nil, "")
+
+	// Tanpa tanda tangan berlaku aturan enrollment lewat Bearer token
+	path := fmt.Sprintf("/media/lesson/%d", lesson.ID)
+	h.Expect(http.StatusUnauthorized, "GET", path, nil, "")
+	h.Expect(http.StatusOK, "GET", path, nil, h.Token(student))
+	h.Expect(http.StatusOK, "GET", path, nil, h.Token(admin))
+	if code := mediaError(t, h.Expect(http.StatusForbidden, "GET", path, nil, h.Token(outsider))); code != "not_enrolled" {
+		t.Errorf("outsider: %s", code)
+	}
+	h.Expect(http.StatusNotFound, "GET", fmt.Sprintf("/media/lesson/%d", other.ID), nil, h.Token(student))
+}
+
+func TestLessonMediaURLsRequireEnrollment(t *testing.T) {
+	h := testutil.New(t)
+	admin := h.CreateUser("admin")
+	student := h.CreateUser("user")
+	other := h.CreateCourse(admin)
+	enrolled := h.CreateCourse(admin)
+	h.Enroll(student, enrolled)
+
+	// Lesson di course lain dengan id yang sama dengan course yang diikuti student
+	h.CreateLesson(enrolled, "filler")
+	lesson := lessonWithImage(t, h, other)
+	if lesson.ID != enrolled.ID {
+		t.Fatalf("lesson %d does not share the id of course %d", lesson.ID, enrolled.ID)
+	}
+	var attachment struct {
+		Data models.LessonAttachment `json:"data"`
+	}
+	h.DoForm("POST", fmt.Sprintf("/api/v1/lesson/%d/attachments", lesson.ID), nil,
+		&testutil.Upload{Field: "file", Name: "notes.txt", Content: []byte("notes")}, h.Token(admin)).JSON(t, &attachment)
+	content := fmt.Sprintf("[notes](attachment:%d)", attachment.Data.ID)
+	if res := h.DoForm("PUT", fmt.Sprintf("/api/v1/lesson/%d", lesson.ID), map[string]string{"content": content}, nil, h.Token(admin)); res.Code != http.StatusOK {
+		t.Fatalf("update: %d %s", res.Code, res.Body)
+	}
+
+	for _, prefix := range []string{"", "/api/v1", "/api/v2"} {
+		res := h.Expect(http.StatusForbidden, "GET", fmt.Sprintf("%s/lesson/%d", prefix, lesson.ID), nil, h.Token(student))
+		if strings.Contains(string(res.Body), "signature=") {
+			t.Errorf("%s: signed URL handed to a user who is not enrolled: %s", prefix, res.Body)
+		}
+	}
+
+	h.Enroll(student, other)
+	var body struct {
+		Data models.Lesson `json:"data"`
+	}
+	h.Expect(http.StatusOK, "GET", fmt.Sprintf("/api/v1/lesson/%d", lesson.ID), nil, h.Token(student)).JSON(t, &body)
+	if !strings.Contains(body.Data.ImageURL, "signature=") || !strings.Contains(body.Data.ContentHTML, "signature=") {
+		t.Errorf("enrolled student got unsigned media: %+v", body.Data)
+	}
+}
+
+func TestPublicUploads(t *testing.T) {
+	h := testutil.New(t)
+	user := h.CreateUser("user")
+
+	var created struct {
+		Data models.Profile `json:"data"`
+	}
+	res := h.DoForm("POST", "/api/v1/profile", map[string]string{
+		"first_name": "../../Ana", "last_name": "Putri", "phone": "0812", "address": "Jl. Merdeka",
+	}, &testutil.Upload{Field: "image", Name: "avatar.png", Content: pngBytes}, h.Token(user))
+	if res.Code != http.StatusCreated {
+		t.Fatalf("create profile: %d %s", res.Code, res.Body)
+	}
+	res.JSON(t, &created)
+	if !strings.HasPrefix(created.Data.Image, "/uploads/profile-") || strings.Count(created.Data.Image, "/") != 2 {
+		t.Fatalf("avatar = %q", created.Data.Image)
+	}
+
+	res = h.Expect(http.StatusOK, "GET", created.Data.Image, nil, "")
+	if string(res.Body) != string(pngBytes) || res.Header.Get("Cache-Control") != "public, max-age=86400" {
+		t.Fatalf("public image: %q %v", res.Body, res.Header)
+	}
+
+	// Hanya thumbnail dan avatar yang publik
+	if _, err := storage.Public.Save("notes.txt", strings.NewReader("secret")); err != nil {
+		t.Fatal(err)
+	}
+	h.Expect(http.StatusNotFound, "GET", "/uploads/notes.txt", nil, "")
+	h.Expect(http.StatusNotFound, "GET", "/uploads/course-missing.png", nil, "")
+}
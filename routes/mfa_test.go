@@ -0,0 +1,144 @@
+package routes_test
+
+import (
+	"backend-go/services"
+	"backend-go/testutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pquerna/otp/totp"
+)
+
+type errorBody struct {
+	Error struct{ Code string } `json:"error"`
+}
+
+// enrollMFA - Set up and confirm TOTP for the user behind token; returns the
+// secret and the recovery codes
+func enrollMFA(t *testing.T, h *testutil.Harness, token string) (string, []string) {
+	t.Helper()
+
+	var setup struct {
+		Data services.MFASetup `json:"data"`
+	}
+	h.Expect(http.StatusOK, "POST", "/api/v1/2fa/setup", nil, token).JSON(t, &setup)
+	if setup.Data.Secret == "" || setup.Data.URI == "" {
+		t.Fatalf("setup returned %+v", setup.Data)
+	}
+
+	h.Expect(http.StatusUnprocessableEntity, "POST", "/api/v1/2fa/confirm", map[string]string{"code": "000000"}, token)
+
+	code, _ := totp.GenerateCode(setup.Data.Secret, time.Now())
+	var confirm struct {
+		Data services.MFAConfirmation `json:"data"`
+	}
+	h.Expect(http.StatusOK, "POST", "/api/v1/2fa/confirm", map[string]string{"code": code}, token).JSON(t, &confirm)
+	if len(confirm.Data.RecoveryCodes) != services.RecoveryCodeCount || confirm.Data.Token == "" {
+		t.Fatalf("confirm returned %+v", confirm.Data)
+	}
+	return setup.Data.Secret, confirm.Data.RecoveryCodes
+}
+
+// challenge - Log in with the fixture password and return the MFA challenge token
+func challenge(t *testing.T, h *testutil.Harness, email string) string {
+	t.Helper()
+
+	var login struct {
+		Token          string `json:"token"`
+		MFARequired    bool   `json:"mfa_required"`
+		ChallengeToken string `json:"challenge_token"`
+	}
+	h.Expect(http.StatusOK, "POST", "/api/v1/login", map[string]string{
+		"email": email, "password": testutil.Password,
+	}, "").JSON(t, &login)
+	if login.Token != "" || !login.MFARequired || login.ChallengeToken == "" {
+		t.Fatalf("login with 2FA returned %+v", login)
+	}
+	return login.ChallengeToken
+}
+
+func TestTwoFactorLogin(t *testing.T) {
+	h := testutil.New(t)
+	user := h.CreateUser("user")
+	secret, recovery := enrollMFA(t, h, h.Token(user))
+
+	ch := challenge(t, h, user.Email)
+
+	// Token challenge bukan access token
+	h.Expect(http.StatusUnauthorized, "GET", "/api/v1/2fa", nil, ch)
+
+	// The confirm step used the current code; a code is only good once
+	current, _ := totp.GenerateCode(secret, time.Now())
+	h.Expect(http.StatusUnprocessableEntity, "POST", "/api/v1/login/2fa", map[string]string{"challenge_token": ch, "code": current}, "")
+
+	next, _ := totp.GenerateCode(secret, time.Now().Add(30*time.Second))
+	var login struct {
+		Token string `json:"token"`
+	}
+	h.Expect(http.StatusOK, "POST", "/api/v1/login/2fa", map[string]string{"challenge_token": ch, "code": next}, "").JSON(t, &login)
+	h.Expect(http.StatusOK, "GET", "/api/v1/courses", nil, login.Token)
+
+	// Recovery codes work once each, with or without the dash
+	h.Expect(http.StatusOK, "POST", "/api/v1/login/2fa", map[string]string{"challenge_token": ch, "code": strings.ReplaceAll(recovery[0], "-", "")}, "")
+	h.Expect(http.StatusUnprocessableEntity, "POST", "/api/v1/login/2fa", map[string]string{"challenge_token": ch, "code": recovery[0]}, "")
+
+	var status struct {
+		Data services.MFAStatus `json:"data"`
+	}
+	h.Expect(http.StatusOK, "GET", "/api/v1/2fa", nil, login.Token).JSON(t, &status)
+	if !status.Data.Enabled || status.Data.RecoveryCodesLeft != services.RecoveryCodeCount-1 {
+		t.Errorf("status = %+v", status.Data)
+	}
+
+	h.Expect(http.StatusUnauthorized, "POST", "/api/v1/login/2fa", map[string]string{"challenge_token": "garbage", "code": next}, "")
+
+	// Disabling with a recovery code turns the plain password login back on
+	h.Expect(http.StatusOK, "POST", "/api/v1/2fa/disable", map[string]string{"code": recovery[1]}, login.Token)
+	h.Expect(http.StatusOK, "POST", "/api/v1/login", map[string]string{"email": user.Email, "password": testutil.Password}, "")
+}
+
+func TestTwoFactorPolicy(t *testing.T) {
+	h := testutil.New(t)
+	services.ConfigureMFA("test", []string{"admin"})
+	t.Cleanup(func() { services.ConfigureMFA("backend-go", nil) })
+
+	admin := h.CreateUser("admin")
+	var login struct {
+		Token            string `json:"token"`
+		MFASetupRequired bool   `json:"mfa_setup_required"`
+	}
+	h.Expect(http.StatusOK, "POST", "/api/v1/login", map[string]string{
+		"email": admin.Email, "password": testutil.Password,
+	}, "").JSON(t, &login)
+	if !login.MFASetupRequired {
+		t.Error("login of an admin without 2FA did not ask for setup")
+	}
+
+	// Tanpa 2FA admin hanya bisa membuka rute /2fa
+	var body errorBody
+	h.Expect(http.StatusForbidden, "GET", "/api/v1/courses", nil, login.Token).JSON(t, &body)
+	if body.Error.Code != "mfa_required" {
+		t.Errorf("code = %q, want mfa_required", body.Error.Code)
+	}
+	h.Expect(http.StatusOK, "GET", "/api/v1/2fa", nil, login.Token)
+
+	secret, _ := enrollMFA(t, h, login.Token)
+	ch := challenge(t, h, admin.Email)
+	code, _ := totp.GenerateCode(secret, time.Now().Add(30*time.Second))
+	var verified struct {
+		Token string `json:"token"`
+	}
+	h.Expect(http.StatusOK, "POST", "/api/v1/login/2fa", map[string]string{"challenge_token": ch, "code": code}, "").JSON(t, &verified)
+	h.Expect(http.StatusOK, "GET", "/api/v1/courses", nil, verified.Token)
+
+	h.Expect(http.StatusForbidden, "POST", "/api/v1/2fa/disable", map[string]string{"code": code}, verified.Token).JSON(t, &body)
+	if body.Error.Code != "mfa_required_by_policy" {
+		t.Errorf("code = %q, want mfa_required_by_policy", body.Error.Code)
+	}
+
+	// Users are not affected by the admin policy
+	user := h.CreateUser("user")
+	h.Expect(http.StatusOK, "GET", "/api/v1/courses", nil, h.Token(user))
+}
@@ -0,0 +1,68 @@
+package routes_test
+
+import (
+	"backend-go/docs"
+	"backend-go/testutil"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// TestSpecCoversRoutes - Every registered route has a spec entry and every
+// spec entry still has a route, so the document cannot silently go stale.
+func TestSpecCoversRoutes(t *testing.T) {
+	h := testutil.New(t)
+	spec := docs.Spec()
+
+	registered := map[string]bool{}
+	for _, r := range h.Router.Routes() {
+		path, method := docs.OpenAPIPath(r.Path), strings.ToLower(r.Method)
+		registered[method+" "+path] = true
+		if _, ok := spec.Paths[path][method]; !ok {
+			t.Errorf("%s %s has no entry in the OpenAPI document", r.Method, r.Path)
+		}
+	}
+
+	for path, item := range spec.Paths {
+		for method := range item {
+			if !registered[method+" "+path] {
+				t.Errorf("OpenAPI document describes %s %s, which is not routed", strings.ToUpper(method), path)
+			}
+		}
+	}
+}
+
+func TestServeSpec(t *testing.T) {
+	h := testutil.New(t)
+
+	var doc struct {
+		OpenAPI string                 `json:"openapi"`
+		Paths   map[string]interface{} `json:"paths"`
+	}
+	h.Expect(http.StatusOK, "GET", "/openapi.json", nil, "").JSON(t, &doc)
+	if doc.OpenAPI != "3.0.3" || len(doc.Paths) == 0 {
+		t.Fatalf("unexpected document: openapi=%q paths=%d", doc.OpenAPI, len(doc.Paths))
+	}
+
+	res := h.Expect(http.StatusOK, "GET", "/docs", nil, "")
+	if !strings.Contains(string(res.Body), "/openapi.json") {
+		t.Fatal("docs page does not load /openapi.json")
+	}
+	// Semua aset dilayani sendiri, tidak ada script dari CDN
+	if strings.Contains(string(res.Body), "://") {
+		t.Fatalf("docs page loads from another origin:\n%s", res.Body)
+	}
+
+	res = h.Expect(http.StatusOK, "GET", "/docs/assets/swagger-ui-bundle.js", nil, "")
+	if !strings.HasPrefix(res.Header.Get("Content-Type"), "text/javascript") || !strings.Contains(string(res.Body), "SwaggerUIBundle") {
+		t.Fatalf("bundle: %v %d bytes", res.Header, len(res.Body))
+	}
+	res = h.Expect(http.StatusOK, "GET", "/docs/assets/swagger-ui.css", nil, "")
+	if !strings.HasPrefix(res.Header.Get("Content-Type"), "text/css") {
+		t.Fatalf("stylesheet: %v", res.Header)
+	}
+	if code := mediaError(t, h.Expect(http.StatusNotFound, "GET", "/docs/assets/README.md", nil, "")); code != "not_found" {
+		t.Errorf("unknown asset: %q", code)
+	}
+	h.Expect(http.StatusNotFound, "GET", "/docs/assets/..%2Fui.html", nil, "")
+}
@@ -0,0 +1,61 @@
+package routes_test
+
+import (
+	"backend-go/storage"
+	"backend-go/testutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestHealthAndReadiness(t *testing.T) {
+	h := testutil.New(t)
+
+	h.Expect(http.StatusOK, "GET", "/healthz", nil, "")
+
+	var ready struct {
+		Status string            `json:"status"`
+		Checks map[string]string `json:"checks"`
+	}
+	h.Expect(http.StatusOK, "GET", "/readyz", nil, "").JSON(t, &ready)
+	if ready.Status != "ok" || ready.Checks["database"] != "ok" || ready.Checks["storage_private"] != "ok" {
+		t.Fatalf("unexpected readiness: %+v", ready)
+	}
+
+	// Root storage berupa file biasa, jadi tidak bisa ditulisi
+	blocked := filepath.Join(t.TempDir(), "not-a-dir")
+	if err := os.WriteFile(blocked, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	storage.Private = storage.NewLocal(blocked)
+
+	h.Expect(http.StatusServiceUnavailable, "GET", "/readyz", nil, "").JSON(t, &ready)
+	if ready.Status != "unavailable" || ready.Checks["storage_private"] != "fail" || ready.Checks["database"] != "ok" {
+		t.Fatalf("unexpected readiness: %+v", ready)
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	h := testutil.New(t)
+	h.Expect(http.StatusOK, "POST", "/api/v1/register", map[string]string{
+		"email": "metrics@example.com", "username": "metrics", "password": testutil.Password,
+	}, "")
+	h.Expect(http.StatusUnauthorized, "POST", "/api/v1/login", map[string]string{
+		"email": "metrics@example.com", "password": "wrong",
+	}, "")
+
+	body := string(h.Expect(http.StatusOK, "GET", "/metrics", nil, "").Body)
+	for _, want := range []string{
+		`http_requests_total{method="POST",route="/api/v1/register",status="200"}`,
+		`http_request_duration_seconds_bucket{method="POST",route="/api/v1/login"`,
+		`app_registrations_total`,
+		`app_logins_total{result="failure"}`,
+		`go_sql_open_connections`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics output lacks %s", want)
+		}
+	}
+}
@@ -0,0 +1,301 @@
+package routes_test
+
+import (
+	"backend-go/middleware"
+	"backend-go/models"
+	"backend-go/repository"
+	"backend-go/services"
+	"backend-go/storage"
+	"backend-go/testutil"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// inOrganization - Send a request for the organization with slug, failing the
+// test unless the response has the given status
+func inOrganization(h *testutil.Harness, slug string, status int, method, path string, body interface{}, token string) *testutil.Response {
+	h.T.Helper()
+
+	var data []byte
+	if body != nil {
+		var err error
+		if data, err = json.Marshal(body); err != nil {
+			h.T.Fatalf("encode request: %v", err)
+		}
+	}
+	req := httptest.NewRequest(method, path, bytes.NewReader(data))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(middleware.OrganizationHeader, slug)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	res := h.Send(req)
+	if res.Code != status {
+		h.T.Fatalf("%s %s at %s: got %d, want %d: %s", method, path, slug, res.Code, status, res.Body)
+	}
+	return res
+}
+
+func TestOrganizationIsolation(t *testing.T) {
+	h := testutil.New(t)
+	adminA := h.CreateUser("admin")
+	courseA := h.CreateCourse(adminA)
+
+	school := h.CreateOrganization("school-b")
+	h.OrganizationID = school.ID
+	adminB := h.CreateUser("admin")
+	courseB := h.CreateCourse(adminB)
+	userB := h.CreateUser("user")
+
+	var courses struct {
+		Data []models.Course `json:"data"`
+	}
+	h.Expect(http.StatusOK, "GET", "/api/v1/courses", nil, h.Token(adminA)).JSON(t, &courses)
+	if len(courses.Data) != 1 || courses.Data[0].ID != courseA.ID {
+		t.Errorf("default organization courses = %+v", courses.Data)
+	}
+	inOrganization(h, "school-b", http.StatusOK, "GET", "/api/v1/courses", nil, h.Token(adminB)).JSON(t, &courses)
+	if len(courses.Data) != 1 || courses.Data[0].ID != courseB.ID {
+		t.Errorf("school-b courses = %+v", courses.Data)
+	}
+	inOrganization(h, "school-b", http.StatusNotFound, "GET", fmt.Sprintf("/api/v1/course/%d", courseA.ID), nil, h.Token(adminB))
+
+	// Token dan login hanya berlaku di organisasi user sendiri
+	h.Expect(http.StatusForbidden, "GET", "/api/v1/courses", nil, h.Token(adminB))
+	login := map[string]string{"email": userB.Email, "password": testutil.Password}
+	h.Expect(http.StatusUnauthorized, "POST", "/api/v1/login", login, "")
+	inOrganization(h, "school-b", http.StatusOK, "POST", "/api/v1/login", login, "")
+
+	inOrganization(h, "school-b", http.StatusOK, "POST", fmt.Sprintf("/api/v1/enroll/%d", courseB.ID), nil, h.Token(userB))
+	var enrollment models.Enrollment
+	if err := h.DB.Where("user_id = ?", userB.ID).First(&enrollment).Error; err != nil || enrollment.OrganizationID != school.ID {
+		t.Errorf("enrollment = %+v, %v", enrollment, err)
+	}
+	inOrganization(h, "school-b", http.StatusNotFound, "POST", fmt.Sprintf("/api/v1/enroll/%d", courseA.ID), nil, h.Token(userB))
+
+	register := map[string]string{"email": "new@example.com", "username": "new", "password": testutil.Password}
+	inOrganization(h, "school-b", http.StatusOK, "POST", "/api/v1/register", register, "")
+	var registered models.User
+	if err := h.DB.Where("email = ?", "new@example.com").First(&registered).Error; err != nil || registered.OrganizationID != school.ID {
+		t.Errorf("registered user = %+v, %v", registered, err)
+	}
+	// Email unik di seluruh platform, username per organisasi
+	register["username"] = "other"
+	h.Expect(http.StatusConflict, "POST", "/api/v1/register", register, "")
+	h.Expect(http.StatusOK, "POST", "/api/v1/register", map[string]string{
+		"email": "new2@example.com", "username": "new", "password": testutil.Password,
+	}, "")
+
+	var audit auditPage
+	inOrganization(h, "school-b", http.StatusOK, "GET", "/api/v1/admin/audit", nil, h.Token(adminB)).JSON(t, &audit)
+	for _, entry := range audit.Data {
+		if entry.OrganizationID != school.ID {
+			t.Errorf("school-b admin sees entry %+v", entry)
+		}
+	}
+	if audit.Meta.Total != 2 {
+		t.Errorf("school-b audit entries = %d, want the enrollment and the registration", audit.Meta.Total)
+	}
+	h.Expect(http.StatusOK, "GET", "/api/v1/admin/audit", nil, h.Token(adminA)).JSON(t, &audit)
+	if audit.Meta.Total != 1 || audit.Data[0].OrganizationID != testutil.DefaultOrganizationID {
+		t.Errorf("default organization audit = %+v", audit)
+	}
+}
+
+// lessonWithImage - Lesson in course whose image is stored in private storage
+func lessonWithImage(t *testing.T, h *testutil.Harness, course *models.Course) *models.Lesson {
+	t.Helper()
+	lesson := h.CreateLesson(course, "# Media")
+	lesson.Image = fmt.Sprintf("lessons/test-%d.png", lesson.ID)
+	if _, err := storage.Private.Save(lesson.Image, bytes.NewReader(pngBytes)); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.DB.Model(lesson).Update("image", lesson.Image).Error; err != nil {
+		t.Fatal(err)
+	}
+	return lesson
+}
+
+func TestMediaOrganizationIsolation(t *testing.T) {
+	h := testutil.New(t)
+	adminA := h.CreateUser("admin")
+	super := h.CreateUser("superadmin")
+	lessonA := lessonWithImage(t, h, h.CreateCourse(adminA))
+	res := h.DoForm("POST", fmt.Sprintf("/api/v1/lesson/%d/attachments", lessonA.ID), nil,
+		&testutil.Upload{Field: "file", Name: "notes.txt", Content: []byte("school A only")}, h.Token(adminA))
+	var attachment struct {
+		Data models.LessonAttachment `json:"data"`
+	}
+	res.JSON(t, &attachment)
+	if res.Code != http.StatusCreated {
+		t.Fatalf("upload: %d %s", res.Code, res.Body)
+	}
+
+	h.OrganizationID = h.CreateOrganization("school-b").ID
+	adminB := h.CreateUser("admin")
+	studentB := h.CreateUser("user")
+	lessonB := lessonWithImage(t, h, h.CreateCourse(adminB))
+
+	image := fmt.Sprintf("/media/lesson/%d", lessonA.ID)
+	download := fmt.Sprintf("/media/attachment/%d", attachment.Data.ID)
+	video := fmt.Sprintf("/media/lesson/%d/video", lessonA.ID)
+
+	// Admin sekolah lain tidak melihat media sekolah A, seolah-olah tidak ada
+	for path, code := range map[string]string{image: "lesson_not_found", download: "attachment_not_found", video: "lesson_not_found"} {
+		for _, user := range []*models.User{adminB, studentB} {
+			if got := mediaError(t, h.Expect(http.StatusNotFound, "GET", path, nil, h.Token(user))); got != code {
+				t.Errorf("GET %s as %s of school-b: %s", path, user.Roles, got)
+			}
+		}
+	}
+
+	h.Expect(http.StatusOK, "GET", image, nil, h.Token(adminA))
+	h.Expect(http.StatusOK, "GET", download, nil, h.Token(adminA))
+	h.Expect(http.StatusOK, "GET", fmt.Sprintf("/media/lesson/%d", lessonB.ID), nil, h.Token(adminB))
+	h.Expect(http.StatusNotFound, "GET", fmt.Sprintf("/media/lesson/%d", lessonB.ID), nil, h.Token(adminA))
+
+	// Superadmin mengelola semua sekolah
+	h.Expect(http.StatusOK, "GET", image, nil, h.Token(super))
+	h.Expect(http.StatusOK, "GET", fmt.Sprintf("/media/lesson/%d", lessonB.ID), nil, h.Token(super))
+}
+
+func TestOrganizationResolution(t *testing.T) {
+	h := testutil.New(t)
+	h.CreateOrganization("school-b")
+	middleware.ConfigureTenancy("example.com", "default")
+	t.Cleanup(func() { middleware.ConfigureTenancy("", "default") })
+
+	var organization struct {
+		Data models.Organization `json:"data"`
+	}
+	for host, slug := range map[string]string{
+		"school-b.example.com":      "school-b",
+		"SCHOOL-B.example.com:8080": "school-b",
+		"example.com":               "default",
+		"a.school-b.example.com":    "default",
+		"school-b.example.org":      "default",
+	} {
+		req := httptest.NewRequest("GET", "/api/v1/organization", nil)
+		req.Host = host
+		res := h.Send(req)
+		if res.Code != http.StatusOK {
+			t.Fatalf("%s: got %d: %s", host, res.Code, res.Body)
+		}
+		res.JSON(t, &organization)
+		if organization.Data.Slug != slug {
+			t.Errorf("%s resolved to %q, want %q", host, organization.Data.Slug, slug)
+		}
+	}
+
+	// Header menang atas subdomain
+	req := httptest.NewRequest("GET", "/api/v1/organization", nil)
+	req.Host = "school-b.example.com"
+	req.Header.Set(middleware.OrganizationHeader, "default")
+	h.Send(req).JSON(t, &organization)
+	if organization.Data.Slug != "default" {
+		t.Errorf("header lost to the subdomain: %+v", organization.Data)
+	}
+
+	inOrganization(h, "nowhere", http.StatusNotFound, "GET", "/api/v1/organization", nil, "")
+	inOrganization(h, "nowhere", http.StatusNotFound, "POST", "/api/v1/login", map[string]string{"email": "a@example.com", "password": "x"}, "")
+}
+
+func TestSuperAdmin(t *testing.T) {
+	h := testutil.New(t)
+	super := h.CreateUser("superadmin")
+	admin := h.CreateUser("admin")
+	target := h.CreateUser("user")
+	adminToken, superToken := h.Token(admin), h.Token(super)
+
+	school := h.CreateOrganization("school-b")
+	h.OrganizationID = school.ID
+	courseB := h.CreateCourse(h.CreateUser("admin"))
+
+	// Superadmin bekerja di organisasi mana pun, admin hanya di organisasinya
+	var courses struct {
+		Data []models.Course `json:"data"`
+	}
+	inOrganization(h, "school-b", http.StatusOK, "GET", "/api/v1/courses", nil, superToken).JSON(t, &courses)
+	if len(courses.Data) != 1 || courses.Data[0].ID != courseB.ID {
+		t.Errorf("superadmin at school-b sees %+v", courses.Data)
+	}
+	inOrganization(h, "school-b", http.StatusOK, "DELETE", fmt.Sprintf("/api/v1/course/%d", courseB.ID), nil, superToken)
+	inOrganization(h, "school-b", http.StatusForbidden, "GET", "/api/v1/courses", nil, adminToken)
+	inOrganization(h, "school-b", http.StatusOK, "POST", "/api/v1/login",
+		map[string]string{"email": super.Email, "password": testutil.Password}, "")
+
+	h.Expect(http.StatusForbidden, "PUT", fmt.Sprintf("/api/v1/admin/users/%d/role", target.ID), map[string]string{"role": "superadmin"}, adminToken)
+	h.Expect(http.StatusForbidden, "POST", fmt.Sprintf("/api/v1/admin/users/%d/suspend", super.ID), map[string]string{"reason": "no"}, adminToken)
+	h.Expect(http.StatusForbidden, "POST", fmt.Sprintf("/api/v1/admin/users/%d/impersonate", super.ID), nil, adminToken)
+	h.Expect(http.StatusOK, "PUT", fmt.Sprintf("/api/v1/admin/users/%d/role", admin.ID), map[string]string{"role": "superadmin"}, superToken)
+	h.Expect(http.StatusForbidden, "POST", fmt.Sprintf("/api/v1/admin/users/%d/impersonate", admin.ID), nil, superToken)
+
+	res := inOrganization(h, "school-b", http.StatusForbidden, "GET", "/api/v1/admin/organizations", nil, h.Token(h.CreateUser("admin")))
+	if !strings.Contains(string(res.Body), "not_superadmin") {
+		t.Errorf("organization admin: %s", res.Body)
+	}
+	var created struct {
+		Data models.Organization `json:"data"`
+	}
+	h.Expect(http.StatusCreated, "POST", "/api/v1/admin/organizations", map[string]string{"name": "School C", "slug": "School-C"}, superToken).JSON(t, &created)
+	if created.Data.Slug != "school-c" {
+		t.Errorf("created = %+v", created.Data)
+	}
+	h.Expect(http.StatusConflict, "POST", "/api/v1/admin/organizations", map[string]string{"name": "Again", "slug": "school-c"}, superToken)
+	h.Expect(http.StatusUnprocessableEntity, "POST", "/api/v1/admin/organizations", map[string]string{"name": "Bad", "slug": "-bad slug"}, superToken)
+	h.Expect(http.StatusUnprocessableEntity, "POST", "/api/v1/admin/organizations", map[string]string{"slug": "no-name"}, superToken)
+	h.Expect(http.StatusConflict, "PUT", fmt.Sprintf("/api/v1/admin/organizations/%d", created.Data.ID), map[string]string{"slug": "school-b"}, superToken)
+	h.Expect(http.StatusOK, "PUT", fmt.Sprintf("/api/v1/admin/organizations/%d", created.Data.ID), map[string]string{"name": "School Cee"}, superToken)
+	h.Expect(http.StatusNotFound, "GET", "/api/v1/admin/organizations/9999", nil, superToken)
+
+	var list struct {
+		Data []models.Organization `json:"data"`
+	}
+	h.Expect(http.StatusOK, "GET", "/api/v1/admin/organizations", nil, superToken).JSON(t, &list)
+	if len(list.Data) != 3 || list.Data[2].Name != "School Cee" {
+		t.Errorf("organizations = %+v", list.Data)
+	}
+	inOrganization(h, "school-c", http.StatusOK, "GET", "/api/v1/organization", nil, "")
+
+	// Log audit superadmin mencakup semua organisasi
+	var audit auditPage
+	h.Expect(http.StatusOK, "GET", "/api/v1/admin/audit?action=course.delete", nil, superToken).JSON(t, &audit)
+	if audit.Meta.Total != 1 || audit.Data[0].OrganizationID != school.ID {
+		t.Errorf("course.delete entries = %+v", audit)
+	}
+}
+
+func TestGrantSuperAdmin(t *testing.T) {
+	h := testutil.New(t)
+	h.OrganizationID = h.CreateOrganization("school-b").ID
+	admin := h.CreateUser("admin")
+	users := services.NewUserAdminService(repository.NewStore(h.DB))
+	ctx := context.Background()
+
+	// Admin yang ada tetap admin organisasinya sampai diberi role superadmin
+	inOrganization(h, "school-b", http.StatusForbidden, "GET", "/api/v1/admin/organizations", nil, h.Token(admin))
+	granted, err := users.GrantSuperAdmin(ctx, admin.Email)
+	if err != nil || granted.Role != models.RoleSuperAdmin {
+		t.Fatalf("grant: %+v %v", granted, err)
+	}
+	inOrganization(h, "school-b", http.StatusOK, "GET", "/api/v1/admin/organizations", nil, h.Token(admin))
+	h.Expect(http.StatusOK, "GET", "/api/v1/admin/organizations", nil, h.Token(admin))
+
+	var entry models.AuditEntry
+	if err := h.DB.Where("action = ? AND entity_id = ?", "user.role_change", admin.ID).First(&entry).Error; err != nil {
+		t.Errorf("grant not audited: %v", err)
+	}
+	if _, err := users.GrantSuperAdmin(ctx, admin.Email); err != nil {
+		t.Errorf("granting twice: %v", err)
+	}
+	if _, err := users.GrantSuperAdmin(ctx, "nobody@example.com"); err != services.ErrUserNotFound {
+		t.Errorf("unknown email = %v", err)
+	}
+}
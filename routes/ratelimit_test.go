@@ -0,0 +1,210 @@
+package routes_test
+
+import (
+	"backend-go/apikeys"
+	"backend-go/middleware"
+	"backend-go/models"
+	"backend-go/ratelimit"
+	"backend-go/services"
+	"backend-go/testutil"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// withRateLimits - Configure limits for the test and remove them afterwards
+func withRateLimits(t *testing.T, store ratelimit.Store, limits map[string]ratelimit.Limit) {
+	t.Helper()
+	middleware.ConfigureRateLimits(store, limits)
+	t.Cleanup(func() { middleware.ConfigureRateLimits(ratelimit.NewMemory(), nil) })
+}
+
+func TestLoginRateLimit(t *testing.T) {
+	h := testutil.New(t)
+	user := h.CreateUser("user")
+	withRateLimits(t, ratelimit.NewMemory(), map[string]ratelimit.Limit{
+		middleware.ScopeLogin: {Burst: 2, Period: time.Minute},
+	})
+
+	login := map[string]string{"email": user.Email, "password": testutil.Password}
+	for i := 0; i < 2; i++ {
+		res := h.Expect(http.StatusOK, "POST", "/api/v1/login", login, "")
+		if got := res.Header.Get("RateLimit-Remaining"); got != strconv.Itoa(1-i) {
+			t.Errorf("RateLimit-Remaining = %q, want %d", got, 1-i)
+		}
+	}
+
+	res := h.Expect(http.StatusTooManyRequests, "POST", "/api/v1/login", login, "")
+	var body struct {
+		Error struct{ Code string } `json:"error"`
+	}
+	res.JSON(t, &body)
+	if body.Error.Code != "rate_limited" {
+		t.Errorf("code = %q, want rate_limited", body.Error.Code)
+	}
+	if retry, _ := strconv.Atoi(res.Header.Get("Retry-After")); retry <= 0 {
+		t.Errorf("Retry-After = %q, want a positive number of seconds", res.Header.Get("Retry-After"))
+	}
+	if res.Header.Get("RateLimit-Limit") != "2" {
+		t.Errorf("RateLimit-Limit = %q, want 2", res.Header.Get("RateLimit-Limit"))
+	}
+
+	// Scope lain punya bucket sendiri
+	h.Expect(http.StatusOK, "GET", "/api/v1/courses", nil, h.Token(user))
+}
+
+// loginFrom - POST /login through a client claiming to be forwarded for ip
+func loginFrom(h *testutil.Harness, ip string, body string) *testutil.Response {
+	req := httptest.NewRequest("POST", "/api/v1/login", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Forwarded-For", ip)
+	return h.Send(req)
+}
+
+func TestRateLimitIgnoresSpoofedForwardedFor(t *testing.T) {
+	h := testutil.New(t)
+	withRateLimits(t, ratelimit.NewMemory(), map[string]ratelimit.Limit{
+		middleware.ScopeLogin: {Burst: 2, Period: time.Minute},
+	})
+	body := `{"email":"nobody@example.com","password":"wrong-password"}`
+
+	// Tanpa proxy tepercaya, header palsu tidak memberi bucket baru
+	for i, ip := range []string{"203.0.113.1", "203.0.113.2", "203.0.113.3"} {
+		res := loginFrom(h, ip, body)
+		if limited := res.Code == http.StatusTooManyRequests; limited != (i == 2) {
+			t.Fatalf("request %d from %s: %d", i, ip, res.Code)
+		}
+	}
+
+	// Di belakang proxy yang dipercaya, alamat yang diteruskannya dipakai
+	if err := h.Router.SetTrustedProxies([]string{"192.0.2.0/24"}); err != nil {
+		t.Fatal(err)
+	}
+	if res := loginFrom(h, "203.0.113.4", body); res.Code == http.StatusTooManyRequests {
+		t.Fatalf("forwarded client behind a trusted proxy: %d", res.Code)
+	}
+}
+
+func TestAPIKeysHaveTheirOwnBucket(t *testing.T) {
+	h := testutil.New(t)
+	token := h.Token(h.CreateUser("user"))
+	_, first := createAPIKey(t, h, token, map[string]interface{}{"name": "ci", "scopes": []string{apikeys.ScopeCoursesRead}})
+	_, second := createAPIKey(t, h, token, map[string]interface{}{"name": "cron", "scopes": []string{apikeys.ScopeCoursesRead}})
+	withRateLimits(t, ratelimit.NewMemory(), map[string]ratelimit.Limit{
+		middleware.ScopeAPI: {Burst: 2, Period: time.Minute},
+	})
+
+	// Semua request datang dari IP yang sama, seperti klien di belakang satu NAT
+	for i := 0; i < 2; i++ {
+		h.Expect(http.StatusOK, "GET", "/api/v1/courses", nil, first)
+	}
+	h.Expect(http.StatusTooManyRequests, "GET", "/api/v1/courses", nil, first)
+	h.Expect(http.StatusOK, "GET", "/api/v1/courses", nil, second)
+	h.Expect(http.StatusOK, "GET", "/api/v1/courses", nil, token)
+}
+
+func TestAuthRoutesHaveSeparateScopes(t *testing.T) {
+	h := testutil.New(t)
+	one := ratelimit.Limit{Burst: 1, Period: time.Minute}
+	withRateLimits(t, ratelimit.NewMemory(), map[string]ratelimit.Limit{
+		middleware.ScopeLogin: one, middleware.ScopeLoginMFA: one, middleware.ScopeSSO: one,
+		middleware.ScopePasswordReset: one, middleware.ScopeEmailConfirm: one,
+	})
+
+	routes := []struct {
+		method, path string
+		body         interface{}
+	}{
+		{"POST", "/api/v1/login", map[string]string{"email": "nobody@example.com", "password": "wrong-password"}},
+		{"POST", "/api/v1/login/2fa", map[string]string{"challenge_token": "x", "code": "000000"}},
+		{"GET", "/api/v1/auth/sso/unknown/login", nil},
+		{"POST", "/api/v1/account/password/reset", map[string]string{"token": "x", "password": "new-password-123"}},
+		{"POST", "/api/v1/account/email/confirm", map[string]string{"token": "x"}},
+	}
+	// Bucket tiap route terpisah: request pertama ke route mana pun tetap lolos
+	for _, r := range routes {
+		if res := h.Do(r.method, r.path, r.body, ""); res.Code == http.StatusTooManyRequests || res.Header.Get("RateLimit-Limit") != "1" {
+			t.Errorf("first %s %s: %d %v", r.method, r.path, res.Code, res.Header)
+		}
+	}
+	for _, r := range routes {
+		if res := h.Do(r.method, r.path, r.body, ""); res.Code != http.StatusTooManyRequests {
+			t.Errorf("second %s %s: %d", r.method, r.path, res.Code)
+		}
+	}
+}
+
+func TestAccountLockout(t *testing.T) {
+	h := testutil.New(t)
+	user := h.CreateUser("user")
+
+	wrong := map[string]string{"email": user.Email, "password": "not-the-password"}
+	for i := 1; i < services.LockoutThreshold; i++ {
+		h.Expect(http.StatusUnauthorized, "POST", "/api/v1/login", wrong, "")
+	}
+	res := h.Expect(http.StatusTooManyRequests, "POST", "/api/v1/login", wrong, "")
+	if res.Header.Get("Retry-After") != strconv.Itoa(int(services.LockoutBase.Seconds())) {
+		t.Errorf("Retry-After = %q, want %v", res.Header.Get("Retry-After"), services.LockoutBase)
+	}
+
+	// Password yang benar pun ditolak selama akun terkunci
+	res = h.Expect(http.StatusTooManyRequests, "POST", "/api/v1/login", map[string]string{
+		"email": user.Email, "password": testutil.Password,
+	}, "")
+	var body struct {
+		Error struct{ Code string } `json:"error"`
+	}
+	res.JSON(t, &body)
+	if body.Error.Code != "account_locked" {
+		t.Errorf("code = %q, want account_locked", body.Error.Code)
+	}
+
+	// Once the lock expires the correct password works and clears the count
+	if err := h.DB.Model(user).Update("locked_until", time.Now().Add(-time.Second)).Error; err != nil {
+		t.Fatal(err)
+	}
+	h.Expect(http.StatusOK, "POST", "/api/v1/login", map[string]string{
+		"email": user.Email, "password": testutil.Password,
+	}, "")
+	var reloaded models.User
+	h.DB.First(&reloaded, user.ID)
+	if reloaded.FailedLogins != 0 || reloaded.LockedUntil != nil {
+		t.Errorf("after login: failed_logins=%d locked_until=%v, want cleared", reloaded.FailedLogins, reloaded.LockedUntil)
+	}
+}
+
+func TestRedisStore(t *testing.T) {
+	mr := miniredis.RunT(t)
+	store := ratelimit.NewRedis(redis.NewClient(&redis.Options{Addr: mr.Addr()}))
+	limit := ratelimit.Limit{Burst: 3, Period: time.Minute}
+	ctx := context.Background()
+
+	for i := 0; i < limit.Burst; i++ {
+		res, err := store.Take(ctx, "k", limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !res.Allowed || res.Remaining != limit.Burst-1-i {
+			t.Fatalf("take %d: %+v", i, res)
+		}
+	}
+	res, err := store.Take(ctx, "k", limit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Allowed || res.RetryAfter <= 0 {
+		t.Fatalf("over the limit: %+v", res)
+	}
+
+	// Bucket lain tidak terpengaruh
+	if res, _ := store.Take(ctx, "other", limit); !res.Allowed {
+		t.Errorf("other key refused: %+v", res)
+	}
+}
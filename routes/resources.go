@@ -0,0 +1,179 @@
+package routes
+
+import (
+	"backend-go/apikeys"
+	"backend-go/controllers"
+	"backend-go/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Rute yang sama di semua versi API, dikelompokkan per resource.
+// Middleware dipasang per group, bukan per rute.
+
+// multipartOverhead - Room for multipart boundaries and form fields around an uploaded file
+const multipartOverhead = 1 << 20
+
+func authRoutes(g *gin.RouterGroup, h *handlers) {
+	g.POST("/register", middleware.RateLimit(middleware.ScopeRegister, middleware.ByIP), h.auth.Register)
+	g.POST("/login", middleware.RateLimit(middleware.ScopeLogin, middleware.ByIP), h.auth.Login)
+	g.POST("/login/2fa", middleware.RateLimit(middleware.ScopeLoginMFA, middleware.ByIP), h.auth.LoginMFA)
+
+	// Login lewat OpenID Connect (SSO kampus, Google, ...)
+	g.GET("/auth/sso", h.auth.SSOProviders)
+	sso := g.Group("/auth/sso/:provider", middleware.RateLimit(middleware.ScopeSSO, middleware.ByIP))
+	sso.GET("/login", h.auth.SSOLogin)
+	sso.GET("/callback", h.auth.SSOCallback)
+}
+
+func mfaRoutes(g *gin.RouterGroup, h *handlers) {
+	// Juga terbuka untuk role yang wajib 2FA tapi belum mengaturnya
+	mfa := g.Group("/2fa", middleware.AllowMFASetup, h.access.IsLogin, middleware.NoImpersonation)
+	mfa.GET("", h.mfa.Status)
+	mfa.POST("/setup", h.mfa.Setup)
+	mfa.POST("/confirm", h.mfa.Confirm)
+	mfa.POST("/recovery-codes", h.mfa.RegenerateRecoveryCodes)
+	mfa.POST("/disable", h.mfa.Disable)
+}
+
+func accountRoutes(g *gin.RouterGroup, h *handlers) {
+	// Link konfirmasi dibuka dari email, belum tentu di perangkat yang login
+	g.POST("/account/email/confirm", middleware.RateLimit(middleware.ScopeEmailConfirm, middleware.ByIP), h.accounts.ConfirmEmailChange)
+	g.POST("/account/password/reset", middleware.RateLimit(middleware.ScopePasswordReset, middleware.ByIP), h.accounts.ResetPassword)
+
+	account := g.Group("/account", h.access.IsLogin, middleware.NoImpersonation)
+	account.GET("", h.accounts.GetAccount)
+	account.PUT("/password", h.accounts.ChangePassword)
+	account.POST("/email", h.accounts.RequestEmailChange)
+	account.DELETE("", h.accounts.DeleteAccount)
+}
+
+func exportRoutes(g *gin.RouterGroup, h *handlers) {
+	// Salinan data pribadi (GDPR); file-nya diunduh lewat /media/export/:id
+	me := g.Group("/me", h.access.IsLogin, middleware.NoImpersonation)
+	me.POST("/export", h.exports.RequestExport)
+	me.GET("/exports", h.exports.ListExports)
+}
+
+func apiKeyRoutes(g *gin.RouterGroup, h *handlers) {
+	// Tanpa scope: kunci API tidak bisa membuat atau mencabut kunci lain
+	keys := g.Group("/api-keys", h.access.IsLogin, middleware.NoImpersonation)
+	keys.GET("", h.apiKeys.ListAPIKeys)
+	keys.POST("", h.apiKeys.CreateAPIKey)
+	keys.DELETE("/:id", h.apiKeys.RevokeAPIKey)
+}
+
+func userAdminRoutes(g *gin.RouterGroup, h *handlers) {
+	users := g.Group("/admin/users", h.access.IsLogin, middleware.IsAdmin)
+	users.GET("", h.users.ListUsers)
+	users.GET("/:id", h.users.GetUser)
+	users.PUT("/:id/role", h.users.SetRole)
+	users.POST("/:id/suspend", h.users.SuspendUser)
+	users.DELETE("/:id/suspend", h.users.UnsuspendUser)
+	users.POST("/:id/password-reset", h.users.ForcePasswordReset)
+	users.POST("/:id/impersonate", h.users.Impersonate)
+}
+
+func auditRoutes(g *gin.RouterGroup, h *handlers) {
+	audit := g.Group("/admin/audit", h.access.IsLogin, middleware.IsAdmin)
+	audit.GET("", h.audit.ListAudit)
+	audit.GET("/export", h.audit.ExportAudit)
+}
+
+func trashRoutes(g *gin.RouterGroup, h *handlers) {
+	trash := g.Group("/admin/trash", h.access.IsLogin, middleware.IsAdmin)
+	trash.GET("", h.trash.ListTrash)
+	trash.POST("/:type/:id/restore", h.trash.RestoreTrash)
+}
+
+func organizationRoutes(g *gin.RouterGroup, h *handlers) {
+	g.GET("/organization", h.orgs.GetCurrentOrganization)
+
+	orgs := g.Group("/admin/organizations", h.access.IsLogin, middleware.IsSuperAdmin)
+	orgs.GET("", h.orgs.ListOrganizations)
+	orgs.POST("", h.orgs.CreateOrganization)
+	orgs.GET("/:id", h.orgs.GetOrganization)
+	orgs.PUT("/:id", h.orgs.UpdateOrganization)
+}
+
+func profileRoutes(g *gin.RouterGroup, h *handlers) {
+	profile := g.Group("/profile", h.access.IsLogin)
+	profile.POST("", h.profiles.CreateProfile)
+	profile.GET("", h.profiles.GetProfile)
+	profile.PUT("", h.profiles.UpdateProfile)
+	profile.DELETE("", h.profiles.DeleteProfile)
+}
+
+func courseRoutes(g *gin.RouterGroup, h *handlers) {
+	g.GET("/course/:id/students", h.courses.GetStudentsInCourse)
+
+	catalog := g.Group("", middleware.Scope(apikeys.ScopeCoursesRead), h.access.IsLogin)
+	catalog.GET("/courses", h.courses.GetCourses)
+	catalog.GET("/course/:id", h.courses.GetCourseByID)
+
+	enrolled := g.Group("/course/:id", middleware.Scope(apikeys.ScopeCoursesRead), h.access.IsEnrolled)
+	enrolled.GET("/lessons", h.lessons.GetLessonsInCourse)
+	enrolled.GET("/quizzes", h.quizzes.GetQuizzesByCourseID)
+
+	admin := g.Group("", h.access.IsLogin, middleware.IsAdmin)
+	admin.POST("/course", h.courses.CreateCourse)
+	admin.PUT("/course/:id", h.courses.UpdateCourse)
+	admin.DELETE("/course/:id", h.courses.DeleteCourse)
+}
+
+func enrollmentRoutes(g *gin.RouterGroup, h *handlers) {
+	authed := g.Group("", middleware.Scope(apikeys.ScopeEnrollmentsManage), h.access.IsLogin)
+	authed.POST("/enroll/:id", h.enrollments.EnrollCourse)
+	authed.DELETE("/enroll/:id", h.enrollments.UnenrollCourse)
+	authed.GET("/enrollments", h.enrollments.GetEnrollments)
+}
+
+func lessonRoutes(g *gin.RouterGroup, h *handlers) {
+	admin := g.Group("", h.access.IsLogin, middleware.IsAdmin)
+	admin.POST("/lesson", h.lessons.CreateLesson)
+	admin.PUT("/lesson/:id", h.lessons.UpdateLesson)
+	admin.DELETE("/lesson/:id", h.lessons.DeleteLesson)
+	admin.GET("/lesson/:id/revisions", h.revisions.GetLessonRevisions)
+	admin.GET("/lesson/:id/revisions/diff", h.revisions.DiffLessonRevisions)
+	admin.GET("/lesson/:id/revisions/:version", h.revisions.GetLessonRevision)
+	admin.POST("/lesson/:id/revisions/:version/restore", h.lessons.RestoreLessonRevision)
+
+	enrolled := g.Group("/lesson/:id", h.access.IsEnrolledInLesson)
+	enrolled.GET("/attachments", h.attachments.GetAttachmentsByLessonID)
+	enrolled.GET("/progress", h.videos.GetPlaybackPosition)
+	enrolled.PUT("/progress", h.videos.UpdatePlaybackPosition)
+
+	staff := g.Group("/lesson/:id", h.access.IsLogin, h.access.IsLessonStaff)
+	staff.POST("/attachments", middleware.LargeTransfer(controllers.MaxAttachmentSize+multipartOverhead), h.attachments.UploadAttachment)
+	staff.POST("/video/uploads", h.videos.CreateVideoUpload)
+
+	//attachment - diunduh lewat /media/attachment/:id (download_url)
+	g.DELETE("/attachment/:id", h.access.IsLogin, h.access.IsAttachmentStaff, h.attachments.DeleteAttachment)
+
+	//video (tus)
+	g.OPTIONS("/lesson/:id/video/uploads", controllers.TusOptions)
+	g.OPTIONS("/video-upload/:id", controllers.TusOptions)
+	upload := g.Group("/video-upload/:id", h.access.IsLogin)
+	upload.HEAD("", h.videos.GetVideoUploadOffset)
+	upload.PATCH("", middleware.LargeTransfer(controllers.MaxVideoSize), h.videos.PatchVideoUpload)
+	upload.DELETE("", h.videos.DeleteVideoUpload)
+}
+
+func quizRoutes(g *gin.RouterGroup, h *handlers) {
+	admin := g.Group("", h.access.IsLogin, middleware.IsAdmin)
+	admin.POST("/quiz", h.quizzes.CreateQuiz)
+	admin.PUT("/quiz/:id", h.quizzes.UpdateQuiz)
+	admin.DELETE("/quiz/:id", h.quizzes.DeleteQuiz)
+	admin.GET("/quiz/:id/revisions", h.revisions.GetQuizRevisions)
+	admin.GET("/quiz/:id/revisions/diff", h.revisions.DiffQuizRevisions)
+	admin.GET("/quiz/:id/revisions/:version", h.revisions.GetQuizRevision)
+	admin.POST("/quiz/:id/revisions/:version/restore", h.quizzes.RestoreQuizRevision)
+
+	g.POST("/quiz/:id/submit", h.access.IsEnrolledInQuiz, h.quizzes.SubmitQuiz)
+	g.GET("/grades", middleware.Scope(apikeys.ScopeGradesRead), h.access.IsLogin, h.quizzes.GetGrades)
+
+	//answer
+	g.POST("/answer", h.access.IsLogin, h.quizzes.CreateAnswer)
+	admin.PUT("/answer/:id", h.quizzes.UpdateAnswer)
+	admin.DELETE("/answer/:id", h.quizzes.DeleteAnswer)
+}
@@ -0,0 +1,131 @@
+package routes_test
+
+import (
+	"backend-go/models"
+	"backend-go/testutil"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+type revisionList struct {
+	Data []models.Revision `json:"data"`
+}
+
+func TestLessonRevisionDiffAndRestore(t *testing.T) {
+	h := testutil.New(t)
+	admin := h.CreateUser("admin")
+	student := h.CreateUser("user")
+	course := h.CreateCourse(admin)
+	// Lesson dari fixture belum punya riwayat, seperti lesson lama sebelum revisi dicatat
+	lesson := h.CreateLesson(course, "# Intro\n\nold paragraph")
+	token := h.Token(admin)
+	base := fmt.Sprintf("/api/v1/lesson/%d", lesson.ID)
+
+	res := h.DoForm("PUT", base, map[string]string{"content": "# Intro\n\nnew paragraph"}, nil, token)
+	if res.Code != http.StatusOK {
+		t.Fatalf("update: %d %s", res.Code, res.Body)
+	}
+
+	// Keadaan sebelum edit pertama disimpan sebagai versi 1
+	var list revisionList
+	h.Expect(http.StatusOK, "GET", base+"/revisions", nil, token).JSON(t, &list)
+	if len(list.Data) != 2 || list.Data[0].Version != 2 || list.Data[1].Version != 1 || list.Data[1].Note != "initial state" {
+		t.Fatalf("revisions = %+v", list.Data)
+	}
+	if list.Data[1].AuthorID != nil || list.Data[0].AuthorID == nil || *list.Data[0].AuthorID != admin.ID {
+		t.Errorf("authors = %v, %v", list.Data[1].AuthorID, list.Data[0].AuthorID)
+	}
+	if list.Data[0].Snapshot != "" {
+		t.Errorf("list carries snapshots: %q", list.Data[0].Snapshot)
+	}
+
+	var first struct {
+		Data models.Revision `json:"data"`
+	}
+	h.Expect(http.StatusOK, "GET", base+"/revisions/1", nil, token).JSON(t, &first)
+	if !strings.Contains(first.Data.Snapshot, "old paragraph") {
+		t.Errorf("version 1 snapshot = %s", first.Data.Snapshot)
+	}
+
+	var diff struct {
+		Data struct {
+			From int    `json:"from"`
+			To   int    `json:"to"`
+			Diff string `json:"diff"`
+		} `json:"data"`
+	}
+	h.Expect(http.StatusOK, "GET", base+"/revisions/diff?from=1&to=2", nil, token).JSON(t, &diff)
+	if diff.Data.From != 1 || diff.Data.To != 2 ||
+		!strings.Contains(diff.Data.Diff, "--- version 1") || !strings.Contains(diff.Data.Diff, "+++ version 2") ||
+		!strings.Contains(diff.Data.Diff, "-old paragraph") || !strings.Contains(diff.Data.Diff, "+new paragraph") {
+		t.Fatalf("diff = %+v", diff.Data)
+	}
+	if strings.Contains(diff.Data.Diff, "name:") {
+		t.Errorf("unchanged fields in diff:\n%s", diff.Data.Diff)
+	}
+	h.Expect(http.StatusNotFound, "GET", base+"/revisions/diff?from=1&to=9", nil, token)
+	h.Expect(http.StatusBadRequest, "GET", base+"/revisions/diff?from=1", nil, token)
+
+	var restored struct {
+		Data models.Lesson `json:"data"`
+	}
+	h.Expect(http.StatusOK, "POST", base+"/revisions/1/restore", nil, token).JSON(t, &restored)
+	if restored.Data.Content != "# Intro\n\nold paragraph" || !strings.Contains(restored.Data.ContentHTML, "old paragraph") {
+		t.Fatalf("restored lesson = %+v", restored.Data)
+	}
+	h.Expect(http.StatusOK, "GET", base+"/revisions", nil, token).JSON(t, &list)
+	if len(list.Data) != 3 || list.Data[0].Note != "restored from version 1" {
+		t.Fatalf("revisions after restore = %+v", list.Data)
+	}
+
+	// Edit berikutnya tidak membuat versi awal lagi
+	h.DoForm("PUT", base, map[string]string{"name": "Renamed"}, nil, token)
+	h.Expect(http.StatusOK, "GET", base+"/revisions", nil, token).JSON(t, &list)
+	if len(list.Data) != 4 || list.Data[0].Version != 4 {
+		t.Fatalf("revisions after another edit = %+v", list.Data)
+	}
+
+	h.Expect(http.StatusNotFound, "POST", base+"/revisions/9/restore", nil, token)
+	h.Expect(http.StatusBadRequest, "GET", base+"/revisions/latest", nil, token)
+	h.Expect(http.StatusForbidden, "GET", base+"/revisions", nil, h.Token(student))
+	h.Expect(http.StatusForbidden, "POST", base+"/revisions/1/restore", nil, h.Token(student))
+}
+
+func TestQuizRevisionRestore(t *testing.T) {
+	h := testutil.New(t)
+	admin := h.CreateUser("admin")
+	course := h.CreateCourse(admin)
+	quiz, _ := h.CreateQuiz(course)
+	token := h.Token(admin)
+	base := fmt.Sprintf("/api/v1/quiz/%d", quiz.ID)
+
+	h.Expect(http.StatusOK, "PUT", base, map[string]interface{}{
+		"name": "Final exam", "description": "Everything", "course_id": course.ID,
+	}, token)
+
+	var diff struct {
+		Data struct {
+			Diff string `json:"diff"`
+		} `json:"data"`
+	}
+	h.Expect(http.StatusOK, "GET", base+"/revisions/diff?from=1&to=2", nil, token).JSON(t, &diff)
+	if !strings.Contains(diff.Data.Diff, "-name: "+quiz.Name) || !strings.Contains(diff.Data.Diff, "+name: Final exam") {
+		t.Fatalf("diff = %s", diff.Data.Diff)
+	}
+
+	var restored struct {
+		Data models.Quiz `json:"data"`
+	}
+	h.Expect(http.StatusOK, "POST", base+"/revisions/1/restore", nil, token).JSON(t, &restored)
+	if restored.Data.Name != quiz.Name || restored.Data.Description != quiz.Description {
+		t.Fatalf("restored quiz = %+v", restored.Data)
+	}
+
+	var list revisionList
+	h.Expect(http.StatusOK, "GET", base+"/revisions", nil, token).JSON(t, &list)
+	if len(list.Data) != 3 || list.Data[0].Note != "restored from version 1" {
+		t.Fatalf("revisions = %+v", list.Data)
+	}
+}
@@ -0,0 +1,175 @@
+package routes_test
+
+import (
+	"backend-go/models"
+	"backend-go/sso"
+	"backend-go/testutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+const ssoPublicURL = "http://api.test"
+
+// withSSO - Configure a provider called "campus" backed by a mock server
+func withSSO(t *testing.T, frontendURL string) *testutil.OIDCServer {
+	t.Helper()
+
+	idp := testutil.NewOIDCServer(t)
+	sso.Configure(sso.Settings{PublicURL: ssoPublicURL, FrontendURL: frontendURL}, []sso.ProviderConfig{{
+		Name:         "campus",
+		DisplayName:  "Campus login",
+		Issuer:       idp.URL,
+		ClientID:     idp.ClientID,
+		ClientSecret: idp.ClientSecret,
+		DefaultRole:  "user",
+	}})
+	t.Cleanup(func() { sso.Configure(sso.Settings{}, nil) })
+	return idp
+}
+
+// ssoLogin - Walk the browser through login → provider → callback and return
+// the callback response
+func ssoLogin(t *testing.T, h *testutil.Harness, tamperState bool) *testutil.Response {
+	t.Helper()
+
+	start := h.Expect(http.StatusFound, "GET", "/api/v1/auth/sso/campus/login", nil, "")
+	cookies := (&http.Response{Header: start.Header}).Cookies()
+	if len(cookies) != 1 || !cookies[0].HttpOnly {
+		t.Fatalf("login set cookies %v, want one HttpOnly flow cookie", cookies)
+	}
+
+	// Browser mengikuti redirect ke provider, provider langsung redirect balik
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	res, err := client.Get(start.Header.Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusFound {
+		t.Fatalf("provider answered %d", res.StatusCode)
+	}
+
+	callback, err := url.Parse(res.Header.Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(callback.String(), ssoPublicURL+"/api/v1/auth/sso/campus/callback?") {
+		t.Fatalf("provider redirected to %s", callback)
+	}
+	if tamperState {
+		q := callback.Query()
+		q.Set("state", "forged")
+		callback.RawQuery = q.Encode()
+	}
+
+	req := httptest.NewRequest("GET", callback.RequestURI(), nil)
+	req.AddCookie(cookies[0])
+	return h.Send(req)
+}
+
+func TestSSOProvisionsAndLinks(t *testing.T) {
+	h := testutil.New(t)
+	idp := withSSO(t, "")
+
+	var providers struct {
+		Data []struct {
+			Name     string `json:"name"`
+			LoginURL string `json:"login_url"`
+		} `json:"data"`
+	}
+	h.Expect(http.StatusOK, "GET", "/api/v1/auth/sso", nil, "").JSON(t, &providers)
+	if len(providers.Data) != 1 || providers.Data[0].LoginURL != "/api/v1/auth/sso/campus/login" {
+		t.Fatalf("providers = %+v", providers.Data)
+	}
+
+	// Identitas baru: user dibuat dengan role default
+	idp.LogInAs(testutil.OIDCIdentity{Subject: "s-1", Email: "ana@uni.example", EmailVerified: true, Username: "ana"})
+	var login struct {
+		Token string `json:"token"`
+	}
+	res := ssoLogin(t, h, false)
+	if res.Code != http.StatusOK {
+		t.Fatalf("callback: %d %s", res.Code, res.Body)
+	}
+	res.JSON(t, &login)
+	h.Expect(http.StatusOK, "GET", "/api/v1/courses", nil, login.Token)
+
+	var created models.User
+	if err := h.DB.Where("email = ?", "ana@uni.example").First(&created).Error; err != nil {
+		t.Fatalf("user was not provisioned: %v", err)
+	}
+	if created.Roles != "user" || created.Username != "ana" {
+		t.Errorf("provisioned %q with role %q", created.Username, created.Roles)
+	}
+
+	// Login berikutnya memakai identitas yang sama, walaupun email di provider berubah
+	idp.LogInAs(testutil.OIDCIdentity{Subject: "s-1", Email: "ana.new@uni.example", EmailVerified: true})
+	if res := ssoLogin(t, h, false); res.Code != http.StatusOK {
+		t.Fatalf("second login: %d %s", res.Code, res.Body)
+	}
+
+	// An existing password account is linked through its verified email
+	existing := h.CreateUser("admin")
+	idp.LogInAs(testutil.OIDCIdentity{Subject: "s-2", Email: existing.Email, EmailVerified: true})
+	if res := ssoLogin(t, h, false); res.Code != http.StatusOK {
+		t.Fatalf("linking login: %d %s", res.Code, res.Body)
+	}
+
+	var users, identities int64
+	h.DB.Model(&models.User{}).Count(&users)
+	h.DB.Model(&models.Identity{}).Count(&identities)
+	if users != 2 || identities != 2 {
+		t.Errorf("users=%d identities=%d, want 2 and 2", users, identities)
+	}
+	var link models.Identity
+	h.DB.Where("subject = ?", "s-2").First(&link)
+	if link.UserID != existing.ID {
+		t.Errorf("s-2 linked to user %d, want %d", link.UserID, existing.ID)
+	}
+}
+
+func TestSSORejects(t *testing.T) {
+	h := testutil.New(t)
+	idp := withSSO(t, "")
+
+	// Email yang belum diverifikasi tidak boleh menautkan akun yang ada
+	existing := h.CreateUser("admin")
+	idp.LogInAs(testutil.OIDCIdentity{Subject: "s-3", Email: existing.Email})
+	var body errorBody
+	res := ssoLogin(t, h, false)
+	res.JSON(t, &body)
+	if res.Code != http.StatusForbidden || body.Error.Code != "sso_email_unverified" {
+		t.Errorf("unverified email: %d %s", res.Code, res.Body)
+	}
+
+	idp.LogInAs(testutil.OIDCIdentity{Subject: "s-4", Email: "bo@uni.example", EmailVerified: true})
+	res = ssoLogin(t, h, true)
+	res.JSON(t, &body)
+	if res.Code != http.StatusBadRequest || body.Error.Code != "sso_invalid_state" {
+		t.Errorf("forged state: %d %s", res.Code, res.Body)
+	}
+
+	// Callback tanpa cookie flow
+	h.Expect(http.StatusBadRequest, "GET", "/api/v1/auth/sso/campus/callback?code=x&state=y", nil, "")
+	h.Expect(http.StatusNotFound, "GET", "/api/v1/auth/sso/nope/login", nil, "")
+}
+
+func TestSSORedirectsToFrontend(t *testing.T) {
+	h := testutil.New(t)
+	idp := withSSO(t, "http://app.test/sso")
+	idp.LogInAs(testutil.OIDCIdentity{Subject: "s-5", Email: "cy@uni.example", EmailVerified: true})
+
+	res := ssoLogin(t, h, false)
+	if res.Code != http.StatusFound {
+		t.Fatalf("callback: %d %s", res.Code, res.Body)
+	}
+	target, _ := url.Parse(res.Header.Get("Location"))
+	fragment, _ := url.ParseQuery(target.Fragment)
+	if target.Host != "app.test" || fragment.Get("token") == "" {
+		t.Fatalf("redirected to %s", target)
+	}
+	h.Expect(http.StatusOK, "GET", "/api/v1/courses", nil, fragment.Get("token"))
+}
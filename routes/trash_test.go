@@ -0,0 +1,171 @@
+package routes_test
+
+import (
+	"backend-go/models"
+	"backend-go/repository"
+	"backend-go/services"
+	"backend-go/storage"
+	"backend-go/testutil"
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// trashPage - One page of GET /admin/trash
+type trashPage struct {
+	Data []services.TrashItem `json:"data"`
+	Meta services.Page        `json:"meta"`
+}
+
+// createAttachment - An attachment of lesson whose file is in private storage
+func createAttachment(t *testing.T, h *testutil.Harness, lesson *models.Lesson, key string) *models.LessonAttachment {
+	t.Helper()
+	if _, err := storage.Private.Save(key, strings.NewReader("notes")); err != nil {
+		t.Fatal(err)
+	}
+	attachment := &models.LessonAttachment{
+		LessonID: lesson.ID, DisplayName: "notes.txt", FileName: "notes.txt", StorageKey: key,
+		MimeType: "text/plain", Size: 5, Checksum: "-", OrganizationID: lesson.OrganizationID,
+	}
+	if err := h.DB.Create(attachment).Error; err != nil {
+		t.Fatal(err)
+	}
+	return attachment
+}
+
+func TestTrashCascadeAndRestore(t *testing.T) {
+	h := testutil.New(t)
+	admin := h.CreateUser("admin")
+	token := h.Token(admin)
+	course := h.CreateCourse(admin)
+	lesson := h.CreateLesson(course, "# Intro")
+	attachment := createAttachment(t, h, lesson, "attachments/notes.txt")
+	quiz, answers := h.CreateQuiz(course, "a", "b")
+
+	// Jawaban b dihapus sendiri lebih dulu, jadi tidak ikut dipulihkan bersama course
+	h.Expect(http.StatusOK, "DELETE", fmt.Sprintf("/api/v1/answer/%d", answers[1].ID), nil, token)
+	h.Expect(http.StatusOK, "DELETE", fmt.Sprintf("/api/v1/course/%d", course.ID), nil, token)
+	h.Expect(http.StatusNotFound, "GET", fmt.Sprintf("/api/v1/course/%d", course.ID), nil, token)
+	for _, row := range []interface{}{&models.Lesson{}, &models.LessonAttachment{}, &models.Quiz{}, &models.Answer{}} {
+		var count int64
+		h.DB.Model(row).Count(&count)
+		if count != 0 {
+			t.Errorf("%T: %d rows left after deleting the course", row, count)
+		}
+	}
+
+	var page trashPage
+	h.Expect(http.StatusOK, "GET", "/api/v1/admin/trash", nil, token).JSON(t, &page)
+	if page.Meta.Total != 2 || page.Data[0].Type != "course" || page.Data[0].ID != course.ID || page.Data[1].Type != "answer" {
+		t.Fatalf("trash = %+v", page)
+	}
+	if item := page.Data[0]; item.Name != course.Name || item.PurgeAt.Sub(item.DeletedAt) != 30*24*time.Hour {
+		t.Errorf("course item = %+v", item)
+	}
+
+	h.Expect(http.StatusOK, "GET", "/api/v1/admin/trash?cascaded=true", nil, token).JSON(t, &page)
+	if page.Meta.Total != 6 {
+		t.Fatalf("trash with cascaded = %+v", page)
+	}
+	cascaded := map[string]bool{}
+	for _, item := range page.Data {
+		cascaded[fmt.Sprintf("%s/%d", item.Type, item.ID)] = item.Cascaded
+	}
+	if !cascaded[fmt.Sprintf("answer/%d", answers[0].ID)] || cascaded[fmt.Sprintf("answer/%d", answers[1].ID)] ||
+		!cascaded[fmt.Sprintf("attachment/%d", attachment.ID)] || cascaded[fmt.Sprintf("course/%d", course.ID)] {
+		t.Errorf("cascaded = %v", cascaded)
+	}
+
+	h.Expect(http.StatusOK, "GET", "/api/v1/admin/trash?type=lesson&cascaded=true", nil, token).JSON(t, &page)
+	if page.Meta.Total != 1 || page.Data[0].ParentType != "course" || page.Data[0].ParentID != course.ID {
+		t.Errorf("lessons in trash = %+v", page)
+	}
+
+	h.Expect(http.StatusConflict, "POST", fmt.Sprintf("/api/v1/admin/trash/lesson/%d/restore", lesson.ID), nil, token)
+
+	var restored struct {
+		Data services.TrashRestore `json:"data"`
+	}
+	h.Expect(http.StatusOK, "POST", fmt.Sprintf("/api/v1/admin/trash/course/%d/restore", course.ID), nil, token).JSON(t, &restored)
+	if restored.Data.Restored != 5 || restored.Data.Item.ID != course.ID {
+		t.Errorf("restore = %+v", restored.Data)
+	}
+	h.Expect(http.StatusOK, "GET", fmt.Sprintf("/api/v1/course/%d", course.ID), nil, token)
+	if err := h.DB.First(&models.LessonAttachment{}, attachment.ID).Error; err != nil {
+		t.Errorf("attachment not restored: %v", err)
+	}
+	if err := h.DB.First(&models.Answer{}, answers[0].ID).Error; err != nil {
+		t.Errorf("answer a not restored: %v", err)
+	}
+	if err := h.DB.First(&models.Answer{}, answers[1].ID).Error; err == nil {
+		t.Error("separately deleted answer b was restored with the course")
+	}
+
+	// Setelah quiz kembali, jawaban b bisa dipulihkan sendiri
+	h.Expect(http.StatusOK, "GET", "/api/v1/admin/trash", nil, token).JSON(t, &page)
+	if page.Meta.Total != 1 || page.Data[0].ParentID != quiz.ID {
+		t.Errorf("trash after restore = %+v", page)
+	}
+	h.Expect(http.StatusOK, "POST", fmt.Sprintf("/api/v1/admin/trash/answer/%d/restore", answers[1].ID), nil, token)
+
+	var audit auditPage
+	h.Expect(http.StatusOK, "GET", "/api/v1/admin/audit?action=course.restore", nil, token).JSON(t, &audit)
+	if audit.Meta.Total != 1 || audit.Data[0].EntityID != course.ID {
+		t.Errorf("course.restore entries = %+v", audit)
+	}
+
+	h.Expect(http.StatusNotFound, "POST", fmt.Sprintf("/api/v1/admin/trash/course/%d/restore", course.ID), nil, token)
+	h.Expect(http.StatusNotFound, "POST", fmt.Sprintf("/api/v1/admin/trash/user/%d/restore", admin.ID), nil, token)
+	h.Expect(http.StatusUnprocessableEntity, "GET", "/api/v1/admin/trash?type=user", nil, token)
+	h.Expect(http.StatusForbidden, "GET", "/api/v1/admin/trash", nil, h.Token(h.CreateUser("user")))
+}
+
+func TestTrashPurge(t *testing.T) {
+	h := testutil.New(t)
+	admin := h.CreateUser("admin")
+	token := h.Token(admin)
+	course := h.CreateCourse(admin)
+	lesson := h.CreateLesson(course, "# Intro")
+	if _, err := storage.Private.Save("videos/intro.mp4", strings.NewReader("video")); err != nil {
+		t.Fatal(err)
+	}
+	h.DB.Model(lesson).Update("video_key", "videos/intro.mp4")
+	createAttachment(t, h, lesson, "attachments/notes.txt")
+	kept := h.CreateCourse(admin)
+
+	h.Expect(http.StatusOK, "DELETE", fmt.Sprintf("/api/v1/course/%d", course.ID), nil, token)
+
+	trash := services.NewTrashService(repository.NewStore(h.DB))
+	if n, _ := trash.PurgeExpired(context.Background(), time.Now()); n != 0 {
+		t.Fatalf("purged %d items before their retention ended", n)
+	}
+	if n, err := trash.PurgeExpired(context.Background(), time.Now().Add(31*24*time.Hour)); n != 3 || err != nil {
+		t.Fatalf("purge: %d %v", n, err)
+	}
+
+	for _, row := range []interface{}{&models.Course{}, &models.Lesson{}, &models.LessonAttachment{}} {
+		var count int64
+		h.DB.Unscoped().Model(row).Count(&count)
+		if _, isCourse := row.(*models.Course); (isCourse && count != 1) || (!isCourse && count != 0) {
+			t.Errorf("%T: %d rows after purge", row, count)
+		}
+	}
+	if err := h.DB.First(&models.Course{}, kept.ID).Error; err != nil {
+		t.Errorf("live course purged: %v", err)
+	}
+	for _, key := range []string{"videos/intro.mp4", "attachments/notes.txt"} {
+		if f, err := storage.Private.Open(key); err == nil {
+			f.Close()
+			t.Errorf("%s still stored after purge", key)
+		}
+	}
+
+	var audit auditPage
+	h.Expect(http.StatusOK, "GET", "/api/v1/admin/audit?action=lesson.purge", nil, token).JSON(t, &audit)
+	if audit.Meta.Total != 1 || audit.Data[0].EntityID != lesson.ID || audit.Data[0].ActorID != nil {
+		t.Errorf("lesson.purge entries = %+v", audit)
+	}
+}
@@ -0,0 +1,191 @@
+package routes_test
+
+import (
+	"backend-go/apikeys"
+	"backend-go/models"
+	"backend-go/services"
+	"backend-go/testutil"
+	"backend-go/utils"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAdminListUsers(t *testing.T) {
+	h := testutil.New(t)
+	admin := h.CreateUser("admin")
+	token := h.Token(admin)
+	var users []uint
+	for i := 0; i < 3; i++ {
+		users = append(users, h.CreateUser("user").ID)
+	}
+	course := h.CreateCourse(admin)
+	target := h.CreateUser("user")
+	h.Enroll(target, course)
+
+	h.Expect(http.StatusForbidden, "GET", "/api/v1/admin/users", nil, h.Token(target))
+
+	var page struct {
+		Data []services.AdminUser `json:"data"`
+		Meta services.Page        `json:"meta"`
+	}
+	h.Expect(http.StatusOK, "GET", "/api/v1/admin/users?role=user&per_page=2&page=2", nil, token).JSON(t, &page)
+	if page.Meta.Total != 4 || page.Meta.Page != 2 || len(page.Data) != 2 || page.Data[0].ID != users[1] {
+		t.Errorf("page 2 = %+v", page)
+	}
+
+	h.Expect(http.StatusOK, "GET", "/api/v1/admin/users?q="+strings.ToUpper(target.Username), nil, token).JSON(t, &page)
+	if page.Meta.Total != 1 || page.Data[0].Email != target.Email {
+		t.Errorf("search = %+v", page)
+	}
+	// Karakter LIKE dicari apa adanya
+	h.Expect(http.StatusOK, "GET", "/api/v1/admin/users?q=%25", nil, token).JSON(t, &page)
+	if page.Meta.Total != 0 {
+		t.Errorf("searching for %% matched %d users", page.Meta.Total)
+	}
+	h.Expect(http.StatusUnprocessableEntity, "GET", "/api/v1/admin/users?status=gone", nil, token)
+
+	var detail struct {
+		Data services.AdminUserDetail `json:"data"`
+	}
+	h.Expect(http.StatusOK, "GET", fmt.Sprintf("/api/v1/admin/users/%d", target.ID), nil, token).JSON(t, &detail)
+	if detail.Data.Email != target.Email || len(detail.Data.Enrollments) != 1 {
+		t.Errorf("detail = %+v", detail.Data)
+	}
+	h.Expect(http.StatusNotFound, "GET", "/api/v1/admin/users/9999", nil, token)
+}
+
+func TestAdminRoleAndSuspension(t *testing.T) {
+	h := testutil.New(t)
+	box := testutil.NewMailbox(t)
+	admin := h.CreateUser("admin")
+	user := h.CreateUser("user")
+	adminToken, userToken := h.Token(admin), h.Token(user)
+	_, key := createAPIKey(t, h, userToken, map[string]interface{}{"name": "ci", "scopes": []string{apikeys.ScopeCoursesRead}})
+	path := fmt.Sprintf("/api/v1/admin/users/%d", user.ID)
+
+	// Role baru berlaku untuk token yang sudah ada
+	h.Expect(http.StatusForbidden, "GET", "/api/v1/admin/users", nil, userToken)
+	h.Expect(http.StatusOK, "PUT", path+"/role", map[string]string{"role": "admin"}, adminToken)
+	h.Expect(http.StatusOK, "GET", "/api/v1/admin/users", nil, userToken)
+
+	// Admin lain hanya bisa diturunkan, diblokir atau direset oleh superadmin
+	var body errorBody
+	h.Expect(http.StatusForbidden, "PUT", path+"/role", map[string]string{"role": "user"}, adminToken).JSON(t, &body)
+	if body.Error.Code != "cannot_manage_admin" {
+		t.Errorf("demoting another admin: %s", body.Error.Code)
+	}
+	h.Expect(http.StatusForbidden, "POST", path+"/suspend", map[string]string{"reason": "no"}, adminToken)
+	h.Expect(http.StatusForbidden, "POST", path+"/password-reset", nil, adminToken)
+	h.Expect(http.StatusOK, "PUT", path+"/role", map[string]string{"role": "user"}, h.Token(h.CreateUser("superadmin")))
+	h.Expect(http.StatusUnprocessableEntity, "PUT", path+"/role", map[string]string{"role": "owner"}, adminToken)
+
+	h.Expect(http.StatusConflict, "PUT", fmt.Sprintf("/api/v1/admin/users/%d/role", admin.ID), map[string]string{"role": "user"}, adminToken).JSON(t, &body)
+	if body.Error.Code != "cannot_manage_self" {
+		t.Errorf("demoting yourself: %s", body.Error.Code)
+	}
+
+	h.Expect(http.StatusOK, "POST", path+"/suspend", map[string]string{"reason": "spam"}, adminToken)
+	h.Expect(http.StatusForbidden, "GET", "/api/v1/courses", nil, userToken).JSON(t, &body)
+	if body.Error.Code != "account_suspended" {
+		t.Errorf("suspended session: %s", body.Error.Code)
+	}
+	h.Expect(http.StatusUnauthorized, "GET", "/api/v1/courses", nil, key)
+	h.Expect(http.StatusForbidden, "POST", "/api/v1/login", map[string]string{"email": user.Email, "password": testutil.Password}, "")
+	if len(box.To(user.Email)) != 1 {
+		t.Errorf("suspension notices = %+v", box.To(user.Email))
+	}
+
+	var listed struct {
+		Data []services.AdminUser `json:"data"`
+	}
+	h.Expect(http.StatusOK, "GET", "/api/v1/admin/users?status=suspended", nil, adminToken).JSON(t, &listed)
+	if len(listed.Data) != 1 || listed.Data[0].ID != user.ID || listed.Data[0].SuspensionReason != "spam" {
+		t.Errorf("suspended users = %+v", listed.Data)
+	}
+
+	h.Expect(http.StatusOK, "DELETE", path+"/suspend", nil, adminToken)
+	h.Expect(http.StatusOK, "GET", "/api/v1/courses", nil, userToken)
+	h.Expect(http.StatusOK, "GET", "/api/v1/courses", nil, key)
+	login(t, h, user.Email, testutil.Password)
+}
+
+func TestAdminForcePasswordReset(t *testing.T) {
+	h := testutil.New(t)
+	box := testutil.NewMailbox(t)
+	admin := h.CreateUser("admin")
+	user := h.CreateUser("user")
+	userToken := h.Token(user)
+
+	// Token iat berpresisi milidetik; beri jeda supaya token lama jelas lebih tua
+	time.Sleep(2 * time.Millisecond)
+	h.Expect(http.StatusAccepted, "POST", fmt.Sprintf("/api/v1/admin/users/%d/password-reset", user.ID), nil, h.Token(admin))
+	h.Expect(http.StatusUnauthorized, "GET", "/api/v1/courses", nil, userToken)
+	h.Expect(http.StatusUnauthorized, "POST", "/api/v1/login", map[string]string{"email": user.Email, "password": testutil.Password}, "")
+
+	sent := box.To(user.Email)
+	if len(sent) != 1 {
+		t.Fatalf("reset mails = %+v", sent)
+	}
+	resetToken := strings.Split(strings.TrimSpace(strings.Split(sent[0].Body, "\n\n")[1]), "\n")[0]
+
+	h.Expect(http.StatusBadRequest, "POST", "/api/v1/account/password/reset", map[string]string{"token": "nope", "new_password": "x"}, "")
+	h.Expect(http.StatusOK, "POST", "/api/v1/account/password/reset", map[string]string{"token": resetToken, "new_password": "fresh-pass"}, "")
+	h.Expect(http.StatusBadRequest, "POST", "/api/v1/account/password/reset", map[string]string{"token": resetToken, "new_password": "again"}, "")
+	login(t, h, user.Email, "fresh-pass")
+}
+
+func TestAdminImpersonation(t *testing.T) {
+	h := testutil.New(t)
+	admin := h.CreateUser("admin")
+	other := h.CreateUser("admin")
+	user := h.CreateUser("user")
+	course := h.CreateCourse(admin)
+	h.Enroll(user, course)
+	adminToken := h.Token(admin)
+
+	h.Expect(http.StatusForbidden, "POST", fmt.Sprintf("/api/v1/admin/users/%d/impersonate", other.ID), nil, adminToken)
+	h.Expect(http.StatusConflict, "POST", fmt.Sprintf("/api/v1/admin/users/%d/impersonate", admin.ID), nil, adminToken)
+
+	var issued struct {
+		Token string `json:"token"`
+	}
+	h.Expect(http.StatusOK, "POST", fmt.Sprintf("/api/v1/admin/users/%d/impersonate", user.ID), nil, adminToken).JSON(t, &issued)
+	claims, err := utils.ParseAccessToken(issued.Token)
+	if err != nil || claims.UserID != user.ID || claims.ImpersonatorID != admin.ID {
+		t.Fatalf("impersonation claims = %+v, %v", claims, err)
+	}
+
+	// Admin melihat apa yang dilihat user, tapi tidak bisa mengubah kredensialnya
+	h.Expect(http.StatusOK, "GET", "/api/v1/enrollments", nil, issued.Token)
+	var body errorBody
+	h.Expect(http.StatusForbidden, "GET", "/api/v1/account", nil, issued.Token).JSON(t, &body)
+	if body.Error.Code != "impersonation_not_allowed" {
+		t.Errorf("account while impersonating: %s", body.Error.Code)
+	}
+	h.Expect(http.StatusForbidden, "POST", "/api/v1/api-keys", map[string]interface{}{"name": "x", "scopes": []string{apikeys.ScopeCoursesRead}}, issued.Token)
+	h.Expect(http.StatusForbidden, "POST", "/api/v1/me/export", nil, issued.Token)
+	h.Expect(http.StatusForbidden, "GET", "/api/v1/admin/users", nil, issued.Token)
+
+	// Token berhenti berlaku begitu admin kehilangan rolenya
+	h.Expect(http.StatusOK, "PUT", fmt.Sprintf("/api/v1/admin/users/%d/role", admin.ID), map[string]string{"role": "user"}, h.Token(h.CreateUser("superadmin")))
+	h.Expect(http.StatusUnauthorized, "GET", "/api/v1/enrollments", nil, issued.Token)
+}
+
+func TestRegisterCreatesPlainUser(t *testing.T) {
+	h := testutil.New(t)
+
+	// Role dari request diabaikan; admin hanya dibuat lewat API admin
+	h.Expect(http.StatusOK, "POST", "/api/v1/register", map[string]string{
+		"email": "climber@example.com", "username": "climber", "password": testutil.Password, "role": "admin",
+	}, "")
+	var user models.User
+	if err := h.DB.Where("email = ?", "climber@example.com").First(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+	if user.Roles != models.RoleUser {
+		t.Fatalf("self-registered role = %q", user.Roles)
+	}
+}
@@ -0,0 +1,101 @@
+package routes
+
+import (
+	"backend-go/middleware"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LegacyDeprecation - The unprefixed routes are v1 served at the root. They
+// keep working until the sunset date so old mobile builds have time to update.
+var LegacyDeprecation = middleware.Deprecation{
+	Since:     time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC),
+	Sunset:    time.Date(2027, time.April, 30, 0, 0, 0, 0, time.UTC),
+	Successor: middleware.MovedTo("", "/api/v1"),
+}
+
+// V1ReadsDeprecation - v1 lesson and quiz reads; v2 replaces them with
+// clearer paths and drops the list routes that never passed the enrollment check.
+var V1ReadsDeprecation = middleware.Deprecation{
+	Since:     time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC),
+	Sunset:    time.Date(2027, time.October, 31, 0, 0, 0, 0, time.UTC),
+	Successor: middleware.MovedTo("/api/v1", "/api/v2"),
+}
+
+// registerV1 - The original API. Its routes must not change in a breaking way;
+// fixes that would break clients go to v2 instead.
+func registerV1(g *gin.RouterGroup, h *handlers) {
+	authRoutes(g, h)
+	mfaRoutes(g, h)
+	accountRoutes(g, h)
+	exportRoutes(g, h)
+	apiKeyRoutes(g, h)
+	userAdminRoutes(g, h)
+	auditRoutes(g, h)
+	trashRoutes(g, h)
+	organizationRoutes(g, h)
+	profileRoutes(g, h)
+	courseRoutes(g, h)
+	enrollmentRoutes(g, h)
+	lessonRoutes(g, h)
+	quizRoutes(g, h)
+
+	// Rute daftar membaca :id yang tidak ada, jadi tidak pernah lolos; tetap ada demi kompatibilitas
+	lists := g.Group("", middleware.Deprecated(V1ReadsDeprecation), h.access.IsEnrolled)
+	lists.GET("/lessons", h.lessons.GetLessons)
+	lists.GET("/quizzes", h.quizzes.GetQuizzes)
+
+	// Path v1 tetap, tapi enrollment dicek lewat course pemilik lesson/quiz/answer
+	reads := g.Group("", middleware.Deprecated(V1ReadsDeprecation))
+	reads.GET("/lesson/:id", h.access.IsEnrolledInLesson, h.lessons.GetLessonByID)
+	reads.GET("/quiz/:id", h.access.IsEnrolledInQuiz, h.quizzes.GetQuizByID)
+	reads.GET("/answer/:id", h.access.IsEnrolledInAnswer, h.quizzes.GetAnswerByID)
+
+	answers := g.Group("", middleware.Deprecated(middleware.Deprecation{
+		Since:  V1ReadsDeprecation.Since,
+		Sunset: V1ReadsDeprecation.Sunset,
+		Successor: func(c *gin.Context) string {
+			return "/api/v2/quiz/" + c.Param("id") + "/answers"
+		},
+	}), h.access.IsEnrolledInQuiz)
+	answers.GET("/answers/:id/question", h.quizzes.GetAnswersByQuizID)
+
+	//test
+	admin := g.Group("", h.access.IsLogin, middleware.IsAdmin)
+	admin.GET("/protected", func(ctx *gin.Context) {
+		ctx.JSON(200, gin.H{
+			"message": "Hello World",
+			"data": gin.H{ // Gunakan gin.H untuk nested map
+				"user_id": ctx.GetInt("userId"),
+				"role":    ctx.GetString("role"),
+			},
+		})
+	})
+}
+
+// registerV2 - Same as v1 except lesson and quiz reads authorize through the
+// lesson or quiz itself, and the list routes that could never pass the
+// enrollment check are gone (use /course/:id/lessons and /course/:id/quizzes).
+func registerV2(g *gin.RouterGroup, h *handlers) {
+	authRoutes(g, h)
+	mfaRoutes(g, h)
+	accountRoutes(g, h)
+	exportRoutes(g, h)
+	apiKeyRoutes(g, h)
+	userAdminRoutes(g, h)
+	auditRoutes(g, h)
+	trashRoutes(g, h)
+	organizationRoutes(g, h)
+	profileRoutes(g, h)
+	courseRoutes(g, h)
+	enrollmentRoutes(g, h)
+	lessonRoutes(g, h)
+	quizRoutes(g, h)
+
+	g.GET("/lesson/:id", h.access.IsEnrolledInLesson, h.lessons.GetLessonByID)
+
+	quiz := g.Group("/quiz/:id", h.access.IsEnrolledInQuiz)
+	quiz.GET("", h.quizzes.GetQuizByID)
+	quiz.GET("/answers", h.quizzes.GetAnswersByQuizID)
+}
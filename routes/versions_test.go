@@ -0,0 +1,126 @@
+package routes_test
+
+import (
+	"backend-go/middleware"
+	"backend-go/testutil"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestLegacyRoutesAreDeprecatedAliases(t *testing.T) {
+	h := testutil.New(t)
+	token := h.Token(h.CreateUser("user"))
+
+	res := h.Expect(http.StatusOK, "GET", "/courses", nil, token)
+	if !strings.HasPrefix(res.Header.Get("Deprecation"), "@") || res.Header.Get("Sunset") == "" {
+		t.Fatalf("legacy route missing deprecation headers: %v", res.Header)
+	}
+	if got, want := res.Header.Get("Link"), `</api/v1/courses>; rel="successor-version"`; got != want {
+		t.Fatalf("Link = %q, want %q", got, want)
+	}
+
+	res = h.Expect(http.StatusOK, "GET", "/api/v1/courses", nil, token)
+	if res.Header.Get("Deprecation") != "" {
+		t.Fatalf("v1 route should not be deprecated: %v", res.Header)
+	}
+}
+
+func TestV2ReadsAuthorizeThroughLessonAndQuiz(t *testing.T) {
+	h := testutil.New(t)
+	admin := h.CreateUser("admin")
+	student := h.CreateUser("user")
+	outsider := h.CreateUser("user")
+
+	// Course kedua supaya lesson/quiz id tidak kebetulan sama dengan course id
+	h.CreateCourse(admin)
+	course := h.CreateCourse(admin)
+	h.CreateLesson(h.CreateCourse(admin), "filler")
+	lesson := h.CreateLesson(course, "# Hello")
+	h.CreateQuiz(h.CreateCourse(admin), "filler")
+	quiz, _ := h.CreateQuiz(course, "yes", "no")
+	h.Enroll(student, course)
+
+	h.Expect(http.StatusOK, "GET", fmt.Sprintf("/api/v2/lesson/%d", lesson.ID), nil, h.Token(student))
+	h.Expect(http.StatusForbidden, "GET", fmt.Sprintf("/api/v2/lesson/%d", lesson.ID), nil, h.Token(outsider))
+	h.Expect(http.StatusOK, "GET", fmt.Sprintf("/api/v2/quiz/%d", quiz.ID), nil, h.Token(student))
+
+	var answers struct {
+		Data []struct {
+			Content string `json:"content"`
+		} `json:"data"`
+	}
+	h.Expect(http.StatusOK, "GET", fmt.Sprintf("/api/v2/quiz/%d/answers", quiz.ID), nil, h.Token(student)).JSON(t, &answers)
+	if len(answers.Data) != 2 {
+		t.Fatalf("got %d answers, want 2", len(answers.Data))
+	}
+
+	// Rute v1 yang digantikan menunjuk ke penggantinya di v2
+	res := h.Do("GET", fmt.Sprintf("/api/v1/lesson/%d", lesson.ID), nil, h.Token(student))
+	if got, want := res.Header.Get("Link"), fmt.Sprintf(`</api/v2/lesson/%d>; rel="successor-version"`, lesson.ID); got != want {
+		t.Fatalf("Link = %q, want %q", got, want)
+	}
+	res = h.Do("GET", fmt.Sprintf("/api/v1/answers/%d/question", quiz.ID), nil, h.Token(student))
+	if got, want := res.Header.Get("Link"), fmt.Sprintf(`</api/v2/quiz/%d/answers>; rel="successor-version"`, quiz.ID); got != want {
+		t.Fatalf("Link = %q, want %q", got, want)
+	}
+}
+
+func TestV1ReadsAuthorizeThroughLessonAndQuiz(t *testing.T) {
+	h := testutil.New(t)
+	admin := h.CreateUser("admin")
+	student := h.CreateUser("user")
+
+	// Lesson, quiz dan answer milik course lain, dengan id yang sama dengan
+	// course yang diikuti student
+	other := h.CreateCourse(admin)
+	enrolled := h.CreateCourse(admin)
+	h.CreateLesson(enrolled, "filler")
+	lesson := h.CreateLesson(other, "# Secret")
+	h.CreateQuiz(enrolled, "filler")
+	quiz, answers := h.CreateQuiz(other, "yes", "no")
+	if lesson.ID != enrolled.ID || quiz.ID != enrolled.ID || answers[0].ID != enrolled.ID {
+		t.Fatalf("ids do not line up: course %d, lesson %d, quiz %d, answer %d", enrolled.ID, lesson.ID, quiz.ID, answers[0].ID)
+	}
+	h.Enroll(student, enrolled)
+	token := h.Token(student)
+
+	for _, prefix := range []string{"/api/v1", ""} {
+		for _, path := range []string{
+			fmt.Sprintf("/lesson/%d", lesson.ID),
+			fmt.Sprintf("/quiz/%d", quiz.ID),
+			fmt.Sprintf("/answer/%d", answers[0].ID),
+			fmt.Sprintf("/answers/%d/question", quiz.ID),
+		} {
+			h.Expect(http.StatusForbidden, "GET", prefix+path, nil, token)
+		}
+	}
+
+	h.Enroll(student, other)
+	h.Expect(http.StatusOK, "GET", fmt.Sprintf("/api/v1/lesson/%d", lesson.ID), nil, token)
+	h.Expect(http.StatusOK, "GET", fmt.Sprintf("/api/v1/quiz/%d", quiz.ID), nil, token)
+	h.Expect(http.StatusOK, "GET", fmt.Sprintf("/api/v1/answer/%d", answers[0].ID), nil, token)
+	h.Expect(http.StatusOK, "GET", fmt.Sprintf("/api/v1/answers/%d/question", quiz.ID), nil, token)
+	h.Expect(http.StatusNotFound, "GET", "/api/v1/answer/9999", nil, token)
+}
+
+func TestSunsetRouteIsGone(t *testing.T) {
+	r := gin.New()
+	r.Use(middleware.ErrorHandler)
+	r.GET("/old", middleware.Deprecated(middleware.Deprecation{
+		Since:     time.Now().Add(-48 * time.Hour),
+		Sunset:    time.Now().Add(-time.Hour),
+		Successor: middleware.MovedTo("", "/api/v1"),
+	}), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/old", nil))
+	if w.Code != http.StatusGone || !strings.Contains(w.Body.String(), `"api_sunset"`) {
+		t.Fatalf("got %d %s, want 410 api_sunset", w.Code, w.Body)
+	}
+}
@@ -0,0 +1,201 @@
+package routes_test
+
+import (
+	"backend-go/models"
+	"backend-go/repository"
+	"backend-go/services"
+	"backend-go/storage"
+	"backend-go/testutil"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// mp4Bytes - Enough of an MP4 for content sniffing, padded to size
+func mp4Bytes(size int) []byte {
+	data := []byte("\x00\x00\x00\x18ftypisom\x00\x00\x02\x00isomiso2mp41")
+	return append(data, bytes.Repeat([]byte{0}, size-len(data))...)
+}
+
+// tus - Send a tus request with the given headers and body
+func tus(h *testutil.Harness, method, path, token string, headers map[string]string, body []byte) *testutil.Response {
+	req := httptest.NewRequest(method, path, bytes.NewReader(body))
+	req.Header.Set("Tus-Resumable", "1.0.0")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return h.Send(req)
+}
+
+// createUpload - Start an upload of length bytes for the lesson and return its URL
+func createUpload(t *testing.T, h *testutil.Harness, lessonID uint, token, filename string, length int) string {
+	t.Helper()
+	res := tus(h, "POST", fmt.Sprintf("/api/v1/lesson/%d/video/uploads", lessonID), token, map[string]string{
+		"Upload-Length":   strconv.Itoa(length),
+		"Upload-Metadata": "filename " + base64.StdEncoding.EncodeToString([]byte(filename)),
+	}, nil)
+	if res.Code != http.StatusCreated || res.Header.Get("Upload-Offset") != "0" {
+		t.Fatalf("create upload: %d %v %s", res.Code, res.Header, res.Body)
+	}
+	location := res.Header.Get("Location")
+	if !strings.HasPrefix(location, "/api/v1/video-upload/") {
+		t.Fatalf("Location = %q", location)
+	}
+	return location
+}
+
+// patch - Send a chunk at offset
+func patch(h *testutil.Harness, location, token string, offset int, chunk []byte) *testutil.Response {
+	return tus(h, "PATCH", location, token, map[string]string{
+		"Content-Type":  "application/offset+octet-stream",
+		"Upload-Offset": strconv.Itoa(offset),
+	}, chunk)
+}
+
+func TestVideoUpload(t *testing.T) {
+	h := testutil.New(t)
+	owner := h.CreateUser("user")
+	student := h.CreateUser("user")
+	course := h.CreateCourse(owner)
+	lesson := h.CreateLesson(course, "# Watch")
+	h.Enroll(student, course)
+	token := h.Token(owner)
+
+	res := tus(h, "OPTIONS", fmt.Sprintf("/api/v1/lesson/%d/video/uploads", lesson.ID), "", nil, nil)
+	if res.Code != http.StatusNoContent || res.Header.Get("Tus-Version") != "1.0.0" || res.Header.Get("Tus-Extension") != "creation,termination" {
+		t.Fatalf("OPTIONS: %d %v", res.Code, res.Header)
+	}
+
+	create := fmt.Sprintf("/api/v1/lesson/%d/video/uploads", lesson.ID)
+	h.Expect(http.StatusPreconditionFailed, "POST", create, nil, token)
+	if res := tus(h, "POST", create, h.Token(student), map[string]string{"Upload-Length": "10"}, nil); res.Code != http.StatusForbidden {
+		t.Fatalf("student creates upload: %d", res.Code)
+	}
+	if res := tus(h, "POST", create, token, nil, nil); res.Code != http.StatusBadRequest {
+		t.Fatalf("missing Upload-Length: %d", res.Code)
+	}
+
+	video := mp4Bytes(64)
+	location := createUpload(t, h, lesson.ID, token, "intro.mp4", len(video))
+
+	// Upload hanya terlihat oleh user yang memulainya
+	if res := tus(h, "HEAD", location, h.Token(student), nil, nil); res.Code != http.StatusNotFound {
+		t.Fatalf("HEAD by someone else: %d", res.Code)
+	}
+	res = tus(h, "HEAD", location, token, nil, nil)
+	if res.Code != http.StatusOK || res.Header.Get("Upload-Offset") != "0" || res.Header.Get("Upload-Length") != "64" {
+		t.Fatalf("HEAD: %d %v", res.Code, res.Header)
+	}
+
+	if res := tus(h, "PATCH", location, token, map[string]string{"Upload-Offset": "0"}, video[:20]); res.Code != http.StatusUnsupportedMediaType {
+		t.Fatalf("PATCH without offset content type: %d", res.Code)
+	}
+	if res := patch(h, location, token, 0, video[:20]); res.Code != http.StatusNoContent || res.Header.Get("Upload-Offset") != "20" {
+		t.Fatalf("first chunk: %d %v %s", res.Code, res.Header, res.Body)
+	}
+
+	// Chunk yang dikirim ulang dari offset lama ditolak dengan offset sekarang
+	res = patch(h, location, token, 0, video[:20])
+	if res.Code != http.StatusConflict || res.Header.Get("Upload-Offset") != "20" || mediaError(t, res) != "upload_offset_mismatch" {
+		t.Fatalf("stale offset: %d %v %s", res.Code, res.Header, res.Body)
+	}
+	if res := tus(h, "HEAD", location, token, nil, nil); res.Header.Get("Upload-Offset") != "20" {
+		t.Fatalf("offset after resend = %q", res.Header.Get("Upload-Offset"))
+	}
+
+	// Offset hanya berpindah kalau tidak ada yang memindahkannya lebih dulu
+	var upload models.VideoUpload
+	if err := h.DB.Where("lesson_id = ?", lesson.ID).First(&upload).Error; err != nil {
+		t.Fatal(err)
+	}
+	videos := repository.NewStore(h.DB).Videos()
+	if moved, err := videos.AdvanceUploadOffset(upload.ID, 0, 40); err != nil || moved {
+		t.Fatalf("advance from a stale offset: %v %v", moved, err)
+	}
+
+	if res := patch(h, location, token, 20, video[20:]); res.Code != http.StatusNoContent || res.Header.Get("Upload-Offset") != "64" {
+		t.Fatalf("last chunk: %d %v %s", res.Code, res.Header, res.Body)
+	}
+	if code := mediaError(t, patch(h, location, token, 64, []byte("x"))); code != "upload_completed" {
+		t.Errorf("PATCH after completion: %s", code)
+	}
+
+	var stored models.Lesson
+	h.DB.First(&stored, lesson.ID)
+	if stored.Type != models.LessonTypeVideo || stored.VideoMimeType != "video/mp4" || stored.VideoSize != 64 {
+		t.Fatalf("lesson after upload = %+v", stored)
+	}
+	stream := fmt.Sprintf("/media/lesson/%d/video", lesson.ID)
+	req := httptest.NewRequest("GET", stream, nil)
+	req.Header.Set("Authorization", "Bearer "+h.Token(student))
+	req.Header.Set("Range", "bytes=0-9")
+	if res := h.Send(req); res.Code != http.StatusPartialContent || !bytes.Equal(res.Body, video[:10]) {
+		t.Fatalf("range request: %d %q", res.Code, res.Body)
+	}
+
+	// Video baru menggantikan yang lama; file lama tetap ada untuk revisi lama
+	replacement := mp4Bytes(32)
+	second := createUpload(t, h, lesson.ID, token, "intro-v2.mp4", len(replacement))
+	if res := patch(h, second, token, 0, replacement); res.Code != http.StatusNoContent {
+		t.Fatalf("replacement: %d %s", res.Code, res.Body)
+	}
+	var replaced models.Lesson
+	h.DB.First(&replaced, lesson.ID)
+	if replaced.VideoKey == stored.VideoKey || replaced.VideoSize != 32 {
+		t.Fatalf("lesson after replacement = %+v", replaced)
+	}
+	if res := h.Send(req); res.Code != http.StatusPartialContent || !bytes.Equal(res.Body, replacement[:10]) {
+		t.Fatalf("range request after replacement: %d %q", res.Code, res.Body)
+	}
+
+	// Revisi upload pertama bisa dipulihkan dan videonya masih bisa diputar
+	admin := h.Token(h.CreateUser("admin"))
+	var first models.Revision
+	h.DB.Where("entity_type = ? AND entity_id = ? AND note = ?", models.RevisionLesson, lesson.ID, "video uploaded").Order("version").First(&first)
+	h.Expect(http.StatusOK, "POST", fmt.Sprintf("/api/v1/lesson/%d/revisions/%d/restore", lesson.ID, first.Version), nil, admin)
+	if res := h.Send(req); res.Code != http.StatusPartialContent || !bytes.Equal(res.Body, video[:10]) {
+		t.Fatalf("range request after restoring the first video: %d %q", res.Code, res.Body)
+	}
+
+	// Bukan video: upload dibuang, lesson tidak berubah
+	text := []byte("just some notes, not a video at all")
+	notVideo := createUpload(t, h, lesson.ID, token, "notes.mp4", len(text))
+	if code := mediaError(t, patch(h, notVideo, token, 0, text)); code != "not_a_video" {
+		t.Errorf("non-video upload: %s", code)
+	}
+	if res := tus(h, "HEAD", notVideo, token, nil, nil); res.Code != http.StatusNotFound {
+		t.Errorf("HEAD after rejected upload: %d", res.Code)
+	}
+
+	abandoned := createUpload(t, h, lesson.ID, token, "draft.mp4", 100)
+	patch(h, abandoned, token, 0, video[:10])
+	if res := tus(h, "DELETE", abandoned, token, nil, nil); res.Code != http.StatusNoContent {
+		t.Fatalf("DELETE: %d", res.Code)
+	}
+	if res := tus(h, "HEAD", abandoned, token, nil, nil); res.Code != http.StatusNotFound {
+		t.Errorf("HEAD after DELETE: %d", res.Code)
+	}
+
+	// Purge lesson menghapus semua video yang pernah diunggah
+	h.Expect(http.StatusOK, "DELETE", fmt.Sprintf("/api/v1/lesson/%d", lesson.ID), nil, admin)
+	trash := services.NewTrashService(repository.NewStore(h.DB))
+	if _, err := trash.PurgeExpired(context.Background(), time.Now().Add(31*24*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{stored.VideoKey, replaced.VideoKey} {
+		if f, err := storage.Private.Open(key); err == nil {
+			f.Close()
+			t.Errorf("video %s still stored after purge", key)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+// Package server runs the HTTP server with production settings and shuts it
+// down gracefully.
+package server
+
+import (
+	"backend-go/background"
+	"backend-go/config"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+)
+
+// New - An http.Server for handler with the configured timeouts and limits
+func New(cfg config.ServerConfig, handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:              cfg.Addr,
+		Handler:           handler,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		ReadTimeout:       cfg.ReadTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+		MaxHeaderBytes:    cfg.MaxHeaderBytes,
+		ErrorLog:          slog.NewLogLogger(slog.Default().Handler(), slog.LevelWarn),
+	}
+}
+
+// Run - Serve until ctx is cancelled (e.g. by SIGTERM), then stop accepting
+// connections, let in-flight requests and background work finish and return.
+// Everything must be drained within cfg.ShutdownTimeout.
+func Run(ctx context.Context, srv *http.Server, cfg config.ServerConfig) error {
+	errs := make(chan error, 1)
+	go func() {
+		var err error
+		if cfg.TLSEnabled() {
+			slog.Info("listening with TLS", "addr", srv.Addr)
+			err = srv.ListenAndServeTLS(cfg.TLSCertFile, cfg.TLSKeyFile)
+		} else {
+			slog.Info("listening", "addr", srv.Addr)
+			err = srv.ListenAndServe()
+		}
+		errs <- err
+	}()
+
+	select {
+	case err := <-errs:
+		// Server berhenti sendiri sebelum ada sinyal, misalnya port sudah dipakai
+		return err
+	case <-ctx.Done():
+	}
+
+	slog.Info("shutting down", "timeout", cfg.ShutdownTimeout.String())
+	deadline, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+
+	// Urutan penting: request yang sedang berjalan bisa memulai background work
+	var failed []error
+	if err := srv.Shutdown(deadline); err != nil {
+		failed = append(failed, fmt.Errorf("drain http: %w", err))
+	}
+	if err := background.Shutdown(deadline); err != nil {
+		failed = append(failed, fmt.Errorf("drain background work: %w", err))
+	}
+	if err := <-errs; err != nil && !errors.Is(err, http.ErrServerClosed) {
+		failed = append(failed, err)
+	}
+	return errors.Join(failed...)
+}
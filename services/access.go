@@ -0,0 +1,134 @@
+package services
+
+import (
+	"backend-go/models"
+	"backend-go/repository"
+	"backend-go/tenancy"
+	"context"
+)
+
+// AccessService - Who may read and who may manage a course's material
+type AccessService struct {
+	store repository.Store
+}
+
+// NewAccessService - Create an AccessService on top of store
+func NewAccessService(store repository.Store) *AccessService {
+	return &AccessService{store: store}
+}
+
+// Lesson - Lesson by id, without rendering its content
+func (s *AccessService) Lesson(ctx context.Context, id uint) (*models.Lesson, error) {
+	store := s.store.WithContext(ctx)
+	lesson, err := store.Lessons().FindByID(id)
+	if err == repository.ErrNotFound {
+		return nil, ErrLessonNotFound
+	}
+	return lesson, err
+}
+
+// Attachment - Attachment by id with its lesson
+func (s *AccessService) Attachment(ctx context.Context, id uint) (*models.LessonAttachment, error) {
+	store := s.store.WithContext(ctx)
+	attachment, err := store.Attachments().FindByID(id)
+	if err == repository.ErrNotFound {
+		return nil, ErrAttachmentNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	// Lesson-nya sudah dihapus (soft delete)
+	if attachment.Lesson == nil {
+		return nil, ErrLessonNotFound
+	}
+	return attachment, nil
+}
+
+// Quiz - Quiz by id
+func (s *AccessService) Quiz(ctx context.Context, id uint) (*models.Quiz, error) {
+	store := s.store.WithContext(ctx)
+	quiz, err := store.Quizzes().FindByID(id)
+	if err == repository.ErrNotFound {
+		return nil, ErrQuizNotFound
+	}
+	return quiz, err
+}
+
+// AnswerQuiz - Quiz the answer id belongs to
+func (s *AccessService) AnswerQuiz(ctx context.Context, id uint) (*models.Quiz, error) {
+	store := s.store.WithContext(ctx)
+	answer, err := store.Quizzes().FindAnswer(id)
+	if err == repository.ErrNotFound {
+		return nil, ErrAnswerNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	quiz, err := store.Quizzes().FindByID(answer.QuizID)
+	// Quiz-nya sudah dihapus, jawabannya ikut tidak terlihat
+	if err == repository.ErrNotFound {
+		return nil, ErrAnswerNotFound
+	}
+	return quiz, err
+}
+
+// IsStaff - The course owner, admins of the organization ctx is for and
+// superadmins may manage a course's material. ctx must be for the caller's
+// organization (the Tenant middleware, or the media routes) so an admin of
+// one school is never staff of another's courses.
+func (s *AccessService) IsStaff(ctx context.Context, userID uint, role string, courseID uint) (bool, error) {
+	store := s.store.WithContext(ctx)
+	course, err := store.Courses().FindByID(courseID)
+	if err == repository.ErrNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	switch role {
+	case models.RoleSuperAdmin:
+		return true, nil
+	case models.RoleAdmin:
+		if course.OrganizationID == tenancy.Current(ctx) {
+			return true, nil
+		}
+	}
+	return course.UserID == userID, nil
+}
+
+// CheckStaff - ErrNotStaff unless userID is staff of courseID
+func (s *AccessService) CheckStaff(ctx context.Context, userID uint, role string, courseID uint) error {
+	staff, err := s.IsStaff(ctx, userID, role, courseID)
+	if err != nil {
+		return err
+	}
+	if !staff {
+		return ErrNotStaff
+	}
+	return nil
+}
+
+// CheckEnrolled - ErrNotEnrolled unless userID is enrolled in courseID
+func (s *AccessService) CheckEnrolled(ctx context.Context, userID, courseID uint) error {
+	store := s.store.WithContext(ctx)
+	if _, err := store.Enrollments().Find(userID, courseID); err != nil {
+		if err == repository.ErrNotFound {
+			return ErrNotEnrolled
+		}
+		return err
+	}
+	return nil
+}
+
+// CheckStaffOrEnrolled - Course staff always pass, everyone else must be enrolled
+func (s *AccessService) CheckStaffOrEnrolled(ctx context.Context, userID uint, role string, courseID uint) error {
+	staff, err := s.IsStaff(ctx, userID, role, courseID)
+	if err != nil {
+		return err
+	}
+	if staff {
+		return nil
+	}
+	return s.CheckEnrolled(ctx, userID, courseID)
+}
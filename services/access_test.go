@@ -0,0 +1,112 @@
+package services_test
+
+import (
+	"backend-go/models"
+	"backend-go/repository"
+	"backend-go/services"
+	"backend-go/tenancy"
+	"context"
+	"errors"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+// fakeStore - Store keeping rows in memory. Transactions run in place without
+// rollback. Repositories a test does not set up panic through the nil
+// embedded interfaces.
+type fakeStore struct {
+	repository.Store
+	courses     *fakeCourses
+	enrollments *fakeEnrollments
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{
+		courses:     &fakeCourses{rows: map[uint]models.Course{}},
+		enrollments: &fakeEnrollments{rows: map[[2]uint]bool{}},
+	}
+}
+
+func (s *fakeStore) Transaction(fn func(tx repository.Store) error) error { return fn(s) }
+
+func (s *fakeStore) Courses() repository.CourseRepository         { return s.courses }
+func (s *fakeStore) Enrollments() repository.EnrollmentRepository { return s.enrollments }
+func (s *fakeStore) WithContext(context.Context) repository.Store { return s }
+
+type fakeCourses struct {
+	repository.CourseRepository
+	rows map[uint]models.Course
+	err  error
+}
+
+func (r *fakeCourses) FindByID(id uint) (*models.Course, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	course, ok := r.rows[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return &course, nil
+}
+
+type fakeEnrollments struct {
+	repository.EnrollmentRepository
+	rows map[[2]uint]bool
+}
+
+func (r *fakeEnrollments) Find(userID, courseID uint) (*models.Enrollment, error) {
+	if !r.rows[[2]uint{userID, courseID}] {
+		return nil, repository.ErrNotFound
+	}
+	return &models.Enrollment{UserID: userID, CourseID: courseID}, nil
+}
+
+func TestAccessStaffOrEnrolled(t *testing.T) {
+	store := newFakeStore()
+	store.courses.rows[1] = models.Course{Model: gorm.Model{ID: 1}, UserID: 10, OrganizationID: 1}
+	store.enrollments.rows[[2]uint{20, 1}] = true
+	access := services.NewAccessService(store)
+	ctx := tenancy.WithOrganization(context.Background(), 1)
+
+	cases := []struct {
+		name     string
+		userID   uint
+		role     string
+		courseID uint
+		staff    error
+		reader   error
+	}{
+		{"owner", 10, models.RoleUser, 1, nil, nil},
+		{"admin", 99, models.RoleAdmin, 1, nil, nil},
+		{"superadmin", 98, models.RoleSuperAdmin, 1, nil, nil},
+		{"student", 20, models.RoleUser, 1, services.ErrNotStaff, nil},
+		{"outsider", 30, models.RoleUser, 1, services.ErrNotStaff, services.ErrNotEnrolled},
+		{"missing course", 10, models.RoleUser, 2, services.ErrNotStaff, services.ErrNotEnrolled},
+	}
+	for _, tc := range cases {
+		if err := access.CheckStaff(ctx, tc.userID, tc.role, tc.courseID); err != tc.staff {
+			t.Errorf("%s: CheckStaff = %v, want %v", tc.name, err, tc.staff)
+		}
+		if err := access.CheckStaffOrEnrolled(ctx, tc.userID, tc.role, tc.courseID); err != tc.reader {
+			t.Errorf("%s: CheckStaffOrEnrolled = %v, want %v", tc.name, err, tc.reader)
+		}
+	}
+
+	// Admin sekolah lain bukan staff, superadmin tetap staff di mana pun
+	elsewhere := tenancy.WithOrganization(context.Background(), 2)
+	if err := access.CheckStaffOrEnrolled(elsewhere, 99, models.RoleAdmin, 1); err != services.ErrNotEnrolled {
+		t.Errorf("admin of another organization = %v", err)
+	}
+	if err := access.CheckStaff(elsewhere, 98, models.RoleSuperAdmin, 1); err != nil {
+		t.Errorf("superadmin from another organization = %v", err)
+	}
+
+	// Error database diteruskan, bukan dianggap "bukan staff"
+	broken := errors.New("connection reset")
+	store.courses.err = broken
+	if err := access.CheckStaffOrEnrolled(ctx, 20, models.RoleUser, 1); err != broken {
+		t.Errorf("store failure = %v, want %v", err, broken)
+	}
+}
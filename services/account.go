@@ -0,0 +1,449 @@
+package services
+
+import (
+	"backend-go/logging"
+	"backend-go/mail"
+	"backend-go/models"
+	"backend-go/repository"
+	"backend-go/storage"
+	"backend-go/tenancy"
+	"backend-go/utils"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// AccountSettings - How account changes behave, set once from main
+type AccountSettings struct {
+	// DeletionGrace is how long a deleted account can still be restored by logging in
+	DeletionGrace time.Duration
+	// EmailTokenTTL is how long the link confirming a new email address works
+	EmailTokenTTL time.Duration
+	// EmailConfirmURL is the frontend page the confirmation link opens, with
+	// the token in its "token" query parameter; without it the email only
+	// contains the token
+	EmailConfirmURL string
+	// PasswordResetURL is the frontend page an admin-forced password reset
+	// link opens, like EmailConfirmURL
+	PasswordResetURL string
+}
+
+var accountSettings = AccountSettings{DeletionGrace: 30 * 24 * time.Hour, EmailTokenTTL: 24 * time.Hour}
+
+// ConfigureAccounts - Set the account settings, called once from main
+func ConfigureAccounts(settings AccountSettings) {
+	accountSettings = settings
+}
+
+// AccountService - The logged-in user's own account: password, email and
+// deletion, plus the session checks every request goes through. Accounts are
+// looked up in every organization (see accountStore).
+type AccountService struct {
+	store repository.Store
+	auth  *AuthService
+}
+
+// NewAccountService - Create an AccountService on top of store
+func NewAccountService(store repository.Store) *AccountService {
+	return &AccountService{store: store, auth: NewAuthService(store)}
+}
+
+// Account - What a user sees about their own account
+type Account struct {
+	ID                  uint       `json:"id"`
+	Email               string     `json:"email"`
+	Username            string     `json:"username"`
+	Role                string     `json:"role"`
+	PendingEmail        string     `json:"pending_email,omitempty"`
+	TwoFactorEnabled    bool       `json:"two_factor_enabled"`
+	DeletionScheduledAt *time.Time `json:"deletion_scheduled_at"`
+	CreatedAt           time.Time  `json:"created_at"`
+}
+
+func accountOf(user *models.User) *Account {
+	return &Account{
+		ID:                  user.ID,
+		Email:               user.Email,
+		Username:            user.Username,
+		Role:                user.Roles,
+		PendingEmail:        user.PendingEmail,
+		TwoFactorEnabled:    user.TwoFactorEnabled(),
+		DeletionScheduledAt: user.DeletionScheduledAt,
+		CreatedAt:           user.CreatedAt,
+	}
+}
+
+// Get - The account of userID
+func (s *AccountService) Get(ctx context.Context, userID uint) (*Account, error) {
+	user, err := s.findUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return accountOf(user), nil
+}
+
+// CheckSession - Whether an access token of userID issued at issuedAt still
+// works: the account exists, is not being deleted or suspended and its
+// sessions were not revoked since
+func (s *AccountService) CheckSession(userID uint, issuedAt time.Time) (*models.User, error) {
+	user, err := s.store.Users().FindByID(userID)
+	if err == repository.ErrNotFound {
+		return nil, ErrSessionRevoked
+	}
+	if err != nil {
+		return nil, err
+	}
+	if user.DeletionScheduledAt != nil ||
+		(user.SessionsValidAfter != nil && issuedAt.Before(*user.SessionsValidAfter)) {
+		return nil, ErrSessionRevoked
+	}
+	if user.Suspended() {
+		return nil, ErrAccountSuspended
+	}
+	return user, nil
+}
+
+// ChangePassword - Replace the password of userID after checking the current
+// one. Every existing session ends; the returned token replaces the caller's.
+func (s *AccountService) ChangePassword(ctx context.Context, userID uint, current, next string) (string, error) {
+	store := accountStore(ctx, s.store)
+	user, err := s.findUser(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+	if err := s.confirmPassword(user, current); err != nil {
+		return "", err
+	}
+
+	hash, err := utils.HashPassword(next)
+	if err != nil {
+		return "", err
+	}
+	err = store.Transaction(func(tx repository.Store) error {
+		if err := tx.Users().SetPassword(userID, hash); err != nil {
+			return err
+		}
+		if err := tx.Users().RevokeSessions(userID, revocationTime()); err != nil {
+			return err
+		}
+		return Audit(ctx, tx, "user.password_change", models.AuditUser, userID, nil, nil)
+	})
+	if err != nil {
+		return "", err
+	}
+
+	notify(ctx, user.Email, "Your password was changed",
+		"The password of your account was just changed and every device was logged out.\n\n"+
+			"If this was not you, reset your password and contact support.")
+
+	// Sesi ini sudah melewati 2FA kalau akunnya memakai 2FA
+	if user.TwoFactorEnabled() {
+		return utils.GenerateVerifiedToken(user.ID, user.Roles)
+	}
+	return utils.GeneateToken(user.ID, user.Roles)
+}
+
+// RequestEmailChange - Send a confirmation link to newEmail; the address
+// only changes once it is followed (ConfirmEmailChange)
+func (s *AccountService) RequestEmailChange(ctx context.Context, userID uint, newEmail, password string) error {
+	store := accountStore(ctx, s.store)
+	user, err := s.findUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if err := s.confirmPassword(user, password); err != nil {
+		return err
+	}
+
+	newEmail = strings.TrimSpace(newEmail)
+	if strings.EqualFold(newEmail, user.Email) {
+		return ErrSameEmail
+	}
+	if taken, err := store.Users().ExistsByEmail(newEmail); err != nil {
+		return err
+	} else if taken {
+		return ErrEmailTaken
+	}
+
+	token, err := emailToken()
+	if err != nil {
+		return err
+	}
+	err = store.Transaction(func(tx repository.Store) error {
+		if err := tx.Users().SetPendingEmail(userID, newEmail, hashEmailToken(token), time.Now().Add(accountSettings.EmailTokenTTL)); err != nil {
+			return err
+		}
+		before := accountOf(user)
+		user.PendingEmail = newEmail
+		return Audit(ctx, tx, "user.email_change_request", models.AuditUser, userID, before, accountOf(user))
+	})
+	if err != nil {
+		return err
+	}
+
+	body := fmt.Sprintf("Confirm that %s is your new email address", newEmail)
+	if accountSettings.EmailConfirmURL != "" {
+		body += " by opening this link:\n\n" + accountSettings.EmailConfirmURL + "?token=" + url.QueryEscape(token)
+	} else {
+		body += " with this token:\n\n" + token
+	}
+	body += fmt.Sprintf("\n\nIt expires in %s. If you did not ask for this, ignore this email.", accountSettings.EmailTokenTTL)
+
+	// Gagal kirim berarti user tidak bisa konfirmasi, jadi dilaporkan ke client
+	return mail.Send(ctx, mail.Message{To: newEmail, Subject: "Confirm your new email address", Body: body})
+}
+
+// ConfirmEmailChange - Switch the account to the address the token was sent to
+func (s *AccountService) ConfirmEmailChange(ctx context.Context, token string) (*Account, error) {
+	store := accountStore(ctx, s.store)
+	user, err := store.Users().FindByEmailChangeHash(hashEmailToken(strings.TrimSpace(token)))
+	if err == repository.ErrNotFound {
+		return nil, ErrInvalidEmailToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	if user.EmailChangeExpiresAt == nil || time.Now().After(*user.EmailChangeExpiresAt) || user.PendingEmail == "" {
+		return nil, ErrInvalidEmailToken
+	}
+
+	// Alamat bisa saja sudah dipakai akun lain sejak link dikirim
+	if taken, err := store.Users().ExistsByEmail(user.PendingEmail); err != nil {
+		return nil, err
+	} else if taken {
+		return nil, ErrEmailTaken
+	}
+
+	// Token membuktikan pemilik akun, jadi perubahan dicatat atas namanya
+	logging.SetUserID(ctx, user.ID)
+	before := accountOf(user)
+	err = store.Transaction(func(tx repository.Store) error {
+		if err := tx.Users().ConfirmEmail(user.ID, user.PendingEmail); err != nil {
+			return err
+		}
+		user.Email, user.PendingEmail = user.PendingEmail, ""
+		return Audit(ctx, tx, "user.email_change", models.AuditUser, user.ID, before, accountOf(user))
+	})
+	if err != nil {
+		return nil, err
+	}
+	notify(ctx, before.Email, "Your email address was changed",
+		fmt.Sprintf("Your account now uses %s. If this was not you, contact support.", user.Email))
+	return accountOf(user), nil
+}
+
+// ResetPassword - Set a new password with the token emailed when an admin
+// forced a reset (UserAdminService.ForcePasswordReset)
+func (s *AccountService) ResetPassword(ctx context.Context, token, password string) error {
+	store := accountStore(ctx, s.store)
+	user, err := store.Users().FindByPasswordResetHash(hashEmailToken(strings.TrimSpace(token)))
+	if err == repository.ErrNotFound {
+		return ErrInvalidResetToken
+	}
+	if err != nil {
+		return err
+	}
+	if user.PasswordResetExpiresAt == nil || time.Now().After(*user.PasswordResetExpiresAt) {
+		return ErrInvalidResetToken
+	}
+
+	hash, err := utils.HashPassword(password)
+	if err != nil {
+		return err
+	}
+	logging.SetUserID(ctx, user.ID)
+	err = store.Transaction(func(tx repository.Store) error {
+		if err := tx.Users().CompletePasswordReset(user.ID, hash); err != nil {
+			return err
+		}
+		// Password baru juga membuka kunci akibat tebakan yang gagal
+		if err := tx.Users().ResetFailedLogins(user.ID); err != nil {
+			return err
+		}
+		return Audit(ctx, tx, "user.password_reset", models.AuditUser, user.ID, nil, nil)
+	})
+	if err != nil {
+		return err
+	}
+
+	notify(ctx, user.Email, "Your password was reset",
+		"Your new password is set and you can log in again. If this was not you, contact support.")
+	return nil
+}
+
+// ScheduleDeletion - Log userID out everywhere and delete the account once
+// the grace period ends. Logging in again before then cancels it.
+func (s *AccountService) ScheduleDeletion(ctx context.Context, userID uint, password string) (time.Time, error) {
+	store := accountStore(ctx, s.store)
+	user, err := s.findUser(ctx, userID)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if err := s.confirmPassword(user, password); err != nil {
+		return time.Time{}, err
+	}
+
+	at := time.Now().Add(accountSettings.DeletionGrace)
+	err = store.Transaction(func(tx repository.Store) error {
+		if err := tx.Users().ScheduleDeletion(userID, at); err != nil {
+			return err
+		}
+		if err := tx.Users().RevokeSessions(userID, revocationTime()); err != nil {
+			return err
+		}
+		before := accountOf(user)
+		user.DeletionScheduledAt = &at
+		return Audit(ctx, tx, "user.deletion_schedule", models.AuditUser, userID, before, accountOf(user))
+	})
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	notify(ctx, user.Email, "Your account will be deleted",
+		fmt.Sprintf("Your account will be deleted on %s. Log in before then to keep it.", at.UTC().Format(time.RFC1123)))
+	return at, nil
+}
+
+// PurgeDue - Anonymize every account whose grace period is over
+func (s *AccountService) PurgeDue(ctx context.Context, now time.Time) (int, error) {
+	store := accountStore(ctx, s.store)
+	users, err := store.Users().ListDueForDeletion(now)
+	if err != nil {
+		return 0, err
+	}
+
+	purged := 0
+	for i := range users {
+		user := &users[i]
+		profile, err := store.Users().FindProfile(user.ID)
+		if err != nil && err != repository.ErrNotFound {
+			return purged, err
+		}
+		exports, err := store.Exports().ListByUser(user.ID)
+		if err != nil {
+			return purged, err
+		}
+		if err := store.Transaction(func(tx repository.Store) error {
+			if err := tx.Users().Anonymize(user, now); err != nil {
+				return err
+			}
+			return Audit(tenancy.WithOrganization(ctx, user.OrganizationID), tx, "user.anonymize", models.AuditUser, user.ID, nil, nil)
+		}); err != nil {
+			return purged, err
+		}
+		purged++
+
+		// Foto profil dan arsip export ada di storage, bukan di database
+		if profile != nil && profile.Image != "" {
+			if err := storage.Public.Remove(filepath.Base(profile.Image)); err != nil {
+				slog.WarnContext(ctx, "could not remove profile image of deleted account", "user_id", user.ID, "error", err)
+			}
+		}
+		for _, export := range exports {
+			if export.StorageKey == "" {
+				continue
+			}
+			if err := storage.Private.Remove(export.StorageKey); err != nil {
+				slog.WarnContext(ctx, "could not remove data export of deleted account", "user_id", user.ID, "error", err)
+			}
+		}
+		slog.InfoContext(ctx, "account deleted", "user_id", user.ID)
+	}
+	return purged, nil
+}
+
+// RunPurge - Call PurgeDue every interval until ctx is cancelled
+func (s *AccountService) RunPurge(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if _, err := s.PurgeDue(ctx, time.Now()); err != nil {
+			slog.ErrorContext(ctx, "account purge failed", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// confirmPassword - Re-check the password of a logged-in user before a
+// sensitive change. Wrong guesses count towards the login lockout, so a
+// stolen session cannot be used to find the password.
+func (s *AccountService) confirmPassword(user *models.User, password string) error {
+	now := time.Now()
+	if err := checkLocked(user, now); err != nil {
+		return err
+	}
+	if !utils.CheckPasswordHash(password, user.Password) {
+		return s.auth.loginFailed(user.ID, now, ErrWrongPassword)
+	}
+	return nil
+}
+
+func (s *AccountService) findUser(ctx context.Context, userID uint) (*models.User, error) {
+	store := accountStore(ctx, s.store)
+	user, err := store.Users().FindByID(userID)
+	if err == repository.ErrNotFound {
+		return nil, ErrUserNotFound
+	}
+	return user, err
+}
+
+// cancelScheduledDeletion - A full login during the grace period keeps the account
+func (s *AuthService) cancelScheduledDeletion(ctx context.Context, user *models.User) error {
+	store := accountStore(ctx, s.store)
+	if user.DeletionScheduledAt == nil {
+		return nil
+	}
+	err := store.Transaction(func(tx repository.Store) error {
+		if err := tx.Users().CancelDeletion(user.ID); err != nil {
+			return err
+		}
+		before := accountOf(user)
+		user.DeletionScheduledAt = nil
+		return Audit(ctx, tx, "user.deletion_cancel", models.AuditUser, user.ID, before, accountOf(user))
+	})
+	if err != nil {
+		return err
+	}
+	notify(ctx, user.Email, "Your account will not be deleted",
+		"You logged in during the grace period, so the deletion of your account was cancelled.")
+	return nil
+}
+
+// notify - Send a security notice. Failures are only logged: the change it
+// reports has already happened.
+func notify(ctx context.Context, to, subject, body string) {
+	if err := mail.Send(ctx, mail.Message{To: to, Subject: subject, Body: body}); err != nil {
+		slog.WarnContext(ctx, "could not send notice", "subject", subject, "error", err)
+	}
+}
+
+// revocationTime - Now, at the millisecond precision of a token's "iat"
+func revocationTime() time.Time {
+	return time.Now().Truncate(time.Millisecond)
+}
+
+// emailToken - 256 random bits for the confirmation link
+func emailToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hashEmailToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
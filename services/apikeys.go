@@ -0,0 +1,158 @@
+package services
+
+import (
+	"backend-go/apikeys"
+	"backend-go/models"
+	"backend-go/repository"
+	"context"
+	"errors"
+	"strings"
+	"time"
+)
+
+// MaxAPIKeys - Usable (not revoked, not expired) keys a user may hold at once
+const MaxAPIKeys = 20
+
+// apiKeyTouchInterval - last_used_at is written at most this often per key,
+// so busy scripts do not turn every read into a write
+const apiKeyTouchInterval = time.Minute
+
+// APIKeyService - Personal API keys: management by their owner, and
+// authentication of requests that carry one
+type APIKeyService struct {
+	store repository.Store
+}
+
+// NewAPIKeyService - Create an APIKeyService on top of store
+func NewAPIKeyService(store repository.Store) *APIKeyService {
+	return &APIKeyService{store: store}
+}
+
+// NewAPIKey - What a user asks for when creating a key
+type NewAPIKey struct {
+	Name      string     `json:"name" validate:"required,max=100"`
+	Scopes    []string   `json:"scopes" validate:"required,min=1"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// List - Every key of userID, newest first, revoked ones included
+func (s *APIKeyService) List(ctx context.Context, userID uint) ([]models.APIKey, error) {
+	store := s.store.WithContext(ctx)
+	return store.APIKeys().ListByUser(userID)
+}
+
+// Create - Issue a key for userID. The returned secret is the only copy.
+func (s *APIKeyService) Create(ctx context.Context, userID uint, input NewAPIKey) (*models.APIKey, string, error) {
+	store := s.store.WithContext(ctx)
+	now := time.Now()
+	scopes, err := normalizeScopes(input.Scopes)
+	if err != nil {
+		return nil, "", err
+	}
+	if input.ExpiresAt != nil && !input.ExpiresAt.After(now) {
+		return nil, "", ErrInvalidExpiry
+	}
+
+	count, err := store.APIKeys().CountUsable(userID, now)
+	if err != nil {
+		return nil, "", err
+	}
+	if count >= MaxAPIKeys {
+		return nil, "", ErrTooManyAPIKeys
+	}
+
+	secret, prefix, hash, err := apikeys.Generate()
+	if err != nil {
+		return nil, "", err
+	}
+	key := &models.APIKey{
+		UserID:      userID,
+		Name:        strings.TrimSpace(input.Name),
+		Prefix:      prefix,
+		Hash:        hash,
+		ScopeString: strings.Join(scopes, " "),
+		Scopes:      scopes,
+		ExpiresAt:   input.ExpiresAt,
+	}
+	err = store.Transaction(func(tx repository.Store) error {
+		if err := tx.APIKeys().Create(key); err != nil {
+			return err
+		}
+		return Audit(ctx, tx, "api_key.create", models.AuditAPIKey, key.ID, nil, key)
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return key, secret, nil
+}
+
+// Revoke - Stop key id of userID from working. Revoking twice is not an error.
+func (s *APIKeyService) Revoke(ctx context.Context, userID, id uint) (*models.APIKey, error) {
+	store := s.store.WithContext(ctx)
+	key, err := store.APIKeys().FindForUser(id, userID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrAPIKeyNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if key.RevokedAt == nil {
+		now := time.Now()
+		before := *key
+		err := store.Transaction(func(tx repository.Store) error {
+			if err := tx.APIKeys().Revoke(key.ID, now); err != nil {
+				return err
+			}
+			key.RevokedAt = &now
+			return Audit(ctx, tx, "api_key.revoke", models.AuditAPIKey, key.ID, before, key)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return key, nil
+}
+
+// Authenticate - The usable key matching secret, with its user loaded
+func (s *APIKeyService) Authenticate(secret string) (*models.APIKey, error) {
+	prefix, ok := apikeys.Prefix(secret)
+	if !ok {
+		return nil, ErrInvalidAPIKey
+	}
+	key, err := s.store.APIKeys().FindByPrefix(prefix)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrInvalidAPIKey
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if !apikeys.Matches(secret, key.Hash) || !key.Usable(now) || key.User == nil || key.User.DeletionScheduledAt != nil || key.User.Suspended() {
+		return nil, ErrInvalidAPIKey
+	}
+	if err := s.store.APIKeys().Touch(key.ID, now, apiKeyTouchInterval); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// normalizeScopes - Check scopes and drop duplicates, keeping the documented order
+func normalizeScopes(scopes []string) ([]string, error) {
+	requested := make(map[string]bool, len(scopes))
+	for _, scope := range scopes {
+		scope = strings.TrimSpace(scope)
+		if !apikeys.Valid(scope) {
+			return nil, ErrInvalidScope.WithDetails(map[string]interface{}{"scope": scope, "allowed": apikeys.Scopes})
+		}
+		requested[scope] = true
+	}
+
+	out := make([]string, 0, len(requested))
+	for _, scope := range apikeys.Scopes {
+		if requested[scope] {
+			out = append(out, scope)
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,109 @@
+package services
+
+import (
+	"backend-go/models"
+	"backend-go/repository"
+	"backend-go/storage"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"path/filepath"
+	"time"
+
+	"github.com/gabriel-vasile/mimetype"
+)
+
+// AttachmentService - Files course staff attach to lessons
+type AttachmentService struct {
+	store repository.Store
+}
+
+// NewAttachmentService - Create an AttachmentService on top of store
+func NewAttachmentService(store repository.Store) *AttachmentService {
+	return &AttachmentService{store: store}
+}
+
+// AttachmentUpload - A file being attached to a lesson
+type AttachmentUpload struct {
+	LessonID   uint
+	UploadedBy uint
+	// FileName is the name the file had on the uploader's machine
+	FileName    string
+	DisplayName string
+	File        io.ReadSeeker
+}
+
+// Upload - Store the file in private storage and attach it to the lesson. The
+// MIME type is detected from the content, not taken from the client.
+func (s *AttachmentService) Upload(ctx context.Context, upload AttachmentUpload) (*models.LessonAttachment, error) {
+	store := s.store.WithContext(ctx)
+
+	mtype, err := mimetype.DetectReader(upload.File)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := upload.File.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	fileName := filepath.Base(upload.FileName)
+	displayName := upload.DisplayName
+	if displayName == "" {
+		displayName = fileName
+	}
+
+	key := fmt.Sprintf("attachments/%d/%d%s", upload.LessonID, time.Now().UnixNano(), filepath.Ext(fileName))
+	hash := sha256.New()
+	size, err := storage.Private.Save(key, io.TeeReader(upload.File, hash))
+	if err != nil {
+		return nil, err
+	}
+
+	attachment := models.LessonAttachment{
+		LessonID:    upload.LessonID,
+		DisplayName: displayName,
+		FileName:    fileName,
+		StorageKey:  key,
+		MimeType:    mtype.String(),
+		Size:        size,
+		Checksum:    hex.EncodeToString(hash.Sum(nil)),
+		UploadedBy:  upload.UploadedBy,
+	}
+	err = store.Transaction(func(tx repository.Store) error {
+		if err := tx.Attachments().Create(&attachment); err != nil {
+			return err
+		}
+		return Audit(ctx, tx, "attachment.create", models.AuditAttachment, attachment.ID, nil, attachment)
+	})
+	if err != nil {
+		storage.Private.Remove(key)
+		return nil, err
+	}
+	return &attachment, nil
+}
+
+// ListByLesson - Attachments of lessonID, oldest first
+func (s *AttachmentService) ListByLesson(ctx context.Context, lessonID uint) ([]models.LessonAttachment, error) {
+	store := s.store.WithContext(ctx)
+	return store.Attachments().ListByLesson(lessonID)
+}
+
+// ListIDs - IDs of the attachments of lessonID
+func (s *AttachmentService) ListIDs(ctx context.Context, lessonID uint) ([]uint, error) {
+	store := s.store.WithContext(ctx)
+	return store.Attachments().ListIDsByLesson(lessonID)
+}
+
+// Delete - Move the attachment to the trash. The file stays in storage so the
+// attachment can still be restored.
+func (s *AttachmentService) Delete(ctx context.Context, attachment *models.LessonAttachment) error {
+	store := s.store.WithContext(ctx)
+	return store.Transaction(func(tx repository.Store) error {
+		if err := MoveToTrash(tx, models.AuditAttachment, attachment.ID); err != nil {
+			return err
+		}
+		return Audit(ctx, tx, "attachment.delete", models.AuditAttachment, attachment.ID, attachment, nil)
+	})
+}
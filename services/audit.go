@@ -0,0 +1,178 @@
+package services
+
+import (
+	"backend-go/logging"
+	"backend-go/models"
+	"backend-go/repository"
+	"backend-go/tenancy"
+	"bytes"
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// auditIgnored - Bookkeeping fields every row has; a change to them says
+// nothing about what the user did
+var auditIgnored = map[string]bool{"ID": true, "CreatedAt": true, "UpdatedAt": true, "DeletedAt": true}
+
+// FieldChange - Value of one field before and after an audited change
+type FieldChange struct {
+	From json.RawMessage `json:"from"`
+	To   json.RawMessage `json:"to"`
+}
+
+// Audit - Append a change to entity entityType/entityID to the audit log
+// through tx, which must be the transaction that made the change, on behalf
+// of the request carried by ctx. before is nil for creations and after is nil
+// for deletions. Only fields the entity shows in JSON are compared, so hidden
+// secrets (password hashes, token hashes) never reach the log.
+func Audit(ctx context.Context, tx repository.Store, action, entityType string, entityID uint, before, after interface{}) error {
+	changes, err := diffFields(before, after)
+	if err != nil {
+		return err
+	}
+
+	entry := models.AuditEntry{
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   entityID,
+		IP:         logging.ClientIP(ctx),
+		RequestID:  logging.RequestID(ctx),
+
+		OrganizationID: tenancy.Current(ctx),
+	}
+	if len(changes) > 0 {
+		data, err := json.Marshal(changes)
+		if err != nil {
+			return err
+		}
+		entry.ChangeString = string(data)
+	}
+	if id := logging.UserID(ctx); id != 0 {
+		entry.ActorID = &id
+	}
+	if id := logging.Impersonator(ctx); id != 0 {
+		entry.ImpersonatorID = &id
+	}
+	return tx.Audit().Record(&entry)
+}
+
+// diffFields - Fields whose JSON differs between before and after. Nested
+// objects are related rows with their own audit trail and are left out.
+func diffFields(before, after interface{}) (map[string]FieldChange, error) {
+	from, err := jsonFields(before)
+	if err != nil {
+		return nil, err
+	}
+	to, err := jsonFields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := map[string]FieldChange{}
+	add := func(name string) {
+		if auditIgnored[name] || isObject(from[name]) || isObject(to[name]) {
+			return
+		}
+		if bytes.Equal(from[name], to[name]) {
+			return
+		}
+		changes[name] = FieldChange{From: orNull(from[name]), To: orNull(to[name])}
+	}
+	for name := range from {
+		add(name)
+	}
+	for name := range to {
+		if _, ok := from[name]; !ok {
+			add(name)
+		}
+	}
+	return changes, nil
+}
+
+func jsonFields(v interface{}) (map[string]json.RawMessage, error) {
+	fields := map[string]json.RawMessage{}
+	if v == nil {
+		return fields, nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+func isObject(v json.RawMessage) bool {
+	return len(v) > 0 && v[0] == '{'
+}
+
+func orNull(v json.RawMessage) json.RawMessage {
+	if v == nil {
+		return json.RawMessage("null")
+	}
+	return v
+}
+
+// AuditService - Admin access to the audit log
+type AuditService struct {
+	store repository.Store
+}
+
+// NewAuditService - Create an AuditService on top of store
+func NewAuditService(store repository.Store) *AuditService {
+	return &AuditService{store: store}
+}
+
+// AuditQuery - Filters and page of the audit log. Since and Until are RFC 3339 times.
+type AuditQuery struct {
+	ActorID    uint       `form:"actor_id"`
+	Action     string     `form:"action" validate:"max=100"`
+	EntityType string     `form:"entity_type" validate:"max=100"`
+	EntityID   uint       `form:"entity_id"`
+	RequestID  string     `form:"request_id" validate:"max=128"`
+	Since      *time.Time `form:"since" time_format:"2006-01-02T15:04:05Z07:00"`
+	Until      *time.Time `form:"until" time_format:"2006-01-02T15:04:05Z07:00"`
+	Page       int        `form:"page" validate:"omitempty,min=1"`
+	PerPage    int        `form:"per_page" validate:"omitempty,min=1,max=100"`
+}
+
+func (q AuditQuery) filter() repository.AuditFilter {
+	return repository.AuditFilter{
+		ActorID:    q.ActorID,
+		Action:     q.Action,
+		EntityType: q.EntityType,
+		EntityID:   q.EntityID,
+		RequestID:  q.RequestID,
+		Since:      q.Since,
+		Until:      q.Until,
+	}
+}
+
+// List - One page of audit entries matching query, newest first
+func (s *AuditService) List(ctx context.Context, query AuditQuery) ([]models.AuditEntry, *Page, error) {
+	store := s.store.WithContext(ctx)
+	page := &Page{Page: max(query.Page, 1), PerPage: query.PerPage}
+	if page.PerPage <= 0 {
+		page.PerPage = DefaultPerPage
+	}
+	page.PerPage = min(page.PerPage, MaxPerPage)
+
+	filter := query.filter()
+	filter.Offset, filter.Limit = (page.Page-1)*page.PerPage, page.PerPage
+	entries, total, err := store.Audit().List(filter)
+	if err != nil {
+		return nil, nil, err
+	}
+	page.Total = total
+	return entries, page, nil
+}
+
+// Export - Call fn with every audit entry matching query, oldest first,
+// ignoring its page
+func (s *AuditService) Export(ctx context.Context, query AuditQuery, fn func(entry *models.AuditEntry) error) error {
+	store := s.store.WithContext(ctx)
+	return store.Audit().Each(query.filter(), fn)
+}
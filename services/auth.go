@@ -0,0 +1,255 @@
+package services
+
+import (
+	"backend-go/logging"
+	"backend-go/metrics"
+	"backend-go/models"
+	"backend-go/repository"
+	"backend-go/tenancy"
+	"backend-go/utils"
+	"context"
+	"time"
+)
+
+// AuthService - Registration and login rules
+type AuthService struct {
+	store repository.Store
+}
+
+// NewAuthService - Create an AuthService on top of store
+func NewAuthService(store repository.Store) *AuthService {
+	return &AuthService{store: store}
+}
+
+// RegisterInput - Data needed to create an account
+type RegisterInput struct {
+	Email    string
+	Username string
+	Password string
+}
+
+// Register - Create a plain user in the organization of ctx with an email
+// unique on the platform and a username unique in the organization. Admins
+// are only made through the user admin API.
+func (s *AuthService) Register(ctx context.Context, input RegisterInput) (*models.User, error) {
+	store := accountStore(ctx, s.store)
+	users := store.Users()
+
+	taken, err := users.ExistsByEmail(input.Email)
+	if err != nil {
+		return nil, err
+	}
+	if taken {
+		return nil, ErrEmailTaken
+	}
+
+	// Username cukup unik di dalam organisasinya
+	taken, err = s.store.WithContext(ctx).Users().ExistsByUsername(input.Username)
+	if err != nil {
+		return nil, err
+	}
+	if taken {
+		return nil, ErrUsernameTaken
+	}
+
+	hashedPassword, err := utils.HashPassword(input.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	user := models.User{
+		Email:    input.Email,
+		Username: input.Username,
+		Password: hashedPassword,
+		Roles:    models.RoleUser,
+
+		OrganizationID: tenancy.Current(ctx),
+	}
+	err = store.Transaction(func(tx repository.Store) error {
+		if err := tx.Users().Create(&user); err != nil {
+			return err
+		}
+		// Belum ada yang login, jadi pendaftaran dicatat atas nama user baru
+		logging.SetUserID(ctx, user.ID)
+		return Audit(ctx, tx, "user.register", models.AuditUser, user.ID, nil, accountOf(&user))
+	})
+	if err != nil {
+		return nil, err
+	}
+	metrics.Registrations.Inc()
+	return &user, nil
+}
+
+// Progressive login lockout: after LockoutThreshold consecutive failures the
+// account is locked for LockoutBase, doubling with every further failure up to
+// LockoutMax. A successful login clears the count.
+const (
+	LockoutThreshold = 5
+	LockoutBase      = time.Minute
+	LockoutMax       = time.Hour
+)
+
+// LoginResult - Outcome of a correct password. Either Token is set, or, for
+// users with two-factor authentication, MFAChallenge must be exchanged for
+// one through CompleteMFALogin.
+type LoginResult struct {
+	Token        string
+	MFAChallenge string
+	// MFASetupRequired means the role requires two-factor authentication the
+	// user has not set up yet; Token then only works for the /2fa routes
+	MFASetupRequired bool
+}
+
+// Login - Check the credentials and issue a JWT, or a challenge when the
+// user has two-factor authentication
+func (s *AuthService) Login(ctx context.Context, email, password string) (*LoginResult, error) {
+	store := accountStore(ctx, s.store)
+	users := store.Users()
+	user, err := users.FindByEmail(email)
+	if err == repository.ErrNotFound {
+		metrics.Logins.WithLabelValues("failure").Inc()
+		return nil, ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, err
+	}
+	// Akun organisasi lain diperlakukan seperti email yang tidak dikenal
+	if CheckOrganization(ctx, user) != nil {
+		metrics.Logins.WithLabelValues("failure").Inc()
+		return nil, ErrInvalidCredentials
+	}
+
+	now := time.Now()
+	if err := checkLocked(user, now); err != nil {
+		return nil, err
+	}
+
+	if !utils.CheckPasswordHash(password, user.Password) {
+		metrics.Logins.WithLabelValues("failure").Inc()
+		return nil, s.loginFailed(user.ID, now, ErrInvalidCredentials)
+	}
+
+	return s.startSession(ctx, user)
+}
+
+// startSession - A JWT for user whose first factor was accepted, or a
+// challenge when the user has two-factor authentication
+func (s *AuthService) startSession(ctx context.Context, user *models.User) (*LoginResult, error) {
+	if user.Suspended() {
+		metrics.Logins.WithLabelValues("suspended").Inc()
+		return nil, ErrAccountSuspended
+	}
+
+	// Hitungan gagal baru di-reset setelah kode kedua benar, supaya password
+	// yang bocor tidak bisa dipakai untuk menebak kode tanpa batas
+	if user.TwoFactorEnabled() {
+		challenge, err := utils.GenerateMFAChallenge(user.ID)
+		if err != nil {
+			return nil, err
+		}
+		metrics.Logins.WithLabelValues("mfa_challenge").Inc()
+		return &LoginResult{MFAChallenge: challenge}, nil
+	}
+
+	if err := s.loginSucceeded(ctx, user); err != nil {
+		return nil, err
+	}
+	token, err := utils.GeneateToken(user.ID, user.Roles)
+	if err != nil {
+		return nil, err
+	}
+	return &LoginResult{Token: token, MFASetupRequired: MFARequired(user.Roles)}, nil
+}
+
+// CompleteMFALogin - Exchange a login challenge and a TOTP or recovery code
+// for a JWT. Wrong codes count towards the lockout like wrong passwords.
+func (s *AuthService) CompleteMFALogin(ctx context.Context, challenge, code string) (string, error) {
+	store := accountStore(ctx, s.store)
+	userID, err := utils.ParseMFAChallenge(challenge)
+	if err != nil {
+		return "", ErrInvalidMFAChallenge
+	}
+
+	users := store.Users()
+	user, err := users.FindByID(userID)
+	if err == repository.ErrNotFound {
+		return "", ErrInvalidMFAChallenge
+	}
+	if err != nil {
+		return "", err
+	}
+	if !user.TwoFactorEnabled() || CheckOrganization(ctx, user) != nil {
+		return "", ErrInvalidMFAChallenge
+	}
+	if user.Suspended() {
+		return "", ErrAccountSuspended
+	}
+
+	now := time.Now()
+	if err := checkLocked(user, now); err != nil {
+		return "", err
+	}
+
+	ok, err := verifySecondFactor(users, user, code)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		metrics.Logins.WithLabelValues("failure").Inc()
+		return "", s.loginFailed(user.ID, now, ErrInvalidMFACode)
+	}
+
+	if err := s.loginSucceeded(ctx, user); err != nil {
+		return "", err
+	}
+	return utils.GenerateVerifiedToken(user.ID, user.Roles)
+}
+
+// checkLocked - ErrAccountLocked while the lockout of user lasts. Credentials
+// are not checked at all during a lockout, so guesses tell nothing.
+func checkLocked(user *models.User, now time.Time) error {
+	if user.LockedUntil != nil && now.Before(*user.LockedUntil) {
+		metrics.Logins.WithLabelValues("locked").Inc()
+		return ErrAccountLocked.WithRetryAfter(user.LockedUntil.Sub(now))
+	}
+	return nil
+}
+
+// loginSucceeded - Clear the failure count, keep an account scheduled for
+// deletion and count the login
+func (s *AuthService) loginSucceeded(ctx context.Context, user *models.User) error {
+	store := accountStore(ctx, s.store)
+	if user.FailedLogins > 0 || user.LockedUntil != nil {
+		if err := store.Users().ResetFailedLogins(user.ID); err != nil {
+			return err
+		}
+	}
+	// Login yang berhasil membatalkan penghapusan atas nama user itu sendiri
+	logging.SetUserID(ctx, user.ID)
+	if err := s.cancelScheduledDeletion(ctx, user); err != nil {
+		return err
+	}
+	metrics.Logins.WithLabelValues("success").Inc()
+	return nil
+}
+
+// loginFailed - Count a failed login and lock the account once over the
+// threshold; below it the failure is reported as wrong
+func (s *AuthService) loginFailed(userID uint, now time.Time, wrong error) error {
+	failures, err := s.store.Users().IncrementFailedLogins(userID)
+	if err != nil {
+		return err
+	}
+	if failures < LockoutThreshold {
+		return wrong
+	}
+
+	lockFor := LockoutMax
+	if doublings := failures - LockoutThreshold; doublings < 16 {
+		lockFor = min(LockoutBase<<doublings, LockoutMax)
+	}
+	if err := s.store.Users().LockUntil(userID, now.Add(lockFor)); err != nil {
+		return err
+	}
+	return ErrAccountLocked.WithRetryAfter(lockFor)
+}
@@ -0,0 +1,86 @@
+package services
+
+import (
+	"backend-go/models"
+	"backend-go/repository"
+	"context"
+)
+
+// CourseService - Course catalogue
+type CourseService struct {
+	store repository.Store
+}
+
+// NewCourseService - Create a CourseService on top of store
+func NewCourseService(store repository.Store) *CourseService {
+	return &CourseService{store: store}
+}
+
+// List - Every course
+func (s *CourseService) List(ctx context.Context) ([]models.Course, error) {
+	store := s.store.WithContext(ctx)
+	return store.Courses().List()
+}
+
+// Get - Course by id
+func (s *CourseService) Get(ctx context.Context, id uint) (*models.Course, error) {
+	store := s.store.WithContext(ctx)
+	course, err := store.Courses().FindByID(id)
+	if err == repository.ErrNotFound {
+		return nil, ErrCourseNotFound
+	}
+	return course, err
+}
+
+// Create - Store a new course
+func (s *CourseService) Create(ctx context.Context, course *models.Course) error {
+	store := s.store.WithContext(ctx)
+	return store.Transaction(func(tx repository.Store) error {
+		if err := tx.Courses().Create(course); err != nil {
+			return err
+		}
+		return Audit(ctx, tx, "course.create", models.AuditCourse, course.ID, nil, course)
+	})
+}
+
+// Update - Apply the non-zero fields of changes to course
+func (s *CourseService) Update(ctx context.Context, course *models.Course, changes models.Course) error {
+	store := s.store.WithContext(ctx)
+	before := *course
+	return store.Transaction(func(tx repository.Store) error {
+		if err := tx.Courses().Update(course, changes); err != nil {
+			return err
+		}
+		return Audit(ctx, tx, "course.update", models.AuditCourse, course.ID, before, course)
+	})
+}
+
+// Delete - Move course id to the trash with its lessons and quizzes
+func (s *CourseService) Delete(ctx context.Context, id uint) error {
+	store := s.store.WithContext(ctx)
+	course, err := s.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	return store.Transaction(func(tx repository.Store) error {
+		if err := MoveToTrash(tx, models.AuditCourse, course.ID); err != nil {
+			return err
+		}
+		return Audit(ctx, tx, "course.delete", models.AuditCourse, course.ID, course, nil)
+	})
+}
+
+// Students - The course and the enrollments (with users) of everyone in it
+func (s *CourseService) Students(ctx context.Context, courseID uint) (*models.Course, []models.Enrollment, error) {
+	store := s.store.WithContext(ctx)
+	course, err := s.Get(ctx, courseID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	enrollments, err := store.Enrollments().ListByCourse(courseID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return course, enrollments, nil
+}
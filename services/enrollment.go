@@ -0,0 +1,84 @@
+package services
+
+import (
+	"backend-go/metrics"
+	"backend-go/models"
+	"backend-go/repository"
+	"context"
+)
+
+// EnrollmentService - Enrolling users in courses
+type EnrollmentService struct {
+	store repository.Store
+}
+
+// NewEnrollmentService - Create an EnrollmentService on top of store
+func NewEnrollmentService(store repository.Store) *EnrollmentService {
+	return &EnrollmentService{store: store}
+}
+
+// Enroll - Enroll userID in courseID; a user can only be enrolled once
+func (s *EnrollmentService) Enroll(ctx context.Context, userID, courseID uint) (*models.Enrollment, error) {
+	store := s.store.WithContext(ctx)
+	if _, err := store.Courses().FindByID(courseID); err != nil {
+		if err == repository.ErrNotFound {
+			return nil, ErrCourseNotFound
+		}
+		return nil, err
+	}
+
+	enrolled, err := s.IsEnrolled(ctx, userID, courseID)
+	if err != nil {
+		return nil, err
+	}
+	if enrolled {
+		return nil, ErrAlreadyEnrolled
+	}
+
+	enrollment := models.Enrollment{UserID: userID, CourseID: courseID}
+	err = store.Transaction(func(tx repository.Store) error {
+		if err := tx.Enrollments().Create(&enrollment); err != nil {
+			return err
+		}
+		return Audit(ctx, tx, "enrollment.create", models.AuditEnrollment, enrollment.ID, nil, enrollment)
+	})
+	if err != nil {
+		return nil, err
+	}
+	metrics.Enrollments.Inc()
+	return &enrollment, nil
+}
+
+// Unenroll - Remove userID from courseID
+func (s *EnrollmentService) Unenroll(ctx context.Context, userID, courseID uint) error {
+	store := s.store.WithContext(ctx)
+	enrollment, err := store.Enrollments().Find(userID, courseID)
+	if err == repository.ErrNotFound {
+		return ErrEnrollmentNotFound
+	}
+	if err != nil {
+		return err
+	}
+	return store.Transaction(func(tx repository.Store) error {
+		if err := tx.Enrollments().Delete(enrollment); err != nil {
+			return err
+		}
+		return Audit(ctx, tx, "enrollment.delete", models.AuditEnrollment, enrollment.ID, enrollment, nil)
+	})
+}
+
+// List - Enrollments (with courses) of userID
+func (s *EnrollmentService) List(ctx context.Context, userID uint) ([]models.Enrollment, error) {
+	store := s.store.WithContext(ctx)
+	return store.Enrollments().ListByUser(userID)
+}
+
+// IsEnrolled - Whether userID is enrolled in courseID
+func (s *EnrollmentService) IsEnrolled(ctx context.Context, userID, courseID uint) (bool, error) {
+	store := s.store.WithContext(ctx)
+	_, err := store.Enrollments().Find(userID, courseID)
+	if err == repository.ErrNotFound {
+		return false, nil
+	}
+	return err == nil, err
+}
@@ -0,0 +1,83 @@
+package services
+
+import (
+	"backend-go/apperr"
+	"net/http"
+)
+
+// Errors returned by the services. They carry their HTTP status and code, so
+// handlers pass them to apperr.Abort unchanged.
+var (
+	ErrUserNotFound       = apperr.NotFound("user_not_found", "User not found")
+	ErrProfileNotFound    = apperr.NotFound("profile_not_found", "Profile not found")
+	ErrCourseNotFound     = apperr.NotFound("course_not_found", "Course not found")
+	ErrEnrollmentNotFound = apperr.NotFound("enrollment_not_found", "Enrollment not found")
+	ErrLessonNotFound     = apperr.NotFound("lesson_not_found", "Lesson not found")
+	ErrQuizNotFound       = apperr.NotFound("quiz_not_found", "Quiz not found")
+	ErrAnswerNotFound     = apperr.NotFound("answer_not_found", "Answer not found")
+	ErrAttachmentNotFound = apperr.NotFound("attachment_not_found", "Attachment not found")
+	ErrRevisionNotFound   = apperr.NotFound("revision_not_found", "Revision not found")
+	ErrUploadNotFound     = apperr.NotFound("upload_not_found", "Upload not found")
+
+	ErrNotEnrolled = apperr.Forbidden("not_enrolled", "You are not enrolled in this course")
+	ErrNotStaff    = apperr.Forbidden("not_course_staff", "You are not staff of this course")
+
+	ErrEmailTaken         = apperr.Conflict("email_taken", "Email already exists")
+	ErrUsernameTaken      = apperr.Conflict("username_taken", "Username already exists")
+	ErrAlreadyEnrolled    = apperr.Conflict("already_enrolled", "Already enrolled in this course")
+	ErrConcurrentEdit     = apperr.Conflict("concurrent_edit", "Someone else changed this at the same time, try again")
+	ErrInvalidRole        = apperr.Unprocessable("invalid_role", "Invalid role")
+	ErrInvalidContent     = apperr.Unprocessable("invalid_content", "Lesson content could not be rendered")
+	ErrAnswerNotInQuiz    = apperr.Unprocessable("answer_not_in_quiz", "Answer does not belong to this quiz")
+	ErrNotAVideo          = apperr.Unprocessable("not_a_video", "Uploaded file is not a video")
+	ErrUploadCompleted    = apperr.Conflict("upload_completed", "Upload already completed")
+	ErrOffsetMismatch     = apperr.Conflict("upload_offset_mismatch", "Upload-Offset does not match current offset")
+	ErrUploadBusy         = apperr.Conflict("upload_busy", "Another chunk of this upload is being written, try again")
+	ErrInvalidCredentials = apperr.Unauthorized("invalid_credentials", "Invalid email or password")
+	ErrAccountLocked      = apperr.New(http.StatusTooManyRequests, "account_locked", "Too many failed logins, try again later")
+
+	ErrMFAAlreadyEnabled   = apperr.Conflict("mfa_already_enabled", "Two-factor authentication is already enabled")
+	ErrMFANotStarted       = apperr.Conflict("mfa_not_started", "Start two-factor setup first")
+	ErrMFANotEnabled       = apperr.Conflict("mfa_not_enabled", "Two-factor authentication is not enabled")
+	ErrInvalidMFACode      = apperr.Unprocessable("invalid_mfa_code", "Invalid two-factor code")
+	ErrInvalidMFAChallenge = apperr.Unauthorized("invalid_mfa_challenge", "Login challenge is invalid or expired, log in again")
+	ErrMFARequired         = apperr.Forbidden("mfa_required", "Your role requires two-factor authentication; set it up under /2fa and log in again")
+	ErrMFAPolicy           = apperr.Forbidden("mfa_required_by_policy", "Two-factor authentication cannot be turned off for your role")
+
+	ErrSSOProviderNotFound = apperr.NotFound("sso_provider_not_found", "Identity provider not found")
+	ErrSSOInvalidState     = apperr.BadRequest("sso_invalid_state", "Login session expired or does not match, start the login again")
+	ErrSSODenied           = apperr.Unauthorized("sso_denied", "The identity provider did not log you in")
+	ErrSSOFailed           = apperr.New(http.StatusBadGateway, "sso_provider_error", "The identity provider could not complete the login")
+	ErrSSOEmailUnverified  = apperr.Forbidden("sso_email_unverified", "The identity provider did not confirm your email address")
+
+	ErrSessionRevoked    = apperr.Unauthorized("session_revoked", "This session has ended, log in again")
+	ErrWrongPassword     = apperr.Forbidden("wrong_password", "Current password is incorrect")
+	ErrSameEmail         = apperr.Unprocessable("same_email", "That is already your email address")
+	ErrInvalidEmailToken = apperr.BadRequest("invalid_email_token", "The confirmation link is invalid or expired, request a new one")
+	ErrInvalidResetToken = apperr.BadRequest("invalid_reset_token", "The password reset link is invalid or expired, ask an admin for a new one")
+
+	ErrAccountSuspended       = apperr.Forbidden("account_suspended", "This account has been suspended")
+	ErrCannotManageSelf       = apperr.Conflict("cannot_manage_self", "Admins cannot change the role of, suspend, reset or impersonate their own account")
+	ErrCannotImpersonate      = apperr.Forbidden("cannot_impersonate", "Admins and suspended users cannot be impersonated")
+	ErrImpersonationForbidden = apperr.Forbidden("impersonation_not_allowed", "This is not allowed while impersonating a user")
+
+	ErrAPIKeyNotFound    = apperr.NotFound("api_key_not_found", "API key not found")
+	ErrInvalidAPIKey     = apperr.Unauthorized("invalid_api_key", "API key is invalid, expired or revoked")
+	ErrInsufficientScope = apperr.Forbidden("insufficient_scope", "This API key is not allowed to use this route")
+	ErrInvalidScope      = apperr.Unprocessable("invalid_scope", "Unknown API key scope")
+	ErrInvalidExpiry     = apperr.Unprocessable("invalid_expiry", "Expiry must be in the future")
+	ErrTooManyAPIKeys    = apperr.Conflict("too_many_api_keys", "Revoke an API key before creating another")
+
+	ErrExportNotFound    = apperr.NotFound("export_not_found", "Export not found or no longer available")
+	ErrExportUnavailable = apperr.New(http.StatusServiceUnavailable, "export_unavailable", "Exports cannot be started right now, try again shortly")
+
+	ErrTrashItemNotFound  = apperr.NotFound("trash_item_not_found", "Deleted item not found")
+	ErrRestoreParentFirst = apperr.Conflict("restore_parent_first", "The item this belongs to is deleted too, restore that first")
+
+	ErrOrganizationNotFound   = apperr.NotFound("organization_not_found", "Organization not found")
+	ErrInvalidSlug            = apperr.Unprocessable("invalid_slug", "Slug must be lowercase letters, digits and dashes, as in a subdomain")
+	ErrSlugTaken              = apperr.Conflict("slug_taken", "Slug is already used by another organization")
+	ErrWrongOrganization      = apperr.Forbidden("wrong_organization", "Your account belongs to another organization")
+	ErrCannotManageAdmin      = apperr.Forbidden("cannot_manage_admin", "Only superadmins can change the role of, suspend or reset other admins")
+	ErrCannotManageSuperAdmin = apperr.Forbidden("cannot_manage_superadmin", "Only superadmins can grant the superadmin role or manage superadmins")
+)
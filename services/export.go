@@ -0,0 +1,334 @@
+package services
+
+import (
+	"archive/zip"
+	"backend-go/background"
+	"backend-go/models"
+	"backend-go/repository"
+	"backend-go/storage"
+	"backend-go/utils"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ExportSettings - How personal data exports behave, set once from main
+type ExportSettings struct {
+	// TTL is how long a finished export can be downloaded before it is deleted
+	TTL time.Duration
+	// PublicURL is the API's external base URL, put in front of the emailed
+	// download link; without it the email contains a relative link
+	PublicURL string
+}
+
+var exportSettings = ExportSettings{TTL: 7 * 24 * time.Hour}
+
+// ConfigureExports - Set the export settings, called once from main
+func ConfigureExports(settings ExportSettings) {
+	exportSettings = settings
+}
+
+// exportStaleAfter - A pending export older than this was interrupted (for
+// example by a restart) and no longer blocks a new request
+const exportStaleAfter = time.Hour
+
+// ExportService - Personal data exports: a ZIP of everything stored about a
+// user, built in the background and downloaded through a signed link
+type ExportService struct {
+	store repository.Store
+}
+
+// NewExportService - Create an ExportService on top of store
+func NewExportService(store repository.Store) *ExportService {
+	return &ExportService{store: store}
+}
+
+// List - The user's exports that can still be downloaded or are being built,
+// ready ones with a short-lived download URL
+func (s *ExportService) List(ctx context.Context, userID uint) ([]models.DataExport, error) {
+	store := s.store.WithContext(ctx)
+	exports, err := store.Exports().ListByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	visible := make([]models.DataExport, 0, len(exports))
+	for _, export := range exports {
+		if export.ExpiresAt != nil && !now.Before(*export.ExpiresAt) {
+			continue
+		}
+		if export.Status == models.ExportReady {
+			export.DownloadURL = utils.SignURL(exportPath(export.ID), utils.MediaURLTTL)
+		}
+		visible = append(visible, export)
+	}
+	return visible, nil
+}
+
+// Request - Start building an export of userID's data. While one is being
+// built, asking again returns that one instead of starting another.
+func (s *ExportService) Request(ctx context.Context, userID uint) (*models.DataExport, error) {
+	store := s.store.WithContext(ctx)
+	pending, err := store.Exports().FindPending(userID)
+	switch {
+	case err == nil && time.Since(pending.CreatedAt) < exportStaleAfter:
+		return pending, nil
+	case err == nil:
+		if err := store.Exports().MarkFailed(pending.ID, time.Now()); err != nil {
+			return nil, err
+		}
+	case err != repository.ErrNotFound:
+		return nil, err
+	}
+
+	export := models.DataExport{UserID: userID, Status: models.ExportPending}
+	err = store.Transaction(func(tx repository.Store) error {
+		if err := tx.Exports().Create(&export); err != nil {
+			return err
+		}
+		return Audit(ctx, tx, "data_export.request", models.AuditExport, export.ID, nil, export)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	id := export.ID
+	if !background.Go("data-export", func(ctx context.Context) { s.Build(ctx, id) }) {
+		if err := store.Exports().MarkFailed(id, time.Now()); err != nil {
+			return nil, err
+		}
+		return nil, ErrExportUnavailable
+	}
+	return &export, nil
+}
+
+// Build - Write the archive of export id to private storage and email its
+// owner a download link. Failures are recorded on the export and reported
+// to the owner, who can simply ask again.
+func (s *ExportService) Build(ctx context.Context, id uint) {
+	store := s.store.WithContext(ctx)
+	export, err := store.Exports().FindByID(id)
+	if err != nil {
+		slog.ErrorContext(ctx, "data export vanished before it was built", "export_id", id, "error", err)
+		return
+	}
+	user, err := store.Users().FindByID(export.UserID)
+	if err != nil {
+		slog.ErrorContext(ctx, "data export of a missing user", "export_id", id, "error", err)
+		s.fail(ctx, export, "")
+		return
+	}
+
+	key := fmt.Sprintf("exports/export-%d-%d.zip", user.ID, export.ID)
+	size, err := s.save(ctx, key, user)
+	if err != nil {
+		slog.ErrorContext(ctx, "data export failed", "export_id", id, "user_id", user.ID, "error", err)
+		if err := storage.Private.Remove(key); err != nil {
+			slog.WarnContext(ctx, "could not remove partial data export", "export_id", id, "error", err)
+		}
+		s.fail(ctx, export, user.Email)
+		return
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(exportSettings.TTL)
+	if err := store.Exports().MarkReady(export.ID, key, size, now, expiresAt); err != nil {
+		slog.ErrorContext(ctx, "could not record finished data export", "export_id", id, "error", err)
+		return
+	}
+
+	// Link di email berlaku selama arsipnya disimpan
+	link := exportSettings.PublicURL + utils.SignURL(exportPath(export.ID), exportSettings.TTL)
+	notify(ctx, user.Email, "Your data export is ready",
+		"The copy of your personal data you asked for can be downloaded here:\n\n"+link+
+			fmt.Sprintf("\n\nThe link works until %s, after which the export is deleted.", expiresAt.UTC().Format(time.RFC1123)))
+	slog.InfoContext(ctx, "data export ready", "export_id", id, "user_id", user.ID, "bytes", size)
+}
+
+// Find - Export id while it can be downloaded
+func (s *ExportService) Find(ctx context.Context, id uint) (*models.DataExport, error) {
+	store := s.store.WithContext(ctx)
+	export, err := store.Exports().FindByID(id)
+	if err == repository.ErrNotFound {
+		return nil, ErrExportNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if export.Status != models.ExportReady || export.ExpiresAt == nil || !time.Now().Before(*export.ExpiresAt) {
+		return nil, ErrExportNotFound
+	}
+	return export, nil
+}
+
+// PurgeExpired - Delete exports whose download period is over, with their archives
+func (s *ExportService) PurgeExpired(ctx context.Context, now time.Time) (int, error) {
+	store := s.store.WithContext(ctx)
+	exports, err := store.Exports().ListExpired(now)
+	if err != nil {
+		return 0, err
+	}
+
+	purged := 0
+	for _, export := range exports {
+		if export.StorageKey != "" {
+			if err := storage.Private.Remove(export.StorageKey); err != nil {
+				return purged, err
+			}
+		}
+		if err := store.Exports().Delete(export.ID); err != nil {
+			return purged, err
+		}
+		purged++
+	}
+	return purged, nil
+}
+
+// RunCleanup - Call PurgeExpired every interval until ctx is cancelled
+func (s *ExportService) RunCleanup(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if _, err := s.PurgeExpired(ctx, time.Now()); err != nil {
+			slog.ErrorContext(ctx, "data export cleanup failed", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *ExportService) fail(ctx context.Context, export *models.DataExport, email string) {
+	store := s.store.WithContext(ctx)
+	if err := store.Exports().MarkFailed(export.ID, time.Now()); err != nil {
+		slog.ErrorContext(ctx, "could not record failed data export", "export_id", export.ID, "error", err)
+	}
+	if email != "" {
+		notify(ctx, email, "Your data export failed",
+			"We could not put together the copy of your personal data you asked for. Please request it again.")
+	}
+}
+
+// save - Stream the archive of user into private storage under key
+func (s *ExportService) save(ctx context.Context, key string, user *models.User) (int64, error) {
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(s.writeArchive(ctx, pw, user))
+	}()
+
+	size, err := storage.Private.Save(key, pr)
+	// Hentikan penulis kalau penyimpanan gagal di tengah jalan
+	pr.CloseWithError(err)
+	return size, err
+}
+
+// exportFile - One JSON document in the archive
+type exportFile struct {
+	name string
+	load func() (interface{}, error)
+}
+
+// writeArchive - Everything stored about user as JSON documents, plus the
+// images they uploaded under files/
+func (s *ExportService) writeArchive(ctx context.Context, w io.Writer, user *models.User) error {
+	store := s.store.WithContext(ctx)
+	var courses []models.Course
+	documents := []exportFile{
+		{"account.json", func() (interface{}, error) { return accountOf(user), nil }},
+		{"profile.json", func() (interface{}, error) {
+			profile, err := store.Users().FindProfile(user.ID)
+			if err == repository.ErrNotFound {
+				return nil, nil
+			}
+			return profile, err
+		}},
+		{"enrollments.json", func() (interface{}, error) { return store.Enrollments().ListByUser(user.ID) }},
+		{"quiz_attempts.json", func() (interface{}, error) { return store.Quizzes().ListAttemptsByUser(user.ID) }},
+		{"quiz_answers.json", func() (interface{}, error) { return store.Quizzes().ListUserAnswersByUser(user.ID) }},
+		{"playback_positions.json", func() (interface{}, error) { return store.Lessons().ListPlaybackByUser(user.ID) }},
+		{"courses_created.json", func() (interface{}, error) {
+			var err error
+			courses, err = store.Courses().ListByOwner(user.ID)
+			return courses, err
+		}},
+		{"login_identities.json", func() (interface{}, error) { return store.Users().ListIdentities(user.ID) }},
+		{"api_keys.json", func() (interface{}, error) { return store.APIKeys().ListByUser(user.ID) }},
+	}
+
+	zw := zip.NewWriter(w)
+	var images []string
+	for _, doc := range documents {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		data, err := doc.load()
+		if err != nil {
+			return fmt.Errorf("%s: %w", doc.name, err)
+		}
+		if profile, ok := data.(*models.Profile); ok && profile != nil && profile.Image != "" {
+			images = append(images, profile.Image)
+		}
+		if err := writeJSON(zw, doc.name, data); err != nil {
+			return err
+		}
+	}
+	for _, course := range courses {
+		if course.Image != "" {
+			images = append(images, course.Image)
+		}
+	}
+
+	for _, image := range images {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := copyUpload(zw, image); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+func writeJSON(zw *zip.Writer, name string, data interface{}) error {
+	f, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	return enc.Encode(data)
+}
+
+// copyUpload - Add a public upload referenced as /uploads/<key> to files/.
+// A file that is gone from storage is left out rather than failing the export.
+func copyUpload(zw *zip.Writer, image string) error {
+	if !strings.HasPrefix(image, "/uploads/") {
+		return nil
+	}
+	key := filepath.Base(image)
+	src, err := storage.Public.Open(key)
+	if err != nil {
+		slog.Warn("uploaded image missing from data export", "key", key, "error", err)
+		return nil
+	}
+	defer src.Close()
+
+	dst, err := zw.Create("files/" + key)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(dst, src)
+	return err
+}
+
+func exportPath(id uint) string {
+	return fmt.Sprintf("/media/export/%d", id)
+}
@@ -0,0 +1,207 @@
+package services
+
+import (
+	"backend-go/models"
+	"backend-go/repository"
+	"backend-go/utils"
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// LessonService - Lessons, their rendered content and their revision history
+type LessonService struct {
+	store repository.Store
+}
+
+// NewLessonService - Create a LessonService on top of store
+func NewLessonService(store repository.Store) *LessonService {
+	return &LessonService{store: store}
+}
+
+// LessonSnapshot - Fields of a lesson captured in every revision
+type LessonSnapshot struct {
+	Name          string `json:"name"`
+	Description   string `json:"description"`
+	Content       string `json:"content"`
+	ContentFormat string `json:"content_format"`
+	Type          string `json:"type"`
+	Image         string `json:"image"`
+	VideoKey      string `json:"video_key"`
+	VideoMimeType string `json:"video_mime_type"`
+	VideoSize     int64  `json:"video_size"`
+	CourseID      uint   `json:"course_id"`
+}
+
+// SnapshotLesson - Capture the revisioned fields of l
+func SnapshotLesson(l *models.Lesson) LessonSnapshot {
+	return LessonSnapshot{
+		Name:          l.Name,
+		Description:   l.Description,
+		Content:       l.Content,
+		ContentFormat: l.ContentFormat,
+		Type:          l.Type,
+		Image:         l.Image,
+		VideoKey:      l.VideoKey,
+		VideoMimeType: l.VideoMimeType,
+		VideoSize:     l.VideoSize,
+		CourseID:      l.CourseID,
+	}
+}
+
+// Apply - Copy the snapshot back onto l
+func (s LessonSnapshot) Apply(l *models.Lesson) {
+	l.Name = s.Name
+	l.Description = s.Description
+	l.Content = s.Content
+	l.ContentFormat = s.ContentFormat
+	l.Type = s.Type
+	l.Image = s.Image
+	l.VideoKey = s.VideoKey
+	l.VideoMimeType = s.VideoMimeType
+	l.VideoSize = s.VideoSize
+	l.CourseID = s.CourseID
+}
+
+// RenderLessonContent - Refresh the cached HTML from the lesson's source content
+func RenderLessonContent(lesson *models.Lesson) error {
+	if lesson.ContentFormat == "" {
+		lesson.ContentFormat = utils.ContentFormatMarkdown
+	}
+	html, err := utils.RenderContent(lesson.Content, lesson.ContentFormat)
+	if err != nil {
+		return err
+	}
+	lesson.ContentHTML = html
+	return nil
+}
+
+// List - Every lesson
+func (s *LessonService) List(ctx context.Context) ([]models.Lesson, error) {
+	store := s.store.WithContext(ctx)
+	return store.Lessons().List()
+}
+
+// ListByCourse - Lessons of courseID
+func (s *LessonService) ListByCourse(ctx context.Context, courseID uint) ([]models.Lesson, error) {
+	store := s.store.WithContext(ctx)
+	return store.Lessons().ListByCourse(courseID)
+}
+
+// Get - Lesson by id. Lessons stored before HTML caching get rendered and cached here.
+func (s *LessonService) Get(ctx context.Context, id uint) (*models.Lesson, error) {
+	store := s.store.WithContext(ctx)
+	lesson, err := store.Lessons().FindByID(id)
+	if err == repository.ErrNotFound {
+		return nil, ErrLessonNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if lesson.ContentHTML == "" && lesson.Content != "" {
+		if err := RenderLessonContent(lesson); err == nil {
+			store.Lessons().UpdateContentHTML(lesson.ID, lesson.ContentHTML)
+		}
+	}
+	return lesson, nil
+}
+
+// Create - Store a new lesson in an existing course together with its first revision
+func (s *LessonService) Create(ctx context.Context, lesson *models.Lesson, authorID uint) error {
+	store := s.store.WithContext(ctx)
+	if _, err := store.Courses().FindByID(lesson.CourseID); err != nil {
+		if err == repository.ErrNotFound {
+			return ErrCourseNotFound
+		}
+		return err
+	}
+
+	if lesson.Type == "" {
+		lesson.Type = models.LessonTypeText
+	}
+	if err := RenderLessonContent(lesson); err != nil {
+		return ErrInvalidContent.WithDetails(err.Error())
+	}
+
+	return store.Transaction(func(tx repository.Store) error {
+		if err := tx.Lessons().Create(lesson); err != nil {
+			return err
+		}
+		if err := tx.Revisions().Record(models.RevisionLesson, lesson.ID, authorID, SnapshotLesson(lesson), "created"); err != nil {
+			return err
+		}
+		return Audit(ctx, tx, "lesson.create", models.AuditLesson, lesson.ID, nil, SnapshotLesson(lesson))
+	})
+}
+
+// Update - Save an edited lesson and record the new state as a revision
+func (s *LessonService) Update(ctx context.Context, lesson *models.Lesson, authorID uint, note string) error {
+	store := s.store.WithContext(ctx)
+	if err := RenderLessonContent(lesson); err != nil {
+		return ErrInvalidContent.WithDetails(err.Error())
+	}
+
+	return editWithRevision(store, func(tx repository.Store) error {
+		// lesson sudah diubah pemanggil, jadi keadaan lama dibaca ulang dari database
+		stored, err := tx.Lessons().FindByID(lesson.ID)
+		if err != nil {
+			return err
+		}
+		if err := tx.Lessons().Save(lesson); err != nil {
+			return err
+		}
+		if err := recordRevision(tx, models.RevisionLesson, lesson.ID, authorID, SnapshotLesson(stored), SnapshotLesson(lesson), note); err != nil {
+			return err
+		}
+		return Audit(ctx, tx, "lesson.update", models.AuditLesson, lesson.ID, SnapshotLesson(stored), SnapshotLesson(lesson))
+	})
+}
+
+// Restore - Make revision version the current state of lesson id, recorded as
+// a new revision
+func (s *LessonService) Restore(ctx context.Context, id uint, version int, authorID uint) (*models.Lesson, error) {
+	store := s.store.WithContext(ctx)
+	revision, err := findRevision(store, models.RevisionLesson, id, version)
+	if err != nil {
+		return nil, err
+	}
+	var snapshot LessonSnapshot
+	if err := json.Unmarshal([]byte(revision.Snapshot), &snapshot); err != nil {
+		return nil, err
+	}
+
+	lesson, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	snapshot.Apply(lesson)
+	if err := s.Update(ctx, lesson, authorID, fmt.Sprintf("restored from version %d", revision.Version)); err != nil {
+		return nil, err
+	}
+	return lesson, nil
+}
+
+// AttachmentIDs - IDs of the attachments of lesson id
+func (s *LessonService) AttachmentIDs(ctx context.Context, id uint) ([]uint, error) {
+	store := s.store.WithContext(ctx)
+	return store.Attachments().ListIDsByLesson(id)
+}
+
+// Delete - Move lesson id to the trash with its attachments
+func (s *LessonService) Delete(ctx context.Context, id uint) error {
+	store := s.store.WithContext(ctx)
+	lesson, err := store.Lessons().FindByID(id)
+	if err == repository.ErrNotFound {
+		return ErrLessonNotFound
+	}
+	if err != nil {
+		return err
+	}
+	return store.Transaction(func(tx repository.Store) error {
+		if err := MoveToTrash(tx, models.AuditLesson, lesson.ID); err != nil {
+			return err
+		}
+		return Audit(ctx, tx, "lesson.delete", models.AuditLesson, lesson.ID, SnapshotLesson(lesson), nil)
+	})
+}
@@ -0,0 +1,338 @@
+package services
+
+import (
+	"backend-go/models"
+	"backend-go/repository"
+	"backend-go/utils"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/hotp"
+	"github.com/pquerna/otp/totp"
+)
+
+// TOTP parameters understood by every authenticator app
+const (
+	totpPeriod = 30
+	// totpSkew - Codes of the neighbouring steps are accepted for clock drift
+	totpSkew = 1
+)
+
+// RecoveryCodeCount - Recovery codes issued at once; issuing new ones voids the old
+const RecoveryCodeCount = 10
+
+var (
+	mfaIssuer = "backend-go"
+	// mfaRequiredRoles - Roles that may only use the API after a TOTP login
+	mfaRequiredRoles = map[string]bool{}
+)
+
+// ConfigureMFA - Set the issuer shown in authenticator apps and the roles that
+// must use two-factor authentication, called once from main
+func ConfigureMFA(issuer string, requiredRoles []string) {
+	mfaIssuer = issuer
+	mfaRequiredRoles = make(map[string]bool, len(requiredRoles))
+	for _, role := range requiredRoles {
+		mfaRequiredRoles[role] = true
+	}
+}
+
+// MFARequired - Whether users with role must log in with a second factor
+func MFARequired(role string) bool {
+	return mfaRequiredRoles[role]
+}
+
+// MFAService - TOTP enrollment and recovery codes of the current user
+type MFAService struct {
+	store repository.Store
+}
+
+// NewMFAService - Create an MFAService on top of store
+func NewMFAService(store repository.Store) *MFAService {
+	return &MFAService{store: store}
+}
+
+// MFAStatus - Two-factor state of a user
+type MFAStatus struct {
+	Enabled           bool  `json:"enabled"`
+	Required          bool  `json:"required"`
+	RecoveryCodesLeft int64 `json:"recovery_codes_left"`
+}
+
+// MFASetup - What the user needs to add the account to an authenticator app
+type MFASetup struct {
+	Secret string `json:"secret"`
+	// URI is the otpauth:// provisioning URI, usually shown as a QR code
+	URI string `json:"otpauth_uri"`
+}
+
+// MFAConfirmation - Result of enabling two-factor authentication
+type MFAConfirmation struct {
+	// RecoveryCodes are shown once and never again
+	RecoveryCodes []string `json:"recovery_codes"`
+	// Token is a new access token that counts as a two-factor login
+	Token string `json:"token"`
+}
+
+// Status - Two-factor state of userID
+func (s *MFAService) Status(ctx context.Context, userID uint) (*MFAStatus, error) {
+	store := accountStore(ctx, s.store)
+	user, err := s.findUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	status := MFAStatus{Enabled: user.TwoFactorEnabled(), Required: MFARequired(user.Roles)}
+	if status.Enabled {
+		if status.RecoveryCodesLeft, err = store.Users().CountRecoveryCodes(userID); err != nil {
+			return nil, err
+		}
+	}
+	return &status, nil
+}
+
+// Setup - Generate a new secret for userID. It is only used for logins after
+// Confirm; calling Setup again replaces an unconfirmed secret.
+func (s *MFAService) Setup(ctx context.Context, userID uint) (*MFASetup, error) {
+	store := accountStore(ctx, s.store)
+	user, err := s.findUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TwoFactorEnabled() {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	key, err := totp.Generate(totp.GenerateOpts{Issuer: mfaIssuer, AccountName: user.Email})
+	if err != nil {
+		return nil, err
+	}
+	err = store.Transaction(func(tx repository.Store) error {
+		if err := tx.Users().SetTOTPSecret(userID, key.Secret()); err != nil {
+			return err
+		}
+		return Audit(ctx, tx, "user.mfa_setup", models.AuditUser, userID, nil, nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &MFASetup{Secret: key.Secret(), URI: key.URL()}, nil
+}
+
+// Confirm - Enable two-factor authentication once the user proves the
+// authenticator works by sending its current code
+func (s *MFAService) Confirm(ctx context.Context, userID uint, code string) (*MFAConfirmation, error) {
+	store := accountStore(ctx, s.store)
+	user, err := s.findUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TwoFactorEnabled() {
+		return nil, ErrMFAAlreadyEnabled
+	}
+	if user.TOTPSecret == "" {
+		return nil, ErrMFANotStarted
+	}
+
+	step, ok := matchTOTP(user.TOTPSecret, strings.TrimSpace(code), time.Now())
+	if !ok {
+		return nil, ErrInvalidMFACode
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	err = store.Transaction(func(tx repository.Store) error {
+		now := time.Now()
+		if err := tx.Users().EnableTOTP(userID, now, step); err != nil {
+			return err
+		}
+		if err := tx.Users().ReplaceRecoveryCodes(userID, hashes); err != nil {
+			return err
+		}
+		before := accountOf(user)
+		user.TOTPEnabledAt = &now
+		return Audit(ctx, tx, "user.mfa_enable", models.AuditUser, userID, before, accountOf(user))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := utils.GenerateVerifiedToken(user.ID, user.Roles)
+	if err != nil {
+		return nil, err
+	}
+	return &MFAConfirmation{RecoveryCodes: codes, Token: token}, nil
+}
+
+// RegenerateRecoveryCodes - Replace all recovery codes of userID. Needs a
+// current TOTP code, so a stolen session alone cannot read new codes.
+func (s *MFAService) RegenerateRecoveryCodes(ctx context.Context, userID uint, code string) ([]string, error) {
+	store := accountStore(ctx, s.store)
+	user, err := s.findUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.TwoFactorEnabled() {
+		return nil, ErrMFANotEnabled
+	}
+	if ok, err := verifyTOTP(store.Users(), user, code); err != nil || !ok {
+		return nil, orInvalidCode(err)
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	err = store.Transaction(func(tx repository.Store) error {
+		if err := tx.Users().ReplaceRecoveryCodes(userID, hashes); err != nil {
+			return err
+		}
+		return Audit(ctx, tx, "user.mfa_recovery_codes", models.AuditUser, userID, nil, nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// Disable - Turn two-factor authentication off with a TOTP or recovery code.
+// Roles that require it cannot turn it off.
+func (s *MFAService) Disable(ctx context.Context, userID uint, code string) error {
+	store := accountStore(ctx, s.store)
+	user, err := s.findUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !user.TwoFactorEnabled() {
+		return ErrMFANotEnabled
+	}
+	if MFARequired(user.Roles) {
+		return ErrMFAPolicy
+	}
+	if ok, err := verifySecondFactor(store.Users(), user, code); err != nil || !ok {
+		return orInvalidCode(err)
+	}
+	return store.Transaction(func(tx repository.Store) error {
+		if err := tx.Users().DisableTOTP(userID); err != nil {
+			return err
+		}
+		before := accountOf(user)
+		user.TOTPEnabledAt = nil
+		return Audit(ctx, tx, "user.mfa_disable", models.AuditUser, userID, before, accountOf(user))
+	})
+}
+
+func (s *MFAService) findUser(ctx context.Context, userID uint) (*models.User, error) {
+	store := accountStore(ctx, s.store)
+	user, err := store.Users().FindByID(userID)
+	if err == repository.ErrNotFound {
+		return nil, ErrUserNotFound
+	}
+	return user, err
+}
+
+// orInvalidCode - err, or ErrInvalidMFACode when the code was merely wrong
+func orInvalidCode(err error) error {
+	if err != nil {
+		return err
+	}
+	return ErrInvalidMFACode
+}
+
+// verifySecondFactor - Check a TOTP code, or a recovery code when code is not
+// six digits. Either is used up by a successful check.
+func verifySecondFactor(users repository.UserRepository, user *models.User, code string) (bool, error) {
+	code = strings.TrimSpace(code)
+	if len(code) == otp.DigitsSix.Length() && strings.Trim(code, "0123456789") == "" {
+		return verifyTOTP(users, user, code)
+	}
+	return users.UseRecoveryCode(user.ID, hashRecoveryCode(code))
+}
+
+// verifyTOTP - Check a TOTP code and mark its step used
+func verifyTOTP(users repository.UserRepository, user *models.User, code string) (bool, error) {
+	step, ok := matchTOTP(user.TOTPSecret, strings.TrimSpace(code), time.Now())
+	if !ok || step <= user.TOTPLastStep {
+		return false, nil
+	}
+	// Langkah yang sama tidak boleh dipakai dua kali, juga oleh login yang bersamaan
+	return users.UseTOTPStep(user.ID, step)
+}
+
+// matchTOTP - The time step whose code equals code, allowing totpSkew steps of drift
+func matchTOTP(secret, code string, now time.Time) (int64, bool) {
+	if secret == "" {
+		return 0, false
+	}
+	opts := hotp.ValidateOpts{Digits: otp.DigitsSix, Algorithm: otp.AlgorithmSHA1}
+	current := now.Unix() / totpPeriod
+	for step := current - totpSkew; step <= current+totpSkew; step++ {
+		expected, err := hotp.GenerateCodeCustom(secret, uint64(step), opts)
+		if err != nil {
+			return 0, false
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// recoveryAlphabet - No 0/o, 1/l/i so codes survive being written down
+const recoveryAlphabet = "23456789abcdefghjkmnpqrstuvwxyz"
+
+// newRecoveryCodes - RecoveryCodeCount random codes formatted "xxxxx-xxxxx"
+// and their hashes
+func newRecoveryCodes() (codes, hashes []string, err error) {
+	codes = make([]string, RecoveryCodeCount)
+	hashes = make([]string, RecoveryCodeCount)
+	for i := range codes {
+		code, err := randomString(10, recoveryAlphabet)
+		if err != nil {
+			return nil, nil, err
+		}
+		codes[i] = code[:5] + "-" + code[5:]
+		hashes[i] = hashRecoveryCode(codes[i])
+	}
+	return codes, hashes, nil
+}
+
+// randomString - n characters drawn uniformly from alphabet
+func randomString(n int, alphabet string) (string, error) {
+	out := make([]byte, 0, n)
+	buf := make([]byte, n)
+	// Byte di atas kelipatan panjang alphabet dibuang agar tidak bias
+	limit := 256 - 256%len(alphabet)
+	for len(out) < n {
+		if _, err := rand.Read(buf); err != nil {
+			return "", err
+		}
+		for _, b := range buf {
+			if int(b) < limit && len(out) < n {
+				out = append(out, alphabet[int(b)%len(alphabet)])
+			}
+		}
+	}
+	return string(out), nil
+}
+
+// hashRecoveryCode - SHA-256 of the code ignoring case, spaces and dashes.
+// Codes are random, so a fast hash is enough.
+func hashRecoveryCode(code string) string {
+	normalized := strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, strings.ToLower(code))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
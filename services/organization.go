@@ -0,0 +1,141 @@
+package services
+
+import (
+	"backend-go/models"
+	"backend-go/repository"
+	"backend-go/tenancy"
+	"context"
+	"regexp"
+	"strings"
+)
+
+// slugPattern - A slug is used as a subdomain, so it follows the DNS label rules
+var slugPattern = regexp.MustCompile(`^[a-z0-9](?:[a-z0-9-]{0,61}[a-z0-9])?$`)
+
+// OrganizationInput - Fields of an organization, all required on create;
+// empty fields are left unchanged on update
+type OrganizationInput struct {
+	Name string `json:"name" validate:"required_without=Slug,max=200"`
+	Slug string `json:"slug" validate:"required_without=Name,max=63"`
+}
+
+// OrganizationService - The organizations (schools) sharing the platform
+type OrganizationService struct {
+	store repository.Store
+}
+
+// NewOrganizationService - Create an OrganizationService on top of store
+func NewOrganizationService(store repository.Store) *OrganizationService {
+	return &OrganizationService{store: store}
+}
+
+// List - Every organization
+func (s *OrganizationService) List(ctx context.Context) ([]models.Organization, error) {
+	store := s.store.WithContext(ctx)
+	return store.Organizations().List()
+}
+
+// Get - Organization by id
+func (s *OrganizationService) Get(ctx context.Context, id uint) (*models.Organization, error) {
+	store := s.store.WithContext(ctx)
+	organization, err := store.Organizations().FindByID(id)
+	if err == repository.ErrNotFound {
+		return nil, ErrOrganizationNotFound
+	}
+	return organization, err
+}
+
+// Resolve - Organization reached with slug, as a subdomain or X-Organization header
+func (s *OrganizationService) Resolve(ctx context.Context, slug string) (*models.Organization, error) {
+	store := s.store.WithContext(ctx)
+	organization, err := store.Organizations().FindBySlug(strings.ToLower(slug))
+	if err == repository.ErrNotFound {
+		return nil, ErrOrganizationNotFound
+	}
+	return organization, err
+}
+
+// Create - Add an organization with a name and a free slug
+func (s *OrganizationService) Create(ctx context.Context, input OrganizationInput) (*models.Organization, error) {
+	store := s.store.WithContext(ctx)
+	organization := models.Organization{Name: strings.TrimSpace(input.Name), Slug: strings.ToLower(input.Slug)}
+	if err := s.checkSlug(ctx, organization.Slug); err != nil {
+		return nil, err
+	}
+
+	err := store.Transaction(func(tx repository.Store) error {
+		if err := tx.Organizations().Create(&organization); err != nil {
+			return err
+		}
+		return Audit(ctx, tx, "organization.create", models.AuditOrganization, organization.ID, nil, organization)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &organization, nil
+}
+
+// Update - Rename organization id or move it to another slug
+func (s *OrganizationService) Update(ctx context.Context, id uint, input OrganizationInput) (*models.Organization, error) {
+	store := s.store.WithContext(ctx)
+	organization, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := models.Organization{Name: strings.TrimSpace(input.Name), Slug: strings.ToLower(input.Slug)}
+	if changes.Slug != "" && changes.Slug != organization.Slug {
+		if err := s.checkSlug(ctx, changes.Slug); err != nil {
+			return nil, err
+		}
+	}
+
+	before := *organization
+	err = store.Transaction(func(tx repository.Store) error {
+		if err := tx.Organizations().Update(organization, changes); err != nil {
+			return err
+		}
+		return Audit(ctx, tx, "organization.update", models.AuditOrganization, organization.ID, before, organization)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return organization, nil
+}
+
+// checkSlug - ErrInvalidSlug or ErrSlugTaken unless slug can name a new organization
+func (s *OrganizationService) checkSlug(ctx context.Context, slug string) error {
+	store := s.store.WithContext(ctx)
+	if !slugPattern.MatchString(slug) {
+		return ErrInvalidSlug
+	}
+	taken, err := store.Organizations().ExistsBySlug(slug)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return ErrSlugTaken
+	}
+	return nil
+}
+
+// CheckOrganization - ErrWrongOrganization unless user may act in the
+// organization of ctx: their own, or any for a superadmin
+func CheckOrganization(ctx context.Context, user *models.User) error {
+	id, ok := tenancy.OrganizationID(ctx)
+	if !ok {
+		id = tenancy.Current(ctx)
+	}
+	if id == 0 || user.OrganizationID == id || user.Roles == models.RoleSuperAdmin {
+		return nil
+	}
+	return ErrWrongOrganization
+}
+
+// accountStore - store for users' own accounts: logins, sessions and account
+// settings. Emails are unique across the platform and a superadmin's account
+// stays in its organization while they act in others, so these queries are
+// not limited to the organization of ctx.
+func accountStore(ctx context.Context, store repository.Store) repository.Store {
+	return store.WithContext(tenancy.AllOrganizations(ctx))
+}
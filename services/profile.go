@@ -0,0 +1,65 @@
+package services
+
+import (
+	"backend-go/models"
+	"backend-go/repository"
+	"context"
+)
+
+// ProfileService - A user's own profile
+type ProfileService struct {
+	store repository.Store
+}
+
+// NewProfileService - Create a ProfileService on top of store
+func NewProfileService(store repository.Store) *ProfileService {
+	return &ProfileService{store: store}
+}
+
+// Get - Profile of userID
+func (s *ProfileService) Get(ctx context.Context, userID uint) (*models.Profile, error) {
+	store := s.store.WithContext(ctx)
+	profile, err := store.Users().FindProfile(userID)
+	if err == repository.ErrNotFound {
+		return nil, ErrProfileNotFound
+	}
+	return profile, err
+}
+
+// Create - Store a new profile
+func (s *ProfileService) Create(ctx context.Context, profile *models.Profile) error {
+	store := s.store.WithContext(ctx)
+	return store.Transaction(func(tx repository.Store) error {
+		if err := tx.Users().CreateProfile(profile); err != nil {
+			return err
+		}
+		return Audit(ctx, tx, "profile.create", models.AuditProfile, profile.ID, nil, profile)
+	})
+}
+
+// Update - Apply the non-zero fields of changes to profile
+func (s *ProfileService) Update(ctx context.Context, profile *models.Profile, changes models.Profile) error {
+	store := s.store.WithContext(ctx)
+	before := *profile
+	return store.Transaction(func(tx repository.Store) error {
+		if err := tx.Users().UpdateProfile(profile, changes); err != nil {
+			return err
+		}
+		return Audit(ctx, tx, "profile.update", models.AuditProfile, profile.ID, before, profile)
+	})
+}
+
+// Delete - Remove the profile of userID
+func (s *ProfileService) Delete(ctx context.Context, userID uint) error {
+	store := s.store.WithContext(ctx)
+	profile, err := s.Get(ctx, userID)
+	if err != nil {
+		return err
+	}
+	return store.Transaction(func(tx repository.Store) error {
+		if err := tx.Users().DeleteProfile(profile); err != nil {
+			return err
+		}
+		return Audit(ctx, tx, "profile.delete", models.AuditProfile, profile.ID, profile, nil)
+	})
+}
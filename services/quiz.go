@@ -0,0 +1,282 @@
+package services
+
+import (
+	"backend-go/metrics"
+	"backend-go/models"
+	"backend-go/repository"
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// QuizService - Quizzes, their answers and their revision history
+type QuizService struct {
+	store repository.Store
+}
+
+// NewQuizService - Create a QuizService on top of store
+func NewQuizService(store repository.Store) *QuizService {
+	return &QuizService{store: store}
+}
+
+// QuizSnapshot - Fields of a quiz captured in every revision
+type QuizSnapshot struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Content     string `json:"content"`
+	CourseID    uint   `json:"course_id"`
+}
+
+// SnapshotQuiz - Capture the revisioned fields of q
+func SnapshotQuiz(q *models.Quiz) QuizSnapshot {
+	return QuizSnapshot{
+		Name:        q.Name,
+		Description: q.Description,
+		Content:     q.Content,
+		CourseID:    q.CourseID,
+	}
+}
+
+// Apply - Copy the snapshot back onto q
+func (s QuizSnapshot) Apply(q *models.Quiz) {
+	q.Name = s.Name
+	q.Description = s.Description
+	q.Content = s.Content
+	q.CourseID = s.CourseID
+}
+
+// List - Every quiz with its course
+func (s *QuizService) List(ctx context.Context) ([]models.Quiz, error) {
+	store := s.store.WithContext(ctx)
+	return store.Quizzes().List()
+}
+
+// ListByCourse - Quizzes of courseID
+func (s *QuizService) ListByCourse(ctx context.Context, courseID uint) ([]models.Quiz, error) {
+	store := s.store.WithContext(ctx)
+	return store.Quizzes().ListByCourse(courseID)
+}
+
+// Get - Quiz by id with its course
+func (s *QuizService) Get(ctx context.Context, id uint) (*models.Quiz, error) {
+	store := s.store.WithContext(ctx)
+	quiz, err := store.Quizzes().FindByIDWithCourse(id)
+	if err == repository.ErrNotFound {
+		return nil, ErrQuizNotFound
+	}
+	return quiz, err
+}
+
+// Create - Store a new quiz in an existing course together with its first revision
+func (s *QuizService) Create(ctx context.Context, quiz *models.Quiz, authorID uint) error {
+	store := s.store.WithContext(ctx)
+	if err := s.courseExists(ctx, quiz.CourseID); err != nil {
+		return err
+	}
+
+	return store.Transaction(func(tx repository.Store) error {
+		if err := tx.Quizzes().Create(quiz); err != nil {
+			return err
+		}
+		if err := tx.Revisions().Record(models.RevisionQuiz, quiz.ID, authorID, SnapshotQuiz(quiz), "created"); err != nil {
+			return err
+		}
+		return Audit(ctx, tx, "quiz.create", models.AuditQuiz, quiz.ID, nil, SnapshotQuiz(quiz))
+	})
+}
+
+// Update - Apply changes to quiz id and record the new state as a revision
+func (s *QuizService) Update(ctx context.Context, id uint, changes QuizSnapshot, authorID uint, note string) (*models.Quiz, error) {
+	store := s.store.WithContext(ctx)
+	quiz, err := store.Quizzes().FindByID(id)
+	if err == repository.ErrNotFound {
+		return nil, ErrQuizNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	before := SnapshotQuiz(quiz)
+	changes.Apply(quiz)
+	err = editWithRevision(store, func(tx repository.Store) error {
+		if err := tx.Quizzes().Save(quiz); err != nil {
+			return err
+		}
+		if err := recordRevision(tx, models.RevisionQuiz, quiz.ID, authorID, before, SnapshotQuiz(quiz), note); err != nil {
+			return err
+		}
+		return Audit(ctx, tx, "quiz.update", models.AuditQuiz, quiz.ID, before, SnapshotQuiz(quiz))
+	})
+	return quiz, err
+}
+
+// Restore - Make revision version the current state of quiz id, recorded as a
+// new revision
+func (s *QuizService) Restore(ctx context.Context, id uint, version int, authorID uint) (*models.Quiz, error) {
+	store := s.store.WithContext(ctx)
+	revision, err := findRevision(store, models.RevisionQuiz, id, version)
+	if err != nil {
+		return nil, err
+	}
+	var snapshot QuizSnapshot
+	if err := json.Unmarshal([]byte(revision.Snapshot), &snapshot); err != nil {
+		return nil, err
+	}
+	return s.Update(ctx, id, snapshot, authorID, fmt.Sprintf("restored from version %d", revision.Version))
+}
+
+// Delete - Move quiz id to the trash with its answers
+func (s *QuizService) Delete(ctx context.Context, id uint) error {
+	store := s.store.WithContext(ctx)
+	quiz, err := store.Quizzes().FindByID(id)
+	if err == repository.ErrNotFound {
+		return ErrQuizNotFound
+	}
+	if err != nil {
+		return err
+	}
+	return store.Transaction(func(tx repository.Store) error {
+		if err := MoveToTrash(tx, models.AuditQuiz, id); err != nil {
+			return err
+		}
+		return Audit(ctx, tx, "quiz.delete", models.AuditQuiz, id, SnapshotQuiz(quiz), nil)
+	})
+}
+
+// ListAnswers - Answers of quizID
+func (s *QuizService) ListAnswers(ctx context.Context, quizID uint) ([]models.Answer, error) {
+	store := s.store.WithContext(ctx)
+	return store.Quizzes().ListAnswers(quizID)
+}
+
+// GetAnswer - Answer by id
+func (s *QuizService) GetAnswer(ctx context.Context, id uint) (*models.Answer, error) {
+	store := s.store.WithContext(ctx)
+	answer, err := store.Quizzes().FindAnswer(id)
+	if err == repository.ErrNotFound {
+		return nil, ErrAnswerNotFound
+	}
+	return answer, err
+}
+
+// CreateAnswer - Store a new answer for an existing quiz
+func (s *QuizService) CreateAnswer(ctx context.Context, answer *models.Answer) error {
+	store := s.store.WithContext(ctx)
+	if _, err := store.Quizzes().FindByID(answer.QuizID); err != nil {
+		if err == repository.ErrNotFound {
+			return ErrQuizNotFound
+		}
+		return err
+	}
+	return store.Transaction(func(tx repository.Store) error {
+		if err := tx.Quizzes().CreateAnswer(answer); err != nil {
+			return err
+		}
+		return Audit(ctx, tx, "answer.create", models.AuditAnswer, answer.ID, nil, answer)
+	})
+}
+
+// UpdateAnswer - Replace the content and quiz of answer id
+func (s *QuizService) UpdateAnswer(ctx context.Context, id uint, content string, quizID uint) (*models.Answer, error) {
+	store := s.store.WithContext(ctx)
+	answer, err := s.GetAnswer(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	before := *answer
+	answer.Content = content
+	answer.QuizID = quizID
+	err = store.Transaction(func(tx repository.Store) error {
+		if err := tx.Quizzes().SaveAnswer(answer); err != nil {
+			return err
+		}
+		return Audit(ctx, tx, "answer.update", models.AuditAnswer, answer.ID, before, answer)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return answer, nil
+}
+
+// DeleteAnswer - Move answer id to the trash
+func (s *QuizService) DeleteAnswer(ctx context.Context, id uint) error {
+	store := s.store.WithContext(ctx)
+	answer, err := s.GetAnswer(ctx, id)
+	if err != nil {
+		return err
+	}
+	return store.Transaction(func(tx repository.Store) error {
+		if err := MoveToTrash(tx, models.AuditAnswer, id); err != nil {
+			return err
+		}
+		return Audit(ctx, tx, "answer.delete", models.AuditAnswer, id, answer, nil)
+	})
+}
+
+// QuizSubmission - One attempt at a quiz with the answers the user picked
+type QuizSubmission struct {
+	Attempt models.UserQuiz     `json:"attempt"`
+	Answers []models.UserAnswer `json:"answers"`
+}
+
+// Submit - Record userID's attempt at quizID. Every picked answer must belong to the quiz.
+func (s *QuizService) Submit(ctx context.Context, quizID, userID uint, answerIDs []uint) (*QuizSubmission, error) {
+	store := s.store.WithContext(ctx)
+	if _, err := store.Quizzes().FindByID(quizID); err != nil {
+		if err == repository.ErrNotFound {
+			return nil, ErrQuizNotFound
+		}
+		return nil, err
+	}
+
+	answers, err := store.Quizzes().ListAnswers(quizID)
+	if err != nil {
+		return nil, err
+	}
+	valid := make(map[uint]bool, len(answers))
+	for _, answer := range answers {
+		valid[answer.ID] = true
+	}
+
+	submission := QuizSubmission{Attempt: models.UserQuiz{UserID: userID, QuizID: quizID}}
+	for _, id := range answerIDs {
+		if !valid[id] {
+			return nil, ErrAnswerNotInQuiz
+		}
+		submission.Answers = append(submission.Answers, models.UserAnswer{UserID: userID, AnswerID: id})
+	}
+
+	err = store.Transaction(func(tx repository.Store) error {
+		if err := tx.Quizzes().CreateAttempt(&submission.Attempt); err != nil {
+			return err
+		}
+		if err := tx.Quizzes().CreateUserAnswers(submission.Answers); err != nil {
+			return err
+		}
+		return Audit(ctx, tx, "quiz.submit", models.AuditQuizAttempt, submission.Attempt.ID, nil,
+			map[string]interface{}{"QuizID": quizID, "AnswerIDs": answerIDs})
+	})
+	if err != nil {
+		return nil, err
+	}
+	metrics.QuizSubmissions.Inc()
+	return &submission, nil
+}
+
+// ListAttempts - userID's quiz attempts with their quiz, newest first
+func (s *QuizService) ListAttempts(ctx context.Context, userID uint) ([]models.UserQuiz, error) {
+	store := s.store.WithContext(ctx)
+	return store.Quizzes().ListAttemptsByUser(userID)
+}
+
+func (s *QuizService) courseExists(ctx context.Context, courseID uint) error {
+	store := s.store.WithContext(ctx)
+	if _, err := store.Courses().FindByID(courseID); err != nil {
+		if err == repository.ErrNotFound {
+			return ErrCourseNotFound
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package services
+
+import (
+	"backend-go/models"
+	"backend-go/repository"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// RevisionService - Reading the history of lessons and quizzes
+type RevisionService struct {
+	store repository.Store
+}
+
+// NewRevisionService - Create a RevisionService on top of store
+func NewRevisionService(store repository.Store) *RevisionService {
+	return &RevisionService{store: store}
+}
+
+// RevisionDiff - Unified diff between two revisions of an entity
+type RevisionDiff struct {
+	From int    `json:"from"`
+	To   int    `json:"to"`
+	Diff string `json:"diff"`
+}
+
+// List - Revisions of the entity without their snapshots, newest first
+func (s *RevisionService) List(ctx context.Context, entityType string, entityID uint) ([]models.Revision, error) {
+	store := s.store.WithContext(ctx)
+	return store.Revisions().List(entityType, entityID)
+}
+
+// Get - One revision of the entity by version number
+func (s *RevisionService) Get(ctx context.Context, entityType string, entityID uint, version int) (*models.Revision, error) {
+	return findRevision(s.store.WithContext(ctx), entityType, entityID, version)
+}
+
+// Diff - Compare two revisions of the entity field by field
+func (s *RevisionService) Diff(ctx context.Context, entityType string, entityID uint, from, to int) (*RevisionDiff, error) {
+	store := s.store.WithContext(ctx)
+	a, err := findRevision(store, entityType, entityID, from)
+	if err != nil {
+		return nil, err
+	}
+	b, err := findRevision(store, entityType, entityID, to)
+	if err != nil {
+		return nil, err
+	}
+
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(snapshotText(a.Snapshot)),
+		B:        difflib.SplitLines(snapshotText(b.Snapshot)),
+		FromFile: fmt.Sprintf("version %d", a.Version),
+		ToFile:   fmt.Sprintf("version %d", b.Version),
+		Context:  3,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &RevisionDiff{From: a.Version, To: b.Version, Diff: diff}, nil
+}
+
+// revisionAttempts - How often a change is tried when concurrent edits keep
+// taking its revision version
+const revisionAttempts = 3
+
+// editWithRevision - Run fn, a change recording a revision, in a transaction.
+// When a concurrent change recorded the same version first the transaction is
+// rolled back and fn runs again, so it must start from what it reads inside tx.
+func editWithRevision(store repository.Store, fn func(tx repository.Store) error) error {
+	for attempt := 0; attempt < revisionAttempts; attempt++ {
+		err := store.Transaction(fn)
+		if !errors.Is(err, repository.ErrVersionTaken) {
+			return err
+		}
+	}
+	return ErrConcurrentEdit
+}
+
+// recordRevision - Append after as the next revision of the entity. Entities
+// whose history is still empty (created before revisions were kept) first get
+// before, their state ahead of this change, as version 1.
+func recordRevision(tx repository.Store, entityType string, entityID, authorID uint, before, after interface{}, note string) error {
+	latest, err := tx.Revisions().Latest(entityType, entityID)
+	if err != nil {
+		return err
+	}
+	if latest == 0 {
+		if err := tx.Revisions().Record(entityType, entityID, 0, before, "initial state"); err != nil {
+			return err
+		}
+	}
+	return tx.Revisions().Record(entityType, entityID, authorID, after, note)
+}
+
+// findRevision - Revision of the entity by version, ErrRevisionNotFound when missing
+func findRevision(store repository.Store, entityType string, entityID uint, version int) (*models.Revision, error) {
+	revision, err := store.Revisions().Find(entityType, entityID, version)
+	if err == repository.ErrNotFound {
+		return nil, ErrRevisionNotFound
+	}
+	return revision, err
+}
+
+// snapshotText - Flatten a JSON snapshot into stable "field: value" lines for diffing
+func snapshotText(snapshot string) string {
+	var fields map[string]interface{}
+	if err := json.Unmarshal([]byte(snapshot), &fields); err != nil {
+		return snapshot
+	}
+
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for _, k := range keys {
+		// Konten panjang ditulis per baris supaya diff-nya bisa dibaca
+		if s, ok := fields[k].(string); ok && strings.Contains(s, "\n") {
+			fmt.Fprintf(&b, "%s:\n%s\n", k, strings.TrimRight(s, "\n"))
+			continue
+		}
+		fmt.Fprintf(&b, "%s: %v\n", k, fields[k])
+	}
+	return b.String()
+}
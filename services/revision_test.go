@@ -0,0 +1,104 @@
+package services_test
+
+import (
+	"backend-go/models"
+	"backend-go/repository"
+	"backend-go/services"
+	"context"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+// revisionStore - fakeStore with one quiz, its history and the audit log
+type revisionStore struct {
+	*fakeStore
+	quizzes   *fakeQuizzes
+	revisions *fakeRevisions
+}
+
+func (s *revisionStore) Quizzes() repository.QuizRepository           { return s.quizzes }
+func (s *revisionStore) Revisions() repository.RevisionRepository     { return s.revisions }
+func (s *revisionStore) Audit() repository.AuditRepository            { return fakeAudit{} }
+func (s *revisionStore) WithContext(context.Context) repository.Store { return s }
+func (s *revisionStore) Transaction(fn func(tx repository.Store) error) error {
+	return fn(s)
+}
+
+type fakeQuizzes struct {
+	repository.QuizRepository
+	quiz models.Quiz
+}
+
+func (r *fakeQuizzes) FindByID(id uint) (*models.Quiz, error) {
+	if id != r.quiz.ID {
+		return nil, repository.ErrNotFound
+	}
+	quiz := r.quiz
+	return &quiz, nil
+}
+
+func (r *fakeQuizzes) Save(quiz *models.Quiz) error {
+	r.quiz = *quiz
+	return nil
+}
+
+type fakeRevisions struct {
+	repository.RevisionRepository
+	notes []string
+	// taken - How many more Record calls lose the race for their version
+	taken int
+}
+
+func (r *fakeRevisions) Latest(string, uint) (int, error) { return len(r.notes), nil }
+
+func (r *fakeRevisions) Record(entityType string, entityID, authorID uint, snapshot interface{}, note string) error {
+	if r.taken > 0 {
+		r.taken--
+		return repository.ErrVersionTaken
+	}
+	r.notes = append(r.notes, note)
+	return nil
+}
+
+type fakeAudit struct {
+	repository.AuditRepository
+}
+
+func (fakeAudit) Record(*models.AuditEntry) error { return nil }
+
+func newRevisionStore(taken int) *revisionStore {
+	return &revisionStore{
+		fakeStore: newFakeStore(),
+		quizzes:   &fakeQuizzes{quiz: models.Quiz{Model: gorm.Model{ID: 1}, Name: "Quiz", CourseID: 1}},
+		revisions: &fakeRevisions{notes: []string{"created"}, taken: taken},
+	}
+}
+
+func TestQuizUpdateRetriesTakenVersion(t *testing.T) {
+	store := newRevisionStore(2)
+	quiz, err := services.NewQuizService(store).Update(context.Background(), 1, services.QuizSnapshot{Name: "Exam", CourseID: 1}, 7, "renamed")
+	if err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if quiz.Name != "Exam" || len(store.revisions.notes) != 2 || store.revisions.notes[1] != "renamed" {
+		t.Fatalf("quiz = %+v, revisions = %v", quiz, store.revisions.notes)
+	}
+
+	// Terus kalah berarti ada yang mengedit bersamaan, bukan error server
+	store = newRevisionStore(10)
+	if _, err := services.NewQuizService(store).Update(context.Background(), 1, services.QuizSnapshot{Name: "Exam"}, 7, ""); err != services.ErrConcurrentEdit {
+		t.Fatalf("update under constant conflicts = %v", err)
+	}
+}
+
+func TestFirstEditRecordsInitialState(t *testing.T) {
+	store := newRevisionStore(0)
+	store.revisions.notes = nil
+	if _, err := services.NewQuizService(store).Update(context.Background(), 1, services.QuizSnapshot{Name: "Exam", CourseID: 1}, 7, "renamed"); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if len(store.revisions.notes) != 2 || store.revisions.notes[0] != "initial state" || store.revisions.notes[1] != "renamed" {
+		t.Fatalf("revisions = %v", store.revisions.notes)
+	}
+}
@@ -0,0 +1,145 @@
+package services
+
+import (
+	"backend-go/logging"
+	"backend-go/metrics"
+	"backend-go/models"
+	"backend-go/repository"
+	"backend-go/sso"
+	"backend-go/tenancy"
+	"backend-go/utils"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// maxUsernameAttempts - Numbered suffixes tried before giving up on a username
+const maxUsernameAttempts = 100
+
+// LoginWithIdentity - Log in the user linked to an identity confirmed by an
+// OpenID Connect provider. An unknown identity is linked to the user with the
+// same verified email, or to a new user with defaultRole. Users of another
+// organization are refused with ErrWrongOrganization.
+func (s *AuthService) LoginWithIdentity(ctx context.Context, identity *sso.Identity, defaultRole string) (*LoginResult, error) {
+	store := accountStore(ctx, s.store)
+	if identity.Subject == "" {
+		return nil, ErrSSOFailed
+	}
+
+	var user *models.User
+	err := store.Transaction(func(tx repository.Store) error {
+		users := tx.Users()
+
+		linked, err := users.FindIdentity(identity.Provider, identity.Subject)
+		if err == nil {
+			if user, err = users.FindByID(linked.UserID); err != nil {
+				return err
+			}
+			if err := CheckOrganization(ctx, user); err != nil {
+				return err
+			}
+			if identity.Email != "" && identity.Email != linked.Email {
+				return users.UpdateIdentityEmail(linked.ID, identity.Email)
+			}
+			return nil
+		}
+		if err != repository.ErrNotFound {
+			return err
+		}
+
+		// Akun hanya boleh ditautkan lewat email yang sudah diverifikasi provider,
+		// kalau tidak siapa pun bisa mengambil alih akun dengan email palsu
+		if identity.Email == "" || !identity.EmailVerified {
+			return ErrSSOEmailUnverified
+		}
+		user, err = users.FindByEmail(identity.Email)
+		provisioned := err == repository.ErrNotFound
+		if provisioned {
+			user, err = provisionUser(ctx, tx, identity, defaultRole)
+		}
+		if err != nil {
+			return err
+		}
+		if err := CheckOrganization(ctx, user); err != nil {
+			return err
+		}
+
+		logging.SetUserID(ctx, user.ID)
+		if provisioned {
+			if err := Audit(ctx, tx, "user.register", models.AuditUser, user.ID, nil, accountOf(user)); err != nil {
+				return err
+			}
+		}
+		link := models.Identity{
+			UserID:   user.ID,
+			Provider: identity.Provider,
+			Subject:  identity.Subject,
+			Email:    identity.Email,
+		}
+		if err := users.CreateIdentity(&link); err != nil {
+			return err
+		}
+		return Audit(ctx, tx, "user.identity_link", models.AuditUser, user.ID, nil, link)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.startSession(ctx, user)
+}
+
+// provisionUser - Create the user for an identity seen for the first time in
+// the organization of ctx. The password is random, so the account can only log
+// in through the provider.
+func provisionUser(ctx context.Context, tx repository.Store, identity *sso.Identity, role string) (*models.User, error) {
+	username, err := freeUsername(tx.WithContext(ctx).Users(), identity)
+	if err != nil {
+		return nil, err
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+	// bcrypt hanya membaca 72 byte pertama, hex dari 32 byte masih di bawahnya
+	hash, err := utils.HashPassword(hex.EncodeToString(secret))
+	if err != nil {
+		return nil, err
+	}
+
+	user := models.User{
+		Email: identity.Email, Username: username, Password: hash, Roles: role,
+		OrganizationID: tenancy.Current(ctx),
+	}
+	if err := tx.Users().Create(&user); err != nil {
+		return nil, err
+	}
+	metrics.Registrations.Inc()
+	return &user, nil
+}
+
+// freeUsername - The provider's username, or the local part of the email,
+// with a number appended when it is taken
+func freeUsername(users repository.UserRepository, identity *sso.Identity) (string, error) {
+	base := identity.Username
+	if base == "" {
+		base, _, _ = strings.Cut(identity.Email, "@")
+	}
+
+	for n := 1; n <= maxUsernameAttempts; n++ {
+		candidate := base
+		if n > 1 {
+			candidate = fmt.Sprintf("%s%d", base, n)
+		}
+		taken, err := users.ExistsByUsername(candidate)
+		if err != nil {
+			return "", err
+		}
+		if !taken {
+			return candidate, nil
+		}
+	}
+	return "", ErrUsernameTaken
+}
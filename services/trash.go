@@ -0,0 +1,228 @@
+package services
+
+import (
+	"backend-go/models"
+	"backend-go/repository"
+	"backend-go/storage"
+	"backend-go/tenancy"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"time"
+)
+
+// TrashSettings - How long deleted content is kept, set once from main
+type TrashSettings struct {
+	// Retention is how long deleted content can be restored before it is
+	// removed for good
+	Retention time.Duration
+}
+
+var trashSettings = TrashSettings{Retention: 30 * 24 * time.Hour}
+
+// ConfigureTrash - Set the trash settings, called once from main
+func ConfigureTrash(settings TrashSettings) {
+	trashSettings = settings
+}
+
+// trashPurgeBatch - Expired rows loaded at a time by PurgeExpired
+const trashPurgeBatch = 500
+
+// MoveToTrash - Soft-delete entityType id and everything it owns (a course its
+// lessons and quizzes, a lesson its attachments, a quiz its answers) as one
+// deletion, so a restore brings them back together. Call inside a transaction.
+func MoveToTrash(tx repository.Store, entityType string, id uint) error {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return err
+	}
+	return tx.Trash().Trash(entityType, id, time.Now(), hex.EncodeToString(b))
+}
+
+// TrashItem - A deleted course, lesson, attachment, quiz or answer
+type TrashItem struct {
+	Type string `json:"type"`
+	ID   uint   `json:"id"`
+	// Name is the name, file name or answer text
+	Name       string `json:"name"`
+	ParentType string `json:"parent_type,omitempty"`
+	ParentID   uint   `json:"parent_id,omitempty"`
+	// Cascaded items were deleted together with their parent and come back with it
+	Cascaded  bool      `json:"cascaded"`
+	DeletedAt time.Time `json:"deleted_at"`
+	// PurgeAt is when the item is removed for good
+	PurgeAt time.Time `json:"purge_at"`
+}
+
+// TrashQuery - Filters and page of the trash
+type TrashQuery struct {
+	Type string `form:"type" validate:"omitempty,oneof=course lesson attachment quiz answer"`
+	// Cascaded also lists items deleted together with their parent
+	Cascaded bool `form:"cascaded"`
+	Page     int  `form:"page" validate:"omitempty,min=1"`
+	PerPage  int  `form:"per_page" validate:"omitempty,min=1,max=100"`
+}
+
+// TrashRestore - What a restore brought back
+type TrashRestore struct {
+	Item TrashItem `json:"item"`
+	// Restored counts the item and everything deleted together with it
+	Restored int64 `json:"restored"`
+}
+
+// TrashService - Deleted content: listing, restore and the purge after the
+// retention period
+type TrashService struct {
+	store repository.Store
+}
+
+// NewTrashService - Create a TrashService on top of store
+func NewTrashService(store repository.Store) *TrashService {
+	return &TrashService{store: store}
+}
+
+// List - One page of deleted items matching query, most recently deleted first
+func (s *TrashService) List(ctx context.Context, query TrashQuery) ([]TrashItem, *Page, error) {
+	store := s.store.WithContext(ctx)
+	page := &Page{Page: max(query.Page, 1), PerPage: query.PerPage}
+	if page.PerPage <= 0 {
+		page.PerPage = DefaultPerPage
+	}
+	page.PerPage = min(page.PerPage, MaxPerPage)
+
+	rows, total, err := store.Trash().List(repository.TrashFilter{
+		Type:     query.Type,
+		Cascaded: query.Cascaded,
+		Offset:   (page.Page - 1) * page.PerPage,
+		Limit:    page.PerPage,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	page.Total = total
+
+	items := make([]TrashItem, 0, len(rows))
+	for i := range rows {
+		items = append(items, trashItemOf(&rows[i]))
+	}
+	return items, page, nil
+}
+
+// Restore - Bring back deleted entityType id with everything deleted together
+// with it. An item whose parent is still deleted cannot be restored on its own.
+func (s *TrashService) Restore(ctx context.Context, entityType string, id uint) (*TrashRestore, error) {
+	store := s.store.WithContext(ctx)
+	var result *TrashRestore
+	err := store.Transaction(func(tx repository.Store) error {
+		row, err := tx.Trash().Find(entityType, id)
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrTrashItemNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if parentTrashed, err := tx.Trash().ParentTrashed(row); err != nil {
+			return err
+		} else if parentTrashed {
+			return ErrRestoreParentFirst.WithDetails(map[string]interface{}{
+				"parent_type": trashParents[row.Type], "parent_id": row.ParentID,
+			})
+		}
+
+		restored, err := tx.Trash().Restore(row)
+		if err != nil {
+			return err
+		}
+		result = &TrashRestore{Item: trashItemOf(row), Restored: restored}
+		return Audit(ctx, tx, row.Type+".restore", row.Type, row.ID, nil, nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// PurgeExpired - Remove for good what was deleted longer than the retention
+// period ago, with the files only it used
+func (s *TrashService) PurgeExpired(ctx context.Context, now time.Time) (int, error) {
+	store := s.store.WithContext(ctx)
+	before := now.Add(-trashSettings.Retention)
+	purged := 0
+	for {
+		rows, err := store.Trash().ListExpired(before, trashPurgeBatch)
+		if err != nil {
+			return purged, err
+		}
+		for i := range rows {
+			row := &rows[i]
+			var files []string
+			err := store.Transaction(func(tx repository.Store) error {
+				var err error
+				if files, err = tx.Trash().Purge(row); err != nil {
+					return err
+				}
+				return Audit(tenancy.WithOrganization(ctx, row.OrganizationID), tx, row.Type+".purge", row.Type, row.ID, nil, nil)
+			})
+			// Sudah dipulihkan atau dihapus oleh proses lain
+			if errors.Is(err, repository.ErrNotFound) {
+				continue
+			}
+			if err != nil {
+				return purged, err
+			}
+			purged++
+
+			for _, key := range files {
+				if err := storage.Private.Remove(key); err != nil {
+					slog.WarnContext(ctx, "could not remove file of purged content", "type", row.Type, "id", row.ID, "error", err)
+				}
+			}
+		}
+		if len(rows) < trashPurgeBatch {
+			return purged, nil
+		}
+	}
+}
+
+// RunPurge - Call PurgeExpired every interval until ctx is cancelled
+func (s *TrashService) RunPurge(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if n, err := s.PurgeExpired(ctx, time.Now()); err != nil {
+			slog.ErrorContext(ctx, "trash purge failed", "error", err)
+		} else if n > 0 {
+			slog.InfoContext(ctx, "trash purged", "items", n)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// trashParents - Type of the item owning each type of deleted item
+var trashParents = map[string]string{
+	models.AuditLesson:     models.AuditCourse,
+	models.AuditQuiz:       models.AuditCourse,
+	models.AuditAttachment: models.AuditLesson,
+	models.AuditAnswer:     models.AuditQuiz,
+}
+
+func trashItemOf(row *repository.TrashedRow) TrashItem {
+	item := TrashItem{
+		Type:      row.Type,
+		ID:        row.ID,
+		Name:      row.Name,
+		Cascaded:  row.Cascaded,
+		DeletedAt: row.DeletedAt,
+		PurgeAt:   row.DeletedAt.Add(trashSettings.Retention),
+	}
+	if row.ParentID != 0 {
+		item.ParentType, item.ParentID = trashParents[row.Type], row.ParentID
+	}
+	return item
+}
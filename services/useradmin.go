@@ -0,0 +1,389 @@
+package services
+
+import (
+	"backend-go/mail"
+	"backend-go/models"
+	"backend-go/repository"
+	"backend-go/utils"
+	"context"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Pagination of admin listings
+const (
+	DefaultPerPage = 20
+	MaxPerPage     = 100
+)
+
+// ImpersonationTTL - How long a support session as another user lasts
+const ImpersonationTTL = time.Hour
+
+// UserAdminService - Admin management of other users' accounts
+type UserAdminService struct {
+	store repository.Store
+}
+
+// NewUserAdminService - Create a UserAdminService on top of store
+func NewUserAdminService(store repository.Store) *UserAdminService {
+	return &UserAdminService{store: store}
+}
+
+// UserQuery - Filters and page of the admin user list
+type UserQuery struct {
+	Query   string `form:"q" validate:"max=100"`
+	Role    string `form:"role" validate:"omitempty,oneof=admin user superadmin"`
+	Status  string `form:"status" validate:"omitempty,oneof=active suspended"`
+	Page    int    `form:"page" validate:"omitempty,min=1"`
+	PerPage int    `form:"per_page" validate:"omitempty,min=1,max=100"`
+}
+
+// Page - Where a page of results sits in the whole list
+type Page struct {
+	Page    int   `json:"page"`
+	PerPage int   `json:"per_page"`
+	Total   int64 `json:"total"`
+}
+
+// AdminUser - What admins see about a user
+type AdminUser struct {
+	ID                   uint       `json:"id"`
+	Email                string     `json:"email"`
+	Username             string     `json:"username"`
+	Role                 string     `json:"role"`
+	TwoFactorEnabled     bool       `json:"two_factor_enabled"`
+	SuspendedAt          *time.Time `json:"suspended_at"`
+	SuspensionReason     string     `json:"suspension_reason,omitempty"`
+	LockedUntil          *time.Time `json:"locked_until"`
+	PasswordResetPending bool       `json:"password_reset_pending"`
+	DeletionScheduledAt  *time.Time `json:"deletion_scheduled_at"`
+	CreatedAt            time.Time  `json:"created_at"`
+}
+
+// AdminUserDetail - A user with their profile and enrollments
+type AdminUserDetail struct {
+	AdminUser
+	Profile     *models.Profile     `json:"profile"`
+	Enrollments []models.Enrollment `json:"enrollments"`
+}
+
+func adminUserOf(user *models.User) *AdminUser {
+	return &AdminUser{
+		ID:                   user.ID,
+		Email:                user.Email,
+		Username:             user.Username,
+		Role:                 user.Roles,
+		TwoFactorEnabled:     user.TwoFactorEnabled(),
+		SuspendedAt:          user.SuspendedAt,
+		SuspensionReason:     user.SuspensionReason,
+		LockedUntil:          user.LockedUntil,
+		PasswordResetPending: user.PasswordResetHash != "",
+		DeletionScheduledAt:  user.DeletionScheduledAt,
+		CreatedAt:            user.CreatedAt,
+	}
+}
+
+// List - One page of users matching query, newest first
+func (s *UserAdminService) List(ctx context.Context, query UserQuery) ([]AdminUser, *Page, error) {
+	store := s.store.WithContext(ctx)
+	page := &Page{Page: max(query.Page, 1), PerPage: query.PerPage}
+	if page.PerPage <= 0 {
+		page.PerPage = DefaultPerPage
+	}
+	page.PerPage = min(page.PerPage, MaxPerPage)
+
+	filter := repository.UserFilter{
+		Query:  strings.TrimSpace(query.Query),
+		Role:   query.Role,
+		Offset: (page.Page - 1) * page.PerPage,
+		Limit:  page.PerPage,
+	}
+	if query.Status != "" {
+		suspended := query.Status == "suspended"
+		filter.Suspended = &suspended
+	}
+
+	users, total, err := store.Users().Search(filter)
+	if err != nil {
+		return nil, nil, err
+	}
+	page.Total = total
+
+	out := make([]AdminUser, 0, len(users))
+	for i := range users {
+		out = append(out, *adminUserOf(&users[i]))
+	}
+	return out, page, nil
+}
+
+// Get - User id with their profile and enrollments
+func (s *UserAdminService) Get(ctx context.Context, id uint) (*AdminUserDetail, error) {
+	store := s.store.WithContext(ctx)
+	user, err := s.findUser(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	detail := &AdminUserDetail{AdminUser: *adminUserOf(user)}
+	detail.Profile, err = store.Users().FindProfile(id)
+	if err != nil && err != repository.ErrNotFound {
+		return nil, err
+	}
+	if detail.Enrollments, err = store.Enrollments().ListByUser(id); err != nil {
+		return nil, err
+	}
+	return detail, nil
+}
+
+// SetRole - Make user id an admin, a superadmin or a plain user. Only
+// superadmins grant or take away the superadmin role. It applies to the
+// user's next request, existing sessions included.
+func (s *UserAdminService) SetRole(ctx context.Context, actorID, id uint, role string) (*AdminUser, error) {
+	store := s.store.WithContext(ctx)
+	user, err := s.findOther(ctx, actorID, id)
+	if err != nil {
+		return nil, err
+	}
+	role = strings.ToLower(role)
+	if role != models.RoleAdmin && role != models.RoleUser && role != models.RoleSuperAdmin {
+		return nil, ErrInvalidRole
+	}
+	if role == models.RoleSuperAdmin {
+		if err := s.checkSuperAdmin(ctx, actorID); err != nil {
+			return nil, err
+		}
+	}
+
+	if role != user.Roles {
+		before := adminUserOf(user)
+		err := store.Transaction(func(tx repository.Store) error {
+			if err := tx.Users().SetRole(id, role); err != nil {
+				return err
+			}
+			user.Roles = role
+			return Audit(ctx, tx, "user.role_change", models.AuditUser, id, before, adminUserOf(user))
+		})
+		if err != nil {
+			return nil, err
+		}
+		slog.InfoContext(ctx, "user role changed", "target_user_id", id, "from", before.Role, "to", role)
+	}
+	return adminUserOf(user), nil
+}
+
+// GrantSuperAdmin - Make the user with email, in any organization, a
+// superadmin. The API only lets superadmins grant the role, so the first one
+// is made with the `superadmin` command, which calls this.
+func (s *UserAdminService) GrantSuperAdmin(ctx context.Context, email string) (*AdminUser, error) {
+	store := accountStore(ctx, s.store)
+	user, err := store.Users().FindByEmail(email)
+	if err == repository.ErrNotFound {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if user.Roles == models.RoleSuperAdmin {
+		return adminUserOf(user), nil
+	}
+
+	before := adminUserOf(user)
+	err = store.Transaction(func(tx repository.Store) error {
+		if err := tx.Users().SetRole(user.ID, models.RoleSuperAdmin); err != nil {
+			return err
+		}
+		user.Roles = models.RoleSuperAdmin
+		return Audit(ctx, tx, "user.role_change", models.AuditUser, user.ID, before, adminUserOf(user))
+	})
+	if err != nil {
+		return nil, err
+	}
+	slog.InfoContext(ctx, "superadmin granted", "target_user_id", user.ID, "from", before.Role)
+	return adminUserOf(user), nil
+}
+
+// Suspend - Block user id from logging in and end their sessions and API
+// keys until Unsuspend. Calling it again only updates the reason.
+func (s *UserAdminService) Suspend(ctx context.Context, actorID, id uint, reason string) (*AdminUser, error) {
+	store := s.store.WithContext(ctx)
+	user, err := s.findOther(ctx, actorID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	at := time.Now()
+	if user.SuspendedAt != nil {
+		at = *user.SuspendedAt
+	}
+	reason = strings.TrimSpace(reason)
+	wasSuspended := user.Suspended()
+	before := adminUserOf(user)
+	err = store.Transaction(func(tx repository.Store) error {
+		if err := tx.Users().Suspend(id, at, reason); err != nil {
+			return err
+		}
+		user.SuspendedAt, user.SuspensionReason = &at, reason
+		return Audit(ctx, tx, "user.suspend", models.AuditUser, id, before, adminUserOf(user))
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !wasSuspended {
+		slog.InfoContext(ctx, "user suspended", "target_user_id", id, "reason", reason)
+		notify(ctx, user.Email, "Your account was suspended",
+			"An administrator suspended your account, so you cannot log in for now. Contact support to find out more.")
+	}
+	return adminUserOf(user), nil
+}
+
+// Unsuspend - Let user id log in again
+func (s *UserAdminService) Unsuspend(ctx context.Context, actorID, id uint) (*AdminUser, error) {
+	store := s.store.WithContext(ctx)
+	user, err := s.findOther(ctx, actorID, id)
+	if err != nil {
+		return nil, err
+	}
+	if user.SuspendedAt == nil {
+		return adminUserOf(user), nil
+	}
+
+	before := adminUserOf(user)
+	err = store.Transaction(func(tx repository.Store) error {
+		if err := tx.Users().Unsuspend(id); err != nil {
+			return err
+		}
+		user.SuspendedAt, user.SuspensionReason = nil, ""
+		return Audit(ctx, tx, "user.unsuspend", models.AuditUser, id, before, adminUserOf(user))
+	})
+	if err != nil {
+		return nil, err
+	}
+	slog.InfoContext(ctx, "user unsuspended", "target_user_id", id)
+	notify(ctx, user.Email, "Your account was restored", "Your account is no longer suspended and you can log in again.")
+	return adminUserOf(user), nil
+}
+
+// ForcePasswordReset - Clear the password of user id, end their sessions and
+// email them a link to choose a new one (AccountService.ResetPassword)
+func (s *UserAdminService) ForcePasswordReset(ctx context.Context, actorID, id uint) error {
+	store := s.store.WithContext(ctx)
+	user, err := s.findOther(ctx, actorID, id)
+	if err != nil {
+		return err
+	}
+
+	token, err := emailToken()
+	if err != nil {
+		return err
+	}
+	err = store.Transaction(func(tx repository.Store) error {
+		if err := tx.Users().RequirePasswordReset(id, hashEmailToken(token), time.Now().Add(accountSettings.EmailTokenTTL)); err != nil {
+			return err
+		}
+		if err := tx.Users().RevokeSessions(id, revocationTime()); err != nil {
+			return err
+		}
+		return Audit(ctx, tx, "user.password_reset_force", models.AuditUser, id, nil, nil)
+	})
+	if err != nil {
+		return err
+	}
+	slog.InfoContext(ctx, "password reset forced", "target_user_id", id)
+
+	body := "An administrator reset your password and logged you out everywhere. Choose a new password"
+	if accountSettings.PasswordResetURL != "" {
+		body += " by opening this link:\n\n" + accountSettings.PasswordResetURL + "?token=" + url.QueryEscape(token)
+	} else {
+		body += " with this token:\n\n" + token
+	}
+	body += fmt.Sprintf("\n\nIt expires in %s.", accountSettings.EmailTokenTTL)
+
+	// Tanpa email ini user tidak bisa login lagi, jadi kegagalan dilaporkan ke admin
+	return mail.Send(ctx, mail.Message{To: user.Email, Subject: "Choose a new password", Body: body})
+}
+
+// Impersonate - A short-lived token to act as user id for support. The token
+// names the admin, who is logged with every request made with it.
+func (s *UserAdminService) Impersonate(ctx context.Context, actorID, id uint) (string, time.Time, error) {
+	store := s.store.WithContext(ctx)
+	user, err := s.findOther(ctx, actorID, id)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	if models.IsAdminRole(user.Roles) || user.Suspended() || user.DeletionScheduledAt != nil {
+		return "", time.Time{}, ErrCannotImpersonate
+	}
+
+	expiresAt := time.Now().Add(ImpersonationTTL)
+	token, err := utils.GenerateImpersonationToken(user.ID, user.Roles, actorID, ImpersonationTTL)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	// Tidak ada data yang berubah, tapi siapa menyamar sebagai siapa harus tercatat
+	if err := Audit(ctx, store, "user.impersonate", models.AuditUser, id, nil,
+		map[string]interface{}{"ImpersonationExpiresAt": expiresAt}); err != nil {
+		return "", time.Time{}, err
+	}
+	slog.WarnContext(ctx, "impersonation started", "target_user_id", id, "expires_at", expiresAt)
+	return token, expiresAt, nil
+}
+
+// CheckImpersonator - Whether admin id may still act as another user: the
+// session that started it may have ended or the admin may have lost the role
+func (s *UserAdminService) CheckImpersonator(id uint, issuedAt time.Time) error {
+	admin, err := NewAccountService(s.store).CheckSession(id, issuedAt)
+	if err != nil {
+		return ErrSessionRevoked
+	}
+	if !models.IsAdminRole(admin.Roles) {
+		return ErrSessionRevoked
+	}
+	return nil
+}
+
+// findOther - User id, who must not be the acting admin. Admins and
+// superadmins are only managed by superadmins, so an organization admin
+// cannot demote, suspend or lock out a fellow admin.
+func (s *UserAdminService) findOther(ctx context.Context, actorID, id uint) (*models.User, error) {
+	if actorID == id {
+		return nil, ErrCannotManageSelf
+	}
+	user, err := s.findUser(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if models.IsAdminRole(user.Roles) {
+		err := s.checkSuperAdmin(ctx, actorID)
+		if err == ErrCannotManageSuperAdmin && user.Roles == models.RoleAdmin {
+			return nil, ErrCannotManageAdmin
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return user, nil
+}
+
+// checkSuperAdmin - ErrCannotManageSuperAdmin unless actorID is a superadmin
+func (s *UserAdminService) checkSuperAdmin(ctx context.Context, actorID uint) error {
+	actor, err := accountStore(ctx, s.store).Users().FindByID(actorID)
+	if err != nil {
+		return err
+	}
+	if actor.Roles != models.RoleSuperAdmin {
+		return ErrCannotManageSuperAdmin
+	}
+	return nil
+}
+
+func (s *UserAdminService) findUser(ctx context.Context, id uint) (*models.User, error) {
+	store := s.store.WithContext(ctx)
+	user, err := store.Users().FindByID(id)
+	if err == repository.ErrNotFound {
+		return nil, ErrUserNotFound
+	}
+	return user, err
+}
@@ -0,0 +1,201 @@
+package services
+
+import (
+	"backend-go/models"
+	"backend-go/repository"
+	"backend-go/storage"
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/gabriel-vasile/mimetype"
+)
+
+// VideoService - Resumable video uploads for lessons and learners' playback positions
+type VideoService struct {
+	store repository.Store
+}
+
+// NewVideoService - Create a VideoService on top of store
+func NewVideoService(store repository.Store) *VideoService {
+	return &VideoService{store: store}
+}
+
+// CreateUpload - Start an upload; its bytes go to a temporary file in private storage
+func (s *VideoService) CreateUpload(ctx context.Context, upload *models.VideoUpload) error {
+	store := s.store.WithContext(ctx)
+	return store.Transaction(func(tx repository.Store) error {
+		if err := tx.Videos().CreateUpload(upload); err != nil {
+			return err
+		}
+		// Key diisi setelah ID tersedia
+		upload.StorageKey = fmt.Sprintf("uploads/%d.part", upload.ID)
+		if err := tx.Videos().SetUploadKey(upload.ID, upload.StorageKey); err != nil {
+			return err
+		}
+		return Audit(ctx, tx, "video_upload.create", models.AuditVideoUpload, upload.ID, nil, upload)
+	})
+}
+
+// FindUpload - Upload id; only the user who started it may continue it, for
+// everyone else it does not exist
+func (s *VideoService) FindUpload(ctx context.Context, id, userID uint) (*models.VideoUpload, error) {
+	store := s.store.WithContext(ctx)
+	upload, err := store.Videos().FindUpload(id)
+	if err == repository.ErrNotFound {
+		return nil, ErrUploadNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if upload.UploadedBy != userID {
+		return nil, ErrUploadNotFound
+	}
+	return upload, nil
+}
+
+// uploadLocks - Upload IDs with a chunk being written by this process
+var uploadLocks sync.Map
+
+// Append - Write body at offset, which must be the upload's current offset,
+// never past its length. Once every byte has arrived the upload is checked and
+// attached to its lesson. upload.Offset is left at the stored offset, even when
+// err is not nil.
+//
+// Only one chunk per upload is written at a time: a concurrent PATCH gets
+// ErrUploadBusy, and the offset only moves if nobody moved it meanwhile.
+func (s *VideoService) Append(ctx context.Context, upload *models.VideoUpload, offset int64, body io.Reader) error {
+	store := s.store.WithContext(ctx)
+	if _, busy := uploadLocks.LoadOrStore(upload.ID, true); busy {
+		return ErrUploadBusy
+	}
+	defer uploadLocks.Delete(upload.ID)
+
+	// Dibaca ulang setelah lock, request lain mungkin sudah menulis chunk
+	current, err := store.Videos().FindUpload(upload.ID)
+	if err == repository.ErrNotFound {
+		return ErrUploadNotFound
+	}
+	if err != nil {
+		return err
+	}
+	*upload = *current
+	if upload.Completed {
+		return ErrUploadCompleted
+	}
+	if offset != upload.Offset {
+		return ErrOffsetMismatch
+	}
+
+	// Chunk tidak boleh melewati Upload-Length yang dijanjikan di awal
+	written, err := storage.Private.WriteAt(upload.StorageKey, upload.Offset, io.LimitReader(body, upload.Length-upload.Offset))
+	advanced, dbErr := store.Videos().AdvanceUploadOffset(upload.ID, upload.Offset, upload.Offset+written)
+	if dbErr != nil {
+		return dbErr
+	}
+	if !advanced {
+		// Instance lain memindahkan offset duluan; client harus HEAD lalu lanjut
+		if current, err := store.Videos().FindUpload(upload.ID); err == nil {
+			*upload = *current
+		}
+		return ErrOffsetMismatch
+	}
+	upload.Offset += written
+	if err != nil {
+		return err
+	}
+
+	if upload.Offset == upload.Length {
+		return s.finish(ctx, upload)
+	}
+	return nil
+}
+
+// DeleteUpload - Abandon an upload (tus termination extension)
+func (s *VideoService) DeleteUpload(ctx context.Context, upload *models.VideoUpload) error {
+	store := s.store.WithContext(ctx)
+	if !upload.Completed {
+		storage.Private.Remove(upload.StorageKey)
+	}
+	return store.Transaction(func(tx repository.Store) error {
+		if err := tx.Videos().DeleteUpload(upload); err != nil {
+			return err
+		}
+		return Audit(ctx, tx, "video_upload.delete", models.AuditVideoUpload, upload.ID, upload, nil)
+	})
+}
+
+// finish - Verify the uploaded bytes are a video and attach them to the lesson
+func (s *VideoService) finish(ctx context.Context, upload *models.VideoUpload) error {
+	store := s.store.WithContext(ctx)
+
+	f, err := storage.Private.Open(upload.StorageKey)
+	if err != nil {
+		return err
+	}
+	mtype, err := mimetype.DetectReader(f)
+	f.Close()
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(mtype.String(), "video/") {
+		storage.Private.Remove(upload.StorageKey)
+		store.Videos().DeleteUpload(upload)
+		return ErrNotAVideo.WithDetails(fmt.Sprintf("uploaded file is %s", mtype.String()))
+	}
+
+	ext := filepath.Ext(upload.FileName)
+	if ext == "" {
+		ext = mtype.Extension()
+	}
+	key := fmt.Sprintf("videos/%d/%d%s", upload.LessonID, upload.ID, ext)
+	if err := storage.Private.Move(upload.StorageKey, key); err != nil {
+		return err
+	}
+
+	err = editWithRevision(store, func(tx repository.Store) error {
+		if err := tx.Videos().CompleteUpload(upload.ID, key); err != nil {
+			return err
+		}
+		upload.Completed, upload.StorageKey = true, key
+
+		lesson, err := tx.Lessons().FindByID(upload.LessonID)
+		if err != nil {
+			return err
+		}
+		before := SnapshotLesson(lesson)
+		lesson.Type = models.LessonTypeVideo
+		lesson.VideoKey = key
+		lesson.VideoMimeType = mtype.String()
+		lesson.VideoSize = upload.Length
+		if err := tx.Lessons().Save(lesson); err != nil {
+			return err
+		}
+		if err := recordRevision(tx, models.RevisionLesson, lesson.ID, upload.UploadedBy, before, SnapshotLesson(lesson), "video uploaded"); err != nil {
+			return err
+		}
+		return Audit(ctx, tx, "lesson.video_upload", models.AuditLesson, lesson.ID, before, SnapshotLesson(lesson))
+	})
+	// Video lama tetap disimpan: revisi lama masih menunjuk ke sana. File-nya
+	// dihapus bersama upload-nya saat lesson di-purge dari trash.
+	return err
+}
+
+// Playback - Where userID stopped in lessonID, zero when they never started
+func (s *VideoService) Playback(ctx context.Context, userID, lessonID uint) (*models.PlaybackPosition, error) {
+	store := s.store.WithContext(ctx)
+	position, err := store.Videos().FindPlayback(userID, lessonID)
+	if err == repository.ErrNotFound {
+		return &models.PlaybackPosition{UserID: userID, LessonID: lessonID}, nil
+	}
+	return position, err
+}
+
+// SavePlayback - Remember the position, replacing the previous one
+func (s *VideoService) SavePlayback(ctx context.Context, position *models.PlaybackPosition) error {
+	store := s.store.WithContext(ctx)
+	return store.Videos().SavePlayback(position)
+}
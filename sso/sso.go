@@ -0,0 +1,217 @@
+// Package sso implements login through OpenID Connect identity providers
+// (university SSO, Google, Azure AD, ...) with the authorization code flow and
+// PKCE. Providers are discovered from their issuer URL on first use.
+package sso
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+)
+
+// ErrUnknownProvider - No provider is configured under the name
+var ErrUnknownProvider = errors.New("unknown identity provider")
+
+// ErrNonceMismatch - The ID token was not issued for this login attempt
+var ErrNonceMismatch = errors.New("id token nonce does not match")
+
+// ProviderConfig - One identity provider
+type ProviderConfig struct {
+	// Name appears in the login and callback URLs, e.g.
+	// /api/v1/auth/sso/<name>/login and /api/v1/auth/sso/<name>/callback
+	Name         string
+	DisplayName  string
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+	// DefaultRole is given to users created on their first login
+	DefaultRole string
+	// TrustEmail treats the email claim as verified for providers that never
+	// send email_verified (their directory owns the addresses)
+	TrustEmail bool
+}
+
+// Identity - Who the provider says logged in
+type Identity struct {
+	Provider      string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+	Username      string
+}
+
+// Provider - A configured identity provider. Discovery runs on first use and
+// is retried on the next login when it fails.
+type Provider struct {
+	ProviderConfig
+
+	mu       sync.Mutex
+	oidc     *oidc.Provider
+	verifier *oidc.IDTokenVerifier
+}
+
+// Settings - Where the API and the web app live
+type Settings struct {
+	// PublicURL is the API's external base URL; callback URLs registered at
+	// the providers start with it
+	PublicURL string
+	// FrontendURL, when set, receives the login result in the URL fragment
+	// after the callback instead of a JSON response
+	FrontendURL string
+}
+
+var (
+	providersMu sync.RWMutex
+	providers   = map[string]*Provider{}
+	settings    Settings
+)
+
+// Configure - Replace the settings and providers, called once from main
+func Configure(s Settings, configs []ProviderConfig) {
+	next := make(map[string]*Provider, len(configs))
+	for _, cfg := range configs {
+		next[cfg.Name] = &Provider{ProviderConfig: cfg}
+	}
+
+	providersMu.Lock()
+	providers = next
+	settings = s
+	providersMu.Unlock()
+}
+
+// Current - The configured settings
+func Current() Settings {
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+	return settings
+}
+
+// Get - The provider called name
+func Get(name string) (*Provider, error) {
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+
+	p, ok := providers[name]
+	if !ok {
+		return nil, ErrUnknownProvider
+	}
+	return p, nil
+}
+
+// List - Every configured provider, sorted by name
+func List() []*Provider {
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+
+	list := make([]*Provider, 0, len(providers))
+	for _, p := range providers {
+		list = append(list, p)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// discover - Fetch the provider metadata once
+func (p *Provider) discover(ctx context.Context) (*oidc.Provider, *oidc.IDTokenVerifier, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.oidc == nil {
+		// go-oidc menyimpan context ini untuk mengambil JWKS nanti, jadi tidak
+		// boleh ikut batal bersama request yang memicu discovery
+		provider, err := oidc.NewProvider(context.WithoutCancel(ctx), p.Issuer)
+		if err != nil {
+			return nil, nil, fmt.Errorf("discover %s: %w", p.Issuer, err)
+		}
+		p.oidc = provider
+		p.verifier = provider.Verifier(&oidc.Config{ClientID: p.ClientID})
+	}
+	return p.oidc, p.verifier, nil
+}
+
+func (p *Provider) oauth2Config(provider *oidc.Provider, redirectURL string) *oauth2.Config {
+	scopes := p.Scopes
+	if len(scopes) == 0 {
+		scopes = []string{oidc.ScopeOpenID, "email", "profile"}
+	}
+	return &oauth2.Config{
+		ClientID:     p.ClientID,
+		ClientSecret: p.ClientSecret,
+		Endpoint:     provider.Endpoint(),
+		RedirectURL:  redirectURL,
+		Scopes:       scopes,
+	}
+}
+
+// AuthCodeURL - Where to send the browser to log in. The PKCE challenge is
+// derived from verifier; state and nonce tie the callback to this attempt.
+func (p *Provider) AuthCodeURL(ctx context.Context, redirectURL, state, nonce, verifier string) (string, error) {
+	provider, _, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+	return p.oauth2Config(provider, redirectURL).AuthCodeURL(state,
+		oidc.Nonce(nonce), oauth2.S256ChallengeOption(verifier)), nil
+}
+
+// Exchange - Trade the authorization code for a verified ID token and read
+// the identity from it
+func (p *Provider) Exchange(ctx context.Context, redirectURL, code, nonce, verifier string) (*Identity, error) {
+	provider, idVerifier, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := p.oauth2Config(provider, redirectURL).Exchange(ctx, code, oauth2.VerifierOption(verifier))
+	if err != nil {
+		return nil, fmt.Errorf("exchange code: %w", err)
+	}
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return nil, errors.New("token response has no id_token")
+	}
+	idToken, err := idVerifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		return nil, fmt.Errorf("verify id token: %w", err)
+	}
+	if idToken.Nonce != nonce {
+		return nil, ErrNonceMismatch
+	}
+
+	var claims struct {
+		Email             string      `json:"email"`
+		EmailVerified     interface{} `json:"email_verified"`
+		Name              string      `json:"name"`
+		PreferredUsername string      `json:"preferred_username"`
+	}
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, fmt.Errorf("read id token claims: %w", err)
+	}
+
+	return &Identity{
+		Provider:      p.Name,
+		Subject:       idToken.Subject,
+		Email:         claims.Email,
+		EmailVerified: p.TrustEmail || isTrue(claims.EmailVerified),
+		Name:          claims.Name,
+		Username:      claims.PreferredUsername,
+	}, nil
+}
+
+// isTrue - email_verified is a boolean, but some providers send "true"
+func isTrue(v interface{}) bool {
+	switch v := v.(type) {
+	case bool:
+		return v
+	case string:
+		return v == "true"
+	}
+	return false
+}
@@ -0,0 +1,157 @@
+package storage
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrInvalidKey is returned when a key tries to escape the storage root
+var ErrInvalidKey = errors.New("invalid storage key")
+
+// File - Seekable file handle, suitable for http.ServeContent
+type File interface {
+	io.ReadSeekCloser
+	Stat() (fs.FileInfo, error)
+}
+
+// Backend - Abstraction over where uploaded files are stored
+type Backend interface {
+	Save(key string, r io.Reader) (int64, error)
+	WriteAt(key string, offset int64, r io.Reader) (int64, error)
+	Move(from, to string) error
+	Open(key string) (File, error)
+	Remove(key string) error
+	// Ping reports whether the backend can currently store files
+	Ping() error
+}
+
+// Local - Backend that keeps files on the local filesystem
+type Local struct {
+	Root string
+}
+
+// NewLocal - Create a local backend rooted at dir
+func NewLocal(dir string) *Local {
+	return &Local{Root: dir}
+}
+
+// Public holds files anyone may fetch (course thumbnails, avatars).
+// Private holds paid lesson material and is only served through the media routes.
+var (
+	Public  Backend = NewLocal("./public/uploads")
+	Private Backend = NewLocal("./storage/private")
+)
+
+// Configure - Point the public and private backends at the configured directories
+func Configure(publicDir, privateDir string) {
+	Public = NewLocal(publicDir)
+	Private = NewLocal(privateDir)
+}
+
+func (l *Local) path(key string) (string, error) {
+	clean := filepath.Clean("/" + key)
+	if clean == "/" || strings.Contains(key, "..") {
+		return "", ErrInvalidKey
+	}
+	return filepath.Join(l.Root, filepath.FromSlash(clean)), nil
+}
+
+// Save - Write the reader to key, creating parent directories as needed
+func (l *Local) Save(key string, r io.Reader) (int64, error) {
+	p, err := l.path(key)
+	if err != nil {
+		return 0, err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), os.ModePerm); err != nil {
+		return 0, err
+	}
+
+	f, err := os.Create(p)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	return io.Copy(f, r)
+}
+
+// WriteAt - Write the reader into key starting at offset, discarding anything
+// already stored past offset. Used to resume chunked uploads.
+func (l *Local) WriteAt(key string, offset int64, r io.Reader) (int64, error) {
+	p, err := l.path(key)
+	if err != nil {
+		return 0, err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), os.ModePerm); err != nil {
+		return 0, err
+	}
+
+	f, err := os.OpenFile(p, os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	// Potongan yang gagal di tengah jalan dibuang, client akan mengirim ulang dari offset
+	if err := f.Truncate(offset); err != nil {
+		return 0, err
+	}
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return 0, err
+	}
+	return io.Copy(f, r)
+}
+
+// Move - Rename the file stored under from to to
+func (l *Local) Move(from, to string) error {
+	src, err := l.path(from)
+	if err != nil {
+		return err
+	}
+	dst, err := l.path(to)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), os.ModePerm); err != nil {
+		return err
+	}
+	return os.Rename(src, dst)
+}
+
+// Open - Open the file stored under key
+func (l *Local) Open(key string) (File, error) {
+	p, err := l.path(key)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(p)
+}
+
+// Remove - Delete the file stored under key; a missing file is not an error
+func (l *Local) Remove(key string) error {
+	p, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// Ping - Check the root directory exists and is writable
+func (l *Local) Ping() error {
+	if err := os.MkdirAll(l.Root, 0o755); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(l.Root, ".ping-*")
+	if err != nil {
+		return err
+	}
+	f.Close()
+	return os.Remove(f.Name())
+}
@@ -0,0 +1,134 @@
+// Package tenancy keeps the organizations (schools) sharing this backend apart.
+// The organization a request is for travels in its context; the GORM plugin
+// filters every query on a model with an OrganizationID field by it and fills
+// the field in on create.
+package tenancy
+
+import (
+	"context"
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// Field - Name of the field marking a model as owned by an organization
+const Field = "OrganizationID"
+
+type ctxKey struct{}
+
+// scope - The organization a context is for and whether its queries are
+// limited to it
+type scope struct {
+	organizationID uint
+	// all - Queries see every organization's rows
+	all bool
+}
+
+// WithOrganization - Context for organization id, whose queries only see its rows
+func WithOrganization(ctx context.Context, id uint) context.Context {
+	return context.WithValue(ctx, ctxKey{}, scope{organizationID: id})
+}
+
+// AllOrganizations - Context whose queries see every organization's rows,
+// e.g. to find a user before knowing whether they may use this organization.
+// It stays for the organization of ctx (see Current), but rows created with it
+// keep the organization they were given.
+func AllOrganizations(ctx context.Context) context.Context {
+	s, _ := ctx.Value(ctxKey{}).(scope)
+	s.all = true
+	return context.WithValue(ctx, ctxKey{}, s)
+}
+
+// OrganizationID - The organization ctx's queries are limited to; false when
+// they are not limited (no tenant, as in background jobs, or AllOrganizations)
+func OrganizationID(ctx context.Context) (uint, bool) {
+	if ctx == nil {
+		return 0, false
+	}
+	s, ok := ctx.Value(ctxKey{}).(scope)
+	if !ok || s.all {
+		return 0, false
+	}
+	return s.organizationID, true
+}
+
+// Current - The organization ctx is for, limited or not; 0 when there is none
+func Current(ctx context.Context) uint {
+	if ctx == nil {
+		return 0
+	}
+	s, _ := ctx.Value(ctxKey{}).(scope)
+	return s.organizationID
+}
+
+// Plugin - GORM plugin applying the organization of the statement's context.
+// Raw SQL is not rewritten; repositories using it filter themselves.
+type Plugin struct{}
+
+// Name - Plugin name for gorm.DB.Use
+func (Plugin) Name() string { return "tenancy" }
+
+// Initialize - Register the callbacks on db
+func (Plugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	if err := cb.Create().Before("gorm:create").Register("tenancy:create", assign); err != nil {
+		return err
+	}
+	if err := cb.Query().Before("gorm:query").Register("tenancy:query", filter); err != nil {
+		return err
+	}
+	if err := cb.Row().Before("gorm:row").Register("tenancy:row", filter); err != nil {
+		return err
+	}
+	if err := cb.Update().Before("gorm:update").Register("tenancy:update", filter); err != nil {
+		return err
+	}
+	return cb.Delete().Before("gorm:delete").Register("tenancy:delete", filter)
+}
+
+// field - The organization field of the statement's model, nil for models
+// every organization shares
+func field(db *gorm.DB) *schema.Field {
+	if db.Statement.Schema == nil {
+		return nil
+	}
+	return db.Statement.Schema.LookUpField(Field)
+}
+
+// filter - Limit the statement to the rows of the context's organization
+func filter(db *gorm.DB) {
+	id, ok := OrganizationID(db.Statement.Context)
+	f := field(db)
+	if !ok || f == nil || db.Error != nil {
+		return
+	}
+	db.Statement.AddClause(clause.Where{Exprs: []clause.Expression{
+		clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: f.DBName}, Value: id},
+	}})
+}
+
+// assign - Give new rows the context's organization unless they already name one
+func assign(db *gorm.DB) {
+	id, ok := OrganizationID(db.Statement.Context)
+	f := field(db)
+	if !ok || f == nil || db.Error != nil {
+		return
+	}
+
+	ctx, rv := db.Statement.Context, db.Statement.ReflectValue
+	set := func(row reflect.Value) {
+		if _, zero := f.ValueOf(ctx, row); zero {
+			db.AddError(f.Set(ctx, row, id))
+		}
+	}
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			set(reflect.Indirect(rv.Index(i)))
+		}
+	case reflect.Struct:
+		set(rv)
+	}
+}
@@ -0,0 +1,117 @@
+package testutil
+
+import (
+	"backend-go/migrations"
+	"backend-go/tenancy"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// PostgresURLEnv names the variable pointing the harness at a real Postgres server.
+// Each test then gets its own schema, migrated with the SQL migrations and dropped
+// afterwards. Without it the harness falls back to an in-memory SQLite database
+// built from the SQLite copies of the same migrations.
+const PostgresURLEnv = "TEST_DATABASE_URL"
+
+// DefaultOrganizationID - The organization existing data was moved to, which
+// requests without a subdomain or X-Organization header use
+const DefaultOrganizationID = 1
+
+// NewDB - Open a throwaway database for t with the full schema. It is closed
+// (and on Postgres dropped) when the test ends.
+func NewDB(t testing.TB) *gorm.DB {
+	t.Helper()
+
+	if url := os.Getenv(PostgresURLEnv); url != "" {
+		return newPostgres(t, url)
+	}
+	return newSQLite(t)
+}
+
+func newSQLite(t testing.TB) *gorm.DB {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open("file::memory:?_pragma=foreign_keys(1)"), &gorm.Config{
+		Logger:         logger.Default.LogMode(logger.Silent),
+		TranslateError: true,
+	})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+
+	// Every connection to :memory: is a separate database, so keep exactly one
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("sqlite pool: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	if err := migrations.Up(db); err != nil {
+		t.Fatalf("migrate sqlite: %v", err)
+	}
+	return useTenancy(t, db)
+}
+
+// useTenancy - Filter queries by organization, as config.ConnectDB does
+func useTenancy(t testing.TB, db *gorm.DB) *gorm.DB {
+	t.Helper()
+	if err := db.Use(tenancy.Plugin{}); err != nil {
+		t.Fatalf("tenancy plugin: %v", err)
+	}
+	return db
+}
+
+func newPostgres(t testing.TB, url string) *gorm.DB {
+	t.Helper()
+
+	quiet := &gorm.Config{Logger: logger.Default.LogMode(logger.Silent), TranslateError: true}
+
+	admin, err := gorm.Open(postgres.Open(url), quiet)
+	if err != nil {
+		t.Fatalf("open postgres: %v", err)
+	}
+	adminDB, _ := admin.DB()
+
+	schema := fmt.Sprintf("test_%d", time.Now().UnixNano())
+	if err := admin.Exec("CREATE SCHEMA " + schema).Error; err != nil {
+		adminDB.Close()
+		t.Fatalf("create schema: %v", err)
+	}
+
+	db, err := gorm.Open(postgres.Open(withSearchPath(url, schema)), quiet)
+	if err != nil {
+		t.Fatalf("open postgres schema: %v", err)
+	}
+	sqlDB, _ := db.DB()
+
+	t.Cleanup(func() {
+		sqlDB.Close()
+		admin.Exec("DROP SCHEMA " + schema + " CASCADE")
+		adminDB.Close()
+	})
+
+	if err := migrations.Up(db); err != nil {
+		t.Fatalf("migrate postgres: %v", err)
+	}
+	return useTenancy(t, db)
+}
+
+// withSearchPath - Add a search_path runtime parameter to a URL or keyword/value DSN
+func withSearchPath(dsn, schema string) string {
+	if !strings.Contains(dsn, "://") {
+		return dsn + " search_path=" + schema
+	}
+	if strings.Contains(dsn, "?") {
+		return dsn + "&search_path=" + schema
+	}
+	return dsn + "?search_path=" + schema
+}
@@ -0,0 +1,139 @@
+package testutil
+
+import (
+	"backend-go/models"
+	"backend-go/utils"
+	"fmt"
+)
+
+// Password - Plain-text password of every fixture user
+const Password = "secret-password"
+
+var sequence int
+
+func next() int {
+	sequence++
+	return sequence
+}
+
+// CreateOrganization - Insert an organization reached with slug
+func (h *Harness) CreateOrganization(slug string) *models.Organization {
+	h.T.Helper()
+
+	organization := models.Organization{Name: "Organization " + slug, Slug: slug}
+	h.create(&organization)
+	return &organization
+}
+
+// CreateUser - Insert a user with the given role ("user", "admin" or "superadmin")
+func (h *Harness) CreateUser(role string) *models.User {
+	h.T.Helper()
+
+	hash, err := utils.HashPassword(Password)
+	if err != nil {
+		h.T.Fatalf("hash password: %v", err)
+	}
+
+	n := next()
+	user := models.User{
+		Email:    fmt.Sprintf("user%d@example.com", n),
+		Username: fmt.Sprintf("user%d", n),
+		Password: hash,
+		Roles:    role,
+
+		OrganizationID: h.OrganizationID,
+	}
+	h.create(&user)
+	return &user
+}
+
+// Token - A valid bearer token for user
+func (h *Harness) Token(user *models.User) string {
+	h.T.Helper()
+
+	token, err := utils.GeneateToken(user.ID, user.Roles)
+	if err != nil {
+		h.T.Fatalf("generate token: %v", err)
+	}
+	return token
+}
+
+// CreateCourse - Insert a course owned by owner
+func (h *Harness) CreateCourse(owner *models.User) *models.Course {
+	h.T.Helper()
+
+	n := next()
+	course := models.Course{
+		Name:        fmt.Sprintf("Course %d", n),
+		Description: "A course created by the test harness",
+		UserID:      owner.ID,
+
+		OrganizationID: h.OrganizationID,
+	}
+	h.create(&course)
+	return &course
+}
+
+// Enroll - Enroll user in course
+func (h *Harness) Enroll(user *models.User, course *models.Course) *models.Enrollment {
+	h.T.Helper()
+
+	enrollment := models.Enrollment{UserID: user.ID, CourseID: course.ID, OrganizationID: course.OrganizationID}
+	h.create(&enrollment)
+	return &enrollment
+}
+
+// CreateLesson - Insert a Markdown lesson in course with its HTML already rendered
+func (h *Harness) CreateLesson(course *models.Course, content string) *models.Lesson {
+	h.T.Helper()
+
+	html, err := utils.RenderContent(content, utils.ContentFormatMarkdown)
+	if err != nil {
+		h.T.Fatalf("render lesson: %v", err)
+	}
+
+	n := next()
+	lesson := models.Lesson{
+		Name:          fmt.Sprintf("Lesson %d", n),
+		Description:   "A lesson created by the test harness",
+		Content:       content,
+		ContentFormat: utils.ContentFormatMarkdown,
+		ContentHTML:   html,
+		Type:          models.LessonTypeText,
+		CourseID:      course.ID,
+
+		OrganizationID: course.OrganizationID,
+	}
+	h.create(&lesson)
+	return &lesson
+}
+
+// CreateQuiz - Insert a quiz in course with one answer per entry of answers
+func (h *Harness) CreateQuiz(course *models.Course, answers ...string) (*models.Quiz, []models.Answer) {
+	h.T.Helper()
+
+	n := next()
+	quiz := models.Quiz{
+		Name:        fmt.Sprintf("Quiz %d", n),
+		Description: "A quiz created by the test harness",
+		Content:     "Pick the right answer",
+		CourseID:    course.ID,
+
+		OrganizationID: course.OrganizationID,
+	}
+	h.create(&quiz)
+
+	created := make([]models.Answer, len(answers))
+	for i, content := range answers {
+		created[i] = models.Answer{Content: content, QuizID: quiz.ID, OrganizationID: quiz.OrganizationID}
+		h.create(&created[i])
+	}
+	return &quiz, created
+}
+
+func (h *Harness) create(value interface{}) {
+	h.T.Helper()
+	if err := h.DB.Create(value).Error; err != nil {
+		h.T.Fatalf("create %T: %v", value, err)
+	}
+}
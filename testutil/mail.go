@@ -0,0 +1,44 @@
+package testutil
+
+import (
+	"backend-go/mail"
+	"context"
+	"sync"
+	"testing"
+)
+
+// Mailbox - Collects the messages the application sends during a test
+type Mailbox struct {
+	mu       sync.Mutex
+	messages []mail.Message
+}
+
+// NewMailbox - Capture outgoing mail until the test ends
+func NewMailbox(t testing.TB) *Mailbox {
+	t.Helper()
+
+	box := &Mailbox{}
+	mail.Configure(box)
+	t.Cleanup(func() { mail.Configure(mail.LogSender{}) })
+	return box
+}
+
+func (b *Mailbox) Send(ctx context.Context, msg mail.Message) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.messages = append(b.messages, msg)
+	return nil
+}
+
+// To - Messages sent to address, oldest first
+func (b *Mailbox) To(address string) []mail.Message {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var out []mail.Message
+	for _, msg := range b.messages {
+		if msg.To == address {
+			out = append(out, msg)
+		}
+	}
+	return out
+}
@@ -1,37 +1,205 @@
 package utils
 
 import (
+	"errors"
+	"math"
 	"time"
-	"os"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
-var jwtSecret = []byte(os.Getenv("JWT_SECRET"))
+var (
+	jwtSecret []byte
+	jwtTTL    = time.Hour * 72
+)
+
+// MFAChallengeTTL - Time allowed to enter the second factor after the password
+const MFAChallengeTTL = 5 * time.Minute
+
+// tokenUseMFAChallenge - "use" claim of challenge tokens. Access tokens have
+// no "use" claim, so a challenge can never be sent as a Bearer token.
+const tokenUseMFAChallenge = "mfa_challenge"
+
+// tokenUseSSOFlow - "use" claim of the state kept between an SSO redirect and its callback
+const tokenUseSSOFlow = "sso_flow"
+
+// errWrongTokenUse - The token is valid but meant for something else
+var errWrongTokenUse = errors.New("token is not meant for this use")
+
+// ConfigureJWT - Set the signing secret and token lifetime, called once from main
+func ConfigureJWT(secret string, ttl time.Duration) {
+	jwtSecret = []byte(secret)
+	jwtTTL = ttl
+}
 
 func GeneateToken(id uint, role string) (string, error){
 	claims := jwt.MapClaims{
 		"user_id": id,
-		"exp": time.Now().Add(time.Hour * 72).Unix(),
+		"exp": time.Now().Add(jwtTTL).Unix(),
+		"iat": issuedAt(time.Now()),
 		"role": role,
 	}
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	return token.SignedString(jwtSecret)
 }
 
+// GenerateVerifiedToken - Access token for a login that also passed a second factor
+func GenerateVerifiedToken(id uint, role string) (string, error) {
+	claims := jwt.MapClaims{
+		"user_id": id,
+		"exp":     time.Now().Add(jwtTTL).Unix(),
+		"iat":     issuedAt(time.Now()),
+		"role":    role,
+		"mfa":     true,
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(jwtSecret)
+}
+
+// GenerateImpersonationToken - Access token for user id acting on behalf of
+// admin impersonatorID. The "impersonator_id" claim marks it, so every
+// request made with it can be told apart from the user's own.
+func GenerateImpersonationToken(id uint, role string, impersonatorID uint, ttl time.Duration) (string, error) {
+	claims := jwt.MapClaims{
+		"user_id":         id,
+		"exp":             time.Now().Add(ttl).Unix(),
+		"iat":             issuedAt(time.Now()),
+		"role":            role,
+		"mfa":             true,
+		"impersonator_id": impersonatorID,
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(jwtSecret)
+}
+
+// GenerateMFAChallenge - Short-lived token proving the password of id was
+// correct; it is exchanged for an access token together with a TOTP code
+func GenerateMFAChallenge(id uint) (string, error) {
+	claims := jwt.MapClaims{
+		"user_id": id,
+		"exp":     time.Now().Add(MFAChallengeTTL).Unix(),
+		"use":     tokenUseMFAChallenge,
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(jwtSecret)
+}
+
+// ParseMFAChallenge - User id of a valid challenge token
+func ParseMFAChallenge(tokenString string) (uint, error) {
+	claims, err := parseClaims(tokenString)
+	if err != nil {
+		return 0, err
+	}
+	if use, _ := claims["use"].(string); use != tokenUseMFAChallenge {
+		return 0, errWrongTokenUse
+	}
+	userID, _ := claims["user_id"].(float64)
+	return uint(userID), nil
+}
+
+// SSOFlow - What an OpenID Connect callback needs from the login redirect that
+// started it. It travels in a signed cookie so any instance can finish the login.
+type SSOFlow struct {
+	Provider    string
+	State       string
+	Nonce       string
+	Verifier    string
+	RedirectURL string
+}
+
+// GenerateSSOFlow - Sign flow, valid for ttl
+func GenerateSSOFlow(flow SSOFlow, ttl time.Duration) (string, error) {
+	claims := jwt.MapClaims{
+		"exp":      time.Now().Add(ttl).Unix(),
+		"use":      tokenUseSSOFlow,
+		"provider": flow.Provider,
+		"state":    flow.State,
+		"nonce":    flow.Nonce,
+		"verifier": flow.Verifier,
+		"redirect": flow.RedirectURL,
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(jwtSecret)
+}
+
+// ParseSSOFlow - The flow in a valid, unexpired token from GenerateSSOFlow
+func ParseSSOFlow(tokenString string) (SSOFlow, error) {
+	claims, err := parseClaims(tokenString)
+	if err != nil {
+		return SSOFlow{}, err
+	}
+	if use, _ := claims["use"].(string); use != tokenUseSSOFlow {
+		return SSOFlow{}, errWrongTokenUse
+	}
+	str := func(key string) string {
+		v, _ := claims[key].(string)
+		return v
+	}
+	return SSOFlow{
+		Provider:    str("provider"),
+		State:       str("state"),
+		Nonce:       str("nonce"),
+		Verifier:    str("verifier"),
+		RedirectURL: str("redirect"),
+	}, nil
+}
+
 func ParseToken(tokenString string) (uint, string, error) {
+	claims, err := ParseAccessToken(tokenString)
+	return claims.UserID, claims.Role, err
+}
+
+// AccessClaims - What an access token says about its user
+type AccessClaims struct {
+	UserID uint
+	Role   string
+	// MFA is true when the login passed a second factor
+	MFA bool
+	// IssuedAt is zero for tokens issued before it was recorded
+	IssuedAt time.Time
+	// ImpersonatorID is the admin using the token on the user's behalf, zero
+	// for the user's own tokens
+	ImpersonatorID uint
+}
+
+// ParseAccessToken - Claims of a valid access token. Challenge tokens are refused.
+func ParseAccessToken(tokenString string) (AccessClaims, error) {
+	claims, err := parseClaims(tokenString)
+	if err != nil {
+		return AccessClaims{}, err
+	}
+	if _, ok := claims["use"]; ok {
+		return AccessClaims{}, errWrongTokenUse
+	}
+
+	userID, ok := claims["user_id"].(float64)
+	role, ok2 := claims["role"].(string)
+	if !ok || !ok2 {
+		return AccessClaims{}, jwt.ErrTokenInvalidClaims
+	}
+	mfa, _ := claims["mfa"].(bool)
+	var issued time.Time
+	if iat, ok := claims["iat"].(float64); ok {
+		issued = time.UnixMilli(int64(math.Round(iat * 1000)))
+	}
+	impersonator, _ := claims["impersonator_id"].(float64)
+	return AccessClaims{UserID: uint(userID), Role: role, MFA: mfa, IssuedAt: issued, ImpersonatorID: uint(impersonator)}, nil
+}
+
+// issuedAt - "iat" with millisecond precision, so a token issued right after
+// its user's sessions were revoked is not mistaken for an older one
+func issuedAt(t time.Time) float64 {
+	return float64(t.UnixMilli()) / 1000
+}
+
+// parseClaims - Claims of a token signed with our secret that has not expired
+func parseClaims(tokenString string) (jwt.MapClaims, error) {
 	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
 		return jwtSecret, nil
-	})
-
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
 	if err != nil {
-		return 0, "", err
+		return nil, err
 	}
 
-	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid{
-		userID := uint(claims["user_id"].(float64))
-		role := claims["role"].(string)
-		return userID, role, nil
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return nil, jwt.ErrTokenInvalidClaims
 	}
-	return 0, "", err
-}
\ No newline at end of file
+	return claims, nil
+}
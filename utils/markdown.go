@@ -0,0 +1,88 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// Lesson content formats
+const (
+	ContentFormatMarkdown = "markdown"
+	ContentFormatPlain    = "plain"
+)
+
+// attachmentRef matches "attachment:12", the way lesson Markdown embeds its own files
+var attachmentRef = regexp.MustCompile(`^attachment:(\d+)$`)
+
+// AttachmentMediaPath matches the stable attachment URLs left in rendered HTML
+var AttachmentMediaPath = regexp.MustCompile(`/media/attachment/(\d+)"`)
+
+var markdown = goldmark.New(
+	goldmark.WithExtensions(extension.GFM),
+	goldmark.WithParserOptions(
+		parser.WithASTTransformers(util.Prioritized(attachmentLinkTransformer{}, 100)),
+	),
+)
+
+var sanitizer = func() *bluemonday.Policy {
+	p := bluemonday.UGCPolicy()
+	// Simpan nama bahasa di code block supaya highlighter di frontend bisa bekerja
+	p.AllowAttrs("class").Matching(regexp.MustCompile(`^language-[\w+#-]+$`)).OnElements("code")
+	return p
+}()
+
+// attachmentLinkTransformer - Rewrite attachment:ID links and images to /media/attachment/ID
+type attachmentLinkTransformer struct{}
+
+func (attachmentLinkTransformer) Transform(doc *ast.Document, reader text.Reader, pc parser.Context) {
+	ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch node := n.(type) {
+		case *ast.Image:
+			node.Destination = rewriteAttachmentRef(node.Destination)
+		case *ast.Link:
+			node.Destination = rewriteAttachmentRef(node.Destination)
+		}
+		return ast.WalkContinue, nil
+	})
+}
+
+func rewriteAttachmentRef(dest []byte) []byte {
+	if m := attachmentRef.FindSubmatch(dest); m != nil {
+		return []byte(fmt.Sprintf("/media/attachment/%s", m[1]))
+	}
+	return dest
+}
+
+// RenderContent - Render lesson content to sanitized HTML
+func RenderContent(source, format string) (string, error) {
+	if format == ContentFormatPlain {
+		var buf strings.Builder
+		for _, para := range strings.Split(strings.ReplaceAll(source, "\r\n", "\n"), "\n\n") {
+			if strings.TrimSpace(para) == "" {
+				continue
+			}
+			buf.WriteString("<p>" + strings.ReplaceAll(html.EscapeString(para), "\n", "<br>") + "</p>\n")
+		}
+		return buf.String(), nil
+	}
+
+	var buf bytes.Buffer
+	if err := markdown.Convert([]byte(source), &buf); err != nil {
+		return "", err
+	}
+	return sanitizer.Sanitize(buf.String()), nil
+}